@@ -0,0 +1,135 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package file provides a document loader that reads documents from the local file system.
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// MetaKeyFileName is the metadata key storing the base name of the loaded file.
+	MetaKeyFileName = "_file_name"
+	// MetaKeyExtension is the metadata key storing the extension of the loaded file.
+	MetaKeyExtension = "_extension"
+)
+
+// FileLoaderConfig is the config for FileLoader.
+type FileLoaderConfig struct {
+	// UseNameAsID uses the base name of the file as the ID of the loaded documents.
+	UseNameAsID bool
+	// Parser parses the file content into documents.
+	// Default is parser.ExtParser with parser.TextParser as fallback if not set.
+	Parser parser.Parser
+}
+
+// FileLoader loads documents from a local file, the src.URI of Load is the file path.
+// eg:
+//
+//	loader, _ := file.NewFileLoader(ctx, &file.FileLoaderConfig{})
+//	docs, err := loader.Load(ctx, document.Source{URI: "./testdata/test.md"})
+type FileLoader struct {
+	useNameAsID bool
+	parser      parser.Parser
+}
+
+// NewFileLoader creates a new FileLoader.
+func NewFileLoader(ctx context.Context, conf *FileLoaderConfig) (*FileLoader, error) {
+	if conf == nil {
+		conf = &FileLoaderConfig{}
+	}
+
+	p := conf.Parser
+	if p == nil {
+		var err error
+		p, err = parser.NewExtParser(ctx, &parser.ExtParserConfig{})
+		if err != nil {
+			return nil, fmt.Errorf("create default parser failed: %w", err)
+		}
+	}
+
+	return &FileLoader{
+		useNameAsID: conf.UseNameAsID,
+		parser:      p,
+	}, nil
+}
+
+// Load reads the file at src.URI and parses it into documents.
+func (f *FileLoader) Load(ctx context.Context, src document.Source, opts ...document.LoaderOption) ([]*schema.Document, error) {
+	option := document.GetLoaderCommonOptions(&document.LoaderOptions{}, opts...)
+
+	file, err := os.Open(src.URI)
+	if err != nil {
+		return nil, fmt.Errorf("open file failed, uri=%s: %w", src.URI, err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat file failed, uri=%s: %w", src.URI, err)
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("uri is a directory, uri=%s", src.URI)
+	}
+
+	name := filepath.Base(src.URI)
+	ext := filepath.Ext(name)
+
+	// merge instead of override the extra meta passed in by caller, which takes precedence.
+	extraMeta := map[string]any{
+		MetaKeyFileName:  name,
+		MetaKeyExtension: ext,
+	}
+	for k, v := range parser.GetCommonOptions(&parser.Options{}, option.ParserOptions...).ExtraMeta {
+		extraMeta[k] = v
+	}
+
+	parserOpts := append([]parser.Option{parser.WithURI(src.URI)}, option.ParserOptions...)
+	parserOpts = append(parserOpts, parser.WithExtraMeta(extraMeta))
+
+	docs, err := f.parser.Parse(ctx, file, parserOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("parse file failed, uri=%s: %w", src.URI, err)
+	}
+
+	if f.useNameAsID {
+		for i, doc := range docs {
+			if doc == nil {
+				continue
+			}
+			if len(docs) == 1 {
+				doc.ID = name
+			} else {
+				doc.ID = fmt.Sprintf("%s_%d", name, i)
+			}
+		}
+	}
+
+	return docs, nil
+}
+
+// GetType returns the type of the loader.
+func (f *FileLoader) GetType() string {
+	return "FileLoader"
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestFileLoader(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("load file", func(t *testing.T) {
+		loader, err := NewFileLoader(ctx, &FileLoaderConfig{UseNameAsID: true})
+		assert.NoError(t, err)
+
+		docs, err := loader.Load(ctx, document.Source{URI: "testdata/test.md"},
+			document.WithParserOptions(parser.WithExtraMeta(map[string]any{"k": "v"})))
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Equal(t, "test.md", docs[0].ID)
+		assert.Equal(t, "# Title\nhello world", docs[0].Content)
+		assert.Equal(t, "testdata/test.md", docs[0].MetaData[parser.MetaKeySource])
+		assert.Equal(t, "test.md", docs[0].MetaData[MetaKeyFileName])
+		assert.Equal(t, ".md", docs[0].MetaData[MetaKeyExtension])
+		assert.Equal(t, "v", docs[0].MetaData["k"])
+	})
+
+	t.Run("load not exist file", func(t *testing.T) {
+		loader, err := NewFileLoader(ctx, nil)
+		assert.NoError(t, err)
+
+		_, err = loader.Load(ctx, document.Source{URI: "testdata/not_exist.md"})
+		assert.ErrorContains(t, err, "open file failed")
+	})
+
+	t.Run("load directory", func(t *testing.T) {
+		loader, err := NewFileLoader(ctx, nil)
+		assert.NoError(t, err)
+
+		_, err = loader.Load(ctx, document.Source{URI: "testdata"})
+		assert.ErrorContains(t, err, "is a directory")
+	})
+
+	t.Run("loader node in graph", func(t *testing.T) {
+		loader, err := NewFileLoader(ctx, nil)
+		assert.NoError(t, err)
+
+		g := compose.NewGraph[document.Source, []string]()
+		assert.NoError(t, g.AddLoaderNode("loader", loader))
+		assert.NoError(t, g.AddLambdaNode("lines", compose.InvokableLambda(
+			func(ctx context.Context, docs []*schema.Document) ([]string, error) {
+				var lines []string
+				for _, doc := range docs {
+					lines = append(lines, strings.Split(doc.Content, "\n")...)
+				}
+				return lines, nil
+			})))
+		assert.NoError(t, g.AddEdge(compose.START, "loader"))
+		assert.NoError(t, g.AddEdge("loader", "lines"))
+		assert.NoError(t, g.AddEdge("lines", compose.END))
+
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		lines, err := r.Invoke(ctx, document.Source{URI: "testdata/test.md"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"# Title", "hello world"}, lines)
+	})
+}
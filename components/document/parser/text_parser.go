@@ -24,7 +24,7 @@ import (
 )
 
 const (
-	// MetaKeySource is the metadata key storing the document's source URI.
+	// MetaKeySource is the metadata key storing the document's source URI, which can be read by schema.Document.SourceName.
 	MetaKeySource = "_source"
 )
 
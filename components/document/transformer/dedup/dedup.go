@@ -0,0 +1,100 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dedup provides a document transformer that removes documents with duplicate content,
+// e.g. the overlapping chunks retrieved from different sources in a RAG pipeline.
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Config is the config for the dedup transformer.
+type Config struct {
+	// HashFn returns the key of a document, documents with the same key are duplicates.
+	// Optional. Default is NormalizedContentHash, i.e. the content differing only in whitespace is the same.
+	HashFn func(doc *schema.Document) string
+}
+
+// Transformer is a document.Transformer that removes duplicate documents.
+// Of the documents sharing the same key, the one with the highest score (see schema.Document.Score) is kept,
+// or the first one if they're scored the same. It takes the position of the first of them,
+// so the order of the remaining documents is preserved.
+// eg:
+//
+//	transformer, _ := dedup.NewDedupTransformer(ctx, &dedup.Config{})
+//	_ = graph.AddDocumentTransformerNode("dedup", transformer)
+type Transformer struct {
+	hashFn func(doc *schema.Document) string
+}
+
+// NewDedupTransformer creates a new dedup Transformer.
+func NewDedupTransformer(_ context.Context, conf *Config) (*Transformer, error) {
+	hashFn := NormalizedContentHash
+	if conf != nil && conf.HashFn != nil {
+		hashFn = conf.HashFn
+	}
+	return &Transformer{hashFn: hashFn}, nil
+}
+
+// Transform removes the duplicates of src.
+func (t *Transformer) Transform(_ context.Context, src []*schema.Document, _ ...document.TransformerOption) ([]*schema.Document, error) {
+	ret := make([]*schema.Document, 0, len(src))
+	// index in ret of the document kept for each key
+	kept := make(map[string]int, len(src))
+	for _, doc := range src {
+		if doc == nil {
+			continue
+		}
+
+		key := t.hashFn(doc)
+		i, ok := kept[key]
+		if !ok {
+			kept[key] = len(ret)
+			ret = append(ret, doc)
+			continue
+		}
+		if doc.Score() > ret[i].Score() {
+			ret[i] = doc
+		}
+	}
+
+	return ret, nil
+}
+
+// GetType returns the type of the transformer.
+func (t *Transformer) GetType() string {
+	return "DedupTransformer"
+}
+
+// NormalizedContentHash returns the SHA-256 of the content of doc, with leading and trailing whitespace trimmed,
+// and each run of inner whitespace collapsed into a single space.
+func NormalizedContentHash(doc *schema.Document) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(doc.Content), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ContentHash returns the SHA-256 of the content of doc as is, i.e. only identical content is the same.
+func ContentHash(doc *schema.Document) string {
+	sum := sha256.Sum256([]byte(doc.Content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dedup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+var _ document.Transformer = (*Transformer)(nil)
+
+func TestDedupTransformer(t *testing.T) {
+	ctx := context.Background()
+
+	// chunks of the same paragraph retrieved from two sources, differing only in whitespace
+	docs := []*schema.Document{
+		(&schema.Document{ID: "a_0", Content: "eino is a framework\nfor LLM apps"}).WithScore(0.7),
+		(&schema.Document{ID: "b_0", Content: "graphs compose components"}).WithScore(0.6),
+		(&schema.Document{ID: "b_1", Content: "  eino is a  framework for\tLLM apps \n"}).WithScore(0.9),
+		(&schema.Document{ID: "a_1", Content: "graphs compose components"}).WithScore(0.6),
+		nil,
+		(&schema.Document{ID: "c_0", Content: "streams are first-class"}).WithScore(0.5),
+	}
+
+	t.Run("normalized content", func(t *testing.T) {
+		d, err := NewDedupTransformer(ctx, nil)
+		assert.NoError(t, err)
+
+		out, err := d.Transform(ctx, docs)
+		assert.NoError(t, err)
+		// the highest scored duplicate takes the position of the first one, ties keep the first
+		assert.Equal(t, []string{"b_1", "b_0", "c_0"}, ids(out))
+	})
+
+	t.Run("exact content", func(t *testing.T) {
+		d, err := NewDedupTransformer(ctx, &Config{HashFn: ContentHash})
+		assert.NoError(t, err)
+
+		out, err := d.Transform(ctx, docs)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a_0", "b_0", "b_1", "c_0"}, ids(out))
+	})
+
+	t.Run("document transformer node", func(t *testing.T) {
+		d, err := NewDedupTransformer(ctx, &Config{})
+		assert.NoError(t, err)
+
+		g := compose.NewGraph[[]*schema.Document, []*schema.Document]()
+		assert.NoError(t, g.AddDocumentTransformerNode("dedup", d))
+		assert.NoError(t, g.AddEdge(compose.START, "dedup"))
+		assert.NoError(t, g.AddEdge("dedup", compose.END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		out, err := r.Invoke(ctx, docs)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b_1", "b_0", "c_0"}, ids(out))
+	})
+}
+
+func ids(docs []*schema.Document) []string {
+	ret := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ret = append(ret, doc.ID)
+	}
+	return ret
+}
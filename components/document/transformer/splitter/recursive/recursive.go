@@ -0,0 +1,210 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package recursive provides a document transformer that recursively splits documents
+// into chunks by a priority list of separators.
+package recursive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// MetaKeyChunkIndex is the metadata key storing the index of the chunk within its source document.
+	MetaKeyChunkIndex = "_chunk_index"
+)
+
+// defaultSeparators splits by paragraph first, then by line, and finally by word.
+var defaultSeparators = []string{"\n\n", "\n", " "}
+
+// Config is the config for the recursive splitter.
+type Config struct {
+	// ChunkSize is the max number of characters of each chunk, required.
+	// a chunk can only exceed it when it consists of a single piece that can not be split by any separator.
+	ChunkSize int
+	// OverlapSize is the max number of characters shared by adjacent chunks, must be less than ChunkSize.
+	OverlapSize int
+	// Separators are tried in order, the text is split by the first separator it contains,
+	// and pieces still longer than ChunkSize are split by the following separators.
+	// Default is paragraph ("\n\n"), line ("\n") and word (" ") if not set.
+	Separators []string
+}
+
+// Splitter is a document.Transformer that splits documents into chunks.
+// Each chunk keeps a copy of the metadata of its source document, with MetaKeyChunkIndex added.
+// If the source document has an ID, the ID of the chunk is "{ID}_{chunk index}".
+// eg:
+//
+//	splitter, _ := recursive.NewSplitter(ctx, &recursive.Config{ChunkSize: 1000, OverlapSize: 100})
+//	chunks, err := splitter.Transform(ctx, docs)
+type Splitter struct {
+	chunkSize   int
+	overlapSize int
+	separators  []string
+}
+
+// NewSplitter creates a new recursive Splitter.
+func NewSplitter(ctx context.Context, conf *Config) (*Splitter, error) {
+	if conf == nil {
+		return nil, errors.New("recursive splitter config is required")
+	}
+	if conf.ChunkSize <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive, got %d", conf.ChunkSize)
+	}
+	if conf.OverlapSize < 0 || conf.OverlapSize >= conf.ChunkSize {
+		return nil, fmt.Errorf("overlap size must be in [0, chunk size), got %d", conf.OverlapSize)
+	}
+
+	separators := conf.Separators
+	if len(separators) == 0 {
+		separators = defaultSeparators
+	}
+	for _, sep := range separators {
+		if len(sep) == 0 {
+			return nil, errors.New("separator must not be empty")
+		}
+	}
+
+	return &Splitter{
+		chunkSize:   conf.ChunkSize,
+		overlapSize: conf.OverlapSize,
+		separators:  separators,
+	}, nil
+}
+
+// Transform splits each of the src documents into chunks.
+func (s *Splitter) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	var ret []*schema.Document
+	for _, doc := range src {
+		if doc == nil {
+			continue
+		}
+
+		for i, chunk := range s.splitText(doc.Content, s.separators) {
+			meta := make(map[string]any, len(doc.MetaData)+1)
+			for k, v := range doc.MetaData {
+				meta[k] = v
+			}
+			meta[MetaKeyChunkIndex] = i
+
+			id := doc.ID
+			if len(id) > 0 {
+				id = fmt.Sprintf("%s_%d", id, i)
+			}
+
+			ret = append(ret, &schema.Document{
+				ID:       id,
+				Content:  chunk,
+				MetaData: meta,
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+// GetType returns the type of the splitter.
+func (s *Splitter) GetType() string {
+	return "RecursiveSplitter"
+}
+
+func (s *Splitter) splitText(text string, separators []string) []string {
+	sep := ""
+	var nextSeparators []string
+	for i, sp := range separators {
+		if strings.Contains(text, sp) {
+			sep = sp
+			nextSeparators = separators[i+1:]
+			break
+		}
+	}
+	if len(sep) == 0 {
+		// a single piece which can not be split any further.
+		return s.mergeSplits([]string{text}, "")
+	}
+
+	var chunks, pieces []string
+	for _, piece := range strings.Split(text, sep) {
+		if len(strings.TrimSpace(piece)) == 0 {
+			continue
+		}
+		if utf8.RuneCountInString(piece) <= s.chunkSize {
+			pieces = append(pieces, piece)
+			continue
+		}
+
+		if len(pieces) > 0 {
+			chunks = append(chunks, s.mergeSplits(pieces, sep)...)
+			pieces = nil
+		}
+		chunks = append(chunks, s.splitText(piece, nextSeparators)...)
+	}
+	if len(pieces) > 0 {
+		chunks = append(chunks, s.mergeSplits(pieces, sep)...)
+	}
+
+	return chunks
+}
+
+// mergeSplits joins pieces into chunks no larger than chunk size,
+// carrying the tail pieces of the previous chunk over to the next one as overlap.
+func (s *Splitter) mergeSplits(pieces []string, sep string) []string {
+	sepLen := utf8.RuneCountInString(sep)
+
+	var chunks, current []string
+	total := 0
+	for _, piece := range pieces {
+		pieceLen := utf8.RuneCountInString(piece)
+
+		if len(current) > 0 && total+sepLen+pieceLen > s.chunkSize {
+			chunks = appendChunk(chunks, strings.Join(current, sep))
+
+			for len(current) > 0 && (total > s.overlapSize || total+sepLen+pieceLen > s.chunkSize) {
+				total -= utf8.RuneCountInString(current[0])
+				if len(current) > 1 {
+					total -= sepLen
+				}
+				current = current[1:]
+			}
+		}
+
+		if len(current) > 0 {
+			total += sepLen
+		}
+		current = append(current, piece)
+		total += pieceLen
+	}
+	if len(current) > 0 {
+		chunks = appendChunk(chunks, strings.Join(current, sep))
+	}
+
+	return chunks
+}
+
+func appendChunk(chunks []string, chunk string) []string {
+	chunk = strings.TrimSpace(chunk)
+	if len(chunk) == 0 {
+		return chunks
+	}
+	return append(chunks, chunk)
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recursive
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+var _ document.Transformer = (*Splitter)(nil)
+
+func TestSplitter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewSplitter(ctx, nil)
+		assert.Error(t, err)
+		_, err = NewSplitter(ctx, &Config{})
+		assert.ErrorContains(t, err, "chunk size must be positive")
+		_, err = NewSplitter(ctx, &Config{ChunkSize: 10, OverlapSize: 10})
+		assert.ErrorContains(t, err, "overlap size")
+		_, err = NewSplitter(ctx, &Config{ChunkSize: 10, Separators: []string{""}})
+		assert.ErrorContains(t, err, "separator must not be empty")
+	})
+
+	t.Run("separator priority", func(t *testing.T) {
+		s, err := NewSplitter(ctx, &Config{ChunkSize: 20})
+		assert.NoError(t, err)
+
+		docs, err := s.Transform(ctx, []*schema.Document{{
+			Content: "first paragraph\n\nsecond line one\nsecond line two\n\nthird",
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"first paragraph", "second line one", "second line two", "third"}, contents(docs))
+	})
+
+	t.Run("chunk size and overlap", func(t *testing.T) {
+		s, err := NewSplitter(ctx, &Config{ChunkSize: 12, OverlapSize: 6})
+		assert.NoError(t, err)
+
+		docs, err := s.Transform(ctx, []*schema.Document{{Content: "aa bb cc dd ee ff gg hh"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"aa bb cc dd", "cc dd ee ff", "ee ff gg hh"}, contents(docs))
+		for _, doc := range docs {
+			assert.LessOrEqual(t, utf8.RuneCountInString(doc.Content), 12)
+		}
+	})
+
+	t.Run("single token exceeds chunk size", func(t *testing.T) {
+		s, err := NewSplitter(ctx, &Config{ChunkSize: 5})
+		assert.NoError(t, err)
+
+		docs, err := s.Transform(ctx, []*schema.Document{{Content: "hi verylongword yo"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"hi", "verylongword", "yo"}, contents(docs))
+	})
+
+	t.Run("count characters instead of bytes", func(t *testing.T) {
+		s, err := NewSplitter(ctx, &Config{ChunkSize: 5})
+		assert.NoError(t, err)
+
+		docs, err := s.Transform(ctx, []*schema.Document{{Content: "你好 世界"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"你好 世界"}, contents(docs))
+	})
+
+	t.Run("metadata and id", func(t *testing.T) {
+		s, err := NewSplitter(ctx, &Config{ChunkSize: 5})
+		assert.NoError(t, err)
+
+		src := &schema.Document{ID: "doc", Content: "hello world", MetaData: map[string]any{"k": "v"}}
+		docs, err := s.Transform(ctx, []*schema.Document{src})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 2)
+		for i, doc := range docs {
+			assert.Equal(t, "v", doc.MetaData["k"])
+			assert.Equal(t, i, doc.MetaData[MetaKeyChunkIndex])
+		}
+		assert.Equal(t, "doc_0", docs[0].ID)
+		assert.Equal(t, "doc_1", docs[1].ID)
+		_, ok := src.MetaData[MetaKeyChunkIndex]
+		assert.False(t, ok)
+	})
+
+	t.Run("transformer node in graph", func(t *testing.T) {
+		s, err := NewSplitter(ctx, &Config{ChunkSize: 11})
+		assert.NoError(t, err)
+
+		g := compose.NewGraph[[]*schema.Document, []*schema.Document]()
+		assert.NoError(t, g.AddDocumentTransformerNode("splitter", s))
+		assert.NoError(t, g.AddEdge(compose.START, "splitter"))
+		assert.NoError(t, g.AddEdge("splitter", compose.END))
+
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		docs, err := r.Invoke(ctx, []*schema.Document{{Content: strings.Repeat("hello ", 4)}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"hello hello", "hello hello"}, contents(docs))
+	})
+}
+
+func contents(docs []*schema.Document) []string {
+	ret := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ret = append(ret, doc.Content)
+	}
+	return ret
+}
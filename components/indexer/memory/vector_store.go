@@ -0,0 +1,287 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package memory provides an in-memory vector store, which implements both indexer.Indexer and
+// retriever.Retriever with cosine similarity, mainly for local development and testing.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+)
+
+const defaultTopK = 4
+
+// VectorStoreConfig is the config for VectorStore.
+type VectorStoreConfig struct {
+	// Embedding embeds the documents to store and the queries to retrieve with,
+	// can be overridden by indexer.WithEmbedding and retriever.WithEmbedding.
+	// Documents already carrying a dense vector (see schema.Document.WithDenseVector) are stored without embedding.
+	Embedding embedding.Embedder
+	// TopK is the default number of documents to retrieve, default is 4 if not set.
+	TopK int
+}
+
+// VectorStore stores documents with their vectors in memory, and retrieves them by cosine similarity.
+// It is safe for concurrent use.
+// eg:
+//
+//	store, _ := memory.NewVectorStore(ctx, &memory.VectorStoreConfig{Embedding: embedder})
+//	ids, err := store.Store(ctx, docs)
+//	docs, err := store.Retrieve(ctx, "query", retriever.WithTopK(3), memory.WithMetaDataFilter(map[string]any{"source": "a.md"}))
+type VectorStore struct {
+	embedding embedding.Embedder
+	topK      int
+
+	mu      sync.RWMutex
+	entries []*entry
+	// id -> index of entries
+	index map[string]int
+}
+
+type entry struct {
+	doc    *schema.Document
+	vector []float64
+	norm   float64
+}
+
+// NewVectorStore creates a new in-memory VectorStore.
+func NewVectorStore(ctx context.Context, conf *VectorStoreConfig) (*VectorStore, error) {
+	if conf == nil {
+		conf = &VectorStoreConfig{}
+	}
+
+	topK := conf.TopK
+	if topK <= 0 {
+		topK = defaultTopK
+	}
+
+	return &VectorStore{
+		embedding: conf.Embedding,
+		topK:      topK,
+		index:     make(map[string]int),
+	}, nil
+}
+
+// Store embeds and stores the documents, documents with an existing ID replace the stored ones.
+// Documents without ID are assigned a random one. The IDs of the stored documents are returned in order.
+func (v *VectorStore) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) ([]string, error) {
+	options := indexer.GetCommonOptions(&indexer.Options{Embedding: v.embedding}, opts...)
+
+	vectors := make([][]float64, len(docs))
+	var texts []string
+	var toEmbed []int
+	for i, doc := range docs {
+		if doc == nil {
+			return nil, fmt.Errorf("document[%d] is nil", i)
+		}
+		if vec := doc.DenseVector(); len(vec) > 0 {
+			vectors[i] = vec
+			continue
+		}
+		texts = append(texts, doc.Content)
+		toEmbed = append(toEmbed, i)
+	}
+
+	if len(texts) > 0 {
+		if options.Embedding == nil {
+			return nil, errors.New("embedding is required to store documents without dense vector")
+		}
+		embedded, err := options.Embedding.EmbedStrings(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("embed documents failed: %w", err)
+		}
+		if len(embedded) != len(texts) {
+			return nil, fmt.Errorf("expected %d vectors, but got %d", len(texts), len(embedded))
+		}
+		for i, idx := range toEmbed {
+			vectors[idx] = embedded[i]
+		}
+	}
+
+	entries := make([]*entry, len(docs))
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		if len(vectors[i]) == 0 {
+			return nil, fmt.Errorf("vector of document[%d] is empty", i)
+		}
+
+		id := doc.ID
+		if len(id) == 0 {
+			id = uuid.NewString()
+		}
+		ids[i] = id
+
+		entries[i] = &entry{
+			doc:    copyDocument(doc, id),
+			vector: vectors[i],
+			norm:   norm(vectors[i]),
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for i, e := range entries {
+		if idx, ok := v.index[ids[i]]; ok {
+			v.entries[idx] = e
+			continue
+		}
+		v.index[ids[i]] = len(v.entries)
+		v.entries = append(v.entries, e)
+	}
+
+	return ids, nil
+}
+
+type retrieveOptions struct {
+	filter func(doc *schema.Document) bool
+}
+
+// WithFilter only retrieves documents for which filter returns true.
+func WithFilter(filter func(doc *schema.Document) bool) retriever.Option {
+	return retriever.WrapImplSpecificOptFn(func(o *retrieveOptions) {
+		o.filter = filter
+	})
+}
+
+// WithMetaDataFilter only retrieves documents whose metadata contains all the given key-value pairs.
+func WithMetaDataFilter(meta map[string]any) retriever.Option {
+	return WithFilter(func(doc *schema.Document) bool {
+		for k, expected := range meta {
+			if actual, ok := doc.MetaData[k]; !ok || !reflect.DeepEqual(actual, expected) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Retrieve returns the top k documents most similar to the query, ordered by cosine similarity descending.
+// The similarity is set as the score of each returned document, see schema.Document.Score.
+func (v *VectorStore) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	options := retriever.GetCommonOptions(&retriever.Options{
+		TopK:      &v.topK,
+		Embedding: v.embedding,
+	}, opts...)
+	implOptions := retriever.GetImplSpecificOptions(&retrieveOptions{}, opts...)
+
+	if options.Embedding == nil {
+		return nil, errors.New("embedding is required to retrieve documents")
+	}
+
+	vectors, err := options.Embedding.EmbedStrings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query failed: %w", err)
+	}
+	if len(vectors) != 1 || len(vectors[0]) == 0 {
+		return nil, errors.New("embedding of query is empty")
+	}
+	queryVector, queryNorm := vectors[0], norm(vectors[0])
+
+	type scored struct {
+		e     *entry
+		score float64
+	}
+
+	v.mu.RLock()
+	candidates := make([]scored, 0, len(v.entries))
+	for _, e := range v.entries {
+		if implOptions.filter != nil && !implOptions.filter(e.doc) {
+			continue
+		}
+		score, err := cosineSimilarity(queryVector, queryNorm, e.vector, e.norm)
+		if err != nil {
+			v.mu.RUnlock()
+			return nil, fmt.Errorf("compare with document[%s] failed: %w", e.doc.ID, err)
+		}
+		if options.ScoreThreshold != nil && score < *options.ScoreThreshold {
+			continue
+		}
+		candidates = append(candidates, scored{e: e, score: score})
+	}
+	v.mu.RUnlock()
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	topK := len(candidates)
+	if options.TopK != nil && *options.TopK >= 0 && *options.TopK < topK {
+		topK = *options.TopK
+	}
+
+	docs := make([]*schema.Document, 0, topK)
+	for _, c := range candidates[:topK] {
+		docs = append(docs, copyDocument(c.e.doc, c.e.doc.ID).WithScore(c.score))
+	}
+
+	return docs, nil
+}
+
+// GetType returns the type of the vector store.
+func (v *VectorStore) GetType() string {
+	return "MemoryVectorStore"
+}
+
+// copyDocument copies the document and its metadata, so that neither the caller nor the store
+// would see the modifications of the other.
+func copyDocument(doc *schema.Document, id string) *schema.Document {
+	meta := make(map[string]any, len(doc.MetaData))
+	for k, v := range doc.MetaData {
+		meta[k] = v
+	}
+	return &schema.Document{
+		ID:       id,
+		Content:  doc.Content,
+		MetaData: meta,
+	}
+}
+
+func norm(vector []float64) float64 {
+	var sum float64
+	for _, x := range vector {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
+
+func cosineSimilarity(a []float64, normA float64, b []float64, normB float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("dimension mismatch: %d != %d", len(a), len(b))
+	}
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+
+	var dot float64
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return dot / (normA * normB), nil
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+)
+
+// keywordEmbedder embeds a text into the counts of keywords it contains.
+type keywordEmbedder struct {
+	keywords []string
+}
+
+func (k *keywordEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float64, len(k.keywords))
+		for j, kw := range k.keywords {
+			vectors[i][j] = float64(strings.Count(text, kw))
+		}
+	}
+	return vectors, nil
+}
+
+var (
+	_ indexer.Indexer     = (*VectorStore)(nil)
+	_ retriever.Retriever = (*VectorStore)(nil)
+)
+
+func TestVectorStore(t *testing.T) {
+	ctx := context.Background()
+	emb := &keywordEmbedder{keywords: []string{"weather", "file", "go"}}
+
+	newStore := func(t *testing.T) *VectorStore {
+		store, err := NewVectorStore(ctx, &VectorStoreConfig{Embedding: emb})
+		assert.NoError(t, err)
+
+		ids, err := store.Store(ctx, []*schema.Document{
+			{ID: "weather", Content: "weather weather", MetaData: map[string]any{"source": "a.md"}},
+			{ID: "weather_file", Content: "weather file", MetaData: map[string]any{"source": "b.md"}},
+			{ID: "file", Content: "file file go", MetaData: map[string]any{"source": "a.md"}},
+			{ID: "go", Content: "go", MetaData: map[string]any{"source": "b.md"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather", "weather_file", "file", "go"}, ids)
+		return store
+	}
+
+	t.Run("ranked retrieval", func(t *testing.T) {
+		store := newStore(t)
+
+		docs, err := store.Retrieve(ctx, "weather")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather", "weather_file", "file", "go"}, ids(docs))
+		assert.InDelta(t, 1.0, docs[0].Score(), 1e-9)
+		assert.InDelta(t, 0.7071, docs[1].Score(), 1e-4)
+		assert.Equal(t, 0.0, docs[2].Score())
+
+		docs, err = store.Retrieve(ctx, "file", retriever.WithTopK(2))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"file", "weather_file"}, ids(docs))
+
+		docs, err = store.Retrieve(ctx, "weather", retriever.WithScoreThreshold(0.5))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather", "weather_file"}, ids(docs))
+	})
+
+	t.Run("metadata filter", func(t *testing.T) {
+		store := newStore(t)
+
+		docs, err := store.Retrieve(ctx, "weather", WithMetaDataFilter(map[string]any{"source": "b.md"}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather_file", "go"}, ids(docs))
+
+		docs, err = store.Retrieve(ctx, "weather", WithFilter(func(doc *schema.Document) bool {
+			return doc.ID != "weather"
+		}), retriever.WithTopK(1))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather_file"}, ids(docs))
+	})
+
+	t.Run("upsert and copy", func(t *testing.T) {
+		store := newStore(t)
+
+		doc := &schema.Document{ID: "go", Content: "weather", MetaData: map[string]any{"source": "c.md"}}
+		_, err := store.Store(ctx, []*schema.Document{doc})
+		assert.NoError(t, err)
+		doc.MetaData["source"] = "modified"
+
+		docs, err := store.Retrieve(ctx, "weather", retriever.WithTopK(2))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather", "go"}, ids(docs))
+		assert.Equal(t, "c.md", docs[1].MetaData["source"])
+
+		docs[1].MetaData["source"] = "modified"
+		docs, err = store.Retrieve(ctx, "weather", WithMetaDataFilter(map[string]any{"source": "c.md"}))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"go"}, ids(docs))
+	})
+
+	t.Run("dense vector and generated id", func(t *testing.T) {
+		store, err := NewVectorStore(ctx, nil)
+		assert.NoError(t, err)
+
+		storedIDs, err := store.Store(ctx, []*schema.Document{(&schema.Document{Content: "x"}).WithDenseVector([]float64{1, 0, 0})})
+		assert.NoError(t, err)
+		assert.Len(t, storedIDs, 1)
+		assert.NotEmpty(t, storedIDs[0])
+
+		_, err = store.Store(ctx, []*schema.Document{{Content: "y"}})
+		assert.ErrorContains(t, err, "embedding is required")
+
+		docs, err := store.Retrieve(ctx, "weather", retriever.WithEmbedding(emb))
+		assert.NoError(t, err)
+		assert.Equal(t, storedIDs, ids(docs))
+	})
+
+	t.Run("dimension mismatch", func(t *testing.T) {
+		store := newStore(t)
+
+		_, err := store.Retrieve(ctx, "weather", retriever.WithEmbedding(&keywordEmbedder{keywords: []string{"weather"}}))
+		assert.ErrorContains(t, err, "dimension mismatch")
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		store := newStore(t)
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				_, err := store.Store(ctx, []*schema.Document{{ID: fmt.Sprintf("doc_%d", i), Content: "go"}})
+				assert.NoError(t, err)
+			}(i)
+			go func() {
+				defer wg.Done()
+				_, err := store.Retrieve(ctx, "go")
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		docs, err := store.Retrieve(ctx, "go", retriever.WithTopK(100))
+		assert.NoError(t, err)
+		assert.Len(t, docs, 14)
+	})
+}
+
+func ids(docs []*schema.Document) []string {
+	ret := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ret = append(ret, doc.ID)
+	}
+	return ret
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+// BatchChatModel is an optional interface of chat models, which generate outputs for multiple inputs in one call,
+// e.g. by concurrent requests or a batch API of the provider, for workloads like scoring many prompts.
+// Implementations should preserve the order of inputs, and return a *BatchError if only some of the inputs fail,
+// see ConcurrentGenerate for a default implementation.
+type BatchChatModel interface {
+	BatchGenerate(ctx context.Context, inputs [][]*schema.Message, opts ...Option) ([]*schema.Message, error)
+}
+
+// BatchError is returned by BatchGenerate when some of the inputs fail, the outputs of the succeeded ones are still returned,
+// with nil at the index of the failed ones.
+type BatchError struct {
+	// Errors has the same length as the inputs, nil for the succeeded inputs.
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	var sb strings.Builder
+	failed := 0
+	for i, err := range e.Errors {
+		if err == nil {
+			continue
+		}
+		if failed > 0 {
+			sb.WriteString("; ")
+		}
+		failed++
+		sb.WriteString(fmt.Sprintf("[%d]: %v", i, err))
+	}
+	return fmt.Sprintf("batch generate fail, %d of %d inputs failed: %s", failed, len(e.Errors), sb.String())
+}
+
+// Unwrap returns the errors of the failed inputs, so that errors.Is and errors.As match any of them,
+// e.g. errors.Is(err, ErrRateLimited).
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+const defaultBatchConcurrency = 5
+
+// ConcurrentGenerate calls m.Generate for each of inputs concurrently, with at most concurrency calls in flight,
+// and returns the outputs in the order of inputs. If concurrency is not positive, 5 is used.
+// The failed inputs are reported by a *BatchError, along with the outputs of the succeeded ones.
+// Once ctx is done, the inputs not yet started fail with ctx.Err().
+// It can be used by chat models to implement BatchChatModel, e.g.
+//
+//	func (m *MyModel) BatchGenerate(ctx context.Context, inputs [][]*schema.Message, opts ...model.Option) ([]*schema.Message, error) {
+//		return model.ConcurrentGenerate(ctx, m, inputs, m.concurrency, opts...)
+//	}
+func ConcurrentGenerate(ctx context.Context, m BaseChatModel, inputs [][]*schema.Message, concurrency int,
+	opts ...Option) ([]*schema.Message, error) {
+
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	outputs := make([]*schema.Message, len(inputs))
+	errs := make([]error, len(inputs))
+
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i := range inputs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				if panicErr := recover(); panicErr != nil {
+					errs[i] = safe.NewPanicErr(panicErr, debug.Stack())
+				}
+				<-sem
+				wg.Done()
+			}()
+
+			outputs[i], errs[i] = m.Generate(ctx, inputs[i], opts...)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return outputs, &BatchError{Errors: errs}
+		}
+	}
+	return outputs, nil
+}
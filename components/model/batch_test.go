@@ -0,0 +1,105 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+type echoModel struct {
+	BaseChatModel
+	inFlight, maxInFlight int32
+}
+
+func (e *echoModel) Generate(_ context.Context, input []*schema.Message, _ ...Option) (*schema.Message, error) {
+	n := atomic.AddInt32(&e.inFlight, 1)
+	defer atomic.AddInt32(&e.inFlight, -1)
+	for {
+		m := atomic.LoadInt32(&e.maxInFlight)
+		if n <= m || atomic.CompareAndSwapInt32(&e.maxInFlight, m, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	switch input[0].Content {
+	case "rate limited":
+		return nil, ErrRateLimited
+	case "panic":
+		panic("boom")
+	}
+	return schema.AssistantMessage(input[0].Content, nil), nil
+}
+
+func TestConcurrentGenerate(t *testing.T) {
+	convey.Convey("test concurrent generate", t, func() {
+		ctx := context.Background()
+		inputs := func(contents ...string) [][]*schema.Message {
+			ret := make([][]*schema.Message, len(contents))
+			for i, c := range contents {
+				ret[i] = []*schema.Message{schema.UserMessage(c)}
+			}
+			return ret
+		}
+
+		convey.Convey("order preserved and concurrency bounded", func() {
+			m := &echoModel{}
+			outs, err := ConcurrentGenerate(ctx, m, inputs("a", "b", "c", "d", "e", "f", "g"), 3)
+			convey.So(err, convey.ShouldBeNil)
+			contents := make([]string, len(outs))
+			for i, o := range outs {
+				contents[i] = o.Content
+			}
+			convey.So(contents, convey.ShouldResemble, []string{"a", "b", "c", "d", "e", "f", "g"})
+			convey.So(m.maxInFlight, convey.ShouldBeLessThanOrEqualTo, 3)
+			convey.So(m.maxInFlight, convey.ShouldBeGreaterThan, 1)
+		})
+
+		convey.Convey("partial failure", func() {
+			outs, err := ConcurrentGenerate(ctx, &echoModel{}, inputs("a", "rate limited", "panic"), 0)
+			convey.So(err, convey.ShouldNotBeNil)
+			convey.So(errors.Is(err, ErrRateLimited), convey.ShouldBeTrue)
+
+			var batchErr *BatchError
+			convey.So(errors.As(err, &batchErr), convey.ShouldBeTrue)
+			convey.So(len(batchErr.Errors), convey.ShouldEqual, 3)
+			convey.So(batchErr.Errors[0], convey.ShouldBeNil)
+			convey.So(batchErr.Errors[1], convey.ShouldEqual, ErrRateLimited)
+			convey.So(batchErr.Errors[2].Error(), convey.ShouldContainSubstring, "boom")
+			convey.So(err.Error(), convey.ShouldContainSubstring, "2 of 3 inputs failed")
+
+			convey.So(outs[0].Content, convey.ShouldEqual, "a")
+			convey.So(outs[1], convey.ShouldBeNil)
+			convey.So(outs[2], convey.ShouldBeNil)
+		})
+
+		convey.Convey("canceled", func() {
+			cctx, cancel := context.WithCancel(ctx)
+			cancel()
+			_, err := ConcurrentGenerate(cctx, &echoModel{}, inputs("a", "b", "c"), 1)
+			convey.So(errors.Is(err, context.Canceled), convey.ShouldBeTrue)
+		})
+	})
+}
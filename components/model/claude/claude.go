@@ -0,0 +1,503 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package claude provides a ToolCallingChatModel backed by the Anthropic Messages API.
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/internal/modelutil"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	defaultBaseURL   = "https://api.anthropic.com"
+	apiVersion       = "2023-06-01"
+	defaultMaxTokens = 1024
+)
+
+// ChatModel implements model.ToolCallingChatModel on top of the Anthropic Messages API.
+type ChatModel struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	tools      []*schema.ToolInfo
+	// modelName is the default model, which can be overridden per call by model.WithModel.
+	modelName string
+	// maxTokens is the default max_tokens, which the API requires. It can be overridden per call by model.WithMaxTokens.
+	maxTokens int
+}
+
+// Option configures a ChatModel created by NewChatModel.
+type Option func(m *ChatModel)
+
+// WithModelName sets the default model name, e.g. "claude-sonnet-4-5".
+func WithModelName(name string) Option {
+	return func(m *ChatModel) {
+		m.modelName = name
+	}
+}
+
+// WithBaseURL sets the base URL of the API, https://api.anthropic.com by default.
+func WithBaseURL(url string) Option {
+	return func(m *ChatModel) {
+		m.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithAPIKey sets the API key, read from the ANTHROPIC_API_KEY environment variable by default.
+func WithAPIKey(key string) Option {
+	return func(m *ChatModel) {
+		m.apiKey = key
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(m *ChatModel) {
+		m.httpClient = c
+	}
+}
+
+// WithMaxTokens sets the default max_tokens, 1024 by default.
+func WithMaxTokens(n int) Option {
+	return func(m *ChatModel) {
+		m.maxTokens = n
+	}
+}
+
+// NewChatModel creates a ChatModel. Tools are bound by WithTools.
+// eg:
+//
+//	m := claude.NewChatModel(
+//		claude.WithAPIKey(apiKey),
+//		claude.WithModelName("claude-sonnet-4-5"),
+//	)
+func NewChatModel(opts ...Option) *ChatModel {
+	m := &ChatModel{
+		httpClient: http.DefaultClient,
+		baseURL:    defaultBaseURL,
+		apiKey:     os.Getenv("ANTHROPIC_API_KEY"),
+		maxTokens:  defaultMaxTokens,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// request is the request body of the Messages API.
+type request struct {
+	Model         string      `json:"model"`
+	MaxTokens     int         `json:"max_tokens"`
+	System        string      `json:"system,omitempty"`
+	Messages      []message   `json:"messages"`
+	Tools         []toolDef   `json:"tools,omitempty"`
+	ToolChoice    *toolChoice `json:"tool_choice,omitempty"`
+	Temperature   *float64    `json:"temperature,omitempty"`
+	TopP          *float64    `json:"top_p,omitempty"`
+	StopSequences []string    `json:"stop_sequences,omitempty"`
+	Stream        bool        `json:"stream,omitempty"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock is the union of the text, tool_use and tool_result content blocks.
+type contentBlock struct {
+	Type string `json:"type"`
+	// text
+	Text string `json:"text,omitempty"`
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type toolDef struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type toolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// response is the response of a non-streaming call to the Messages API.
+type response struct {
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      usage          `json:"usage"`
+}
+
+// Generate implements model.BaseChatModel.
+func (m *ChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	options := m.getOptions(opts...)
+	req, err := m.buildRequest(input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := m.send(ctx, req, options)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp response
+	if err = json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decode claude response failed: %w", err)
+	}
+
+	result := &schema.Message{
+		Role: schema.Assistant,
+		ResponseMeta: &schema.ResponseMeta{
+			FinishReason: resp.StopReason,
+			Usage:        toTokenUsage(resp.Usage),
+		},
+	}
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			result.Content += block.Text
+		case "tool_use":
+			result.ToolCalls = append(result.ToolCalls, schema.ToolCall{
+				ID:   block.ID,
+				Type: "function",
+				Function: schema.FunctionCall{
+					Name:      block.Name,
+					Arguments: string(block.Input),
+				},
+			})
+		}
+	}
+	return result, nil
+}
+
+// Stream implements model.BaseChatModel. Tool call arguments arrive as input_json_delta chunks
+// and are merged by Index when the stream is concatenated.
+func (m *ChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	options := m.getOptions(opts...)
+	req, err := m.buildRequest(input, options)
+	if err != nil {
+		return nil, err
+	}
+	req.Stream = true
+
+	httpResp, err := m.send(ctx, req, options)
+	if err != nil {
+		return nil, err
+	}
+
+	sr, sw := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer httpResp.Body.Close()
+		sw.CloseWithError(readEvents(httpResp.Body, func(msg *schema.Message) bool {
+			return sw.Send(msg, nil)
+		}))
+	}()
+
+	return sr, nil
+}
+
+// streamEvent is an event of a streaming response, with only the fields in use.
+type streamEvent struct {
+	Type         string       `json:"type"`
+	Index        int          `json:"index"`
+	ContentBlock contentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Message struct {
+		Usage usage `json:"usage"`
+	} `json:"message"`
+	Usage usage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// readEvents parses the SSE events into message chunks. send returns true when the reader is closed.
+// A nil error means the stream ended normally.
+func readEvents(body io.Reader, send func(msg *schema.Message) bool) error {
+	// content block indexes count text blocks too, so tool calls are indexed by tool_use blocks only
+	toolIndexes := make(map[int]int)
+	var inputTokens int
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var event streamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			return fmt.Errorf("decode claude stream event failed: %w", err)
+		}
+
+		var msg *schema.Message
+		switch event.Type {
+		case "message_start":
+			inputTokens = event.Message.Usage.InputTokens
+		case "content_block_start":
+			if event.ContentBlock.Type == "tool_use" {
+				index := len(toolIndexes)
+				toolIndexes[event.Index] = index
+				msg = &schema.Message{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{
+					Index:    &index,
+					ID:       event.ContentBlock.ID,
+					Type:     "function",
+					Function: schema.FunctionCall{Name: event.ContentBlock.Name},
+				}}}
+			} else if event.ContentBlock.Text != "" {
+				msg = &schema.Message{Role: schema.Assistant, Content: event.ContentBlock.Text}
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				msg = &schema.Message{Role: schema.Assistant, Content: event.Delta.Text}
+			case "input_json_delta":
+				index, ok := toolIndexes[event.Index]
+				if !ok {
+					return fmt.Errorf("input_json_delta of unknown content block: %d", event.Index)
+				}
+				msg = &schema.Message{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{
+					Index:    &index,
+					Function: schema.FunctionCall{Arguments: event.Delta.PartialJSON},
+				}}}
+			}
+		case "message_delta":
+			u := event.Usage
+			u.InputTokens = inputTokens
+			msg = &schema.Message{Role: schema.Assistant, ResponseMeta: &schema.ResponseMeta{
+				FinishReason: event.Delta.StopReason,
+				Usage:        toTokenUsage(u),
+			}}
+		case "message_stop":
+			return nil
+		case "error":
+			return fmt.Errorf("claude stream error[%s]: %s", event.Error.Type, event.Error.Message)
+		}
+
+		if msg != nil {
+			if closed := send(msg); closed {
+				return nil
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// WithTools implements model.ToolCallingChatModel. It replaces the bound tools and returns a new instance.
+func (m *ChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	if err := modelutil.ValidateTools(tools); err != nil {
+		return nil, err
+	}
+	newModel := *m
+	newModel.tools = slices.Clone(tools)
+	return &newModel, nil
+}
+
+// getOptions merges the defaults of the instance with the options of a call.
+func (m *ChatModel) getOptions(opts ...model.Option) *model.Options {
+	base := &model.Options{Tools: m.tools, MaxTokens: &m.maxTokens}
+	if m.modelName != "" {
+		base.Model = &m.modelName
+	}
+	return model.GetCommonOptions(base, opts...)
+}
+
+// buildRequest builds the request body. System messages are moved to the separate system field.
+func (m *ChatModel) buildRequest(input []*schema.Message, options *model.Options) (*request, error) {
+	if options.Model == nil || *options.Model == "" {
+		return nil, fmt.Errorf("model name is not set, set it by WithModelName or model.WithModel")
+	}
+
+	req := &request{
+		Model:         *options.Model,
+		MaxTokens:     *options.MaxTokens,
+		StopSequences: options.Stop,
+	}
+	if options.Temperature != nil {
+		t := modelutil.Float32To64(*options.Temperature)
+		req.Temperature = &t
+	}
+	if options.TopP != nil {
+		p := modelutil.Float32To64(*options.TopP)
+		req.TopP = &p
+	}
+
+	var err error
+	if req.System, req.Messages, err = toMessages(input); err != nil {
+		return nil, err
+	}
+
+	toolInfos, err := modelutil.FilterAllowedTools(options.Tools, options.AllowedToolNames)
+	if err != nil {
+		return nil, err
+	}
+	if req.Tools, err = toTools(toolInfos); err != nil {
+		return nil, err
+	}
+	if req.ToolChoice, err = toToolChoice(options); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// toMessages converts the messages to the Anthropic format: system messages are joined into the system field,
+// tool calls become tool_use blocks and tool results become tool_result blocks of user messages.
+// Adjacent messages of the same role are merged, since user and assistant must alternate.
+func toMessages(input []*schema.Message) (string, []message, error) {
+	var systems []string
+	messages := make([]message, 0, len(input))
+	appendBlocks := func(role string, blocks ...contentBlock) {
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, message{Role: role, Content: blocks})
+	}
+
+	for _, msg := range input {
+		switch msg.Role {
+		case schema.System:
+			systems = append(systems, modelutil.TextContent(msg))
+		case schema.User:
+			appendBlocks("user", contentBlock{Type: "text", Text: modelutil.TextContent(msg)})
+		case schema.Assistant:
+			var blocks []contentBlock
+			if msg.Content != "" {
+				blocks = append(blocks, contentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				args := tc.Function.Arguments
+				if args == "" {
+					args = "{}"
+				}
+				if !json.Valid([]byte(args)) {
+					return "", nil, fmt.Errorf("arguments of tool call[%s] are not valid JSON: %s", tc.ID, args)
+				}
+				blocks = append(blocks, contentBlock{Type: "tool_use", ID: tc.ID, Name: tc.Function.Name, Input: json.RawMessage(args)})
+			}
+			if len(blocks) > 0 {
+				appendBlocks("assistant", blocks...)
+			}
+		case schema.Tool:
+			appendBlocks("user", contentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: modelutil.TextContent(msg)})
+		default:
+			return "", nil, fmt.Errorf("unknown message role: %s", msg.Role)
+		}
+	}
+	return strings.Join(systems, "\n\n"), messages, nil
+}
+
+// toTools converts the tools to the Anthropic format, with the JSON Schema of the parameters as input_schema.
+func toTools(toolInfos []*schema.ToolInfo) ([]toolDef, error) {
+	tools := make([]toolDef, 0, len(toolInfos))
+	for _, info := range toolInfos {
+		def, err := schema.ToAnthropicTool(info)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, toolDef{Name: info.Name, Description: info.Desc, InputSchema: def["input_schema"]})
+	}
+	return tools, nil
+}
+
+// toToolChoice maps schema.ToolChoice to the tool_choice parameter.
+func toToolChoice(options *model.Options) (*toolChoice, error) {
+	if options.ToolChoice == nil {
+		return nil, nil
+	}
+	switch *options.ToolChoice {
+	case schema.ToolChoiceForbidden:
+		return &toolChoice{Type: "none"}, nil
+	case schema.ToolChoiceAllowed:
+		return &toolChoice{Type: "auto"}, nil
+	case schema.ToolChoiceForced:
+		if len(options.AllowedToolNames) == 1 {
+			return &toolChoice{Type: "tool", Name: options.AllowedToolNames[0]}, nil
+		}
+		return &toolChoice{Type: "any"}, nil
+	default:
+		return nil, fmt.Errorf("unknown tool choice: %s", *options.ToolChoice)
+	}
+}
+
+// send sends the request. Non-2xx responses are wrapped with the model error matching the status code.
+func (m *ChatModel) send(ctx context.Context, req *request, options *model.Options) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Api-Key", m.apiKey)
+	httpReq.Header.Set("Anthropic-Version", apiVersion)
+	modelutil.SetHeaders(httpReq, options.Headers)
+
+	resp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(resp.Body)
+	return nil, modelutil.ClassifyHTTPStatusError(resp.StatusCode, fmt.Errorf("claude api returns status %d: %s", resp.StatusCode, msg))
+}
+
+func toTokenUsage(u usage) *schema.TokenUsage {
+	return &schema.TokenUsage{
+		PromptTokens:     u.InputTokens,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      u.InputTokens + u.OutputTokens,
+	}
+}
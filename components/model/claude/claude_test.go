@@ -0,0 +1,305 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+)
+
+// stubServer simulates the Anthropic Messages API and records the requests it receives.
+type stubServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []map[string]any
+	headers  []http.Header
+	// queue holds the responses in order: a JSON body for non-streaming requests,
+	// the data of the SSE events for streaming requests, or a status code.
+	queue []any
+}
+
+func newStubServer(t *testing.T) *stubServer {
+	s := &stubServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, body)
+		s.headers = append(s.headers, r.Header.Clone())
+		var resp any
+		if len(s.queue) > 0 {
+			resp, s.queue = s.queue[0], s.queue[1:]
+		}
+		s.mu.Unlock()
+
+		switch v := resp.(type) {
+		case []string:
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, data := range v {
+				_, _ = fmt.Fprintf(w, "event: stub\ndata: %s\n\n", data)
+			}
+		case int:
+			http.Error(w, `{"type":"error","error":{"type":"stub","message":"stub error"}}`, v)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, v)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubServer) model(opts ...Option) *ChatModel {
+	return NewChatModel(append([]Option{
+		WithBaseURL(s.URL),
+		WithAPIKey("stub"),
+		WithModelName("stub-model"),
+	}, opts...)...)
+}
+
+func (s *stubServer) enqueue(resps ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, resps...)
+}
+
+func (s *stubServer) lastRequest() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+func (s *stubServer) lastHeader() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.headers) == 0 {
+		return nil
+	}
+	return s.headers[len(s.headers)-1]
+}
+
+var stubWeatherToolInfo = &schema.ToolInfo{
+	Name: "get_weather",
+	Desc: "get weather of a city",
+	ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"city": {Type: schema.String, Desc: "city name", Required: true},
+	}),
+}
+
+// newWeatherTool returns a get_weather tool that does not access the network.
+func newWeatherTool() tool.InvokableTool {
+	type weatherReq struct {
+		City string `json:"city"`
+	}
+	type weatherResp struct {
+		Weather string `json:"weather"`
+		Temp    int    `json:"temp"`
+	}
+	return utils.NewTool[weatherReq, weatherResp](stubWeatherToolInfo,
+		func(ctx context.Context, req weatherReq) (weatherResp, error) {
+			return weatherResp{Weather: "Sunny", Temp: 25}, nil
+		})
+}
+
+func TestChatModelGenerate(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubServer(t)
+
+	srv.enqueue(`{"content":[{"type":"text","text":"let me check"},{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"beijing"}}],` +
+		`"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`)
+
+	m, err := srv.model().WithTools([]*schema.ToolInfo{stubWeatherToolInfo})
+	assert.NoError(t, err)
+
+	out, err := m.Generate(ctx, []*schema.Message{
+		schema.SystemMessage("you are a helpful assistant."),
+		schema.SystemMessage("answer briefly."),
+		schema.UserMessage("weather of beijing and shanghai?"),
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "toolu_0", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"shanghai"}`}},
+		}),
+		schema.ToolMessage("sunny", "toolu_0"),
+	}, model.WithTemperature(0.7), model.WithHeaders(map[string]string{"X-Trace": "trace"}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "let me check", out.Content)
+	assert.Equal(t, []schema.ToolCall{{ID: "toolu_1", Type: "function", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"beijing"}`}}}, out.ToolCalls)
+	assert.Equal(t, "tool_use", out.ResponseMeta.FinishReason)
+	assert.Equal(t, 15, out.ResponseMeta.Usage.TotalTokens)
+
+	header := srv.lastHeader()
+	assert.Equal(t, "stub", header.Get("X-Api-Key"))
+	assert.Equal(t, apiVersion, header.Get("Anthropic-Version"))
+	assert.Equal(t, "trace", header.Get("X-Trace"))
+
+	req := srv.lastRequest()
+	assert.Equal(t, "stub-model", req["model"])
+	assert.Equal(t, float64(defaultMaxTokens), req["max_tokens"])
+	assert.Equal(t, 0.7, req["temperature"])
+	assert.Equal(t, "you are a helpful assistant.\n\nanswer briefly.", req["system"])
+	assert.Equal(t, []any{
+		map[string]any{"role": "user", "content": []any{map[string]any{"type": "text", "text": "weather of beijing and shanghai?"}}},
+		map[string]any{"role": "assistant", "content": []any{map[string]any{
+			"type": "tool_use", "id": "toolu_0", "name": "get_weather", "input": map[string]any{"city": "shanghai"},
+		}}},
+		map[string]any{"role": "user", "content": []any{map[string]any{"type": "tool_result", "tool_use_id": "toolu_0", "content": "sunny"}}},
+	}, req["messages"])
+
+	tools := req["tools"].([]any)
+	assert.Len(t, tools, 1)
+	assert.Equal(t, "get_weather", tools[0].(map[string]any)["name"])
+	inputSchema := tools[0].(map[string]any)["input_schema"].(map[string]any)
+	assert.Equal(t, "object", inputSchema["type"])
+	assert.Equal(t, []any{"city"}, inputSchema["required"])
+}
+
+func TestChatModelToolChoice(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubServer(t)
+	m, err := srv.model().WithTools([]*schema.ToolInfo{stubWeatherToolInfo})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		opts     []model.Option
+		expected any
+	}{
+		{[]model.Option{model.WithToolChoice(schema.ToolChoiceForbidden)}, map[string]any{"type": "none"}},
+		{[]model.Option{model.WithToolChoice(schema.ToolChoiceAllowed)}, map[string]any{"type": "auto"}},
+		{[]model.Option{model.WithToolChoice(schema.ToolChoiceForced)}, map[string]any{"type": "any"}},
+		{[]model.Option{model.WithToolChoice(schema.ToolChoiceForced, "get_weather")}, map[string]any{"type": "tool", "name": "get_weather"}},
+	}
+	for _, c := range cases {
+		srv.enqueue(`{"content":[{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}],"stop_reason":"tool_use"}`)
+		_, err = m.Generate(ctx, []*schema.Message{schema.UserMessage("hi")}, c.opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, srv.lastRequest()["tool_choice"])
+	}
+}
+
+func TestChatModelStream(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubServer(t)
+	srv.enqueue([]string{
+		`{"type":"message_start","message":{"usage":{"input_tokens":10,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"ping"}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"let me "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"check"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\": "}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"beijing\"}"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":8}}`,
+		`{"type":"message_stop"}`,
+	})
+
+	m, err := srv.model().WithTools([]*schema.ToolInfo{stubWeatherToolInfo})
+	assert.NoError(t, err)
+
+	sr, err := m.Stream(ctx, []*schema.Message{schema.UserMessage("weather of beijing?")})
+	assert.NoError(t, err)
+	out, err := schema.ConcatMessageStream(sr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, true, srv.lastRequest()["stream"])
+	assert.Equal(t, "let me check", out.Content)
+	assert.Len(t, out.ToolCalls, 1)
+	assert.Equal(t, "toolu_1", out.ToolCalls[0].ID)
+	assert.Equal(t, "get_weather", out.ToolCalls[0].Function.Name)
+	assert.Equal(t, `{"city": "beijing"}`, out.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, "tool_use", out.ResponseMeta.FinishReason)
+	assert.Equal(t, 18, out.ResponseMeta.Usage.TotalTokens)
+
+	t.Run("error event", func(t *testing.T) {
+		srv.enqueue([]string{
+			`{"type":"message_start","message":{"usage":{"input_tokens":10}}}`,
+			`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+		})
+		sr, err := m.Stream(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+		_, err = schema.ConcatMessageStream(sr)
+		assert.ErrorContains(t, err, "overloaded_error")
+	})
+}
+
+func TestChatModelErrorClassification(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubServer(t)
+	m := srv.model()
+
+	for status, expected := range map[int]error{
+		http.StatusTooManyRequests:     model.ErrRateLimited,
+		529:                            model.ErrModelUnavailable,
+		http.StatusBadRequest:          model.ErrBadRequest,
+		http.StatusInternalServerError: model.ErrModelUnavailable,
+	} {
+		srv.enqueue(status)
+		_, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.True(t, errors.Is(err, expected), "status %d: %v", status, err)
+	}
+
+	_, err := NewChatModel(WithBaseURL(srv.URL)).Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+	assert.ErrorContains(t, err, "model name is not set")
+}
+
+func TestChatModelInReactAgent(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubServer(t)
+	srv.enqueue(
+		`{"content":[{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"beijing"}}],"stop_reason":"tool_use"}`,
+		`{"content":[{"type":"text","text":"it's sunny in beijing"}],"stop_reason":"end_turn"}`,
+	)
+
+	agent, err := react.NewAgent(ctx, &react.AgentConfig{
+		ToolCallingModel: srv.model(),
+		ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{newWeatherTool()}},
+	})
+	assert.NoError(t, err)
+
+	out, err := agent.Generate(ctx, []*schema.Message{schema.UserMessage("weather of beijing?")})
+	assert.NoError(t, err)
+	assert.Equal(t, "it's sunny in beijing", out.Content)
+
+	messages := srv.lastRequest()["messages"].([]any)
+	assert.Len(t, messages, 3)
+	assert.Equal(t, map[string]any{"role": "user", "content": []any{map[string]any{
+		"type": "tool_result", "tool_use_id": "toolu_1", "content": `{"weather":"Sunny","temp":25}`,
+	}}}, messages[2])
+}
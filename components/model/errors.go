@@ -0,0 +1,30 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "errors"
+
+// Errors classifying the failures of model calls, which implementations should wrap, so that callers could tell them apart by errors.Is,
+// e.g. to decide whether to retry or what to tell the user.
+var (
+	// ErrRateLimited indicates the request is rejected due to rate limit, which is usually retryable after a while.
+	ErrRateLimited = errors.New("model rate limited")
+	// ErrBadRequest indicates the request is invalid, e.g. malformed messages or unsupported parameters, which shouldn't be retried as is.
+	ErrBadRequest = errors.New("model bad request")
+	// ErrModelUnavailable indicates the model service is unavailable or overloaded for now, which is usually retryable.
+	ErrModelUnavailable = errors.New("model unavailable")
+)
@@ -54,3 +54,9 @@ type ToolCallingChatModel interface {
 	// This method does not modify the current instance, making it safer for concurrent use.
 	WithTools(tools []*schema.ToolInfo) (ToolCallingChatModel, error)
 }
+
+// ToolsAdvertiser is an optional interface for chat models to report the tools bound to them, e.g. by WithTools or BindTools.
+// It lets a graph find out a chat model deciding tool calls has no tools to call, see compose.WithToolDecider.
+type ToolsAdvertiser interface {
+	BoundTools() []*schema.ToolInfo
+}
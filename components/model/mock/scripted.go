@@ -0,0 +1,113 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mock provides a scripted chat model, to test graphs and agents deterministically without calling a real model.
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ScriptedCall records a call to the ScriptedChatModel.
+type ScriptedCall struct {
+	Input   []*schema.Message
+	Tools   []*schema.ToolInfo
+	Options *model.Options
+}
+
+// script keeps the scripted responses and the calls, shared by the instances returned by WithTools.
+type script struct {
+	mu        sync.Mutex
+	responses []*schema.Message
+	calls     []ScriptedCall
+}
+
+// ScriptedChatModel returns the scripted responses in order, and records the input of each call,
+// e.g. a tool call message followed by a final answer to drive a multi-turn ReAct loop.
+// It returns an error once the responses are exhausted.
+type ScriptedChatModel struct {
+	script *script
+	tools  []*schema.ToolInfo
+}
+
+var _ model.ToolCallingChatModel = (*ScriptedChatModel)(nil)
+
+// NewScriptedChatModel creates a chat model answering with responses in order.
+// e.g.
+//
+//	cm := mock.NewScriptedChatModel(
+//		schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"Beijing"}`}}}),
+//		schema.AssistantMessage("it's sunny in Beijing", nil),
+//	)
+func NewScriptedChatModel(responses ...*schema.Message) *ScriptedChatModel {
+	return &ScriptedChatModel{
+		script: &script{responses: responses},
+	}
+}
+
+func (m *ScriptedChatModel) next(input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	options := model.GetCommonOptions(&model.Options{Tools: m.tools}, opts...)
+
+	m.script.mu.Lock()
+	defer m.script.mu.Unlock()
+
+	m.script.calls = append(m.script.calls, ScriptedCall{
+		Input:   input,
+		Tools:   options.Tools,
+		Options: options,
+	})
+	if len(m.script.calls) > len(m.script.responses) {
+		return nil, fmt.Errorf("scripted model exhausted: call %d, but only %d responses scripted",
+			len(m.script.calls), len(m.script.responses))
+	}
+	return m.script.responses[len(m.script.calls)-1], nil
+}
+
+// Generate returns the next scripted response.
+func (m *ScriptedChatModel) Generate(_ context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.next(input, opts...)
+}
+
+// Stream returns the next scripted response as a stream of a single chunk.
+func (m *ScriptedChatModel) Stream(_ context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.next(input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+// WithTools returns a new instance bound with tools, which shares the scripted responses and the calls with the original one.
+func (m *ScriptedChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return &ScriptedChatModel{
+		script: m.script,
+		tools:  tools,
+	}, nil
+}
+
+// Calls returns all the calls so far.
+func (m *ScriptedChatModel) Calls() []ScriptedCall {
+	m.script.mu.Lock()
+	defer m.script.mu.Unlock()
+	calls := make([]ScriptedCall, len(m.script.calls))
+	copy(calls, m.script.calls)
+	return calls
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mock
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestScriptedChatModel(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("responses in order", func(t *testing.T) {
+		cm := NewScriptedChatModel(schema.AssistantMessage("first", nil), schema.AssistantMessage("second", nil))
+
+		out, err := cm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")}, model.WithTemperature(0.5))
+		assert.NoError(t, err)
+		assert.Equal(t, "first", out.Content)
+
+		sr, err := cm.Stream(ctx, []*schema.Message{schema.UserMessage("hello")})
+		assert.NoError(t, err)
+		chunk, err := sr.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, "second", chunk.Content)
+		_, err = sr.Recv()
+		assert.Equal(t, io.EOF, err)
+
+		calls := cm.Calls()
+		assert.Len(t, calls, 2)
+		assert.Equal(t, []*schema.Message{schema.UserMessage("hi")}, calls[0].Input)
+		assert.Equal(t, float32(0.5), *calls[0].Options.Temperature)
+		assert.Equal(t, []*schema.Message{schema.UserMessage("hello")}, calls[1].Input)
+	})
+
+	t.Run("with tools", func(t *testing.T) {
+		cm := NewScriptedChatModel(schema.AssistantMessage("first", nil), schema.AssistantMessage("second", nil))
+		tools := []*schema.ToolInfo{{Name: "get_weather"}}
+
+		bound, err := cm.WithTools(tools)
+		assert.NoError(t, err)
+		_, err = bound.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+		_, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+
+		// the bound instance shares the responses and the calls
+		calls := cm.Calls()
+		assert.Len(t, calls, 2)
+		assert.Equal(t, tools, calls[0].Tools)
+		assert.Nil(t, calls[1].Tools)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		cm := NewScriptedChatModel()
+		_, err := cm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.ErrorContains(t, err, "scripted model exhausted: call 1, but only 0 responses scripted")
+		_, err = cm.Stream(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.ErrorContains(t, err, "scripted model exhausted")
+	})
+}
@@ -0,0 +1,409 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ollama provides a ToolCallingChatModel backed by the /api/chat endpoint of an Ollama server.
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/internal/modelutil"
+	"github.com/cloudwego/eino/schema"
+)
+
+const defaultBaseURL = "http://localhost:11434"
+
+// ChatModel implements model.ToolCallingChatModel on top of the Ollama /api/chat endpoint.
+// It needs no API key, which makes it handy for local and offline development.
+type ChatModel struct {
+	httpClient *http.Client
+	baseURL    string
+	tools      []*schema.ToolInfo
+	// modelName is the default model, e.g. "qwen2.5:0.5b", which can be overridden per call by model.WithModel.
+	modelName string
+}
+
+// Option configures a ChatModel created by NewChatModel.
+type Option func(m *ChatModel)
+
+// WithModelName sets the default model name.
+func WithModelName(name string) Option {
+	return func(m *ChatModel) {
+		m.modelName = name
+	}
+}
+
+// WithBaseURL sets the address of the Ollama server, http://localhost:11434 by default.
+func WithBaseURL(url string) Option {
+	return func(m *ChatModel) {
+		m.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(m *ChatModel) {
+		m.httpClient = c
+	}
+}
+
+// NewChatModel creates a ChatModel. Tools are bound by WithTools.
+// eg:
+//
+//	m := ollama.NewChatModel(ollama.WithModelName("qwen2.5:0.5b"))
+func NewChatModel(opts ...Option) *ChatModel {
+	m := &ChatModel{
+		httpClient: http.DefaultClient,
+		baseURL:    defaultBaseURL,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// request is the request body of /api/chat.
+type request struct {
+	Model    string         `json:"model"`
+	Messages []message      `json:"messages"`
+	Tools    []toolDef      `json:"tools,omitempty"`
+	Format   any            `json:"format,omitempty"`
+	Options  map[string]any `json:"options,omitempty"`
+	// Stream defaults to true on the server, so it must not be omitted.
+	Stream bool `json:"stream"`
+}
+
+type message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+// toolCall is a tool call of Ollama. It has no ID, and its arguments are a JSON object rather than a string.
+type toolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type toolDef struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Parameters  any    `json:"parameters"`
+	} `json:"function"`
+}
+
+// response is the response of a non-streaming call. Each line of a streaming response is a chunk of the same shape.
+type response struct {
+	Message         message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+	Error           string  `json:"error"`
+}
+
+// Generate implements model.BaseChatModel.
+func (m *ChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	options := m.getOptions(opts...)
+	req, err := m.buildRequest(input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := m.send(ctx, req, options)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp response
+	if err = json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decode ollama response failed: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", resp.Error)
+	}
+
+	toolCallIndex := 0
+	return resp.toMessage(&toolCallIndex, false), nil
+}
+
+// Stream implements model.BaseChatModel. The response is line-delimited JSON, and each tool call arrives whole in one chunk.
+func (m *ChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	options := m.getOptions(opts...)
+	req, err := m.buildRequest(input, options)
+	if err != nil {
+		return nil, err
+	}
+	req.Stream = true
+
+	httpResp, err := m.send(ctx, req, options)
+	if err != nil {
+		return nil, err
+	}
+
+	sr, sw := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer httpResp.Body.Close()
+		sw.CloseWithError(readChunks(httpResp.Body, func(msg *schema.Message) bool {
+			return sw.Send(msg, nil)
+		}))
+	}()
+
+	return sr, nil
+}
+
+// readChunks parses the streaming response line by line into message chunks. send returns true when the reader is closed.
+// A nil error means the stream ended normally.
+func readChunks(body io.Reader, send func(msg *schema.Message) bool) error {
+	toolCallIndex := 0
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk response
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("decode ollama stream chunk failed: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if closed := send(chunk.toMessage(&toolCallIndex, true)); closed {
+			return nil
+		}
+		if chunk.Done {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// toMessage converts r to a schema.Message. Ollama returns no tool call IDs, so IDs unique within a response are made from toolCallIndex.
+// Streaming chunks also set Index, so that the tool calls stay apart when the stream is concatenated.
+func (r *response) toMessage(toolCallIndex *int, isStream bool) *schema.Message {
+	msg := &schema.Message{
+		Role:    schema.Assistant,
+		Content: r.Message.Content,
+	}
+	for _, tc := range r.Message.ToolCalls {
+		index := *toolCallIndex
+		*toolCallIndex++
+		args := string(tc.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		call := schema.ToolCall{
+			ID:       fmt.Sprintf("call_%d", index),
+			Type:     "function",
+			Function: schema.FunctionCall{Name: tc.Function.Name, Arguments: args},
+		}
+		if isStream {
+			call.Index = &index
+		}
+		msg.ToolCalls = append(msg.ToolCalls, call)
+	}
+	if r.Done {
+		finishReason := r.DoneReason
+		if len(r.Message.ToolCalls) > 0 || *toolCallIndex > 0 {
+			finishReason = "tool_calls"
+		}
+		msg.ResponseMeta = &schema.ResponseMeta{
+			FinishReason: finishReason,
+			Usage: &schema.TokenUsage{
+				PromptTokens:     r.PromptEvalCount,
+				CompletionTokens: r.EvalCount,
+				TotalTokens:      r.PromptEvalCount + r.EvalCount,
+			},
+		}
+	}
+	return msg
+}
+
+// WithTools implements model.ToolCallingChatModel. It replaces the bound tools and returns a new instance.
+func (m *ChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	if err := modelutil.ValidateTools(tools); err != nil {
+		return nil, err
+	}
+	newModel := *m
+	newModel.tools = slices.Clone(tools)
+	return &newModel, nil
+}
+
+// getOptions merges the defaults of the instance with the options of a call.
+func (m *ChatModel) getOptions(opts ...model.Option) *model.Options {
+	base := &model.Options{Tools: m.tools}
+	if m.modelName != "" {
+		base.Model = &m.modelName
+	}
+	return model.GetCommonOptions(base, opts...)
+}
+
+// buildRequest builds the request body. Sampling parameters go to options, where max_tokens is num_predict.
+func (m *ChatModel) buildRequest(input []*schema.Message, options *model.Options) (*request, error) {
+	if options.Model == nil || *options.Model == "" {
+		return nil, fmt.Errorf("model name is not set, set it by WithModelName or model.WithModel")
+	}
+
+	req := &request{Model: *options.Model}
+	var err error
+	if req.Messages, err = toMessages(input); err != nil {
+		return nil, err
+	}
+
+	// Ollama has no tool_choice, so tools are left out when tool calls are forbidden
+	if options.ToolChoice == nil || *options.ToolChoice != schema.ToolChoiceForbidden {
+		toolInfos, err := modelutil.FilterAllowedTools(options.Tools, options.AllowedToolNames)
+		if err != nil {
+			return nil, err
+		}
+		if req.Tools, err = toTools(toolInfos); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.ResponseFormat != nil {
+		switch options.ResponseFormat.Type {
+		case schema.ResponseFormatTypeJSONObject:
+			req.Format = "json"
+		case schema.ResponseFormatTypeJSONSchema:
+			if options.ResponseFormat.JSONSchema == nil || options.ResponseFormat.JSONSchema.Schema == nil {
+				return nil, fmt.Errorf("schema is required for json_schema response format")
+			}
+			req.Format = options.ResponseFormat.JSONSchema.Schema
+		}
+	}
+
+	opts := map[string]any{}
+	if options.Temperature != nil {
+		opts["temperature"] = modelutil.Float32To64(*options.Temperature)
+	}
+	if options.TopP != nil {
+		opts["top_p"] = modelutil.Float32To64(*options.TopP)
+	}
+	if options.MaxTokens != nil {
+		opts["num_predict"] = *options.MaxTokens
+	}
+	if len(options.Stop) > 0 {
+		opts["stop"] = options.Stop
+	}
+	if len(opts) > 0 {
+		req.Options = opts
+	}
+
+	return req, nil
+}
+
+// toMessages converts the messages to the Ollama format. Tool call arguments become JSON objects, and images must be base64 data.
+func toMessages(input []*schema.Message) ([]message, error) {
+	messages := make([]message, 0, len(input))
+	for _, msg := range input {
+		om := message{Role: string(msg.Role), Content: modelutil.TextContent(msg)}
+		switch msg.Role {
+		case schema.System:
+		case schema.User:
+			for _, part := range msg.UserInputMultiContent {
+				if part.Type != schema.ChatMessagePartTypeImageURL || part.Image == nil {
+					continue
+				}
+				if part.Image.Base64Data == nil || *part.Image.Base64Data == "" {
+					return nil, fmt.Errorf("ollama only supports images in base64 data")
+				}
+				om.Images = append(om.Images, *part.Image.Base64Data)
+			}
+		case schema.Assistant:
+			for _, tc := range msg.ToolCalls {
+				args := tc.Function.Arguments
+				if args == "" {
+					args = "{}"
+				}
+				if !json.Valid([]byte(args)) {
+					return nil, fmt.Errorf("arguments of tool call[%s] are not valid JSON: %s", tc.ID, args)
+				}
+				var call toolCall
+				call.Function.Name = tc.Function.Name
+				call.Function.Arguments = json.RawMessage(args)
+				om.ToolCalls = append(om.ToolCalls, call)
+			}
+		case schema.Tool:
+			om.ToolName = msg.ToolName
+		default:
+			return nil, fmt.Errorf("unknown message role: %s", msg.Role)
+		}
+		messages = append(messages, om)
+	}
+	return messages, nil
+}
+
+// toTools converts the tools to the Ollama format, which is the OpenAI function format.
+func toTools(toolInfos []*schema.ToolInfo) ([]toolDef, error) {
+	tools := make([]toolDef, 0, len(toolInfos))
+	for _, info := range toolInfos {
+		def, err := schema.ToOpenAITool(info)
+		if err != nil {
+			return nil, err
+		}
+		var t toolDef
+		t.Type = "function"
+		t.Function.Name = info.Name
+		t.Function.Description = info.Desc
+		t.Function.Parameters = def["function"].(map[string]any)["parameters"]
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// send sends the request. Non-2xx responses are wrapped with the model error matching the status code.
+func (m *ChatModel) send(ctx context.Context, req *request, options *model.Options) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	modelutil.SetHeaders(httpReq, options.Headers)
+
+	resp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(resp.Body)
+	return nil, modelutil.ClassifyHTTPStatusError(resp.StatusCode, fmt.Errorf("ollama api returns status %d: %s", resp.StatusCode, msg))
+}
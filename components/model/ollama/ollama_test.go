@@ -0,0 +1,272 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+)
+
+// stubServer simulates the Ollama /api/chat endpoint and records the requests it receives.
+type stubServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []map[string]any
+	// queue holds the responses in order: a JSON body for non-streaming requests,
+	// the JSON lines for streaming requests, or a status code.
+	queue []any
+}
+
+func newStubServer(t *testing.T) *stubServer {
+	s := &stubServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.URL.Path != "/api/chat" {
+			http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, body)
+		var resp any
+		if len(s.queue) > 0 {
+			resp, s.queue = s.queue[0], s.queue[1:]
+		}
+		s.mu.Unlock()
+
+		switch v := resp.(type) {
+		case []string:
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for _, line := range v {
+				_, _ = fmt.Fprintln(w, line)
+			}
+		case int:
+			http.Error(w, `{"error":"stub error"}`, v)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, v)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubServer) model() *ChatModel {
+	return NewChatModel(WithBaseURL(s.URL), WithModelName("stub-model"))
+}
+
+func (s *stubServer) enqueue(resps ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, resps...)
+}
+
+func (s *stubServer) lastRequest() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+var stubWeatherToolInfo = &schema.ToolInfo{
+	Name: "get_weather",
+	Desc: "get weather of a city",
+	ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"city": {Type: schema.String, Desc: "city name", Required: true},
+	}),
+}
+
+// newWeatherTool returns a get_weather tool that does not access the network.
+func newWeatherTool() tool.InvokableTool {
+	type weatherReq struct {
+		City string `json:"city"`
+	}
+	type weatherResp struct {
+		Weather string `json:"weather"`
+		Temp    int    `json:"temp"`
+	}
+	return utils.NewTool[weatherReq, weatherResp](stubWeatherToolInfo,
+		func(ctx context.Context, req weatherReq) (weatherResp, error) {
+			return weatherResp{Weather: "Sunny", Temp: 25}, nil
+		})
+}
+
+func TestChatModelGenerate(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubServer(t)
+	srv.enqueue(`{"model":"stub-model","message":{"role":"assistant","content":"","tool_calls":[` +
+		`{"function":{"name":"get_weather","arguments":{"city":"beijing"}}},{"function":{"name":"get_weather","arguments":{"city":"shanghai"}}}]},` +
+		`"done":true,"done_reason":"stop","prompt_eval_count":10,"eval_count":5}`)
+
+	m, err := srv.model().WithTools([]*schema.ToolInfo{stubWeatherToolInfo})
+	assert.NoError(t, err)
+
+	out, err := m.Generate(ctx, []*schema.Message{
+		schema.SystemMessage("you are a helpful assistant."),
+		schema.UserMessage("weather?"),
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "call_0", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"hangzhou"}`}},
+		}),
+		schema.ToolMessage("sunny", "call_0", schema.WithToolName("get_weather")),
+	}, model.WithTemperature(0.2), model.WithMaxTokens(100), model.WithStop([]string{"\n\n"}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []schema.ToolCall{
+		{ID: "call_0", Type: "function", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"beijing"}`}},
+		{ID: "call_1", Type: "function", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"shanghai"}`}},
+	}, out.ToolCalls)
+	assert.Equal(t, "tool_calls", out.ResponseMeta.FinishReason)
+	assert.Equal(t, 15, out.ResponseMeta.Usage.TotalTokens)
+
+	req := srv.lastRequest()
+	assert.Equal(t, "stub-model", req["model"])
+	assert.Equal(t, false, req["stream"])
+	assert.Equal(t, map[string]any{"temperature": 0.2, "num_predict": float64(100), "stop": []any{"\n\n"}}, req["options"])
+	assert.Equal(t, []any{
+		map[string]any{"role": "system", "content": "you are a helpful assistant."},
+		map[string]any{"role": "user", "content": "weather?"},
+		map[string]any{"role": "assistant", "content": "", "tool_calls": []any{map[string]any{
+			"function": map[string]any{"name": "get_weather", "arguments": map[string]any{"city": "hangzhou"}},
+		}}},
+		map[string]any{"role": "tool", "content": "sunny", "tool_name": "get_weather"},
+	}, req["messages"])
+
+	tools := req["tools"].([]any)
+	assert.Len(t, tools, 1)
+	function := tools[0].(map[string]any)["function"].(map[string]any)
+	assert.Equal(t, "get_weather", function["name"])
+	assert.Equal(t, []any{"city"}, function["parameters"].(map[string]any)["required"])
+
+	t.Run("tools forbidden", func(t *testing.T) {
+		srv.enqueue(`{"message":{"role":"assistant","content":"hi"},"done":true,"done_reason":"stop"}`)
+		out, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("hi")}, model.WithToolChoice(schema.ToolChoiceForbidden))
+		assert.NoError(t, err)
+		assert.Equal(t, "hi", out.Content)
+		assert.Equal(t, "stop", out.ResponseMeta.FinishReason)
+		assert.NotContains(t, srv.lastRequest(), "tools")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv.enqueue(http.StatusNotFound)
+		_, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.ErrorIs(t, err, model.ErrBadRequest)
+		assert.ErrorContains(t, err, "stub error")
+	})
+}
+
+func TestChatModelStream(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubServer(t)
+	srv.enqueue([]string{
+		`{"message":{"role":"assistant","content":"let me "},"done":false}`,
+		`{"message":{"role":"assistant","content":"check"},"done":false}`,
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"beijing"}}}]},"done":false}`,
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"shanghai"}}}]},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":10,"eval_count":8}`,
+	})
+
+	m, err := srv.model().WithTools([]*schema.ToolInfo{stubWeatherToolInfo})
+	assert.NoError(t, err)
+
+	sr, err := m.Stream(ctx, []*schema.Message{schema.UserMessage("weather?")})
+	assert.NoError(t, err)
+	out, err := schema.ConcatMessageStream(sr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, true, srv.lastRequest()["stream"])
+	assert.Equal(t, "let me check", out.Content)
+	assert.Len(t, out.ToolCalls, 2)
+	assert.Equal(t, "call_0", out.ToolCalls[0].ID)
+	assert.Equal(t, `{"city":"beijing"}`, out.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, "call_1", out.ToolCalls[1].ID)
+	assert.Equal(t, `{"city":"shanghai"}`, out.ToolCalls[1].Function.Arguments)
+	assert.Equal(t, "tool_calls", out.ResponseMeta.FinishReason)
+	assert.Equal(t, 18, out.ResponseMeta.Usage.TotalTokens)
+
+	t.Run("error chunk", func(t *testing.T) {
+		srv.enqueue([]string{
+			`{"message":{"role":"assistant","content":"let me "},"done":false}`,
+			`{"error":"model runner has unexpectedly stopped"}`,
+		})
+		sr, err := m.Stream(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+		_, err = schema.ConcatMessageStream(sr)
+		assert.ErrorContains(t, err, "unexpectedly stopped")
+	})
+}
+
+func TestChatModelInReactAgent(t *testing.T) {
+	ctx := context.Background()
+
+	newAgent := func(m model.ToolCallingChatModel) *react.Agent {
+		agent, err := react.NewAgent(ctx, &react.AgentConfig{
+			ToolCallingModel: m,
+			ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{newWeatherTool()}},
+		})
+		assert.NoError(t, err)
+		return agent
+	}
+
+	t.Run("stub", func(t *testing.T) {
+		srv := newStubServer(t)
+		srv.enqueue(
+			`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"beijing"}}}]},"done":true}`,
+			`{"message":{"role":"assistant","content":"it's sunny in beijing"},"done":true,"done_reason":"stop"}`,
+		)
+
+		out, err := newAgent(srv.model()).Generate(ctx, []*schema.Message{schema.UserMessage("weather of beijing?")})
+		assert.NoError(t, err)
+		assert.Equal(t, "it's sunny in beijing", out.Content)
+
+		messages := srv.lastRequest()["messages"].([]any)
+		assert.Len(t, messages, 3)
+		assert.Equal(t, map[string]any{"role": "tool", "content": `{"weather":"Sunny","temp":25}`, "tool_name": "get_weather"}, messages[2])
+	})
+
+	// with OLLAMA_MODEL set (e.g. qwen2.5:0.5b), run against a local Ollama server, whose address can be overridden by OLLAMA_HOST
+	t.Run("local", func(t *testing.T) {
+		modelName := os.Getenv("OLLAMA_MODEL")
+		if modelName == "" {
+			t.Skip("OLLAMA_MODEL is not set, skip")
+		}
+		opts := []Option{WithModelName(modelName)}
+		if host := os.Getenv("OLLAMA_HOST"); host != "" {
+			opts = append(opts, WithBaseURL(host))
+		}
+
+		out, err := newAgent(NewChatModel(opts...)).Generate(ctx, []*schema.Message{schema.UserMessage("weather of beijing?")})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, out.Content)
+	})
+}
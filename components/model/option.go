@@ -30,6 +30,12 @@ type Options struct {
 	TopP *float32
 	// Stop is the stop words for the model, which controls the stopping condition of the model.
 	Stop []string
+	// Seed is the seed of the sampling, with which the model tries to generate the same output for the same input, e.g. for deterministic tests.
+	Seed *int
+	// PresencePenalty penalizes the tokens already appeared in the text so far, which encourages the model to talk about new topics.
+	PresencePenalty *float32
+	// FrequencyPenalty penalizes the tokens by their frequency in the text so far, which decreases the likelihood of repeating the same line.
+	FrequencyPenalty *float32
 	// Tools is a list of tools the model may call.
 	Tools []*schema.ToolInfo
 	// ToolChoice controls which tool is called by the model.
@@ -37,6 +43,14 @@ type Options struct {
 	// AllowedToolNames specifies a list of tool names that the model is allowed to call.
 	// This allows for constraining the model to a specific subset of the available tools.
 	AllowedToolNames []string
+	// ResponseFormat specifies the format of the generated content, e.g. JSON conforming to a schema.
+	ResponseFormat *schema.ResponseFormat
+	// ParallelToolCalls controls whether the model may emit multiple tool calls in one message.
+	ParallelToolCalls *bool
+	// StrictTools controls whether the arguments of tool calls are guaranteed to match the parameters of the tools.
+	StrictTools *bool
+	// Headers is the extra HTTP headers sent with the request of the model, e.g. tenant id or trace id required by a gateway.
+	Headers map[string]string
 }
 
 // Option is the call option for ChatModel component.
@@ -91,6 +105,34 @@ func WithStop(stop []string) Option {
 	}
 }
 
+// WithSeed is the option to set the seed of the sampling for the model.
+// Determinism is best effort, refer to the ChatModel implementation.
+func WithSeed(seed int) Option {
+	return Option{
+		apply: func(opts *Options) {
+			opts.Seed = &seed
+		},
+	}
+}
+
+// WithPresencePenalty is the option to set the presence penalty for the model, e.g. in [-2, 2] for OpenAI.
+func WithPresencePenalty(penalty float32) Option {
+	return Option{
+		apply: func(opts *Options) {
+			opts.PresencePenalty = &penalty
+		},
+	}
+}
+
+// WithFrequencyPenalty is the option to set the frequency penalty for the model, e.g. in [-2, 2] for OpenAI.
+func WithFrequencyPenalty(penalty float32) Option {
+	return Option{
+		apply: func(opts *Options) {
+			opts.FrequencyPenalty = &penalty
+		},
+	}
+}
+
 // WithTools is the option to set tools for the model.
 func WithTools(tools []*schema.ToolInfo) Option {
 	if tools == nil {
@@ -114,6 +156,57 @@ func WithToolChoice(toolChoice schema.ToolChoice, allowedToolNames ...string) Op
 	}
 }
 
+// WithResponseFormat sets the format of the content generated by the model, e.g. JSON conforming to a schema.
+// Models not supporting the format may ignore it or return an error, refer to the ChatModel implementation.
+func WithResponseFormat(format *schema.ResponseFormat) Option {
+	return Option{
+		apply: func(opts *Options) {
+			opts.ResponseFormat = format
+		},
+	}
+}
+
+// WithParallelToolCalls sets whether the model may emit multiple tool calls in one message.
+// It's a hint to the model, callers should still handle any number of tool calls in the output.
+func WithParallelToolCalls(enabled bool) Option {
+	return Option{
+		apply: func(opts *Options) {
+			opts.ParallelToolCalls = &enabled
+		},
+	}
+}
+
+// WithStrictTools sets whether to enable the strict mode of function calling, e.g. "strict": true of OpenAI,
+// in which the arguments of tool calls are guaranteed to match the JSON schema of the tool parameters.
+// Implementations should set strict on each tool, and convert the parameters by schema.ToStrictJSONSchema
+// to satisfy the requirements of strict mode, see schema.ToOpenAIStrictTool.
+// Models not supporting strict mode may ignore it.
+func WithStrictTools(strict bool) Option {
+	return Option{
+		apply: func(opts *Options) {
+			opts.StrictTools = &strict
+		},
+	}
+}
+
+// WithHeaders adds extra HTTP headers to the request of the model, e.g. tenant id or trace id required by a gateway.
+// Headers of multiple WithHeaders options are merged, and the latter one takes precedence for the same key.
+// Implementations should merge them with, rather than replace, the headers set when creating the client.
+func WithHeaders(headers map[string]string) Option {
+	return Option{
+		apply: func(opts *Options) {
+			merged := make(map[string]string, len(opts.Headers)+len(headers))
+			for k, v := range opts.Headers {
+				merged[k] = v
+			}
+			for k, v := range headers {
+				merged[k] = v
+			}
+			opts.Headers = merged
+		},
+	}
+}
+
 // WrapImplSpecificOptFn is the option to wrap the implementation specific option function.
 func WrapImplSpecificOptFn[T any](optFn func(*T)) Option {
 	return Option{
@@ -38,6 +38,11 @@ func TestOptions(t *testing.T) {
 			tools                      = []*schema.ToolInfo{{Name: "asd"}, {Name: "qwe"}}
 			toolChoice                 = schema.ToolChoiceForced
 			allowedToolNames           = []string{"web_search"}
+			responseFormat             = &schema.ResponseFormat{Type: schema.ResponseFormatTypeJSONObject}
+			parallelToolCalls          = false
+			seed                       = 42
+			presencePenalty    float32 = 0.5
+			frequencyPenalty   float32 = -0.5
 		)
 
 		opts := GetCommonOptions(
@@ -54,17 +59,29 @@ func TestOptions(t *testing.T) {
 			WithStop([]string{"hello", "bye"}),
 			WithTools(tools),
 			WithToolChoice(toolChoice, allowedToolNames...),
+			WithResponseFormat(responseFormat),
+			WithParallelToolCalls(parallelToolCalls),
+			WithStrictTools(true),
+			WithSeed(seed),
+			WithPresencePenalty(presencePenalty),
+			WithFrequencyPenalty(frequencyPenalty),
 		)
 
 		convey.So(opts, convey.ShouldResemble, &Options{
-			Model:            &modelName,
-			Temperature:      &temperature,
-			MaxTokens:        &maxToken,
-			TopP:             &topP,
-			Stop:             []string{"hello", "bye"},
-			Tools:            tools,
-			ToolChoice:       &toolChoice,
-			AllowedToolNames: allowedToolNames,
+			Model:             &modelName,
+			Temperature:       &temperature,
+			MaxTokens:         &maxToken,
+			TopP:              &topP,
+			Stop:              []string{"hello", "bye"},
+			Tools:             tools,
+			ToolChoice:        &toolChoice,
+			AllowedToolNames:  allowedToolNames,
+			ResponseFormat:    responseFormat,
+			ParallelToolCalls: &parallelToolCalls,
+			StrictTools:       &[]bool{true}[0],
+			Seed:              &seed,
+			PresencePenalty:   &presencePenalty,
+			FrequencyPenalty:  &frequencyPenalty,
 		})
 	})
 
@@ -82,6 +99,16 @@ func TestOptions(t *testing.T) {
 		convey.So(opts.Tools, convey.ShouldNotBeNil)
 		convey.So(len(opts.Tools), convey.ShouldEqual, 0)
 	})
+
+	convey.Convey("test headers option", t, func() {
+		base := &Options{Headers: map[string]string{"X-Tenant-ID": "t0", "X-App": "eino"}}
+		opts := GetCommonOptions(base,
+			WithHeaders(map[string]string{"X-Tenant-ID": "t1"}),
+			WithHeaders(map[string]string{"X-Trace-ID": "abc"}),
+		)
+
+		convey.So(opts.Headers, convey.ShouldResemble, map[string]string{"X-Tenant-ID": "t1", "X-App": "eino", "X-Trace-ID": "abc"})
+	})
 }
 
 type implOption struct {
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrUnsupportedSchema indicates the parameter schema of a tool uses JSON schema features the provider doesn't support,
+// which is returned by ProviderSchemaValidator wrapped with the details.
+var ErrUnsupportedSchema = errors.New("unsupported tool schema")
+
+// ProviderSchemaValidator is an optional interface of chat models, which validates the parameter schemas of tools
+// against the JSON schema subset supported by the provider, e.g. some providers reject the "format" keyword,
+// and some require the top level schema to be an object.
+// It's called before the tools are bound to the model, e.g. when creating a react agent,
+// so that an unsupported schema fails fast, rather than being silently ignored or misread by the model.
+// See SchemaConstraints for a helper to implement it.
+type ProviderSchemaValidator interface {
+	ValidateToolSchemas(tools []*schema.ToolInfo) error
+}
+
+// SchemaConstraints describes the JSON schema subset supported by a provider.
+// e.g.
+//
+//	func (m *MyModel) ValidateToolSchemas(tools []*schema.ToolInfo) error {
+//		return (&model.SchemaConstraints{UnsupportedKeywords: []string{"format"}, RequireObjectRoot: true}).Validate(tools)
+//	}
+type SchemaConstraints struct {
+	// UnsupportedKeywords are the keywords rejected by the provider, at any level of the schema, e.g. "format", "pattern", "oneOf".
+	UnsupportedKeywords []string
+	// RequireObjectRoot requires the top level schema to be of type "object".
+	RequireObjectRoot bool
+	// MaxDepth limits the nesting depth of object and array schemas, the top level object counts as 1. 0 means unlimited.
+	MaxDepth int
+}
+
+// Validate checks the parameter schemas of tools, and returns an error wrapping ErrUnsupportedSchema,
+// which lists all the unsupported features found with their paths in the schema, e.g. "#/properties/date: keyword format is not supported".
+// Tools without parameters are skipped.
+func (c *SchemaConstraints) Validate(tools []*schema.ToolInfo) error {
+	for _, t := range tools {
+		if t == nil || t.ParamsOneOf == nil {
+			continue
+		}
+		js, err := t.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return fmt.Errorf("convert parameters of tool[%s] to json schema fail: %w", t.Name, err)
+		}
+		if js == nil {
+			continue
+		}
+		data, err := json.Marshal(js)
+		if err != nil {
+			return fmt.Errorf("marshal json schema of tool[%s] fail: %w", t.Name, err)
+		}
+		root := map[string]any{}
+		if err = json.Unmarshal(data, &root); err != nil {
+			return fmt.Errorf("unmarshal json schema of tool[%s] fail: %w", t.Name, err)
+		}
+
+		var violations []string
+		if c.RequireObjectRoot && root["type"] != "object" {
+			violations = append(violations, "#: top level schema must be of type object")
+		}
+		c.walk(root, "#", 0, &violations)
+		if len(violations) > 0 {
+			return fmt.Errorf("%w, tool[%s]: %s", ErrUnsupportedSchema, t.Name, strings.Join(violations, "; "))
+		}
+	}
+	return nil
+}
+
+func (c *SchemaConstraints) walk(node map[string]any, path string, depth int, violations *[]string) {
+	for _, kw := range c.UnsupportedKeywords {
+		if _, ok := node[kw]; ok {
+			*violations = append(*violations, fmt.Sprintf("%s: keyword %s is not supported", path, kw))
+		}
+	}
+
+	if typ, _ := node["type"].(string); typ == "object" || typ == "array" {
+		depth++
+		if c.MaxDepth > 0 && depth == c.MaxDepth+1 {
+			*violations = append(*violations, fmt.Sprintf("%s: nesting depth exceeds %d", path, c.MaxDepth))
+		}
+	}
+
+	walkMap := func(key string) {
+		m, ok := node[key].(map[string]any)
+		if !ok {
+			return
+		}
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if child, ok := m[name].(map[string]any); ok {
+				c.walk(child, path+"/"+key+"/"+name, depth, violations)
+			}
+		}
+	}
+	walkChild := func(key string) {
+		switch child := node[key].(type) {
+		case map[string]any:
+			c.walk(child, path+"/"+key, depth, violations)
+		case []any:
+			for i, item := range child {
+				if m, ok := item.(map[string]any); ok {
+					c.walk(m, fmt.Sprintf("%s/%s/%d", path, key, i), depth, violations)
+				}
+			}
+		}
+	}
+
+	walkMap("properties")
+	walkMap("$defs")
+	walkMap("definitions")
+	for _, key := range []string{"items", "additionalProperties", "not", "anyOf", "oneOf", "allOf"} {
+		walkChild(key)
+	}
+}
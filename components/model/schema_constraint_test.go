@@ -0,0 +1,78 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eino-contrib/jsonschema"
+	"github.com/smartystreets/goconvey/convey"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestSchemaConstraints(t *testing.T) {
+	convey.Convey("test schema constraints", t, func() {
+		props := orderedmap.New[string, *jsonschema.Schema]()
+		props.Set("date", &jsonschema.Schema{Type: "string", Format: "date"})
+		itemProps := orderedmap.New[string, *jsonschema.Schema]()
+		itemProps.Set("email", &jsonschema.Schema{Type: "string", Format: "email", Pattern: ".+@.+"})
+		props.Set("contacts", &jsonschema.Schema{
+			Type:  "array",
+			Items: &jsonschema.Schema{Type: "object", Properties: itemProps},
+		})
+		nested := &schema.ToolInfo{
+			Name:        "book",
+			ParamsOneOf: schema.NewParamsOneOfByJSONSchema(&jsonschema.Schema{Type: "object", Properties: props}),
+		}
+		noParams := &schema.ToolInfo{Name: "now"}
+
+		convey.Convey("supported", func() {
+			c := &SchemaConstraints{RequireObjectRoot: true, MaxDepth: 3}
+			convey.So(c.Validate([]*schema.ToolInfo{nested, noParams}), convey.ShouldBeNil)
+		})
+
+		convey.Convey("unsupported keywords", func() {
+			c := &SchemaConstraints{UnsupportedKeywords: []string{"format", "pattern"}}
+			err := c.Validate([]*schema.ToolInfo{noParams, nested})
+			convey.So(errors.Is(err, ErrUnsupportedSchema), convey.ShouldBeTrue)
+			convey.So(err.Error(), convey.ShouldEqual, "unsupported tool schema, tool[book]: "+
+				"#/properties/contacts/items/properties/email: keyword format is not supported; "+
+				"#/properties/contacts/items/properties/email: keyword pattern is not supported; "+
+				"#/properties/date: keyword format is not supported")
+		})
+
+		convey.Convey("max depth", func() {
+			c := &SchemaConstraints{MaxDepth: 2}
+			err := c.Validate([]*schema.ToolInfo{nested})
+			convey.So(errors.Is(err, ErrUnsupportedSchema), convey.ShouldBeTrue)
+			convey.So(err.Error(), convey.ShouldContainSubstring, "#/properties/contacts/items: nesting depth exceeds 2")
+		})
+
+		convey.Convey("object root", func() {
+			c := &SchemaConstraints{RequireObjectRoot: true}
+			err := c.Validate([]*schema.ToolInfo{{
+				Name:        "echo",
+				ParamsOneOf: schema.NewParamsOneOfByJSONSchema(&jsonschema.Schema{Type: "string"}),
+			}})
+			convey.So(errors.Is(err, ErrUnsupportedSchema), convey.ShouldBeTrue)
+			convey.So(err.Error(), convey.ShouldContainSubstring, "#: top level schema must be of type object")
+		})
+	})
+}
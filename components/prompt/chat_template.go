@@ -18,6 +18,13 @@ package prompt
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudwego/eino/callbacks"
 	"github.com/cloudwego/eino/components"
@@ -30,6 +37,34 @@ type DefaultChatTemplate struct {
 	templates []schema.MessagesTemplate
 	// formatType is the format type for the chat template.
 	formatType schema.FormatType
+	// required is the variables that must be provided when formatting.
+	required []string
+	// valueFormatters renders the variables by key, registered by WithValueFormatter.
+	valueFormatters map[string]ValueFormatter
+}
+
+// ValueFormatter renders a variable into the text substituted into the template.
+type ValueFormatter func(v any) string
+
+// WithValueFormatter registers a formatter rendering the variable of key, which takes precedence over the default rendering,
+// and applies to all format types. It returns the template itself, so that it can be chained after FromMessages.
+//
+// Without a formatter, the variables of FString templates are rendered with the following defaults,
+// which suit the values unmarshalled from JSON, where all numbers are float64:
+//   - floats are rendered without exponent and trailing zeros, e.g. 12345678 rather than 1.2345678e+07.
+//   - time.Time is rendered in RFC3339, e.g. 2024-01-02T15:04:05+08:00.
+//   - nil is rendered as an empty string rather than <nil>.
+//
+// eg.
+//
+//	template := prompt.FromMessages(schema.FString, schema.UserMessage("the price is {price}")).
+//		WithValueFormatter("price", func(v any) string { return fmt.Sprintf("%.2f", v) })
+func (t *DefaultChatTemplate) WithValueFormatter(key string, formatter ValueFormatter) *DefaultChatTemplate {
+	if t.valueFormatters == nil {
+		t.valueFormatters = make(map[string]ValueFormatter)
+	}
+	t.valueFormatters[key] = formatter
+	return t
 }
 
 // FromMessages creates a new DefaultChatTemplate from the given templates and format type.
@@ -46,9 +81,55 @@ func FromMessages(formatType schema.FormatType, templates ...schema.MessagesTemp
 	}
 }
 
+// FromMessagesWithRequired is like FromMessages, but also declares the variables that must be present in the input of Format.
+// Format fails with an error listing all the missing variables, instead of leaving the placeholders unexpanded or failing inside a template.
+// eg.
+//
+//	template := prompt.FromMessagesWithRequired(schema.FString, []string{"context", "question"},
+//		schema.SystemMessage("answer the question based on the context: {context}"),
+//		schema.UserMessage("{question}"))
+func FromMessagesWithRequired(formatType schema.FormatType, required []string, templates ...schema.MessagesTemplate) *DefaultChatTemplate {
+	return &DefaultChatTemplate{
+		templates:  templates,
+		formatType: formatType,
+		required:   required,
+	}
+}
+
+// SystemMessageMode determines how DefaultChatTemplate handles multiple leading system messages of the formatted result,
+// e.g. a system message of the template followed by the one in the chat history expanded from MessagesPlaceholder,
+// which some models don't handle well.
+type SystemMessageMode uint8
+
+const (
+	// SystemMessageKeepAll keeps all the system messages as they are, which is the default.
+	SystemMessageKeepAll SystemMessageMode = iota
+	// SystemMessageMerge merges the leading system messages into one, concatenating their contents with line breaks.
+	SystemMessageMerge
+	// SystemMessageKeepFirst keeps only the first of the leading system messages.
+	SystemMessageKeepFirst
+)
+
+type options struct {
+	systemMessageMode SystemMessageMode
+}
+
+// WithSystemMessageMode sets how DefaultChatTemplate handles multiple leading system messages,
+// which is applied after MessagesPlaceholder is expanded.
+// eg.
+//
+//	msgs, err := template.Format(ctx, vs, prompt.WithSystemMessageMode(prompt.SystemMessageMerge))
+//	// or in graph
+//	out, err := runnable.Invoke(ctx, vs, compose.WithChatTemplateOption(prompt.WithSystemMessageMode(prompt.SystemMessageMerge)))
+func WithSystemMessageMode(mode SystemMessageMode) Option {
+	return WrapImplSpecificOptFn(func(o *options) {
+		o.systemMessageMode = mode
+	})
+}
+
 // Format formats the chat template with the given context and variables.
 func (t *DefaultChatTemplate) Format(ctx context.Context,
-	vs map[string]any, _ ...Option) (result []*schema.Message, err error) {
+	vs map[string]any, opts ...Option) (result []*schema.Message, err error) {
 	ctx = callbacks.EnsureRunInfo(ctx, t.GetType(), components.ComponentOfPrompt)
 	ctx = callbacks.OnStart(ctx, &CallbackInput{
 		Variables: vs,
@@ -60,9 +141,14 @@ func (t *DefaultChatTemplate) Format(ctx context.Context,
 		}
 	}()
 
+	if err = t.checkRequired(vs); err != nil {
+		return nil, err
+	}
+
+	formatted := t.formatValues(vs)
 	result = make([]*schema.Message, 0, len(t.templates))
 	for _, template := range t.templates {
-		msgs, err := template.Format(ctx, vs, t.formatType)
+		msgs, err := template.Format(ctx, formatted, t.formatType)
 		if err != nil {
 			return nil, err
 		}
@@ -70,6 +156,8 @@ func (t *DefaultChatTemplate) Format(ctx context.Context,
 		result = append(result, msgs...)
 	}
 
+	result = handleLeadingSystemMessages(result, GetImplSpecificOptions(&options{}, opts...).systemMessageMode)
+
 	_ = callbacks.OnEnd(ctx, &CallbackOutput{
 		Result:    result,
 		Templates: t.templates,
@@ -78,6 +166,103 @@ func (t *DefaultChatTemplate) Format(ctx context.Context,
 	return result, nil
 }
 
+// RequiredVariables returns the variables expected by Format, i.e. the ones declared by FromMessagesWithRequired,
+// and the ones referenced by the templates, which are inferred by schema.ExtractVariables.
+func (t *DefaultChatTemplate) RequiredVariables() []string {
+	vars := schema.ExtractVariables(t.formatType, t.templates...)
+	for _, k := range t.required {
+		if !slices.Contains(vars, k) {
+			vars = append(vars, k)
+		}
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+func (t *DefaultChatTemplate) checkRequired(vs map[string]any) error {
+	var missing []string
+	for _, k := range t.required {
+		if _, ok := vs[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required variables of chat template: %v", missing)
+	}
+	return nil
+}
+
+// formatValues renders the variables by the registered formatters, and by the defaults for FString, into a new map.
+// Other values are kept as is, e.g. the messages for MessagesPlaceholder.
+func (t *DefaultChatTemplate) formatValues(vs map[string]any) map[string]any {
+	if len(t.valueFormatters) == 0 && t.formatType != schema.FString {
+		return vs
+	}
+
+	ret := make(map[string]any, len(vs))
+	for k, v := range vs {
+		if f, ok := t.valueFormatters[k]; ok {
+			ret[k] = f(v)
+		} else if t.formatType == schema.FString {
+			ret[k] = defaultFormatValue(v)
+		} else {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+func defaultFormatValue(v any) any {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return formatFloat(val, 64)
+	case float32:
+		return formatFloat(float64(val), 32)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return val.Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+func formatFloat(f float64, bitSize int) any {
+	// keep the exponent for extreme values, which are unreadable either way
+	if math.IsInf(f, 0) || math.IsNaN(f) || math.Abs(f) >= 1e21 {
+		return f
+	}
+	return strconv.FormatFloat(f, 'f', -1, bitSize)
+}
+
+func handleLeadingSystemMessages(msgs []*schema.Message, mode SystemMessageMode) []*schema.Message {
+	n := 0
+	for n < len(msgs) && msgs[n] != nil && msgs[n].Role == schema.System {
+		n++
+	}
+	if n < 2 || mode == SystemMessageKeepAll {
+		return msgs
+	}
+
+	first := msgs[0]
+	if mode == SystemMessageMerge {
+		contents := make([]string, 0, n)
+		for _, msg := range msgs[:n] {
+			contents = append(contents, msg.Content)
+		}
+		merged := *first
+		merged.Content = strings.Join(contents, "\n")
+		first = &merged
+	}
+
+	return append([]*schema.Message{first}, msgs[n:]...)
+}
+
 // GetType returns the type of the chat template (Default).
 func (t *DefaultChatTemplate) GetType() string {
 	return "Default"
@@ -18,7 +18,9 @@ package prompt
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -83,6 +85,118 @@ func TestFormat(t *testing.T) {
 	assert.Equal(t, expected, msgs)
 }
 
+func TestFormatWithRequired(t *testing.T) {
+	chatTemplate := FromMessagesWithRequired(schema.FString, []string{"context", "question", "chat_history"},
+		schema.SystemMessage("here is the context: {context}"),
+		schema.MessagesPlaceholder("chat_history", true),
+		schema.UserMessage("question: {question}"))
+
+	msgs, err := chatTemplate.Format(context.Background(), map[string]any{
+		"context":      "it's beautiful day",
+		"question":     "how is the day today",
+		"chat_history": []*schema.Message{},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*schema.Message{
+		schema.SystemMessage("here is the context: it's beautiful day"),
+		schema.UserMessage("question: how is the day today"),
+	}, msgs)
+
+	_, err = chatTemplate.Format(context.Background(), map[string]any{"question": "how is the day today"})
+	assert.EqualError(t, err, "missing required variables of chat template: [context chat_history]")
+
+	assert.Equal(t, []string{"chat_history", "context", "question"}, chatTemplate.RequiredVariables())
+	assert.Equal(t, []string{"context", "question"}, FromMessages(schema.FString,
+		schema.SystemMessage("here is the context: {context}"),
+		schema.MessagesPlaceholder("chat_history", true),
+		schema.UserMessage("question: {question}")).RequiredVariables())
+}
+
+func TestFormatWithSystemMessageMode(t *testing.T) {
+	chatTemplate := FromMessages(schema.FString,
+		schema.SystemMessage("you are a helpful assistant."),
+		schema.MessagesPlaceholder("history", false),
+		schema.UserMessage("{question}"))
+	vs := map[string]any{
+		"history": []*schema.Message{
+			schema.SystemMessage("answer in English."),
+			schema.UserMessage("who are you"),
+			schema.AssistantMessage("I'm a helpful assistant", nil),
+		},
+		"question": "how is the day today",
+	}
+	rest := []*schema.Message{
+		schema.UserMessage("who are you"),
+		schema.AssistantMessage("I'm a helpful assistant", nil),
+		schema.UserMessage("how is the day today"),
+	}
+
+	msgs, err := chatTemplate.Format(context.Background(), vs)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 5)
+	assert.Equal(t, "answer in English.", msgs[1].Content)
+
+	msgs, err = chatTemplate.Format(context.Background(), vs, WithSystemMessageMode(SystemMessageMerge))
+	assert.NoError(t, err)
+	assert.Equal(t, append([]*schema.Message{schema.SystemMessage("you are a helpful assistant.\nanswer in English.")}, rest...), msgs)
+	// the messages in the history are not modified
+	assert.Equal(t, "answer in English.", vs["history"].([]*schema.Message)[0].Content)
+
+	msgs, err = chatTemplate.Format(context.Background(), vs, WithSystemMessageMode(SystemMessageKeepFirst))
+	assert.NoError(t, err)
+	assert.Equal(t, append([]*schema.Message{schema.SystemMessage("you are a helpful assistant.")}, rest...), msgs)
+}
+
+func TestFormatValues(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 1, 2, 15, 4, 5, 0, time.FixedZone("CST", 8*3600))
+	vs := map[string]any{
+		"count":  float64(12345678),
+		"ratio":  0.25,
+		"small":  float32(2.5),
+		"at":     at,
+		"at_ptr": &at,
+		"none":   nil,
+		"name":   "eino",
+		"price":  float64(3),
+	}
+
+	t.Run("fstring defaults", func(t *testing.T) {
+		tpl := FromMessages(schema.FString, schema.UserMessage("{count} {ratio} {small} {at} {at_ptr} [{none}] {name} {price}"))
+		msgs, err := tpl.Format(ctx, vs)
+		assert.NoError(t, err)
+		assert.Equal(t, "12345678 0.25 2.5 2024-01-02T15:04:05+08:00 2024-01-02T15:04:05+08:00 [] eino 3", msgs[0].Content)
+		// the input is not modified
+		assert.Equal(t, float64(12345678), vs["count"])
+	})
+
+	t.Run("custom formatter", func(t *testing.T) {
+		tpl := FromMessages(schema.FString, schema.UserMessage("{price} at {at}")).
+			WithValueFormatter("price", func(v any) string { return fmt.Sprintf("$%.2f", v) }).
+			WithValueFormatter("at", func(v any) string { return v.(time.Time).Format(time.DateOnly) })
+		msgs, err := tpl.Format(ctx, vs)
+		assert.NoError(t, err)
+		assert.Equal(t, "$3.00 at 2024-01-02", msgs[0].Content)
+
+		// custom formatters apply to other format types, while the defaults don't
+		jinja := FromMessages(schema.Jinja2, schema.UserMessage("{{price}} {{ratio * 2}}")).
+			WithValueFormatter("price", func(v any) string { return fmt.Sprintf("$%.2f", v) })
+		msgs, err = jinja.Format(ctx, vs)
+		assert.NoError(t, err)
+		assert.Equal(t, "$3.00 0.5", msgs[0].Content)
+	})
+
+	t.Run("placeholder kept", func(t *testing.T) {
+		tpl := FromMessages(schema.FString, schema.MessagesPlaceholder("history", false), schema.UserMessage("{count}"))
+		msgs, err := tpl.Format(ctx, map[string]any{
+			"history": []*schema.Message{schema.UserMessage("hi")},
+			"count":   float64(1),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.Message{schema.UserMessage("hi"), schema.UserMessage("1")}, msgs)
+	})
+}
+
 func TestDocumentFormat(t *testing.T) {
 	docs := []*schema.Document{
 		{
@@ -25,6 +25,10 @@ import (
 var _ ChatTemplate = &DefaultChatTemplate{}
 
 // ChatTemplate formats variables into a list of messages according to a prompt schema.
+// A chat template is inherently Collect-style, as the variables must be complete before formatting.
+// When used in a graph whose upstream node streams its output, e.g. running the graph by Transform,
+// the streamed variable maps are buffered and concatenated before Format is called:
+// chunks of the same string variable are joined, and the merged map is formatted once.
 type ChatTemplate interface {
 	Format(ctx context.Context, vs map[string]any, opts ...Option) ([]*schema.Message, error)
 }
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package reranker
+
+import (
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/schema"
+)
+
+// CallbackInput is the input for the reranker callback.
+type CallbackInput struct {
+	// Query is the query the docs are reranked against.
+	Query string
+	// Docs are the documents to rerank.
+	Docs []*schema.Document
+	// TopN is the max number of the returned docs.
+	TopN int
+	// ScoreThreshold is the score threshold for the reranker.
+	ScoreThreshold *float64
+
+	// Extra is the extra information for the reranker.
+	Extra map[string]any
+}
+
+// CallbackOutput is the output for the reranker callback.
+type CallbackOutput struct {
+	// Docs are the reranked documents.
+	Docs []*schema.Document
+	// Extra is the extra information for the reranker.
+	Extra map[string]any
+}
+
+// ConvCallbackInput converts the callback input to the reranker callback input.
+func ConvCallbackInput(src callbacks.CallbackInput) *CallbackInput {
+	switch t := src.(type) {
+	case *CallbackInput:
+		return t
+	case *Request:
+		return &CallbackInput{
+			Query: t.Query,
+			Docs:  t.Docs,
+			TopN:  t.TopN,
+		}
+	default:
+		return nil
+	}
+}
+
+// ConvCallbackOutput converts the callback output to the reranker callback output.
+func ConvCallbackOutput(src callbacks.CallbackOutput) *CallbackOutput {
+	switch t := src.(type) {
+	case *CallbackOutput:
+		return t
+	case []*schema.Document:
+		return &CallbackOutput{
+			Docs: t,
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package reranker
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Reranker is the interface for reranker.
+// It reorders docs by their relevance to the query, most relevant first, and returns at most topN of them.
+// A topN not greater than 0 means all the docs are returned.
+// Implementations should set the relevance score of the returned docs by schema.Document.WithScore.
+//
+// e.g.
+//
+//	docs, err := retriever.Retrieve(ctx, query)
+//	if err != nil {...}
+//	docs, err = reranker.Rerank(ctx, query, docs, 3) // <= using directly
+//
+//	graph.AddRerankerNode("reranker_node_key", reranker) // <= using in graph, with *Request as input
+type Reranker interface {
+	Rerank(ctx context.Context, query string, docs []*schema.Document, topN int, opts ...Option) ([]*schema.Document, error)
+}
+
+// Request is the input of the reranker node in graph, see compose.AddRerankerNode.
+// e.g. in a workflow, the query and the docs can be mapped from the input of the workflow and the output of the retriever respectively.
+type Request struct {
+	// Query is the query the docs are reranked against.
+	Query string
+	// Docs are the documents to rerank.
+	Docs []*schema.Document
+	// TopN is the max number of the returned docs, not greater than 0 means all.
+	TopN int
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package llm provides a reranker which prompts a chat model to score the relevance of documents to the query.
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/reranker"
+	"github.com/cloudwego/eino/schema"
+)
+
+const scoreToolName = "score_documents"
+
+// DefaultSystemPrompt is the default system prompt of the reranker.
+const DefaultSystemPrompt = "You are a relevance judge for a search engine. " +
+	"Given a query and a list of numbered documents, score how relevant each document is to the query, " +
+	"from 0 (irrelevant) to 1 (exactly answers the query), and report the scores of all the documents by calling " + scoreToolName + "."
+
+var scoreTool = &schema.ToolInfo{
+	Name: scoreToolName,
+	Desc: "report the relevance score of each document to the query",
+	ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"scores": {
+			Type:     schema.Array,
+			Desc:     "the relevance scores of the documents",
+			Required: true,
+			ElemInfo: &schema.ParameterInfo{
+				Type: schema.Object,
+				SubParams: map[string]*schema.ParameterInfo{
+					"index": {Type: schema.Integer, Desc: "the number of the document", Required: true},
+					"score": {Type: schema.Number, Desc: "the relevance score from 0 to 1", Required: true},
+				},
+			},
+		},
+	}),
+}
+
+// Config is the config for the LLM reranker.
+type Config struct {
+	// Model is the chat model scoring the documents, which is bound to a tool to report the scores, required.
+	Model model.ToolCallingChatModel
+	// SystemPrompt is the system prompt telling the model how to score the documents.
+	// Optional. Default is DefaultSystemPrompt.
+	SystemPrompt string
+}
+
+// Reranker is a reranker.Reranker which asks a chat model to score all the documents in a single call,
+// and reorders them by the scores, the highest first. The documents with the same score keep their order.
+// The returned documents are copies of the input ones, with the scores set by schema.Document.WithScore,
+// and the documents not scored by the model are scored 0.
+// eg:
+//
+//	r, _ := llm.NewReranker(ctx, &llm.Config{Model: chatModel})
+//	docs, err := r.Rerank(ctx, query, docs, 3)
+type Reranker struct {
+	model        model.ToolCallingChatModel
+	systemPrompt string
+}
+
+// NewReranker creates a new LLM Reranker.
+func NewReranker(_ context.Context, conf *Config) (*Reranker, error) {
+	if conf == nil || conf.Model == nil {
+		return nil, errors.New("model of llm reranker is required")
+	}
+
+	cm, err := conf.Model.WithTools([]*schema.ToolInfo{scoreTool})
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind score tool to model: %w", err)
+	}
+
+	systemPrompt := conf.SystemPrompt
+	if systemPrompt == "" {
+		systemPrompt = DefaultSystemPrompt
+	}
+
+	return &Reranker{
+		model:        cm,
+		systemPrompt: systemPrompt,
+	}, nil
+}
+
+// Rerank reorders docs by the scores given by the model, and returns at most topN of them.
+func (r *Reranker) Rerank(ctx context.Context, query string, docs []*schema.Document, topN int, opts ...reranker.Option) ([]*schema.Document, error) {
+	options := reranker.GetCommonOptions(nil, opts...)
+	if len(docs) == 0 {
+		return []*schema.Document{}, nil
+	}
+
+	msg, err := r.model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(r.systemPrompt),
+		schema.UserMessage(formatDocuments(query, docs)),
+	}, model.WithToolChoice(schema.ToolChoiceForced, scoreToolName))
+	if err != nil {
+		return nil, err
+	}
+
+	scores, err := parseScores(msg, len(docs))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*schema.Document, 0, len(docs))
+	for i, doc := range docs {
+		if options.ScoreThreshold != nil && scores[i] < *options.ScoreThreshold {
+			continue
+		}
+		ret = append(ret, copyDocument(doc).WithScore(scores[i]))
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Score() > ret[j].Score()
+	})
+	if topN > 0 && len(ret) > topN {
+		ret = ret[:topN]
+	}
+
+	return ret, nil
+}
+
+// GetType returns the type of the reranker.
+func (r *Reranker) GetType() string {
+	return "LLM"
+}
+
+func formatDocuments(query string, docs []*schema.Document) string {
+	sb := &strings.Builder{}
+	sb.WriteString("Query: ")
+	sb.WriteString(query)
+	sb.WriteString("\n\nDocuments:\n")
+	for i, doc := range docs {
+		content := ""
+		if doc != nil {
+			content = doc.Content
+		}
+		_, _ = fmt.Fprintf(sb, "[%d] %s\n", i, content)
+	}
+	return sb.String()
+}
+
+// parseScores returns the score of each of the n documents, by the arguments of the score tool called by the model.
+func parseScores(msg *schema.Message, n int) ([]float64, error) {
+	for _, tc := range msg.ToolCalls {
+		if tc.Function.Name != scoreToolName {
+			continue
+		}
+
+		var args struct {
+			Scores []struct {
+				Index int     `json:"index"`
+				Score float64 `json:"score"`
+			} `json:"scores"`
+		}
+		if err := sonic.UnmarshalString(tc.Function.Arguments, &args); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal scores from model: %w, arguments: %s", err, tc.Function.Arguments)
+		}
+
+		scores := make([]float64, n)
+		for _, s := range args.Scores {
+			if s.Index < 0 || s.Index >= n {
+				return nil, fmt.Errorf("model scored document[%d], which is out of range [0, %d)", s.Index, n)
+			}
+			scores[s.Index] = s.Score
+		}
+		return scores, nil
+	}
+
+	return nil, fmt.Errorf("model didn't call %s to report the scores, content: %s", scoreToolName, msg.Content)
+}
+
+func copyDocument(doc *schema.Document) *schema.Document {
+	if doc == nil {
+		return &schema.Document{}
+	}
+	meta := make(map[string]any, len(doc.MetaData)+1)
+	for k, v := range doc.MetaData {
+		meta[k] = v
+	}
+	return &schema.Document{
+		ID:       doc.ID,
+		Content:  doc.Content,
+		MetaData: meta,
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/reranker"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	template "github.com/cloudwego/eino/utils/callbacks"
+)
+
+var _ reranker.Reranker = (*Reranker)(nil)
+
+// fakeScoreModel reports the given scores by calling the score tool.
+type fakeScoreModel struct {
+	arguments string
+	tools     []*schema.ToolInfo
+	input     []*schema.Message
+	options   *model.Options
+}
+
+func (m *fakeScoreModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.input = input
+	m.options = model.GetCommonOptions(nil, opts...)
+	if m.arguments == "" {
+		return schema.AssistantMessage("all relevant", nil), nil
+	}
+	return schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       "call_1",
+		Function: schema.FunctionCall{Name: scoreToolName, Arguments: m.arguments},
+	}}), nil
+}
+
+func (m *fakeScoreModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *fakeScoreModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	m.tools = tools
+	return m, nil
+}
+
+func TestReranker(t *testing.T) {
+	ctx := context.Background()
+
+	docs := []*schema.Document{
+		{ID: "weather", Content: "Beijing is sunny today", MetaData: map[string]any{"source": "news"}},
+		{ID: "recipe", Content: "how to cook noodles"},
+		{ID: "forecast", Content: "it will rain in Beijing tomorrow"},
+		{ID: "history", Content: "the history of Beijing"},
+	}
+	scores := `{"scores":[{"index":0,"score":0.9},{"index":1,"score":0.1},{"index":2,"score":0.8},{"index":3,"score":0.4}]}`
+
+	_, err := NewReranker(ctx, nil)
+	assert.ErrorContains(t, err, "model of llm reranker is required")
+
+	t.Run("rerank by scores", func(t *testing.T) {
+		cm := &fakeScoreModel{arguments: scores}
+		r, err := NewReranker(ctx, &Config{Model: cm})
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.ToolInfo{scoreTool}, cm.tools)
+
+		out, err := r.Rerank(ctx, "weather in Beijing", docs, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather", "forecast"}, ids(out))
+		assert.Equal(t, 0.9, out[0].Score())
+		assert.Equal(t, "news", out[0].MetaData["source"])
+		// the input documents are not modified
+		assert.Equal(t, 0.0, docs[0].Score())
+
+		// the model is forced to call the score tool, with the numbered documents
+		assert.Equal(t, schema.ToolChoiceForced, *cm.options.ToolChoice)
+		assert.Equal(t, DefaultSystemPrompt, cm.input[0].Content)
+		assert.Contains(t, cm.input[1].Content, "Query: weather in Beijing")
+		assert.Contains(t, cm.input[1].Content, "[2] it will rain in Beijing tomorrow")
+
+		out, err = r.Rerank(ctx, "weather in Beijing", docs, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather", "forecast", "history", "recipe"}, ids(out))
+
+		out, err = r.Rerank(ctx, "weather in Beijing", docs, 0, reranker.WithScoreThreshold(0.5))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather", "forecast"}, ids(out))
+	})
+
+	t.Run("invalid scores", func(t *testing.T) {
+		r, err := NewReranker(ctx, &Config{Model: &fakeScoreModel{}})
+		assert.NoError(t, err)
+		_, err = r.Rerank(ctx, "q", docs, 2)
+		assert.ErrorContains(t, err, "model didn't call score_documents")
+
+		r, err = NewReranker(ctx, &Config{Model: &fakeScoreModel{arguments: `{"scores":[{"index":4,"score":1}]}`}})
+		assert.NoError(t, err)
+		_, err = r.Rerank(ctx, "q", docs, 2)
+		assert.ErrorContains(t, err, "out of range")
+	})
+
+	t.Run("reranker node", func(t *testing.T) {
+		r, err := NewReranker(ctx, &Config{Model: &fakeScoreModel{arguments: scores}, SystemPrompt: "score them"})
+		assert.NoError(t, err)
+
+		var cbInput *reranker.CallbackInput
+		var cbOutput *reranker.CallbackOutput
+		handler := template.NewHandlerHelper().Reranker(&template.RerankerCallbackHandler{
+			OnStart: func(ctx context.Context, runInfo *callbacks.RunInfo, input *reranker.CallbackInput) context.Context {
+				cbInput = input
+				return ctx
+			},
+			OnEnd: func(ctx context.Context, runInfo *callbacks.RunInfo, output *reranker.CallbackOutput) context.Context {
+				cbOutput = output
+				return ctx
+			},
+		}).Handler()
+
+		// the query comes from the input of the workflow, while the documents come from the retriever
+		wf := compose.NewWorkflow[string, []*schema.Document]()
+		wf.AddLambdaNode("retriever", compose.InvokableLambda(func(ctx context.Context, query string) ([]*schema.Document, error) {
+			return docs, nil
+		})).AddInput(compose.START)
+		wf.AddRerankerNode("reranker", r).
+			AddInput(compose.START, compose.ToField("Query")).
+			AddInput("retriever", compose.ToField("Docs"))
+		wf.End().AddInput("reranker")
+
+		run, err := wf.Compile(ctx)
+		assert.NoError(t, err)
+		out, err := run.Invoke(ctx, "weather in Beijing", compose.WithCallbacks(handler))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"weather", "forecast", "history", "recipe"}, ids(out))
+
+		if assert.NotNil(t, cbInput) {
+			assert.Equal(t, "weather in Beijing", cbInput.Query)
+			assert.Len(t, cbInput.Docs, 4)
+		}
+		if assert.NotNil(t, cbOutput) {
+			assert.Equal(t, ids(out), ids(cbOutput.Docs))
+		}
+	})
+}
+
+func ids(docs []*schema.Document) []string {
+	ret := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ret = append(ret, doc.ID)
+	}
+	return ret
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package reranker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	type implOption struct {
+		maxDocLen int
+	}
+
+	opts := []Option{
+		WithScoreThreshold(0.5),
+		WrapImplSpecificOptFn(func(o *implOption) { o.maxDocLen = 100 }),
+	}
+	assert.Equal(t, 0.5, *GetCommonOptions(nil, opts...).ScoreThreshold)
+	assert.Equal(t, &implOption{maxDocLen: 100}, GetImplSpecificOptions(&implOption{}, opts...))
+}
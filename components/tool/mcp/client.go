@@ -0,0 +1,289 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mcp provides a client of the Model Context Protocol (MCP), which exposes the tools of a remote MCP server as tool.BaseTool,
+// so that they can be used in ToolsNodeConfig.Tools without writing Go wrappers.
+// Only the Streamable HTTP transport is supported, see https://modelcontextprotocol.io/specification/2025-03-26/basic/transports.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bytedance/sonic"
+)
+
+// ProtocolVersion is the version of MCP requested by the client.
+const ProtocolVersion = "2025-03-26"
+
+const headerSessionID = "Mcp-Session-Id"
+
+// ClientConfig is the config for Client.
+type ClientConfig struct {
+	// Endpoint is the URL of the MCP endpoint of the server, e.g. http://localhost:8080/mcp.
+	Endpoint string
+	// HTTPClient sends the requests to the server.
+	// Optional. Default is http.DefaultClient.
+	HTTPClient *http.Client
+	// Headers are added to every request, e.g. the authorization header.
+	// Optional.
+	Headers map[string]string
+	// ClientName and ClientVersion are reported to the server on initialization.
+	// Optional. Default is "eino" and "v0.0.0".
+	ClientName    string
+	ClientVersion string
+}
+
+// Client connects to an MCP server, lists its tools and calls them.
+// The session with the server is established on the first request, and re-established once
+// if the connection fails or the server has terminated the session, so that a restarted server is reconnected transparently.
+// NOTE: the request failed by the connection is sent again after reconnecting, so a tool call may run twice if the server
+// has received it before the connection failed.
+// It's safe for concurrent use.
+// eg:
+//
+//	cli, _ := mcp.NewClient(ctx, &mcp.ClientConfig{Endpoint: "http://localhost:8080/mcp"})
+//	tools, err := cli.GetTools(ctx)
+//	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: tools})
+type Client struct {
+	endpoint      string
+	cli           *http.Client
+	headers       map[string]string
+	clientName    string
+	clientVersion string
+
+	nextID atomic.Int64
+
+	mu        sync.Mutex
+	connected bool
+	sessionID string
+}
+
+// NewClient creates a new Client, it doesn't connect to the server until the first request.
+func NewClient(ctx context.Context, conf *ClientConfig) (*Client, error) {
+	if conf == nil || conf.Endpoint == "" {
+		return nil, errors.New("endpoint of mcp server is required")
+	}
+
+	c := &Client{
+		endpoint:      conf.Endpoint,
+		cli:           conf.HTTPClient,
+		headers:       conf.Headers,
+		clientName:    conf.ClientName,
+		clientVersion: conf.ClientVersion,
+	}
+	if c.cli == nil {
+		c.cli = http.DefaultClient
+	}
+	if c.clientName == "" {
+		c.clientName = "eino"
+	}
+	if c.clientVersion == "" {
+		c.clientVersion = "v0.0.0"
+	}
+	return c, nil
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      *int64 `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string                 `json:"jsonrpc"`
+	ID      *int64                 `json:"id,omitempty"`
+	Method  string                 `json:"method,omitempty"`
+	Result  sonic.NoCopyRawMessage `json:"result,omitempty"`
+	Error   *RPCError              `json:"error,omitempty"`
+}
+
+// RPCError is the JSON-RPC error returned by the server.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// errSessionLost means the session should be re-established, because the connection failed or the server has terminated the session.
+var errSessionLost = errors.New("mcp session lost")
+
+// call sends a request to the server and unmarshals the result into result, reconnecting once if the session is lost.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	err := c.callOnce(ctx, method, params, result)
+	if !errors.Is(err, errSessionLost) || ctx.Err() != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.connected = false
+	c.sessionID = ""
+	c.mu.Unlock()
+
+	return c.callOnce(ctx, method, params, result)
+}
+
+func (c *Client) callOnce(ctx context.Context, method string, params, result any) error {
+	sessionID, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.send(ctx, sessionID, method, params, result)
+	return err
+}
+
+// connect initializes the session if not yet, and returns the session id.
+func (c *Client) connect(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.connected {
+		return c.sessionID, nil
+	}
+
+	params := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": c.clientName, "version": c.clientVersion},
+	}
+	sessionID, err := c.send(ctx, "", "initialize", params, nil)
+	if err != nil {
+		return "", fmt.Errorf("initialize mcp session failed: %w", err)
+	}
+	if _, err = c.send(ctx, sessionID, "notifications/initialized", nil, nil); err != nil {
+		return "", fmt.Errorf("initialize mcp session failed: %w", err)
+	}
+
+	c.connected = true
+	c.sessionID = sessionID
+	return sessionID, nil
+}
+
+// send posts a request, or a notification if method starts with "notifications/", and returns the session id assigned by the server.
+func (c *Client) send(ctx context.Context, sessionID, method string, params, result any) (string, error) {
+	req := rpcRequest{JSONRPC: "2.0", Method: method, Params: params}
+	isNotification := strings.HasPrefix(method, "notifications/")
+	if !isNotification {
+		id := c.nextID.Add(1)
+		req.ID = &id
+	}
+	body, err := sonic.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+	if sessionID != "" {
+		httpReq.Header.Set(headerSessionID, sessionID)
+	}
+
+	resp, err := c.cli.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: %w", errSessionLost, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && sessionID != "" {
+		return "", fmt.Errorf("%w: session %s not found", errSessionLost, sessionID)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("mcp server returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	newSessionID := resp.Header.Get(headerSessionID)
+	if isNotification {
+		return newSessionID, nil
+	}
+
+	rpcResp, err := readResponse(resp, *req.ID)
+	if err != nil {
+		return "", err
+	}
+	if rpcResp.Error != nil {
+		return "", rpcResp.Error
+	}
+	if result != nil {
+		if err = sonic.Unmarshal(rpcResp.Result, result); err != nil {
+			return "", fmt.Errorf("unmarshal result of %s failed: %w", method, err)
+		}
+	}
+	return newSessionID, nil
+}
+
+// readResponse reads the response of the request with id, from either a JSON body or an SSE stream,
+// in which the messages other than the response, e.g. notifications of the server, are skipped.
+func readResponse(resp *http.Response, id int64) (*rpcResponse, error) {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var rpcResp rpcResponse
+		if err := sonic.ConfigDefault.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+			return nil, fmt.Errorf("decode mcp response failed: %w", err)
+		}
+		return &rpcResp, nil
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if v, ok := strings.CutPrefix(line, "data:"); ok {
+			data.WriteString(strings.TrimPrefix(v, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		// an empty line dispatches the event
+		var rpcResp rpcResponse
+		err := sonic.UnmarshalString(data.String(), &rpcResp)
+		data.Reset()
+		if err != nil {
+			return nil, fmt.Errorf("decode mcp event failed: %w", err)
+		}
+		if rpcResp.ID != nil && *rpcResp.ID == id && rpcResp.Method == "" {
+			return &rpcResp, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSessionLost, err)
+	}
+	return nil, fmt.Errorf("%w: event stream ended without response", errSessionLost)
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// stubServer is an MCP server of the Streamable HTTP transport, with the tools echo and fail.
+type stubServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	sessions map[string]bool
+	inits    int
+}
+
+func newStubServer(t *testing.T) *stubServer {
+	s := &stubServer{sessions: map[string]bool{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// restart drops all the sessions like a restarted server.
+func (s *stubServer) restart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = map[string]bool{}
+}
+
+func (s *stubServer) handle(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	if err := sonic.ConfigDefault.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params, _ := req.Params.(map[string]any)
+
+	s.mu.Lock()
+	if req.Method == "initialize" {
+		s.inits++
+		sessionID := fmt.Sprintf("session_%d", s.inits)
+		s.sessions[sessionID] = true
+		s.mu.Unlock()
+		w.Header().Set(headerSessionID, sessionID)
+		s.reply(w, req, map[string]any{"protocolVersion": ProtocolVersion, "capabilities": map[string]any{"tools": map[string]any{}}}, false)
+		return
+	}
+	known := s.sessions[r.Header.Get(headerSessionID)]
+	s.mu.Unlock()
+	if !known {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	switch req.Method {
+	case "notifications/initialized":
+		w.WriteHeader(http.StatusAccepted)
+	case "tools/list":
+		if params["cursor"] == nil {
+			s.reply(w, req, map[string]any{
+				"tools": []any{map[string]any{"name": "echo", "description": "echo the text", "inputSchema": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"text": map[string]any{"type": "string"}},
+					"required":   []any{"text"},
+				}}},
+				"nextCursor": "page_2",
+			}, false)
+			return
+		}
+		s.reply(w, req, map[string]any{"tools": []any{map[string]any{"name": "fail"}}}, false)
+	case "tools/call":
+		args, _ := params["arguments"].(map[string]any)
+		if params["name"] == "fail" {
+			s.reply(w, req, map[string]any{"content": []any{map[string]any{"type": "text", "text": "something wrong"}}, "isError": true}, true)
+			return
+		}
+		s.reply(w, req, map[string]any{"content": []any{
+			map[string]any{"type": "text", "text": args["text"]},
+			map[string]any{"type": "image", "data": "aGk=", "mimeType": "image/png"},
+		}}, true)
+	default:
+		s.reply(w, req, nil, false)
+	}
+}
+
+// reply writes the response as JSON, or as an SSE stream preceded by a notification if sse is true.
+func (s *stubServer) reply(w http.ResponseWriter, req rpcRequest, result any, sse bool) {
+	resp := map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result}
+	if result == nil {
+		resp = map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": -32601, "message": "method not found"}}
+	}
+	b, _ := sonic.Marshal(resp)
+	if !sse {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", `{"jsonrpc":"2.0","method":"notifications/progress","params":{"progress":1}}`)
+	_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("get tools", func(t *testing.T) {
+		srv := newStubServer(t)
+		cli, err := NewClient(ctx, &ClientConfig{Endpoint: srv.URL})
+		assert.NoError(t, err)
+
+		tools, err := cli.GetTools(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, tools, 2)
+
+		info, err := tools[0].Info(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "echo", info.Name)
+		assert.Equal(t, "echo the text", info.Desc)
+		js, err := info.ToJSONSchema()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"text"}, js.Required)
+
+		info, err = tools[1].Info(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "fail", info.Name)
+		assert.Nil(t, info.ParamsOneOf)
+	})
+
+	t.Run("call tools", func(t *testing.T) {
+		srv := newStubServer(t)
+		cli, err := NewClient(ctx, &ClientConfig{Endpoint: srv.URL})
+		assert.NoError(t, err)
+		tools, err := cli.GetTools(ctx)
+		assert.NoError(t, err)
+
+		out, err := tools[0].(tool.InvokableTool).InvokableRun(ctx, `{"text": "hello"}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello\n"+`{"data":"aGk=","mimeType":"image/png","type":"image"}`, out)
+
+		_, err = tools[1].(tool.InvokableTool).InvokableRun(ctx, `{}`)
+		assert.ErrorContains(t, err, "mcp tool[fail] returned error: something wrong")
+
+		_, err = tools[0].(tool.InvokableTool).InvokableRun(ctx, `{"text": `)
+		assert.ErrorIs(t, err, compose.ErrInvalidToolArgs)
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		srv := newStubServer(t)
+		cli, err := NewClient(ctx, &ClientConfig{Endpoint: srv.URL})
+		assert.NoError(t, err)
+		tools, err := cli.GetTools(ctx)
+		assert.NoError(t, err)
+
+		srv.restart()
+		out, err := tools[0].(tool.InvokableTool).InvokableRun(ctx, `{"text": "hello again"}`)
+		assert.NoError(t, err)
+		assert.Contains(t, out, "hello again")
+		assert.Equal(t, 2, srv.inits)
+	})
+
+	t.Run("in tools node", func(t *testing.T) {
+		srv := newStubServer(t)
+		cli, err := NewClient(ctx, &ClientConfig{Endpoint: srv.URL})
+		assert.NoError(t, err)
+		tools, err := cli.GetTools(ctx)
+		assert.NoError(t, err)
+
+		tn, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: tools})
+		assert.NoError(t, err)
+		out, err := tn.Invoke(ctx, schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "call_1", Function: schema.FunctionCall{Name: "echo", Arguments: `{"text": "hi"}`}},
+		}))
+		assert.NoError(t, err)
+		assert.Contains(t, out[0].Content, "hi\n")
+	})
+
+	t.Run("rpc error", func(t *testing.T) {
+		srv := newStubServer(t)
+		cli, err := NewClient(ctx, &ClientConfig{Endpoint: srv.URL})
+		assert.NoError(t, err)
+		err = cli.call(ctx, "resources/list", nil, nil)
+		var rpcErr *RPCError
+		assert.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, -32601, rpcErr.Code)
+	})
+
+	t.Run("no endpoint", func(t *testing.T) {
+		_, err := NewClient(ctx, &ClientConfig{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/eino-contrib/jsonschema"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+type listToolsResult struct {
+	Tools []struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		InputSchema sonic.NoCopyRawMessage `json:"inputSchema"`
+	} `json:"tools"`
+	NextCursor string `json:"nextCursor"`
+}
+
+type callToolResult struct {
+	Content []map[string]any `json:"content"`
+	IsError bool             `json:"isError"`
+}
+
+// GetTools lists the tools of the server, and returns them as tool.InvokableTool, whose ToolInfo is built from the input schema of the tool.
+// The tools are listed from the server on every call, so call it again to refresh the tools after the server has changed them,
+// e.g. to add the new ones to a compiled graph by ToolsNode.RegisterTool.
+func (c *Client) GetTools(ctx context.Context) ([]tool.BaseTool, error) {
+	var tools []tool.BaseTool
+	cursor := ""
+	for {
+		var params map[string]any
+		if cursor != "" {
+			params = map[string]any{"cursor": cursor}
+		}
+		var result listToolsResult
+		if err := c.call(ctx, "tools/list", params, &result); err != nil {
+			return nil, fmt.Errorf("list mcp tools failed: %w", err)
+		}
+
+		for _, t := range result.Tools {
+			info := &schema.ToolInfo{Name: t.Name, Desc: t.Description}
+			if len(t.InputSchema) > 0 {
+				js := &jsonschema.Schema{}
+				if err := sonic.Unmarshal(t.InputSchema, js); err != nil {
+					return nil, fmt.Errorf("unmarshal input schema of mcp tool[%s] failed: %w", t.Name, err)
+				}
+				info.ParamsOneOf = schema.NewParamsOneOfByJSONSchema(js)
+			}
+			tools = append(tools, &mcpTool{cli: c, info: info})
+		}
+
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+type mcpTool struct {
+	cli  *Client
+	info *schema.ToolInfo
+}
+
+func (t *mcpTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+// InvokableRun calls the tool on the server, the text contents of the result are joined by new lines,
+// and the other contents, e.g. images, are kept as JSON. A result marked as error by the server is returned as error.
+func (t *mcpTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	args := map[string]any{}
+	if strings.TrimSpace(argumentsInJSON) != "" {
+		if err := sonic.UnmarshalString(argumentsInJSON, &args); err != nil {
+			return "", fmt.Errorf("unmarshal arguments of mcp tool[%s] failed, %w: %w", t.info.Name, compose.ErrInvalidToolArgs, err)
+		}
+	}
+
+	var result callToolResult
+	if err := t.cli.call(ctx, "tools/call", map[string]any{"name": t.info.Name, "arguments": args}, &result); err != nil {
+		return "", fmt.Errorf("call mcp tool[%s] failed: %w", t.info.Name, err)
+	}
+
+	contents := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		if text, ok := content["text"].(string); ok && content["type"] == "text" {
+			contents = append(contents, text)
+			continue
+		}
+		// sorted keys for a stable output
+		b, err := sonic.ConfigStd.MarshalToString(content)
+		if err != nil {
+			return "", err
+		}
+		contents = append(contents, b)
+	}
+	output := strings.Join(contents, "\n")
+
+	if result.IsError {
+		return "", fmt.Errorf("mcp tool[%s] returned error: %s", t.info.Name, output)
+	}
+	return output, nil
+}
+
+func (t *mcpTool) GetType() string {
+	return "MCPTool"
+}
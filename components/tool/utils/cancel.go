@@ -0,0 +1,217 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime/debug"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+// WrapToolWithCancellation wraps any BaseTool to enforce a kill-on-cancel contract.
+// The wrapped tool runs in a separate goroutine, and the wrapper returns ctx.Err() as soon as ctx is done,
+// even if the tool ignores the cancellation, e.g. a tool waiting for an external command without exec.CommandContext.
+// NOTE: the goroutine of the tool is not killed, it's left to finish on its own and its result is dropped,
+// so tools should still respect ctx to release what they started.
+//
+// Parameters:
+//   - t: The original BaseTool to be wrapped
+//
+// Returns:
+//   - A wrapped BaseTool that returns on cancellation based on its capabilities
+func WrapToolWithCancellation(t tool.BaseTool) tool.BaseTool {
+	ih := &infoHelper{info: t.Info}
+	var s tool.StreamableTool
+	if st, ok := t.(tool.StreamableTool); ok {
+		s = st
+	}
+	if it, ok := t.(tool.InvokableTool); ok {
+		if s == nil {
+			return WrapInvokableToolWithCancellation(it)
+		}
+		return &combinedCancelWrapper{
+			infoHelper:         ih,
+			cancelHelper:       &cancelHelper{i: it.InvokableRun},
+			streamCancelHelper: &streamCancelHelper{s: s.StreamableRun},
+		}
+	}
+	if s != nil {
+		return WrapStreamableToolWithCancellation(s)
+	}
+	return t
+}
+
+// WrapInvokableToolWithCancellation wraps an InvokableTool to return ctx.Err() as soon as ctx is done,
+// regardless of whether the tool respects the cancellation.
+func WrapInvokableToolWithCancellation(t tool.InvokableTool) tool.InvokableTool {
+	return &cancelWrapper{
+		infoHelper:   &infoHelper{info: t.Info},
+		cancelHelper: &cancelHelper{i: t.InvokableRun},
+	}
+}
+
+// WrapStreamableToolWithCancellation wraps a StreamableTool to return ctx.Err() as soon as ctx is done,
+// regardless of whether the tool respects the cancellation.
+// If ctx is done while the output stream is being received, ctx.Err() is received as the last error of the stream.
+func WrapStreamableToolWithCancellation(t tool.StreamableTool) tool.StreamableTool {
+	return &streamCancelWrapper{
+		infoHelper:         &infoHelper{info: t.Info},
+		streamCancelHelper: &streamCancelHelper{s: t.StreamableRun},
+	}
+}
+
+type cancelWrapper struct {
+	*infoHelper
+	*cancelHelper
+}
+
+type streamCancelWrapper struct {
+	*infoHelper
+	*streamCancelHelper
+}
+
+type combinedCancelWrapper struct {
+	*infoHelper
+	*cancelHelper
+	*streamCancelHelper
+}
+
+type cancelHelper struct {
+	i func(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error)
+}
+
+func (c *cancelHelper) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	type result struct {
+		output string
+		err    error
+	}
+
+	// buffered, so that the goroutine of a tool ignoring cancellation never blocks after the wrapper returns
+	ch := make(chan result, 1)
+	go func() {
+		defer func() {
+			if panicErr := recover(); panicErr != nil {
+				ch <- result{err: safe.NewPanicErr(panicErr, debug.Stack())}
+			}
+		}()
+
+		output, err := c.i(ctx, argumentsInJSON, opts...)
+		ch <- result{output: output, err: err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.output, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+type streamCancelHelper struct {
+	s func(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error)
+}
+
+func (c *streamCancelHelper) StreamableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	type result struct {
+		sr  *schema.StreamReader[string]
+		err error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		defer func() {
+			if panicErr := recover(); panicErr != nil {
+				ch <- result{err: safe.NewPanicErr(panicErr, debug.Stack())}
+			}
+		}()
+
+		sr, err := c.s(ctx, argumentsInJSON, opts...)
+		ch <- result{sr: sr, err: err}
+	}()
+
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return receiveUntilCancel(ctx, r.sr), nil
+	case <-ctx.Done():
+		// close the stream returned too late, if any
+		go func() {
+			if r := <-ch; r.sr != nil {
+				r.sr.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// receiveUntilCancel forwards the chunks of sr until sr ends or ctx is done, in which case ctx.Err() is sent as the last error.
+func receiveUntilCancel(ctx context.Context, sr *schema.StreamReader[string]) *schema.StreamReader[string] {
+	type chunk struct {
+		value string
+		err   error
+	}
+
+	out, sw := schema.Pipe[string](0)
+	go func() {
+		defer sw.Close()
+
+		chunks := make(chan chunk)
+		done := make(chan struct{})
+		defer close(done)
+
+		// receiving may block forever if the tool ignores cancellation, so it's done in another goroutine
+		go func() {
+			defer close(chunks)
+			defer sr.Close()
+			for {
+				value, err := sr.Recv()
+				if errors.Is(err, io.EOF) {
+					return
+				}
+				select {
+				case chunks <- chunk{value: value, err: err}:
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case c, ok := <-chunks:
+				if !ok {
+					return
+				}
+				if closed := sw.Send(c.value, c.err); closed {
+					return
+				}
+			case <-ctx.Done():
+				sw.Send("", ctx.Err())
+				return
+			}
+		}
+	}()
+
+	return out
+}
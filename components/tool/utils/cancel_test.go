@@ -0,0 +1,158 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// slowTool ignores ctx and blocks until release is closed, like a tool waiting for an external command.
+type slowTool struct {
+	release chan struct{}
+}
+
+func (t *slowTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "slow_tool", Desc: "a slow tool ignoring cancellation"}, nil
+}
+
+func (t *slowTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	<-t.release
+	return "done", nil
+}
+
+func (t *slowTool) StreamableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	sr, sw := schema.Pipe[string](0)
+	go func() {
+		defer sw.Close()
+		sw.Send("started", nil)
+		<-t.release
+		sw.Send("done", nil)
+	}()
+	return sr, nil
+}
+
+func newSlowTool(t *testing.T) *slowTool {
+	st := &slowTool{release: make(chan struct{})}
+	t.Cleanup(func() { close(st.release) })
+	return st
+}
+
+func TestCancellationWrapper(t *testing.T) {
+	t.Run("invoke_returns_on_timeout", func(t *testing.T) {
+		wrapped := WrapInvokableToolWithCancellation(newSlowTool(t))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := wrapped.InvokableRun(ctx, "{}")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("invoke_passes_through_result", func(t *testing.T) {
+		st := &slowTool{release: make(chan struct{})}
+		close(st.release)
+
+		result, err := WrapInvokableToolWithCancellation(st).InvokableRun(context.Background(), "{}")
+		assert.NoError(t, err)
+		assert.Equal(t, "done", result)
+	})
+
+	t.Run("invoke_recovers_panic", func(t *testing.T) {
+		wrapped := WrapInvokableToolWithCancellation(&cancelPanicTool{})
+		_, err := wrapped.InvokableRun(context.Background(), "{}")
+		assert.ErrorContains(t, err, "tool panic")
+	})
+
+	t.Run("stream_returns_on_cancel", func(t *testing.T) {
+		wrapped := WrapStreamableToolWithCancellation(newSlowTool(t))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr, err := wrapped.StreamableRun(ctx, "{}")
+		assert.NoError(t, err)
+		defer sr.Close()
+
+		chunk, err := sr.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, "started", chunk)
+
+		cancel()
+		_, err = sr.Recv()
+		assert.True(t, errors.Is(err, context.Canceled))
+		_, err = sr.Recv()
+		assert.True(t, errors.Is(err, io.EOF))
+	})
+
+	t.Run("stream_passes_through_chunks", func(t *testing.T) {
+		st := &slowTool{release: make(chan struct{})}
+		close(st.release)
+
+		sr, err := WrapStreamableToolWithCancellation(st).StreamableRun(context.Background(), "{}")
+		assert.NoError(t, err)
+		defer sr.Close()
+
+		var chunks []string
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			assert.NoError(t, err)
+			chunks = append(chunks, chunk)
+		}
+		assert.Equal(t, []string{"started", "done"}, chunks)
+	})
+
+	t.Run("wrap_detects_tool_type", func(t *testing.T) {
+		wrapped := WrapToolWithCancellation(newSlowTool(t))
+		_, ok := wrapped.(tool.InvokableTool)
+		assert.True(t, ok)
+		_, ok = wrapped.(tool.StreamableTool)
+		assert.True(t, ok)
+
+		info, err := wrapped.Info(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "slow_tool", info.Name)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = wrapped.(tool.InvokableTool).InvokableRun(ctx, "{}")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
+
+type cancelPanicTool struct{}
+
+func (t *cancelPanicTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "panic_tool"}, nil
+}
+
+func (t *cancelPanicTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	panic("tool panic")
+}
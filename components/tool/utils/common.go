@@ -26,3 +26,14 @@ func marshalString(resp any) (string, error) {
 	}
 	return sonic.MarshalString(resp)
 }
+
+// argumentsUnmarshaler returns the function unmarshalling the arguments in JSON, configured by WithUnmarshalStrict and WithUseNumber.
+func argumentsUnmarshaler(to *toolOptions) func(arguments string, v any) error {
+	if !to.disallowUnknownFields && !to.useNumber {
+		return sonic.UnmarshalString
+	}
+	return sonic.Config{
+		DisallowUnknownFields: to.disallowUnknownFields,
+		UseNumber:             to.useNumber,
+	}.Froze().UnmarshalFromString
+}
@@ -33,6 +33,11 @@ type toolOptions struct {
 	um         UnmarshalArguments
 	m          MarshalOutput
 	scModifier SchemaModifierFn
+	respSchema bool
+	strict     bool
+
+	disallowUnknownFields bool
+	useNumber             bool
 }
 
 // Option is the option func for the tool.
@@ -70,6 +75,43 @@ func WithSchemaModifier(modifier SchemaModifierFn) Option {
 	}
 }
 
+// WithResponseSchema appends the JSON schema of the tool output, inferred from the output type like the parameters are,
+// to the description of the tool created by NewTool or InferTool, so that the model knows the shape of the result before calling it.
+// Fields can be documented by the description tag, e.g. `json:"temp" description:"温度，单位摄氏度"`.
+// Note that the description with the schema appended is still limited to schema.MaxToolDescLength characters.
+func WithResponseSchema() Option {
+	return func(o *toolOptions) {
+		o.respSchema = true
+	}
+}
+
+// WithStrictSchema makes the JSON schema of the tool parameters compatible with the strict mode of function calling,
+// e.g. "strict": true of OpenAI, by schema.ToStrictJSONSchema: all properties are required, the optional ones become nullable,
+// and additionalProperties is false. It applies to the schema inferred by InferTool and GoStruct2ParamsOneOf, as well as the one passed to NewTool.
+// Note that a nullable field is unmarshalled as its zero value when the model passes null.
+func WithStrictSchema() Option {
+	return func(o *toolOptions) {
+		o.strict = true
+	}
+}
+
+// WithUnmarshalStrict fails the tool calls whose arguments have fields unknown to the input type,
+// instead of dropping them silently, so that the mistakes of the model, e.g. a misspelled field, are caught.
+// The error wraps compose.ErrInvalidToolArgs. It doesn't apply if WithUnmarshalArguments is set.
+func WithUnmarshalStrict() Option {
+	return func(o *toolOptions) {
+		o.disallowUnknownFields = true
+	}
+}
+
+// WithUseNumber unmarshals the numbers of the arguments into json.Number instead of float64, for the fields of interface type,
+// e.g. any or map[string]any, so that large integers don't lose precision. It doesn't apply if WithUnmarshalArguments is set.
+func WithUseNumber() Option {
+	return func(o *toolOptions) {
+		o.useNumber = true
+	}
+}
+
 func getToolOptions(opt ...Option) *toolOptions {
 	opts := &toolOptions{
 		um: nil,
@@ -19,12 +19,14 @@ package utils
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/bytedance/sonic"
 	"github.com/eino-contrib/jsonschema"
 
 	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/internal/generic"
 	"github.com/cloudwego/eino/schema"
 )
@@ -36,6 +38,8 @@ type InvokeFunc[T, D any] func(ctx context.Context, input T) (output D, err erro
 type OptionableInvokeFunc[T, D any] func(ctx context.Context, input T, opts ...tool.Option) (output D, err error)
 
 // InferTool creates an InvokableTool from a given function by inferring the ToolInfo from the function's request parameters.
+// Besides the jsonschema tag, the standalone enum, default, minimum, maximum and pattern tags of the fields are reflected into the schema,
+// e.g. `enum:"北京,上海,广州" default:"北京"`.
 // End-user can pass a SchemaCustomizerFn in opts to customize the go struct tag parsing process, overriding default behavior.
 func InferTool[T, D any](toolName, toolDesc string, i InvokeFunc[T, D], opts ...Option) (tool.InvokableTool, error) {
 	ti, err := goStruct2ToolInfo[T](toolName, toolDesc, opts...)
@@ -86,12 +90,24 @@ func goStruct2ParamsOneOf[T any](opts ...Option) (*schema.ParamsOneOf, error) {
 	r := &jsonschema.Reflector{
 		Anonymous:      true,
 		DoNotReference: true,
-		SchemaModifier: jsonschema.SchemaModifierFn(options.scModifier),
+		SchemaModifier: func(jsonTagName string, t reflect.Type, tag reflect.StructTag, js *jsonschema.Schema) {
+			parseSchemaTags(jsonTagName, t, tag, js)
+			if options.scModifier != nil {
+				options.scModifier(jsonTagName, t, tag, js)
+			}
+		},
 	}
 
 	js := r.Reflect(generic.NewInstance[T]())
 	js.Version = ""
 
+	if options.strict {
+		var err error
+		if js, err = schema.ToStrictJSONSchema(js); err != nil {
+			return nil, err
+		}
+	}
+
 	paramsOneOf := schema.NewParamsOneOfByJSONSchema(js)
 
 	return paramsOneOf, nil
@@ -107,24 +123,88 @@ func NewTool[T, D any](desc *schema.ToolInfo, i InvokeFunc[T, D], opts ...Option
 func newOptionableTool[T, D any](desc *schema.ToolInfo, i OptionableInvokeFunc[T, D], opts ...Option) tool.InvokableTool {
 	to := getToolOptions(opts...)
 
+	var infoErr error
+	if to.strict {
+		desc, infoErr = withStrictSchema(desc)
+	}
+	if to.respSchema && infoErr == nil {
+		desc, infoErr = withResponseSchema[D](desc, opts...)
+	}
+
 	return &invokableTool[T, D]{
-		info: desc,
-		um:   to.um,
-		m:    to.m,
-		Fn:   i,
+		info:      desc,
+		infoErr:   infoErr,
+		um:        to.um,
+		m:         to.m,
+		unmarshal: argumentsUnmarshaler(to),
+		Fn:        i,
+	}
+}
+
+// withStrictSchema returns a copy of desc, with the parameters converted by schema.ToStrictJSONSchema.
+func withStrictSchema(desc *schema.ToolInfo) (*schema.ToolInfo, error) {
+	if desc == nil || desc.ParamsOneOf == nil {
+		return desc, nil
+	}
+
+	js, err := desc.ParamsOneOf.ToJSONSchema()
+	if err != nil {
+		return desc, err
+	}
+	if js, err = schema.ToStrictJSONSchema(js); err != nil {
+		return desc, fmt.Errorf("convert params of tool[%s] to strict json schema failed: %w", desc.Name, err)
+	}
+
+	info := *desc
+	info.ParamsOneOf = schema.NewParamsOneOfByJSONSchema(js)
+	return &info, nil
+}
+
+// withResponseSchema returns a copy of desc, with the JSON schema of D appended to the description.
+func withResponseSchema[D any](desc *schema.ToolInfo, opts ...Option) (*schema.ToolInfo, error) {
+	if desc == nil {
+		return nil, nil
+	}
+
+	paramsOneOf, err := goStruct2ParamsOneOf[D](opts...)
+	if err != nil {
+		return desc, err
+	}
+	js, err := paramsOneOf.ToJSONSchema()
+	if err != nil {
+		return desc, err
 	}
+	b, err := sonic.MarshalString(js)
+	if err != nil {
+		return desc, fmt.Errorf("marshal response schema of tool[%s] failed: %w", desc.Name, err)
+	}
+
+	info := *desc
+	if info.Desc != "" {
+		info.Desc += "\n\n"
+	}
+	info.Desc += "Response JSON schema: " + b
+	return &info, nil
 }
 
 type invokableTool[T, D any] struct {
-	info *schema.ToolInfo
+	info    *schema.ToolInfo
+	infoErr error
 
-	um UnmarshalArguments
-	m  MarshalOutput
+	um        UnmarshalArguments
+	m         MarshalOutput
+	unmarshal func(arguments string, v any) error
 
 	Fn OptionableInvokeFunc[T, D]
 }
 
 func (i *invokableTool[T, D]) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	if i.infoErr != nil {
+		return nil, i.infoErr
+	}
+	if err := i.info.Validate(); err != nil {
+		return nil, err
+	}
 	return i.info, nil
 }
 
@@ -136,19 +216,19 @@ func (i *invokableTool[T, D]) InvokableRun(ctx context.Context, arguments string
 		var val any
 		val, err = i.um(ctx, arguments)
 		if err != nil {
-			return "", fmt.Errorf("[LocalFunc] failed to unmarshal arguments, toolName=%s, err=%w", i.getToolName(), err)
+			return "", fmt.Errorf("[LocalFunc] failed to unmarshal arguments, toolName=%s, err=%w: %w", i.getToolName(), compose.ErrInvalidToolArgs, err)
 		}
 		gt, ok := val.(T)
 		if !ok {
-			return "", fmt.Errorf("[LocalFunc] invalid type, toolName=%s, expected=%T, given=%T", i.getToolName(), inst, val)
+			return "", fmt.Errorf("[LocalFunc] %w: invalid type, toolName=%s, expected=%T, given=%T", compose.ErrInvalidToolArgs, i.getToolName(), inst, val)
 		}
 		inst = gt
 	} else {
 		inst = generic.NewInstance[T]()
 
-		err = sonic.UnmarshalString(arguments, &inst)
+		err = i.unmarshal(arguments, &inst)
 		if err != nil {
-			return "", fmt.Errorf("[LocalFunc] failed to unmarshal arguments in json, toolName=%s, err=%w", i.getToolName(), err)
+			return "", fmt.Errorf("[LocalFunc] failed to unmarshal arguments in json, toolName=%s, err=%w: %w", i.getToolName(), compose.ErrInvalidToolArgs, err)
 		}
 	}
 
@@ -157,6 +237,8 @@ func (i *invokableTool[T, D]) InvokableRun(ctx context.Context, arguments string
 		return "", fmt.Errorf("[LocalFunc] failed to invoke tool, toolName=%s, err=%w", i.getToolName(), err)
 	}
 
+	compose.SetTypedToolResult(ctx, resp)
+
 	if i.m != nil {
 		output, err = i.m(ctx, resp)
 		if err != nil {
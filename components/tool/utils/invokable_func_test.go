@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/eino-contrib/jsonschema"
@@ -27,6 +28,7 @@ import (
 	orderedmap "github.com/wk8/go-ordered-map/v2"
 
 	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 )
 
@@ -246,6 +248,56 @@ func TestInferTool(t *testing.T) {
 		assert.NoError(t, err)
 		assert.JSONEq(t, `{"code":200,"msg":"update bruce lee success"}`, content)
 	})
+
+	t.Run("typed_result_in_tools_node", func(t *testing.T) {
+		ctx := context.Background()
+
+		tl, err := InferTool("update_user_info", "full update user info", updateUserInfo)
+		assert.NoError(t, err)
+
+		tn, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{
+			Tools:                []tool.BaseTool{tl},
+			PreserveTypedResults: true,
+		})
+		assert.NoError(t, err)
+
+		out, err := tn.Invoke(ctx, schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "call_1", Function: schema.FunctionCall{Name: "update_user_info", Arguments: `{"name": "bruce lee"}`}},
+		}))
+		assert.NoError(t, err)
+		assert.Len(t, out, 1)
+		assert.JSONEq(t, `{"code":200,"msg":"update bruce lee success"}`, out[0].Content)
+
+		resp, ok := compose.GetTypedToolResult[*UserResult](out[0], "call_1")
+		assert.True(t, ok)
+		assert.Equal(t, &UserResult{Code: 200, Msg: "update bruce lee success"}, resp)
+	})
+}
+
+func TestToolInfoValidation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid name", func(t *testing.T) {
+		tl, err := InferTool("update user info", "full update user info", updateUserInfo)
+		assert.NoError(t, err)
+
+		_, err = tl.Info(ctx)
+		assert.ErrorContains(t, err, `invalid tool name "update user info"`)
+
+		// misconfigured tools are caught when building the ToolsNode, before the graph runs
+		_, err = compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: []tool.BaseTool{tl}})
+		assert.ErrorContains(t, err, `invalid tool name "update user info"`)
+	})
+
+	t.Run("invalid name of streamable tool", func(t *testing.T) {
+		tl := NewStreamTool(&schema.ToolInfo{Name: "update user info"},
+			func(ctx context.Context, input *User) (*schema.StreamReader[*UserResult], error) {
+				return nil, nil
+			})
+
+		_, err := tl.Info(ctx)
+		assert.ErrorContains(t, err, `invalid tool name "update user info"`)
+	})
 }
 
 func TestInferOptionableTool(t *testing.T) {
@@ -302,6 +354,7 @@ func TestNewTool(t *testing.T) {
 
 		content, err := tl.InvokableRun(ctx, `100`) // json unmarshal must contains double quote if is not json string.
 		assert.Error(t, err)
+		assert.ErrorIs(t, err, compose.ErrInvalidToolArgs)
 		assert.Equal(t, "", content)
 	})
 
@@ -317,6 +370,111 @@ func TestNewTool(t *testing.T) {
 	})
 }
 
+func TestResponseSchema(t *testing.T) {
+	ctx := context.Background()
+	type Input struct {
+		City string `json:"city"`
+	}
+	type Output struct {
+		Weather string `json:"weather" description:"天气状况"`
+		Temp    int    `json:"temp" description:"温度，单位摄氏度"`
+	}
+	fn := func(ctx context.Context, input Input) (Output, error) {
+		return Output{Weather: "晴", Temp: 20}, nil
+	}
+
+	t.Run("appended to description", func(t *testing.T) {
+		desc := &schema.ToolInfo{Name: "get_weather", Desc: "查询天气"}
+		tl := NewTool(desc, fn, WithResponseSchema())
+
+		info, err := tl.Info(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "查询天气\n\nResponse JSON schema: "+
+			`{"properties":{"weather":{"description":"天气状况","type":"string"},"temp":{"description":"温度，单位摄氏度","type":"integer"}},`+
+			`"additionalProperties":false,"required":["weather","temp"],"type":"object"}`,
+			info.Desc)
+		// the tool info passed in is not modified
+		assert.Equal(t, "查询天气", desc.Desc)
+	})
+
+	t.Run("infer tool", func(t *testing.T) {
+		tl, err := InferTool("get_weather", "查询天气", fn, WithResponseSchema())
+		assert.NoError(t, err)
+		info, err := tl.Info(ctx)
+		assert.NoError(t, err)
+		assert.Contains(t, info.Desc, `"description":"温度，单位摄氏度"`)
+		// the parameters are still inferred from the input type
+		js, err := info.ParamsOneOf.ToJSONSchema()
+		assert.NoError(t, err)
+		_, ok := js.Properties.Get("city")
+		assert.True(t, ok)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		info, err := NewTool(&schema.ToolInfo{Name: "get_weather", Desc: "查询天气"}, fn).Info(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "查询天气", info.Desc)
+	})
+}
+
+func TestStrictSchema(t *testing.T) {
+	ctx := context.Background()
+	type Filter struct {
+		Field string `json:"field"`
+		Value string `json:"value,omitempty"`
+	}
+	type Input struct {
+		Query   string            `json:"query"`
+		Limit   int               `json:"limit,omitempty"`
+		Filters []Filter          `json:"filters,omitempty"`
+		Labels  map[string]string `json:"labels,omitempty"`
+	}
+	fn := func(ctx context.Context, input Input) (string, error) {
+		return input.Query, nil
+	}
+
+	t.Run("infer tool", func(t *testing.T) {
+		tl, err := InferTool("search", "搜索", fn, WithStrictSchema())
+		assert.NoError(t, err)
+		info, err := tl.Info(ctx)
+		assert.NoError(t, err)
+		js, err := info.ParamsOneOf.ToJSONSchema()
+		assert.NoError(t, err)
+		b, err := json.Marshal(js)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"properties":{"query":{"type":"string"},"limit":{"type":["integer","null"]},`+
+			`"filters":{"items":{"properties":{"field":{"type":"string"},"value":{"type":["string","null"]}},`+
+			`"additionalProperties":false,"required":["field","value"],"type":"object"},"type":["array","null"]},`+
+			`"labels":{"additionalProperties":{"type":"string"},"type":["object","null"]}},`+
+			`"additionalProperties":false,"required":["query","limit","filters","labels"],"type":"object"}`, string(b))
+
+		// null passed for the optional fields is unmarshalled as zero value
+		out, err := tl.InvokableRun(ctx, `{"query":"eino","limit":null,"filters":null,"labels":null}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "eino", out)
+	})
+
+	t.Run("new tool", func(t *testing.T) {
+		desc := &schema.ToolInfo{Name: "search", Desc: "搜索", ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"query": {Type: schema.String, Required: true},
+			"limit": {Type: schema.Integer},
+		})}
+		info, err := NewTool(desc, fn, WithStrictSchema()).Info(ctx)
+		assert.NoError(t, err)
+		js, err := info.ParamsOneOf.ToJSONSchema()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"limit", "query"}, js.Required)
+		assert.Equal(t, jsonschema.FalseSchema, js.AdditionalProperties)
+		limit, _ := js.Properties.Get("limit")
+		assert.Equal(t, []string{"integer", "null"}, limit.TypeEnhanced)
+
+		// the tool info passed in is not modified
+		js, err = desc.ParamsOneOf.ToJSONSchema()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"query"}, js.Required)
+	})
+}
+
 func TestSnakeToCamel(t *testing.T) {
 	t.Run("normal_case", func(t *testing.T) {
 		assert.Equal(t, "GoogleSearch3", snakeToCamel("google_search_3"))
@@ -403,3 +561,135 @@ func TestEnumTag(t *testing.T) {
 	_, err = goStruct2ParamsOneOf[testEnumStruct3]()
 	assert.NoError(t, err)
 }
+
+type testSchemaTagStruct struct {
+	City    string   `json:"city" enum:"北京, 上海,广州" default:"北京" pattern:"^\\p{Han}+$"`
+	Days    int      `json:"days" minimum:"1" maximum:"7" default:"3" enum:"1,3,x"`
+	Temp    float64  `json:"temp" minimum:"-10.5" maximum:"abc"`
+	Celsius bool     `json:"celsius" default:"true"`
+	Tags    []string `json:"tags" enum:"a,b"`
+	Unknown string   `json:"unknown" foo:"bar"`
+	Notes   []string `json:"notes" description:"备注"`
+	Desc    string   `json:"desc" jsonschema:"description=from jsonschema" description:"from tag"`
+}
+
+func TestSchemaTags(t *testing.T) {
+	info, err := goStruct2ParamsOneOf[testSchemaTagStruct]()
+	assert.NoError(t, err)
+	s, err := info.ToJSONSchema()
+	assert.NoError(t, err)
+
+	city, ok := s.Properties.Get("city")
+	assert.True(t, ok)
+	assert.Equal(t, []any{"北京", "上海", "广州"}, city.Enum)
+	assert.Equal(t, "北京", city.Default)
+	assert.Equal(t, `^\p{Han}+$`, city.Pattern)
+
+	days, ok := s.Properties.Get("days")
+	assert.True(t, ok)
+	assert.Equal(t, []any{json.Number("1"), json.Number("3")}, days.Enum)
+	assert.Equal(t, json.Number("3"), days.Default)
+	assert.Equal(t, json.Number("1"), days.Minimum)
+	assert.Equal(t, json.Number("7"), days.Maximum)
+
+	temp, ok := s.Properties.Get("temp")
+	assert.True(t, ok)
+	assert.Equal(t, json.Number("-10.5"), temp.Minimum)
+	assert.Equal(t, json.Number(""), temp.Maximum)
+
+	celsius, ok := s.Properties.Get("celsius")
+	assert.True(t, ok)
+	assert.Equal(t, true, celsius.Default)
+
+	tags, ok := s.Properties.Get("tags")
+	assert.True(t, ok)
+	assert.Nil(t, tags.Enum)
+	assert.Equal(t, []any{"a", "b"}, tags.Items.Enum)
+
+	unknown, ok := s.Properties.Get("unknown")
+	assert.True(t, ok)
+	assert.Nil(t, unknown.Enum)
+
+	notes, ok := s.Properties.Get("notes")
+	assert.True(t, ok)
+	assert.Equal(t, "备注", notes.Description)
+	assert.Equal(t, "", notes.Items.Description)
+
+	desc, ok := s.Properties.Get("desc")
+	assert.True(t, ok)
+	assert.Equal(t, "from jsonschema", desc.Description)
+
+	// the enum array is emitted in the schema sent to the model
+	b, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"enum":["北京","上海","广州"]`)
+
+	// the user-defined schema modifier runs after, so it can override the tags
+	info, err = goStruct2ParamsOneOf[testSchemaTagStruct](WithSchemaModifier(
+		func(jsonTagName string, _ reflect.Type, _ reflect.StructTag, js *jsonschema.Schema) {
+			if jsonTagName == "city" {
+				js.Default = "上海"
+			}
+		}))
+	assert.NoError(t, err)
+	s, err = info.ToJSONSchema()
+	assert.NoError(t, err)
+	city, _ = s.Properties.Get("city")
+	assert.Equal(t, "上海", city.Default)
+}
+
+func TestUnmarshalOptions(t *testing.T) {
+	ctx := context.Background()
+
+	type transferReq struct {
+		To     string `json:"to"`
+		Amount any    `json:"amount"`
+	}
+	newTransferTool := func(opts ...Option) tool.InvokableTool {
+		tl, err := InferTool("transfer", "transfer money", func(ctx context.Context, req *transferReq) (string, error) {
+			return fmt.Sprintf("%s %v %T", req.To, req.Amount, req.Amount), nil
+		}, opts...)
+		assert.NoError(t, err)
+		return tl
+	}
+
+	t.Run("default", func(t *testing.T) {
+		out, err := newTransferTool().InvokableRun(ctx, `{"to":"tom","amount":9007199254740993,"memo":"rent"}`)
+		assert.NoError(t, err)
+		// the unknown field is dropped, and the large integer loses precision
+		assert.Equal(t, "tom 9.007199254740992e+15 float64", out)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		_, err := newTransferTool(WithUnmarshalStrict()).InvokableRun(ctx, `{"to":"tom","amount":1,"memo":"rent"}`)
+		assert.ErrorIs(t, err, compose.ErrInvalidToolArgs)
+
+		out, err := newTransferTool(WithUnmarshalStrict()).InvokableRun(ctx, `{"to":"tom","amount":1}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "tom 1 float64", out)
+	})
+
+	t.Run("use number", func(t *testing.T) {
+		out, err := newTransferTool(WithUseNumber()).InvokableRun(ctx, `{"to":"tom","amount":9007199254740993}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "tom 9007199254740993 json.Number", out)
+	})
+
+	t.Run("stream tool", func(t *testing.T) {
+		st, err := InferStreamTool("transfer", "transfer money", func(ctx context.Context, req *transferReq) (*schema.StreamReader[string], error) {
+			n, ok := req.Amount.(json.Number)
+			assert.True(t, ok)
+			return schema.StreamReaderFromArray([]string{n.String()}), nil
+		}, WithUnmarshalStrict(), WithUseNumber())
+		assert.NoError(t, err)
+
+		_, err = st.StreamableRun(ctx, `{"to":"tom","amount":1,"memo":"rent"}`)
+		assert.ErrorIs(t, err, compose.ErrInvalidToolArgs)
+
+		sr, err := st.StreamableRun(ctx, `{"to":"tom","amount":9007199254740993}`)
+		assert.NoError(t, err)
+		out, err := sr.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, "9007199254740993", out)
+	})
+}
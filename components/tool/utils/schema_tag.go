@@ -0,0 +1,116 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/eino-contrib/jsonschema"
+)
+
+// parseSchemaTags reflects the standalone struct tags below into the json schema of the field,
+// as a shorthand of the corresponding keywords of the jsonschema tag, e.g.
+//
+//	type WeatherReq struct {
+//		City string `json:"city" jsonschema_description:"城市" enum:"北京,上海,广州" default:"北京"`
+//		Days int    `json:"days" minimum:"1" maximum:"7"`
+//		Date string `json:"date" pattern:"^\\d{4}-\\d{2}-\\d{2}$"`
+//		Unit string `json:"unit" description:"温度单位，celsius 或 fahrenheit"`
+//	}
+//
+// description applies to fields of any type, unless the field is described by the jsonschema tag already.
+// enum is a comma separated list of the allowed values. Values are converted to the type of the field,
+// and those that cannot be converted are ignored, so are the tags on object and array fields,
+// whose elements are schemas of their own. Other tags are ignored.
+func parseSchemaTags(_ string, _ reflect.Type, tag reflect.StructTag, js *jsonschema.Schema) {
+	if js == nil {
+		return
+	}
+
+	if v, ok := tag.Lookup("description"); ok && js.Description == "" {
+		js.Description = v
+		// the elements of an array field share the tag of the field, the description belongs to the field only.
+		if js.Items != nil && js.Items.Description == v {
+			js.Items.Description = ""
+		}
+	}
+
+	if js.Type == "" || js.Type == "object" || js.Type == "array" {
+		return
+	}
+
+	if v, ok := tag.Lookup("enum"); ok {
+		var enum []any
+		for _, s := range strings.Split(v, ",") {
+			if val, ok := parseSchemaValue(js.Type, strings.TrimSpace(s)); ok {
+				enum = append(enum, val)
+			}
+		}
+		if len(enum) > 0 {
+			js.Enum = enum
+		}
+	}
+	if v, ok := tag.Lookup("default"); ok {
+		if val, ok := parseSchemaValue(js.Type, v); ok {
+			js.Default = val
+		}
+	}
+	if js.Type == "integer" || js.Type == "number" {
+		if v, ok := tag.Lookup("minimum"); ok {
+			if val, ok := parseSchemaValue("number", v); ok {
+				js.Minimum = val.(json.Number)
+			}
+		}
+		if v, ok := tag.Lookup("maximum"); ok {
+			if val, ok := parseSchemaValue("number", v); ok {
+				js.Maximum = val.(json.Number)
+			}
+		}
+	}
+	if v, ok := tag.Lookup("pattern"); ok && js.Type == "string" {
+		js.Pattern = v
+	}
+}
+
+// parseSchemaValue converts s to the value of the json schema type, numbers are kept as json.Number like the jsonschema tag does.
+func parseSchemaValue(typ, s string) (any, bool) {
+	switch typ {
+	case "string":
+		return s, true
+	case "integer":
+		if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(s), true
+	case "number":
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(s), true
+	case "boolean":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, false
+		}
+		return b, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+const defaultShellTimeout = 30 * time.Second
+
+// ShellInput is the input of the tool created by NewShellTool.
+type ShellInput struct {
+	Command string   `json:"command" jsonschema:"required,description=the command to run which must be one of the allowed commands"`
+	Args    []string `json:"args,omitempty" jsonschema:"description=the arguments of the command. Paths must be inside the working directory"`
+}
+
+// ShellResult is the output of the tool created by NewShellTool.
+type ShellResult struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+}
+
+type shellOptions struct {
+	name    string
+	desc    string
+	workDir string
+	timeout time.Duration
+}
+
+// ShellOption is the option func for NewShellTool.
+type ShellOption func(o *shellOptions)
+
+// WithShellToolName sets the name of the shell tool, default is "shell".
+func WithShellToolName(name string) ShellOption {
+	return func(o *shellOptions) {
+		o.name = name
+	}
+}
+
+// WithShellToolDesc sets the description of the shell tool, default describes the allowed commands.
+func WithShellToolDesc(desc string) ShellOption {
+	return func(o *shellOptions) {
+		o.desc = desc
+	}
+}
+
+// WithShellWorkDir sets the working directory of the commands, which is also the jail the path arguments are confined to.
+// Default is the current working directory of the process.
+func WithShellWorkDir(dir string) ShellOption {
+	return func(o *shellOptions) {
+		o.workDir = dir
+	}
+}
+
+// WithShellTimeout sets the timeout of a single command, default is 30s.
+func WithShellTimeout(timeout time.Duration) ShellOption {
+	return func(o *shellOptions) {
+		o.timeout = timeout
+	}
+}
+
+// NewShellTool creates a tool running external commands for the model, with the following restrictions:
+//   - only the commands in allowlist can be run, by name looked up in PATH, a command given as a path is rejected.
+//   - the command is executed directly rather than by a shell, so pipes, redirections and expansions are not interpreted,
+//     and arguments containing control characters are rejected.
+//   - every argument other than a flag is taken as a path, which must resolve inside the working directory,
+//     symlinks are followed. So are the value of "--flag=value", and the value attached to a short flag, e.g. "-fPATH".
+//   - each command is killed after the timeout.
+//
+// A non-zero exit code is not an error, it's returned in ShellResult along with stdout and stderr,
+// so that the model can see what went wrong. A rejected argument or a timeout is returned as an error.
+// e.g.
+//
+//	t, err := utils.NewShellTool([]string{"ls", "cat", "grep"}, utils.WithShellWorkDir("./workspace"))
+func NewShellTool(allowlist []string, opts ...ShellOption) (tool.InvokableTool, error) {
+	if len(allowlist) == 0 {
+		return nil, errors.New("allowlist of shell tool is empty")
+	}
+
+	o := &shellOptions{
+		name:    "shell",
+		timeout: defaultShellTimeout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	allowed := make(map[string]bool, len(allowlist))
+	for _, c := range allowlist {
+		if c == "" || strings.ContainsRune(c, filepath.Separator) || strings.ContainsRune(c, '/') {
+			return nil, fmt.Errorf("invalid command in allowlist: %q, only command names are allowed", c)
+		}
+		allowed[c] = true
+	}
+
+	workDir := o.workDir
+	if workDir == "" {
+		var err error
+		workDir, err = os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("get working directory fail: %w", err)
+		}
+	}
+	workDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve working directory fail: %w", err)
+	}
+	workDir, err = filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve working directory fail: %w", err)
+	}
+
+	desc := o.desc
+	if desc == "" {
+		desc = fmt.Sprintf("Run a command in the working directory and get its stdout, stderr and exit code. "+
+			"Allowed commands: %s. The command is not run by a shell, so pipes and redirections are not supported.",
+			strings.Join(allowlist, ", "))
+	}
+
+	s := &shellTool{
+		allowed: allowed,
+		workDir: workDir,
+		timeout: o.timeout,
+	}
+
+	return InferTool(o.name, desc, s.run)
+}
+
+type shellTool struct {
+	allowed map[string]bool
+	workDir string
+	timeout time.Duration
+}
+
+func (s *shellTool) run(ctx context.Context, input *ShellInput) (*ShellResult, error) {
+	if !s.allowed[input.Command] {
+		return nil, fmt.Errorf("command not allowed: %q", input.Command)
+	}
+	for _, arg := range input.Args {
+		if err := s.checkArg(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, input.Command, input.Args...)
+	cmd.Dir = s.workDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("command %q is terminated: %w", input.Command, ctx.Err())
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("run command %q fail: %w", input.Command, err)
+	}
+
+	return &ShellResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: cmd.ProcessState.ExitCode(),
+	}, nil
+}
+
+// checkArg rejects control characters, and paths escaping the working directory.
+// Any argument may be a path, e.g. a bare name can be a symlink pointing outside, so all of them are resolved.
+func (s *shellTool) checkArg(arg string) error {
+	for _, r := range arg {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("argument contains control character: %q", arg)
+		}
+	}
+
+	value := arg
+	if strings.HasPrefix(arg, "--") {
+		idx := strings.Index(arg, "=")
+		if idx < 0 {
+			return nil
+		}
+		value = arg[idx+1:]
+	} else if strings.HasPrefix(arg, "-") {
+		// the value of a short flag can be attached, e.g. -fPATH, while combined flags, e.g. -rn, are checked harmlessly as the path "n"
+		if len(arg) <= 2 {
+			return nil
+		}
+		value = arg[2:]
+	}
+	if value == "" {
+		return nil
+	}
+
+	p := value
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(s.workDir, p)
+	}
+	p = filepath.Clean(p)
+	// follow symlinks of the existing part, so that a link inside the jail cannot point outside of it
+	if resolved, err := evalExistingSymlinks(p); err == nil {
+		p = resolved
+	}
+
+	rel, err := filepath.Rel(s.workDir, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path argument is outside of the working directory: %q", arg)
+	}
+	return nil
+}
+
+// evalExistingSymlinks resolves symlinks of the longest existing prefix of p, and appends the rest.
+func evalExistingSymlinks(p string) (string, error) {
+	rest := ""
+	for {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(p), rest)
+		p = parent
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellTool(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o700))
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+
+	st, err := NewShellTool([]string{"cat", "grep", "ls", "sh", "sleep"}, WithShellWorkDir(dir), WithShellTimeout(200*time.Millisecond))
+	require.NoError(t, err)
+
+	run := func(input *ShellInput) (*ShellResult, error) {
+		args, err := json.Marshal(input)
+		require.NoError(t, err)
+		out, err := st.InvokableRun(ctx, string(args))
+		if err != nil {
+			return nil, err
+		}
+		result := &ShellResult{}
+		require.NoError(t, json.Unmarshal([]byte(out), result))
+		return result, nil
+	}
+
+	t.Run("info", func(t *testing.T) {
+		info, err := st.Info(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "shell", info.Name)
+		assert.Contains(t, info.Desc, "cat, grep, ls, sh, sleep")
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		result, err := run(&ShellInput{Command: "cat", Args: []string{"a.txt"}})
+		assert.NoError(t, err)
+		assert.Equal(t, &ShellResult{Stdout: "hello"}, result)
+
+		result, err = run(&ShellInput{Command: "cat", Args: []string{"./sub/../a.txt", filepath.Join(dir, "a.txt")}})
+		assert.NoError(t, err)
+		assert.Equal(t, "hellohello", result.Stdout)
+	})
+
+	t.Run("non-zero exit code", func(t *testing.T) {
+		result, err := run(&ShellInput{Command: "sh", Args: []string{"-c", "echo oops >&2; exit 3"}})
+		assert.NoError(t, err)
+		assert.Equal(t, &ShellResult{Stderr: "oops\n", ExitCode: 3}, result)
+	})
+
+	t.Run("blocked command", func(t *testing.T) {
+		_, err := run(&ShellInput{Command: "rm", Args: []string{"a.txt"}})
+		assert.ErrorContains(t, err, "command not allowed")
+		_, err = run(&ShellInput{Command: "/bin/cat", Args: []string{"a.txt"}})
+		assert.ErrorContains(t, err, "command not allowed")
+		assert.FileExists(t, filepath.Join(dir, "a.txt"))
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		for _, arg := range []string{
+			"../secret.txt",
+			"sub/../../secret.txt",
+			"..",
+			filepath.Join(outside, "secret.txt"),
+			"--file=../secret.txt",
+			"link/secret.txt",
+			"link",
+			"-f" + filepath.Join(outside, "secret.txt"),
+			"-f../secret.txt",
+		} {
+			_, err := run(&ShellInput{Command: "cat", Args: []string{arg}})
+			assert.ErrorContains(t, err, "outside of the working directory", arg)
+		}
+
+		// a symlink given as a bare name
+		_, err := run(&ShellInput{Command: "ls", Args: []string{"link"}})
+		assert.ErrorContains(t, err, "outside of the working directory")
+		// a path attached to a short flag
+		_, err = run(&ShellInput{Command: "grep", Args: []string{"-f" + filepath.Join(outside, "secret.txt"), "-c", "x"}})
+		assert.ErrorContains(t, err, "outside of the working directory")
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		result, err := run(&ShellInput{Command: "grep", Args: []string{"-rn", "--include=*.txt", "-e", "hello", "."}})
+		assert.NoError(t, err)
+		assert.Equal(t, "./a.txt:1:hello\n", result.Stdout)
+	})
+
+	t.Run("control character", func(t *testing.T) {
+		_, err := run(&ShellInput{Command: "cat", Args: []string{"a.txt\nb.txt"}})
+		assert.ErrorContains(t, err, "control character")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := run(&ShellInput{Command: "sleep", Args: []string{"5"}})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("invalid allowlist", func(t *testing.T) {
+		_, err := NewShellTool(nil)
+		assert.Error(t, err)
+		_, err = NewShellTool([]string{"/bin/rm"})
+		assert.Error(t, err)
+	})
+}
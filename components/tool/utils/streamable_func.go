@@ -20,9 +20,8 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/bytedance/sonic"
-
 	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/internal/generic"
 	"github.com/cloudwego/eino/schema"
 )
@@ -71,23 +70,28 @@ func newOptionableStreamTool[T, D any](desc *schema.ToolInfo, s OptionableStream
 	return &streamableTool[T, D]{
 		info: desc,
 
-		um: to.um,
-		m:  to.m,
-		Fn: s,
+		um:        to.um,
+		m:         to.m,
+		unmarshal: argumentsUnmarshaler(to),
+		Fn:        s,
 	}
 }
 
 type streamableTool[T, D any] struct {
 	info *schema.ToolInfo
 
-	um UnmarshalArguments
-	m  MarshalOutput
+	um        UnmarshalArguments
+	m         MarshalOutput
+	unmarshal func(arguments string, v any) error
 
 	Fn OptionableStreamFunc[T, D]
 }
 
 // Info returns the tool info, implement the BaseTool interface.
 func (s *streamableTool[T, D]) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	if err := s.info.Validate(); err != nil {
+		return nil, err
+	}
 	return s.info, nil
 }
 
@@ -100,21 +104,21 @@ func (s *streamableTool[T, D]) StreamableRun(ctx context.Context, argumentsInJSO
 		var val any
 		val, err = s.um(ctx, argumentsInJSON)
 		if err != nil {
-			return nil, fmt.Errorf("[LocalStreamFunc] failed to unmarshal arguments, toolName=%s, err=%w", s.getToolName(), err)
+			return nil, fmt.Errorf("[LocalStreamFunc] failed to unmarshal arguments, toolName=%s, err=%w: %w", s.getToolName(), compose.ErrInvalidToolArgs, err)
 		}
 
 		gt, ok := val.(T)
 		if !ok {
-			return nil, fmt.Errorf("[LocalStreamFunc] type err, toolName=%s, expected=%T, given=%T", s.getToolName(), inst, val)
+			return nil, fmt.Errorf("[LocalStreamFunc] %w: type err, toolName=%s, expected=%T, given=%T", compose.ErrInvalidToolArgs, s.getToolName(), inst, val)
 		}
 		inst = gt
 	} else {
 
 		inst = generic.NewInstance[T]()
 
-		err = sonic.UnmarshalString(argumentsInJSON, &inst)
+		err = s.unmarshal(argumentsInJSON, &inst)
 		if err != nil {
-			return nil, fmt.Errorf("[LocalStreamFunc] failed to unmarshal arguments in json, toolName=%s, err=%w", s.getToolName(), err)
+			return nil, fmt.Errorf("[LocalStreamFunc] failed to unmarshal arguments in json, toolName=%s, err=%w: %w", s.getToolName(), compose.ErrInvalidToolArgs, err)
 		}
 	}
 
@@ -70,4 +70,6 @@ const (
 	ComponentOfTransformer Component = "DocumentTransformer"
 	// ComponentOfTool identifies tool components.
 	ComponentOfTool Component = "Tool"
+	// ComponentOfReranker identifies reranker components.
+	ComponentOfReranker Component = "Reranker"
 )
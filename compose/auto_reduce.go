@@ -0,0 +1,113 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/cloudwego/eino/internal/generic"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+type autoReducer struct {
+	outputType reflect.Type
+	handler    handlerPair
+}
+
+// WithAutoReduce registers a reducer that bridges edges from a node outputting []T to a node expecting T,
+// so that no converter lambda needs to be added in between, e.g. from a ToolsNode to END:
+//
+//	graph := compose.NewGraph[map[string]any, *schema.Message](
+//		compose.WithAutoReduce(func(msgs []*schema.Message) (*schema.Message, error) {
+//			if len(msgs) == 0 {
+//				return nil, errors.New("no messages to take")
+//			}
+//			return msgs[0], nil
+//		}))
+//
+//	_ = graph.AddEdge("node_tools", compose.END)
+//
+// In stream mode, the chunks of []T are concatenated before being reduced, and the reduced value is emitted as a single chunk.
+// Edges whose types mismatch and have no reducer registered still fail at AddEdge.
+// Multiple reducers can be registered for different element types, the last one wins for the same type.
+func WithAutoReduce[T any](reduce func(in []T) (T, error)) NewGraphOption {
+	return func(ngo *newGraphOptions) {
+		if ngo.autoReducers == nil {
+			ngo.autoReducers = make(map[reflect.Type]*autoReducer)
+		}
+		ngo.autoReducers[generic.TypeOf[[]T]()] = &autoReducer{
+			outputType: generic.TypeOf[T](),
+			handler: handlerPair{
+				invoke:    autoReduceValue(reduce),
+				transform: autoReduceStream(reduce),
+			},
+		}
+	}
+}
+
+func autoReduceValue[T any](reduce func(in []T) (T, error)) valueHandler {
+	return func(value any) (any, error) {
+		in, ok := value.([]T)
+		if !ok && value != nil {
+			var t []T
+			return nil, fmt.Errorf("auto reduce fail, expected type: %T, actual type: %T", t, value)
+		}
+		return reduce(in)
+	}
+}
+
+func autoReduceStream[T any](reduce func(in []T) (T, error)) streamHandler {
+	return func(input streamReader) streamReader {
+		in, ok := unpackStreamReader[[]T](input)
+		if !ok {
+			in = schema.StreamReaderWithConvert(input.toAnyStreamReader(), func(v any) ([]T, error) {
+				vv, ok_ := v.([]T)
+				if !ok_ {
+					var t []T
+					return nil, fmt.Errorf("auto reduce fail, expected type: %T, actual type: %T", t, v)
+				}
+				return vv, nil
+			})
+		}
+
+		sr, sw := schema.Pipe[T](1)
+		go func() {
+			defer sw.Close()
+			defer func() {
+				if e := recover(); e != nil {
+					var t T
+					sw.Send(t, safe.NewPanicErr(fmt.Errorf("panic in auto reducer: %v", e), debug.Stack()))
+				}
+			}()
+
+			items, err := concatStreamReader(in)
+			if err != nil && !errors.Is(err, emptyStreamConcatErr) {
+				var t T
+				sw.Send(t, err)
+				return
+			}
+
+			sw.Send(reduce(items))
+		}()
+
+		return packStreamReader(sr)
+	}
+}
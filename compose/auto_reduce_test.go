@@ -0,0 +1,111 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestAutoReduce(t *testing.T) {
+	ctx := context.Background()
+
+	takeFirst := func(msgs []*schema.Message) (*schema.Message, error) {
+		if len(msgs) == 0 {
+			return nil, errors.New("no messages to take")
+		}
+		return msgs[0], nil
+	}
+
+	fanOut := InvokableLambda(func(ctx context.Context, input string) ([]*schema.Message, error) {
+		return []*schema.Message{schema.AssistantMessage(input, nil), schema.AssistantMessage("ignored", nil)}, nil
+	})
+
+	t.Run("mismatch without reducer", func(t *testing.T) {
+		g := NewGraph[string, *schema.Message]()
+		assert.NoError(t, g.AddLambdaNode("fan_out", fanOut))
+		assert.NoError(t, g.AddEdge(START, "fan_out"))
+		assert.ErrorContains(t, g.AddEdge("fan_out", END), "mismatch")
+	})
+
+	t.Run("reducer of another type", func(t *testing.T) {
+		g := NewGraph[string, *schema.Message](WithAutoReduce(func(in []string) (string, error) {
+			return in[0], nil
+		}))
+		assert.NoError(t, g.AddLambdaNode("fan_out", fanOut))
+		assert.NoError(t, g.AddEdge(START, "fan_out"))
+		assert.ErrorContains(t, g.AddEdge("fan_out", END), "mismatch")
+	})
+
+	g := NewGraph[string, *schema.Message](WithAutoReduce(takeFirst))
+	assert.NoError(t, g.AddLambdaNode("fan_out", fanOut))
+	assert.NoError(t, g.AddEdge(START, "fan_out"))
+	assert.NoError(t, g.AddEdge("fan_out", END))
+
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	t.Run("invoke", func(t *testing.T) {
+		out, err := r.Invoke(ctx, "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", out.Content)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		sr, err := r.Stream(ctx, "hello")
+		assert.NoError(t, err)
+		out, err := schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", out.Content)
+	})
+
+	t.Run("reducer error", func(t *testing.T) {
+		g := NewGraph[string, *schema.Message](WithAutoReduce(takeFirst))
+		assert.NoError(t, g.AddLambdaNode("empty", InvokableLambda(func(ctx context.Context, input string) ([]*schema.Message, error) {
+			return nil, nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "empty"))
+		assert.NoError(t, g.AddEdge("empty", END))
+
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		_, err = r.Invoke(ctx, "hello")
+		assert.ErrorContains(t, err, "no messages to take")
+	})
+
+	t.Run("through passthrough", func(t *testing.T) {
+		g := NewGraph[string, *schema.Message](WithAutoReduce(takeFirst))
+		assert.NoError(t, g.AddLambdaNode("fan_out", fanOut))
+		assert.NoError(t, g.AddPassthroughNode("pass"))
+		assert.NoError(t, g.AddEdge(START, "fan_out"))
+		assert.NoError(t, g.AddEdge("fan_out", "pass"))
+		assert.NoError(t, g.AddEdge("pass", END))
+
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		out, err := r.Invoke(ctx, "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", out.Content)
+	})
+}
@@ -18,6 +18,7 @@ package compose
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 
@@ -85,7 +86,8 @@ func newGraphBranch[T any](r *runnablePacker[T, []string, any], endNodes map[str
 }
 
 // NewGraphMultiBranch creates a branch for graphs where a condition selects
-// multiple end nodes; only keys present in endNodes are allowed.
+// multiple end nodes; only keys present in endNodes are allowed,
+// otherwise the run fails with ErrUndeclaredBranchTarget.
 func NewGraphMultiBranch[T any](condition GraphMultiBranchCondition[T], endNodes map[string]bool) *GraphBranch {
 	condRun := func(ctx context.Context, in T, opts ...any) ([]string, error) {
 		ends, err := condition(ctx, in)
@@ -94,9 +96,6 @@ func NewGraphMultiBranch[T any](condition GraphMultiBranchCondition[T], endNodes
 		}
 		ret := make([]string, 0, len(ends))
 		for end := range ends {
-			if !endNodes[end] {
-				return nil, fmt.Errorf("branch invocation returns unintended end node: %s", end)
-			}
 			ret = append(ret, end)
 		}
 
@@ -119,9 +118,6 @@ func NewStreamGraphMultiBranch[T any](condition StreamGraphMultiBranchCondition[
 
 		ret := make([]string, 0, len(ends))
 		for end := range ends {
-			if !endNodes[end] {
-				return nil, fmt.Errorf("branch invocation returns unintended end node: %s", end)
-			}
 			ret = append(ret, end)
 		}
 		return ret, nil
@@ -174,3 +170,66 @@ func NewStreamGraphBranch[T any](condition StreamGraphBranchCondition[T], endNod
 		return map[string]bool{ret: true}, nil
 	}, endNodes)
 }
+
+// ToolNameBranch creates a branch after a chat model node, routing by the name of the tool the model chose,
+// i.e. the first tool call of the message, to the node mapped in routes,
+// or to defaultNode if the message has no tool call or the tool isn't in routes.
+// All the target nodes are checked to exist when the branch is added to the graph.
+// e.g.
+//
+//	branch := compose.ToolNameBranch(map[string]string{
+//		"get_weather": "weather_handler",
+//		"find_file":   "fs_handler",
+//	}, compose.END)
+//
+//	graph.AddBranch("key_of_chat_model_node", branch)
+func ToolNameBranch(routes map[string]string, defaultNode string) *GraphBranch {
+	endNodes := make(map[string]bool, len(routes)+1)
+	for _, node := range routes {
+		endNodes[node] = true
+	}
+	endNodes[defaultNode] = true
+
+	return NewGraphBranch(func(ctx context.Context, msg *schema.Message) (string, error) {
+		if msg == nil || len(msg.ToolCalls) == 0 {
+			return defaultNode, nil
+		}
+		if node, ok := routes[msg.ToolCalls[0].Function.Name]; ok {
+			return node, nil
+		}
+		return defaultNode, nil
+	}, endNodes)
+}
+
+// EndWith returns an error to be returned by a branch condition, which ends the run of the graph immediately with value as the output,
+// without routing to END through more nodes, e.g. returning the message of a chat model without tool calls directly.
+// value must be of the output type of the graph, which is returned as a single chunk stream when the graph is streaming.
+// The nodes running concurrently are left to finish in the background as if the graph reached END.
+// Only branches can end the graph this way, if a subgraph ends with the value, the node of the subgraph outputs it.
+// e.g.
+//
+//	_ = g.AddBranch("node_model", compose.NewGraphBranch(func(ctx context.Context, msg *schema.Message) (string, error) {
+//		if len(msg.ToolCalls) == 0 {
+//			return "", compose.EndWith([]*schema.Message{msg})
+//		}
+//		return "node_tools", nil
+//	}, map[string]bool{"node_tools": true}))
+func EndWith(value any) error {
+	return &endWithSignal{value: value}
+}
+
+type endWithSignal struct {
+	value any
+}
+
+func (e *endWithSignal) Error() string {
+	return fmt.Sprintf("end with value of type %T", e.value)
+}
+
+func getEndWithSignal(err error) (*endWithSignal, bool) {
+	var s *endWithSignal
+	if errors.As(err, &s) {
+		return s, true
+	}
+	return nil, false
+}
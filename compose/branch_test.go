@@ -18,6 +18,7 @@ package compose
 
 import (
 	"context"
+	"errors"
 	"io"
 	"testing"
 
@@ -130,3 +131,153 @@ func TestStreamMultiBranch(t *testing.T) {
 		"2": "start",
 	}, result)
 }
+
+func TestToolNameBranch(t *testing.T) {
+	ctx := context.Background()
+
+	newGraph := func(t *testing.T) *Graph[*schema.Message, string] {
+		g := NewGraph[*schema.Message, string]()
+		for _, key := range []string{"weather_handler", "fs_handler", "fallback"} {
+			key := key
+			assert.NoError(t, g.AddLambdaNode(key, InvokableLambda(func(ctx context.Context, msg *schema.Message) (string, error) {
+				return key, nil
+			})))
+			assert.NoError(t, g.AddEdge(key, END))
+		}
+		return g
+	}
+	toolCall := func(name string) *schema.Message {
+		return schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1", Function: schema.FunctionCall{Name: name}}})
+	}
+
+	t.Run("route by tool name", func(t *testing.T) {
+		g := newGraph(t)
+		assert.NoError(t, g.AddBranch(START, ToolNameBranch(map[string]string{
+			"get_weather": "weather_handler",
+			"find_file":   "fs_handler",
+		}, "fallback")))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		for msg, expected := range map[*schema.Message]string{
+			toolCall("get_weather"):               "weather_handler",
+			toolCall("find_file"):                 "fs_handler",
+			toolCall("cat_file"):                  "fallback",
+			schema.AssistantMessage("hello", nil): "fallback",
+		} {
+			out, err := r.Invoke(ctx, msg)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, out)
+
+			sr, err := r.Stream(ctx, msg)
+			assert.NoError(t, err)
+			out, err = concatStreamReader(sr)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, out)
+		}
+	})
+
+	t.Run("target node not found", func(t *testing.T) {
+		g := newGraph(t)
+		err := g.AddBranch(START, ToolNameBranch(map[string]string{"get_weather": "weather"}, "fallback"))
+		assert.ErrorContains(t, err, "branch end node 'weather' needs to be added to graph first")
+
+		g = newGraph(t)
+		err = g.AddBranch(START, ToolNameBranch(map[string]string{"get_weather": "weather_handler"}, "default"))
+		assert.ErrorContains(t, err, "branch end node 'default' needs to be added to graph first")
+	})
+}
+
+func TestEndWith(t *testing.T) {
+	ctx := context.Background()
+
+	// node_model -> node_tools -> node_converter -> END, ending with the message of node_model directly if there are no tool calls
+	var converterCalls int
+	newGraph := func(endWith func(msg *schema.Message) any) Runnable[string, []*schema.Message] {
+		g := NewGraph[string, []*schema.Message]()
+		assert.NoError(t, g.AddLambdaNode("node_model", InvokableLambda(func(ctx context.Context, in string) (*schema.Message, error) {
+			if in == "weather" {
+				return schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "get_weather"}}}), nil
+			}
+			return schema.AssistantMessage("hello", nil), nil
+		})))
+		assert.NoError(t, g.AddLambdaNode("node_tools", InvokableLambda(func(ctx context.Context, in *schema.Message) ([]*schema.Message, error) {
+			return []*schema.Message{schema.ToolMessage("sunny", in.ToolCalls[0].ID)}, nil
+		})))
+		assert.NoError(t, g.AddLambdaNode("node_converter", InvokableLambda(func(ctx context.Context, in []*schema.Message) ([]*schema.Message, error) {
+			converterCalls++
+			return in, nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "node_model"))
+		assert.NoError(t, g.AddBranch("node_model", NewGraphBranch(func(ctx context.Context, msg *schema.Message) (string, error) {
+			if len(msg.ToolCalls) == 0 {
+				return "", EndWith(endWith(msg))
+			}
+			return "node_tools", nil
+		}, map[string]bool{"node_tools": true})))
+		assert.NoError(t, g.AddEdge("node_tools", "node_converter"))
+		assert.NoError(t, g.AddEdge("node_converter", END))
+
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+		return r
+	}
+
+	r := newGraph(func(msg *schema.Message) any { return []*schema.Message{msg} })
+
+	t.Run("invoke", func(t *testing.T) {
+		converterCalls = 0
+		out, err := r.Invoke(ctx, "hi")
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.Message{schema.AssistantMessage("hello", nil)}, out)
+		assert.Equal(t, 0, converterCalls)
+
+		out, err = r.Invoke(ctx, "weather")
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.Message{schema.ToolMessage("sunny", "1")}, out)
+		assert.Equal(t, 1, converterCalls)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		sr, err := r.Stream(ctx, "hi")
+		assert.NoError(t, err)
+		out, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.Message{schema.AssistantMessage("hello", nil)}, out)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		r := newGraph(func(msg *schema.Message) any { return msg })
+		_, err := r.Invoke(ctx, "hi")
+		assert.ErrorContains(t, err, "end with value fail")
+	})
+}
+
+func TestUndeclaredBranchTarget(t *testing.T) {
+	ctx := context.Background()
+	g := NewGraph[string, string]()
+	assert.NoError(t, g.AddLambdaNode("1", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+		return in, nil
+	})))
+	assert.NoError(t, g.AddLambdaNode("2", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+		return in, nil
+	})))
+	assert.NoError(t, g.AddEdge(START, "1"))
+	assert.NoError(t, g.AddBranch("1", NewGraphBranch(func(ctx context.Context, in string) (string, error) {
+		return in, nil
+	}, map[string]bool{"2": true, END: true})))
+	assert.NoError(t, g.AddEdge("2", END))
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	out, err := r.Invoke(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", out)
+
+	_, err = r.Invoke(ctx, "3")
+	assert.True(t, errors.Is(err, ErrUndeclaredBranchTarget))
+	assert.ErrorContains(t, err, "branch at node[1] returned target[3] which is not a declared successor")
+
+	_, err = r.Stream(ctx, "3")
+	assert.True(t, errors.Is(err, ErrUndeclaredBranchTarget))
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/reranker"
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/internal/generic"
 	"github.com/cloudwego/eino/internal/gmap"
@@ -124,6 +125,11 @@ func (c *Chain[I, O]) getGenericHelper() *genericHelper {
 	return newGenericHelper[I, O]()
 }
 
+// requiredInputKeys returns the input keys required by the chain, e.g. the variables of its chat templates.
+func (c *Chain[I, O]) requiredInputKeys() []string {
+	return c.gg.requiredInputKeys()
+}
+
 // inputType returns the input type of the chain.
 // implements AnyGraph.
 func (c *Chain[I, O]) inputType() reflect.Type {
@@ -262,6 +268,18 @@ func (c *Chain[I, O]) AppendRetriever(node retriever.Retriever, opts ...GraphAdd
 	return c
 }
 
+// AppendReranker adds a Reranker node to the chain, which takes *reranker.Request as input.
+// e.g.
+//
+//	reranker, err := llm.NewReranker(ctx, &llm.Config{Model: chatModel})
+//	if err != nil {...}
+//	chain.AppendReranker(reranker)
+func (c *Chain[I, O]) AppendReranker(node reranker.Reranker, opts ...GraphAddNodeOpt) *Chain[I, O] {
+	gNode, options := toRerankerNode(node, opts...)
+	c.addNode(gNode, options)
+	return c
+}
+
 // AppendLoader adds a Loader node to the chain.
 // e.g.
 //
@@ -365,7 +383,7 @@ func (c *Chain[I, O]) AppendBranch(b *ChainBranch) *Chain[I, O] {
 		nodeKeyEnds := make([]string, 0, len(ends))
 		for _, end := range ends {
 			if nodeKey, ok := key2NodeKey[end]; !ok {
-				return nil, fmt.Errorf("branch invocation returns unintended end node: %s", end)
+				return nil, fmt.Errorf("%w: branch invocation returns unintended end node: %s", ErrUndeclaredBranchTarget, end)
 			} else {
 				nodeKeyEnds = append(nodeKeyEnds, nodeKey)
 			}
@@ -384,7 +402,7 @@ func (c *Chain[I, O]) AppendBranch(b *ChainBranch) *Chain[I, O] {
 		nodeKeyEnds := make([]string, 0, len(ends))
 		for _, end := range ends {
 			if nodeKey, ok := key2NodeKey[end]; !ok {
-				return nil, fmt.Errorf("branch invocation returns unintended end node: %s", end)
+				return nil, fmt.Errorf("%w: branch invocation returns unintended end node: %s", ErrUndeclaredBranchTarget, end)
 			} else {
 				nodeKeyEnds = append(nodeKeyEnds, nodeKey)
 			}
@@ -24,6 +24,7 @@ import (
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/reranker"
 	"github.com/cloudwego/eino/components/retriever"
 )
 
@@ -36,6 +37,8 @@ func NewParallel() *Parallel {
 }
 
 // Parallel run multiple nodes in parallel
+// The outputs of the nodes are merged into a map keyed by their output keys, which doesn't depend on the order they finish.
+// To merge the outputs of multiple nodes in a specified order instead, e.g. concatenating slices, see MergeStrategy.
 //
 // use `NewParallel()` to create a new parallel type
 // Example:
@@ -134,6 +137,17 @@ func (p *Parallel) AddRetriever(outputKey string, node retriever.Retriever, opts
 	return p.addNode(outputKey, gNode, options)
 }
 
+// AddReranker adds a reranker node to the parallel.
+// eg.
+//
+//	reranker, err := llm.NewReranker(ctx, &llm.Config{Model: chatModel})
+//
+//	p.AddReranker("output_key01", reranker)
+func (p *Parallel) AddReranker(outputKey string, node reranker.Reranker, opts ...GraphAddNodeOpt) *Parallel {
+	gNode, options := toRerankerNode(node, append(opts, WithOutputKey(outputKey))...)
+	return p.addNode(outputKey, gNode, options)
+}
+
 // AddLoader adds a loader node to the parallel.
 // eg.
 //
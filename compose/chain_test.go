@@ -871,3 +871,42 @@ func TestChainWithNodeKey(t *testing.T) {
 	})
 
 }
+
+func TestChainChatTemplateStreamedVariables(t *testing.T) {
+	ctx := context.Background()
+
+	c := NewChain[map[string]any, []*schema.Message]()
+	c.AppendLambda(TransformableLambda(func(ctx context.Context, in *schema.StreamReader[map[string]any]) (*schema.StreamReader[map[string]any], error) {
+		return in, nil
+	}))
+	c.AppendChatTemplate(prompt.FromMessages(schema.FString,
+		schema.SystemMessage("you are a {role}"),
+		schema.UserMessage("{question}")))
+	r, err := c.Compile(ctx)
+	assert.NoError(t, err)
+
+	t.Run("streamed map value", func(t *testing.T) {
+		sr := schema.StreamReaderFromArray([]map[string]any{
+			{"role": "help"},
+			{"role": "ful assistant", "question": "what is "},
+			{"question": "eino?"},
+		})
+		out, err := r.Transform(ctx, sr)
+		assert.NoError(t, err)
+		msgs, err := concatStreamReader(out)
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.Message{
+			schema.SystemMessage("you are a helpful assistant"),
+			schema.UserMessage("what is eino?"),
+		}, msgs)
+	})
+
+	t.Run("unconcatable chunks", func(t *testing.T) {
+		sr := schema.StreamReaderFromArray([]map[string]any{
+			{"role": "assistant", "question": "a"},
+			{"question": 1},
+		})
+		_, err := r.Transform(ctx, sr)
+		assert.Error(t, err)
+	})
+}
@@ -17,7 +17,9 @@
 package compose
 
 import (
+	"bytes"
 	"context"
+	"encoding/gob"
 	"fmt"
 
 	"github.com/cloudwego/eino/internal/core"
@@ -50,11 +52,53 @@ func RegisterSerializableType[T any](name string) (err error) {
 
 type CheckPointStore = core.CheckPointStore
 
-type Serializer interface {
+// StateCodec encodes and decodes the checkpoint of a graph run, which holds the graph state,
+// the inputs of nodes to be rerun and the values pending in channels.
+// Custom types that may appear in any of them, e.g. the state struct or a concrete type assigned to
+// an interface field, must be registered by schema.Register or schema.RegisterName before use,
+// which registers the type for both NewJSONStateCodec and NewGobStateCodec.
+type StateCodec interface {
 	Marshal(v any) ([]byte, error)
 	Unmarshal(data []byte, v any) error
 }
 
+// Serializer is an alias of StateCodec, kept for compatibility.
+type Serializer = StateCodec
+
+// NewJSONStateCodec returns the default StateCodec, which encodes checkpoints as JSON with type info
+// of the registered types attached, so that values held by interfaces can be restored.
+func NewJSONStateCodec() StateCodec {
+	return &serialization.InternalSerializer{}
+}
+
+// NewGobStateCodec returns a StateCodec based on encoding/gob, which suits values that are not
+// JSON-friendly, e.g. map keys of struct types or values implementing gob.GobEncoder.
+// Follows the rules of encoding/gob, only exported fields are encoded, and a value held by an interface
+// is decoded as exactly the type it is registered with. So register the pointer type if the value is
+// held as a pointer, which is the usual case for graph state, e.g.
+//
+//	schema.Register[*MyState]()
+func NewGobStateCodec() StateCodec {
+	return gobStateCodec{}
+}
+
+type gobStateCodec struct{}
+
+func (gobStateCodec) Marshal(v any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, fmt.Errorf("gob encode fail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobStateCodec) Unmarshal(data []byte, v any) error {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return fmt.Errorf("gob decode fail: %w", err)
+	}
+	return nil
+}
+
 // WithCheckPointStore sets the checkpoint store implementation for a graph.
 func WithCheckPointStore(store CheckPointStore) GraphCompileOption {
 	return func(o *graphCompileOptions) {
@@ -69,6 +113,14 @@ func WithSerializer(serializer Serializer) GraphCompileOption {
 	}
 }
 
+// WithStateCodec sets the codec used to persist checkpoints, NewJSONStateCodec is used if not set.
+// e.g.
+//
+//	r, err := g.Compile(ctx, WithCheckPointStore(store), WithStateCodec(NewGobStateCodec()))
+func WithStateCodec(codec StateCodec) GraphCompileOption {
+	return WithSerializer(codec)
+}
+
 // WithCheckPointID sets the checkpoint ID to load from and write to by default.
 func WithCheckPointID(checkPointID string) Option {
 	return Option{
@@ -173,7 +225,7 @@ func newCheckPointer(
 	serializer Serializer,
 ) *checkPointer {
 	if serializer == nil {
-		serializer = &serialization.InternalSerializer{}
+		serializer = NewJSONStateCodec()
 	}
 	return &checkPointer{
 		sc:         newStreamConverter(inputPairs, outputPairs),
@@ -23,6 +23,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/internal/callbacks"
 	"github.com/cloudwego/eino/schema"
 )
@@ -614,8 +615,11 @@ func TestNestedSubGraph(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, map[string]*InterruptInfo{
 		"2": {
-			State:           &testStruct{A: "state"},
-			BeforeNodes:     []string{"4"},
+			State:       &testStruct{A: "state"},
+			BeforeNodes: []string{"4"},
+			BeforeNodesInputs: map[string]any{
+				"4": map[string]any{"2": "start11state1state2", "3": "start113"},
+			},
 			RerunNodesExtra: make(map[string]interface{}),
 			SubGraphs:       make(map[string]*InterruptInfo),
 		},
@@ -758,8 +762,11 @@ state24
 	assert.True(t, ok)
 	assert.Equal(t, map[string]*InterruptInfo{
 		"2": {
-			State:           &testStruct{A: "state"},
-			BeforeNodes:     []string{"4"},
+			State:       &testStruct{A: "state"},
+			BeforeNodes: []string{"4"},
+			BeforeNodesInputs: map[string]any{
+				"4": map[string]any{"2": "start11state1state2", "3": "start113"},
+			},
 			RerunNodesExtra: make(map[string]interface{}),
 			SubGraphs:       make(map[string]*InterruptInfo),
 		},
@@ -923,8 +930,11 @@ state24
 	assert.True(t, ok)
 	assert.Equal(t, map[string]*InterruptInfo{
 		"2": {
-			State:           &testStruct{A: "state"},
-			BeforeNodes:     []string{"4"},
+			State:       &testStruct{A: "state"},
+			BeforeNodes: []string{"4"},
+			BeforeNodesInputs: map[string]any{
+				"4": map[string]any{"2": "start11state1state2", "3": "start113"},
+			},
 			RerunNodesExtra: make(map[string]interface{}),
 			SubGraphs:       make(map[string]*InterruptInfo),
 		},
@@ -1067,8 +1077,11 @@ state24
 	assert.True(t, ok)
 	assert.Equal(t, map[string]*InterruptInfo{
 		"2": {
-			State:           &testStruct{A: "state"},
-			BeforeNodes:     []string{"4"},
+			State:       &testStruct{A: "state"},
+			BeforeNodes: []string{"4"},
+			BeforeNodesInputs: map[string]any{
+				"4": map[string]any{"2": "start11state1state2", "3": "start113"},
+			},
 			RerunNodesExtra: make(map[string]interface{}),
 			SubGraphs:       make(map[string]*InterruptInfo),
 		},
@@ -1114,3 +1127,115 @@ start1134
 state24
 3`, result)
 }
+
+type codecState struct {
+	Steps []string
+	Extra any
+}
+
+type codecExtra struct {
+	Key string
+}
+
+func init() {
+	schema.Register[*codecState]()
+	schema.Register[*codecExtra]()
+}
+
+func TestStateCodec(t *testing.T) {
+	for name, codec := range map[string]StateCodec{
+		"json": NewJSONStateCodec(),
+		"gob":  NewGobStateCodec(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newInMemoryStore()
+
+			g := NewGraph[string, string](WithGenLocalState(func(ctx context.Context) *codecState {
+				return &codecState{}
+			}))
+			for _, key := range []string{"1", "2"} {
+				key := key
+				assert.NoError(t, g.AddLambdaNode(key, InvokableLambda(func(ctx context.Context, input string) (string, error) {
+					return input + key, nil
+				}), WithStatePreHandler(func(ctx context.Context, in string, state *codecState) (string, error) {
+					state.Steps = append(state.Steps, key)
+					state.Extra = &codecExtra{Key: key}
+					return in, nil
+				})))
+			}
+			assert.NoError(t, g.AddEdge(START, "1"))
+			assert.NoError(t, g.AddEdge("1", "2"))
+			assert.NoError(t, g.AddEdge("2", END))
+
+			r, err := g.Compile(ctx, WithCheckPointStore(store), WithStateCodec(codec), WithInterruptAfterNodes([]string{"1"}))
+			assert.NoError(t, err)
+
+			_, err = r.Invoke(ctx, "start", WithCheckPointID("cp"))
+			info, ok := ExtractInterruptInfo(err)
+			assert.True(t, ok)
+			assert.Equal(t, &codecState{Steps: []string{"1"}, Extra: &codecExtra{Key: "1"}}, info.State)
+
+			// the stored checkpoint is decodable by the codec, with the mid-run state and pending input restored.
+			data, existed, err := store.Get(ctx, "cp")
+			assert.NoError(t, err)
+			assert.True(t, existed)
+			cp := &checkpoint{}
+			assert.NoError(t, codec.Unmarshal(data, cp))
+			assert.Equal(t, &codecState{Steps: []string{"1"}, Extra: &codecExtra{Key: "1"}}, cp.State)
+			assert.Equal(t, map[string]any{"2": "start1"}, cp.Inputs)
+
+			result, err := r.Invoke(ctx, "", WithCheckPointID("cp"))
+			assert.NoError(t, err)
+			assert.Equal(t, "start12", result)
+		})
+	}
+}
+
+func TestInterruptBeforeToolsNode(t *testing.T) {
+	ctx := context.Background()
+
+	type deleteReq struct {
+		Path string `json:"path"`
+	}
+	var deleted []string
+	deleteTool := newTool(&schema.ToolInfo{Name: "delete_file", Desc: "delete a file"},
+		func(ctx context.Context, in *deleteReq) (string, error) {
+			deleted = append(deleted, in.Path)
+			return "deleted", nil
+		})
+	tn, err := NewToolNode(ctx, &ToolsNodeConfig{Tools: []tool.BaseTool{deleteTool}})
+	assert.NoError(t, err)
+
+	pending := schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       "call_1",
+		Function: schema.FunctionCall{Name: "delete_file", Arguments: `{"path":"/tmp/a"}`},
+	}})
+
+	g := NewGraph[[]*schema.Message, []*schema.Message]()
+	assert.NoError(t, g.AddLambdaNode("node_model", InvokableLambda(func(ctx context.Context, in []*schema.Message) (*schema.Message, error) {
+		return pending, nil
+	})))
+	assert.NoError(t, g.AddToolsNode("node_tools", tn))
+	assert.NoError(t, g.AddEdge(START, "node_model"))
+	assert.NoError(t, g.AddEdge("node_model", "node_tools"))
+	assert.NoError(t, g.AddEdge("node_tools", END))
+
+	r, err := g.Compile(ctx, WithCheckPointStore(newInMemoryStore()), WithInterruptBefore("node_tools"))
+	assert.NoError(t, err)
+
+	_, err = r.Invoke(ctx, []*schema.Message{schema.UserMessage("clean up /tmp/a")}, WithCheckPointID("approval"))
+	info, ok := ExtractInterruptInfo(err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"node_tools"}, info.BeforeNodes)
+	assert.Equal(t, pending, info.BeforeNodesInputs["node_tools"])
+	assert.Empty(t, deleted)
+
+	// approved, resume from the checkpoint
+	out, err := r.Invoke(ctx, nil, WithCheckPointID("approval"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/tmp/a"}, deleted)
+	assert.Len(t, out, 1)
+	assert.Equal(t, "call_1", out[0].ToolCallID)
+	assert.Equal(t, `"deleted"`, out[0].Content)
+}
@@ -17,13 +17,17 @@
 package compose
 
 import (
+	"context"
+
 	"github.com/cloudwego/eino/components"
 	"github.com/cloudwego/eino/components/document"
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/reranker"
 	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
 )
 
 func toComponentNode[I, O, TOption any](
@@ -68,6 +72,24 @@ func toRetrieverNode(node retriever.Retriever, opts ...GraphAddNodeOpt) (*graphN
 		opts...)
 }
 
+// toRerankerNode creates a node taking *reranker.Request as input, whose fields are passed to Rerank.
+func toRerankerNode(node reranker.Reranker, opts ...GraphAddNodeOpt) (*graphNode, *graphAddNodeOpts) {
+	rerank := func(ctx context.Context, req *reranker.Request, opts ...reranker.Option) ([]*schema.Document, error) {
+		if req == nil {
+			return nil, nil
+		}
+		return node.Rerank(ctx, req.Query, req.Docs, req.TopN, opts...)
+	}
+	return toComponentNode(
+		node,
+		components.ComponentOfReranker,
+		rerank,
+		nil,
+		nil,
+		nil,
+		opts...)
+}
+
 func toLoaderNode(node document.Loader, opts ...GraphAddNodeOpt) (*graphNode, *graphAddNodeOpts) {
 	return toComponentNode(
 		node,
@@ -101,7 +123,43 @@ func toChatModelNode(node model.BaseChatModel, opts ...GraphAddNodeOpt) (*graphN
 		opts...)
 }
 
+// toBatchChatModelNode creates a node generating for a batch of inputs. If node doesn't implement model.BatchChatModel,
+// Generate is called concurrently for each input, with the chat model callbacks triggered per input instead of per batch,
+// so that callback handlers of chat model always get the input of Generate.
+func toBatchChatModelNode(node model.BaseChatModel, opts ...GraphAddNodeOpt) (*graphNode, *graphAddNodeOpts) {
+	meta := parseExecutorInfoFromComponent(components.ComponentOfChatModel, node)
+	info, options := getNodeInfo(opts...)
+
+	var batch Invoke[[][]*schema.Message, []*schema.Message, model.Option]
+	if bm, ok := node.(model.BatchChatModel); ok {
+		batch = bm.BatchGenerate
+	} else {
+		generator := &callbackGenerator{BaseChatModel: node, generate: node.Generate}
+		if !meta.isComponentCallbackEnabled {
+			generator.generate = invokeWithCallbacks(generator.generate)
+		}
+		batch = func(ctx context.Context, inputs [][]*schema.Message, opts ...model.Option) ([]*schema.Message, error) {
+			return model.ConcurrentGenerate(ctx, generator, inputs, 0, opts...)
+		}
+	}
+
+	run := runnableLambda(batch, nil, nil, nil, false)
+	gn := toNode(info, run, nil, meta, node, opts...)
+
+	return gn, options
+}
+
+type callbackGenerator struct {
+	model.BaseChatModel
+	generate Invoke[[]*schema.Message, *schema.Message, model.Option]
+}
+
+func (c *callbackGenerator) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return c.generate(ctx, input, opts...)
+}
+
 func toChatTemplateNode(node prompt.ChatTemplate, opts ...GraphAddNodeOpt) (*graphNode, *graphAddNodeOpts) {
+	// only Format is provided, so streamed variables are concatenated by concatStreamReader before formatting.
 	return toComponentNode(
 		node,
 		components.ComponentOfPrompt,
@@ -60,7 +60,7 @@ type dagChannel struct {
 }
 
 func (ch *dagChannel) setMergeConfig(cfg FanInMergeConfig) {
-	ch.mergeConfig.StreamMergeWithSourceEOF = cfg.StreamMergeWithSourceEOF
+	ch.mergeConfig = cfg
 }
 
 func (ch *dagChannel) load(c channel) error {
@@ -156,17 +156,14 @@ func (ch *dagChannel) get(isStream bool, name string, edgeHandler *edgeHandlerMa
 		}
 	}()
 
-	valueList := make([]any, len(ch.Values))
-	names := make([]string, len(ch.Values))
-	i := 0
-	for k, value := range ch.Values {
-		resolvedV, err := edgeHandler.handle(k, name, value, isStream)
+	names := ch.mergeConfig.MergeStrategy.order(ch.Values)
+	valueList := make([]any, len(names))
+	for i, k := range names {
+		resolvedV, err := edgeHandler.handle(k, name, ch.Values[k], isStream)
 		if err != nil {
 			return nil, false, err
 		}
 		valueList[i] = resolvedV
-		names[i] = k
-		i++
 	}
 
 	if len(valueList) == 0 {
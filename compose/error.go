@@ -26,6 +26,25 @@ import (
 // ErrExceedMaxSteps graph will throw this error when the number of steps exceeds the maximum number of steps.
 var ErrExceedMaxSteps = errors.New("exceeds max steps")
 
+// ErrToolNotFound is returned by ToolsNode when a tool call names a tool which is not registered, e.g. hallucinated by the model.
+var ErrToolNotFound = errors.New("tool not found")
+
+// ErrInvalidToolArgs indicates the arguments of a tool call are invalid, e.g. not a valid JSON or mismatched with the parameters of the tool.
+// Tools created by utils return errors wrapping it when the arguments fail to be unmarshalled.
+var ErrInvalidToolArgs = errors.New("invalid tool arguments")
+
+// ErrNodeTimeout is returned when a node runs longer than the timeout set by WithNodeTimeout or WithNodeTimeouts.
+var ErrNodeTimeout = errors.New("node timeout")
+
+// ErrDuplicateNode is returned when adding a node with a key already in use by another node of the graph.
+var ErrDuplicateNode = errors.New("duplicate node")
+
+// ErrDuplicateEdge is returned when adding an edge between the same pair of nodes more than once.
+var ErrDuplicateEdge = errors.New("duplicate edge")
+
+// ErrUndeclaredBranchTarget is returned when a branch condition returns a node not declared in the end nodes of the branch at run time.
+var ErrUndeclaredBranchTarget = errors.New("undeclared branch target")
+
 func newUnexpectedInputTypeErr(expected reflect.Type, got reflect.Type) error {
 	return fmt.Errorf("unexpected input type. expected: %v, got: %v", expected, got)
 }
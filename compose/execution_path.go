@@ -0,0 +1,229 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BranchDecision is the nodes a branch routed to in one execution.
+type BranchDecision struct {
+	// From is the path of the node the branch starts from, e.g. ["agent", "chat_model"] for a branch inside a subgraph.
+	From []string `json:"from"`
+	// Selected are the sorted keys of the nodes the branch routed to, which may include END.
+	Selected []string `json:"selected"`
+}
+
+// ExecutionPath records the path actually taken by the graphs run with the context returned by WithExecutionPath,
+// i.e. the executed nodes in the order they start, and the decisions of branches,
+// e.g. to tell whether the model called a tool, or to render the path by Graph.Mermaid.
+type ExecutionPath struct {
+	mu        sync.Mutex
+	nodes     [][]string
+	decisions []BranchDecision
+}
+
+type executionPathKey struct{}
+
+// WithExecutionPath creates a context that makes the graphs run with it, including subgraphs, record the path taken
+// into the returned ExecutionPath, which can be read once the run finishes.
+// START is recorded when a graph starts, and END is recorded when it finishes successfully.
+// e.g.
+//
+//	ctx, path := compose.WithExecutionPath(ctx)
+//	out, err := runnable.Invoke(ctx, input)
+//	fmt.Println(graph.Mermaid(path))
+func WithExecutionPath(ctx context.Context) (context.Context, *ExecutionPath) {
+	p := &ExecutionPath{}
+	return context.WithValue(ctx, executionPathKey{}, p), p
+}
+
+// Nodes returns the paths of the executed nodes in the order they start, a node executed multiple times, e.g. in a loop, appears multiple times.
+func (p *ExecutionPath) Nodes() [][]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ret := make([][]string, len(p.nodes))
+	for i, n := range p.nodes {
+		ret[i] = append([]string(nil), n...)
+	}
+	return ret
+}
+
+// BranchDecisions returns the decisions of branches in the order they are made.
+func (p *ExecutionPath) BranchDecisions() []BranchDecision {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ret := make([]BranchDecision, len(p.decisions))
+	for i, d := range p.decisions {
+		ret[i] = BranchDecision{
+			From:     append([]string(nil), d.From...),
+			Selected: append([]string(nil), d.Selected...),
+		}
+	}
+	return ret
+}
+
+func (p *ExecutionPath) recordNode(path []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nodes = append(p.nodes, path)
+}
+
+func (p *ExecutionPath) recordBranch(from []string, selected []string) {
+	s := append([]string(nil), selected...)
+	sort.Strings(s)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.decisions = append(p.decisions, BranchDecision{From: from, Selected: s})
+}
+
+func getExecutionPath(ctx context.Context) *ExecutionPath {
+	p, _ := ctx.Value(executionPathKey{}).(*ExecutionPath)
+	return p
+}
+
+// graphNodePath returns the path of the node with key in the graph running with ctx.
+func graphNodePath(ctx context.Context, key string) []string {
+	var path []string
+	if p, ok := getNodePath(ctx); ok {
+		path = append(path, p.path...)
+	}
+	return append(path, key)
+}
+
+func applyExecutionPath(key string, r *composableRunnable) *composableRunnable {
+	if r.isPassthrough {
+		return r
+	}
+
+	nodePath := func(ctx context.Context) []string {
+		if path, ok := getNodePath(ctx); ok {
+			return path.path
+		}
+		return []string{key}
+	}
+
+	i, t := r.i, r.t
+	wrapper := *r
+	wrapper.i = func(ctx context.Context, input any, opts ...any) (any, error) {
+		if p := getExecutionPath(ctx); p != nil {
+			p.recordNode(nodePath(ctx))
+		}
+		return i(ctx, input, opts...)
+	}
+	wrapper.t = func(ctx context.Context, input streamReader, opts ...any) (streamReader, error) {
+		if p := getExecutionPath(ctx); p != nil {
+			p.recordNode(nodePath(ctx))
+		}
+		return t(ctx, input, opts...)
+	}
+	return &wrapper
+}
+
+// Mermaid renders the graph as a Mermaid flowchart, edges added by AddEdge are drawn as solid lines,
+// and the possible routes of branches as dotted lines.
+// If path is not nil, the nodes executed and the edges taken by the outermost graph recorded in it are highlighted,
+// where an edge is considered taken if both ends are executed, and a branch route if the branch made the decision.
+// e.g.
+//
+//	ctx, path := compose.WithExecutionPath(ctx)
+//	_, err := runnable.Invoke(ctx, input)
+//	diagram := graph.Mermaid(path) // paste into a markdown ```mermaid block
+func (g *Graph[I, O]) Mermaid(path *ExecutionPath) string {
+	keys := []string{START}
+	for key := range g.Nodes() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys[1:])
+	keys = append(keys, END)
+
+	ids := make(map[string]string, len(keys))
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	for i, key := range keys {
+		ids[key] = "n" + strconv.Itoa(i)
+		if key == START || key == END {
+			sb.WriteString(fmt.Sprintf("  %s([%s])\n", ids[key], strconv.Quote(key)))
+		} else {
+			sb.WriteString(fmt.Sprintf("  %s[%s]\n", ids[key], strconv.Quote(key)))
+		}
+	}
+
+	executed := map[string]bool{}
+	taken := map[[2]string]bool{}
+	if path != nil {
+		for _, n := range path.Nodes() {
+			if len(n) == 1 {
+				executed[n[0]] = true
+			}
+		}
+		for _, d := range path.BranchDecisions() {
+			if len(d.From) != 1 {
+				continue
+			}
+			for _, to := range d.Selected {
+				taken[[2]string{d.From[0], to}] = true
+			}
+		}
+	}
+
+	var highlighted []string
+	idx := 0
+	for _, e := range g.Edges() {
+		arrow := "-->"
+		if !e.Control {
+			arrow = "-. data .->"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %s\n", ids[e.From], arrow, ids[e.To]))
+		if executed[e.From] && executed[e.To] {
+			highlighted = append(highlighted, strconv.Itoa(idx))
+		}
+		idx++
+	}
+	for _, b := range g.Branches() {
+		for _, to := range b.EndNodes {
+			sb.WriteString(fmt.Sprintf("  %s -.-> %s\n", ids[b.From], ids[to]))
+			if taken[[2]string{b.From, to}] {
+				highlighted = append(highlighted, strconv.Itoa(idx))
+			}
+			idx++
+		}
+	}
+
+	if len(executed) > 0 {
+		var executedIDs []string
+		for _, key := range keys {
+			if executed[key] {
+				executedIDs = append(executedIDs, ids[key])
+			}
+		}
+		sb.WriteString("  classDef executed fill:#d4edda,stroke:#28a745,stroke-width:2px\n")
+		sb.WriteString(fmt.Sprintf("  class %s executed\n", strings.Join(executedIDs, ",")))
+	}
+	if len(highlighted) > 0 {
+		sb.WriteString(fmt.Sprintf("  linkStyle %s stroke:#28a745,stroke-width:3px\n", strings.Join(highlighted, ",")))
+	}
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutionPath(t *testing.T) {
+	ctx := context.Background()
+
+	// chat_model decides whether to call tools, like a model returning tool calls or the final answer
+	g := NewGraph[string, string]()
+	assert.NoError(t, g.AddLambdaNode("chat_model", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+		return in, nil
+	})))
+	assert.NoError(t, g.AddLambdaNode("node_tools", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+		return "sunny", nil
+	})))
+	assert.NoError(t, g.AddEdge(START, "chat_model"))
+	assert.NoError(t, g.AddBranch("chat_model", NewGraphBranch(func(ctx context.Context, in string) (string, error) {
+		if strings.Contains(in, "weather") {
+			return "node_tools", nil
+		}
+		return END, nil
+	}, map[string]bool{"node_tools": true, END: true})))
+	assert.NoError(t, g.AddEdge("node_tools", END))
+
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	t.Run("tool called", func(t *testing.T) {
+		pctx, path := WithExecutionPath(ctx)
+		out, err := r.Invoke(pctx, "weather in beijing")
+		assert.NoError(t, err)
+		assert.Equal(t, "sunny", out)
+
+		assert.Equal(t, [][]string{{START}, {"chat_model"}, {"node_tools"}, {END}}, path.Nodes())
+		assert.Equal(t, []BranchDecision{{From: []string{"chat_model"}, Selected: []string{"node_tools"}}}, path.BranchDecisions())
+
+		assert.Equal(t, `flowchart TD
+  n0(["start"])
+  n1["chat_model"]
+  n2["node_tools"]
+  n3(["end"])
+  n2 --> n3
+  n0 --> n1
+  n1 -.-> n3
+  n1 -.-> n2
+  classDef executed fill:#d4edda,stroke:#28a745,stroke-width:2px
+  class n0,n1,n2,n3 executed
+  linkStyle 0,1,3 stroke:#28a745,stroke-width:3px
+`, g.Mermaid(path))
+	})
+
+	t.Run("answered directly", func(t *testing.T) {
+		pctx, path := WithExecutionPath(ctx)
+		out, err := r.Invoke(pctx, "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", out)
+
+		assert.Equal(t, [][]string{{START}, {"chat_model"}, {END}}, path.Nodes())
+		assert.Equal(t, []BranchDecision{{From: []string{"chat_model"}, Selected: []string{END}}}, path.BranchDecisions())
+		assert.Contains(t, g.Mermaid(path), "class n0,n1,n3 executed\n  linkStyle 1,2 ")
+	})
+
+	t.Run("without path", func(t *testing.T) {
+		diagram := g.Mermaid(nil)
+		assert.NotContains(t, diagram, "classDef")
+		assert.NotContains(t, diagram, "linkStyle")
+	})
+
+	t.Run("subgraph", func(t *testing.T) {
+		outer := NewGraph[string, string]()
+		assert.NoError(t, outer.AddGraphNode("agent", g))
+		assert.NoError(t, outer.AddEdge(START, "agent"))
+		assert.NoError(t, outer.AddEdge("agent", END))
+		or, err := outer.Compile(ctx)
+		assert.NoError(t, err)
+
+		pctx, path := WithExecutionPath(ctx)
+		_, err = or.Invoke(pctx, "weather in shanghai")
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{START}, {"agent"}, {"agent", START}, {"agent", "chat_model"}, {"agent", "node_tools"}, {"agent", END}, {END},
+		}, path.Nodes())
+		assert.Equal(t, []BranchDecision{{From: []string{"agent", "chat_model"}, Selected: []string{"node_tools"}}}, path.BranchDecisions())
+	})
+}
@@ -24,12 +24,13 @@ import (
 )
 
 type newGraphOptions struct {
-	withState func(ctx context.Context) any
-	stateType reflect.Type
+	withState    func(ctx context.Context) any
+	stateType    reflect.Type
+	autoReducers map[reflect.Type]*autoReducer
 }
 
 // NewGraphOption configures behavior when creating a new graph, such as
-// providing local state generation.
+// providing local state generation or auto reducers of edges.
 type NewGraphOption func(ngo *newGraphOptions)
 
 // WithGenLocalState registers a function to generate per-run local state
@@ -154,5 +155,17 @@ func compileAnyGraph[I, O any](ctx context.Context, g AnyGraph, opts ...GraphCom
 		return nil, err
 	}
 
-	return rp, nil
+	compiled := &compiledGraph[I, O]{
+		Runnable:   rp,
+		inputType:  g.inputType(),
+		outputType: g.outputType(),
+	}
+	if getter, ok := g.(requiredInputKeysGetter); ok {
+		compiled.requiredInputKeys = getter.requiredInputKeys()
+	}
+	if validator, ok := g.(graphValidator); ok {
+		compiled.validator = validator
+	}
+
+	return compiled, nil
 }
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/cloudwego/eino/components/document"
@@ -28,6 +29,7 @@ import (
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/reranker"
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/internal/generic"
 	"github.com/cloudwego/eino/internal/gmap"
@@ -70,6 +72,7 @@ type graph struct {
 	stateType      reflect.Type
 	stateGenerator func(ctx context.Context) any
 	newOpts        []NewGraphOption
+	autoReducers   map[reflect.Type]*autoReducer
 
 	expectedInputType, expectedOutputType reflect.Type
 
@@ -115,6 +118,11 @@ func newGraphFromGeneric[I, O any](
 }
 
 func newGraph(cfg *newGraphConfig) *graph {
+	options := &newGraphOptions{}
+	for _, opt := range cfg.newOpts {
+		opt(options)
+	}
+
 	return &graph{
 		nodes:        make(map[string]*graphNode),
 		dataEdges:    make(map[string][]string),
@@ -137,6 +145,7 @@ func newGraph(cfg *newGraphConfig) *graph {
 		stateType:      cfg.stateType,
 		stateGenerator: cfg.stateGenerator,
 		newOpts:        cfg.newOpts,
+		autoReducers:   options.autoReducers,
 
 		handlerOnEdges:   make(map[string]map[string][]handlerPair),
 		handlerPreNode:   make(map[string][]handlerPair),
@@ -178,8 +187,9 @@ func (g *graph) addNode(key string, node *graphNode, options *graphAddNodeOpts)
 		return fmt.Errorf("node '%s' is reserved, cannot add manually", key)
 	}
 
-	if _, ok := g.nodes[key]; ok {
-		return fmt.Errorf("node '%s' already present", key)
+	if existing, ok := g.nodes[key]; ok {
+		return fmt.Errorf("%w: node '%s' already present as a %s node, cannot add a %s node with the same key",
+			ErrDuplicateNode, key, existing.executorMeta.component, node.executorMeta.component)
 	}
 
 	// check options
@@ -263,7 +273,7 @@ func (g *graph) addEdgeWithMappings(startNode, endNode string, noControl bool, n
 	if !noControl {
 		for i := range g.controlEdges[startNode] {
 			if g.controlEdges[startNode][i] == endNode {
-				return fmt.Errorf("control edge[%s]-[%s] have been added yet", startNode, endNode)
+				return fmt.Errorf("%w: control edge[%s]-[%s] has already been added", ErrDuplicateEdge, startNode, endNode)
 			}
 		}
 
@@ -278,7 +288,7 @@ func (g *graph) addEdgeWithMappings(startNode, endNode string, noControl bool, n
 	if !noData {
 		for i := range g.dataEdges[startNode] {
 			if g.dataEdges[startNode][i] == endNode {
-				return fmt.Errorf("data edge[%s]-[%s] have been added yet", startNode, endNode)
+				return fmt.Errorf("%w: data edge[%s]-[%s] has already been added", ErrDuplicateEdge, startNode, endNode)
 			}
 		}
 
@@ -317,6 +327,17 @@ func (g *graph) AddRetrieverNode(key string, node retriever.Retriever, opts ...G
 	return g.addNode(key, gNode, options)
 }
 
+// AddRerankerNode adds a node that implements reranker.Reranker, which takes *reranker.Request as input.
+// e.g.
+//
+//	reranker, err := llm.NewReranker(ctx, &llm.Config{Model: chatModel})
+//
+//	graph.AddRerankerNode("reranker_node_key", reranker)
+func (g *graph) AddRerankerNode(key string, node reranker.Reranker, opts ...GraphAddNodeOpt) error {
+	gNode, options := toRerankerNode(node, opts...)
+	return g.addNode(key, gNode, options)
+}
+
 // AddLoaderNode adds a node that implements document.Loader.
 // e.g.
 //
@@ -352,7 +373,20 @@ func (g *graph) AddChatModelNode(key string, node model.BaseChatModel, opts ...G
 	return g.addNode(key, gNode, options)
 }
 
+// AddBatchChatModelNode add node that generates for a batch of inputs, whose input is [][]*schema.Message,
+// and output is []*schema.Message in the same order.
+// BatchGenerate is called if node implements model.BatchChatModel, otherwise Generate is called concurrently by model.ConcurrentGenerate.
+// If some of the inputs fail, the node fails with the *model.BatchError, which can be got by errors.As.
+// e.g.
+//
+//	graph.AddBatchChatModelNode("batch_chat_model_node_key", chatModel)
+func (g *graph) AddBatchChatModelNode(key string, node model.BaseChatModel, opts ...GraphAddNodeOpt) error {
+	gNode, options := toBatchChatModelNode(node, opts...)
+	return g.addNode(key, gNode, options)
+}
+
 // AddChatTemplateNode add node that implements prompt.ChatTemplate.
+// If the input of the node is streamed, the variables are concatenated before formatting, see prompt.ChatTemplate.
 // e.g.
 //
 //	chatTemplate, err := prompt.FromMessages(schema.FString, &schema.Message{
@@ -555,9 +589,13 @@ func (g *graph) updateToValidateMap() error {
 				} else if len(endNode.mappings) == 0 {
 					// common node check
 					result := checkAssignable(startNodeOutputType, endNodeInputType)
+					if result == assignableTypeMustNot && g.addAutoReducer(startNode, endNode.endNode, startNodeOutputType, endNodeInputType) {
+						continue
+					}
 					if result == assignableTypeMustNot {
-						return fmt.Errorf("graph edge[%s]-[%s]: start node's output type[%s] and end node's input type[%s] mismatch",
-							startNode, endNode.endNode, startNodeOutputType.String(), endNodeInputType.String())
+						return fmt.Errorf("graph edge[%s]-[%s]: start node's output type[%s] and end node's input type[%s] mismatch, %s",
+							startNode, endNode.endNode, startNodeOutputType.String(), endNodeInputType.String(),
+							typeMismatchHint(startNodeOutputType, endNodeInputType))
 					} else if result == assignableTypeMay {
 						// add runtime check edges
 						if _, ok := g.handlerOnEdges[startNode]; !ok {
@@ -601,6 +639,29 @@ func (g *graph) updateToValidateMap() error {
 	return nil
 }
 
+// addAutoReducer bridges the edge by the auto reducer registered for the start node's output type, if any.
+func (g *graph) addAutoReducer(startNode, endNode string, startNodeOutputType, endNodeInputType reflect.Type) bool {
+	reducer, ok := g.autoReducers[startNodeOutputType]
+	if !ok {
+		return false
+	}
+
+	result := checkAssignable(reducer.outputType, endNodeInputType)
+	if result == assignableTypeMustNot {
+		return false
+	}
+
+	if _, ok = g.handlerOnEdges[startNode]; !ok {
+		g.handlerOnEdges[startNode] = make(map[string][]handlerPair)
+	}
+	g.handlerOnEdges[startNode][endNode] = append(g.handlerOnEdges[startNode][endNode], reducer.handler)
+	if result == assignableTypeMay {
+		g.handlerOnEdges[startNode][endNode] = append(g.handlerOnEdges[startNode][endNode], g.getNodeGenericHelper(endNode).inputConverter)
+	}
+
+	return true
+}
+
 func (g *graph) getNodeGenericHelper(name string) *genericHelper {
 	if name == START {
 		return g.genericHelper.forPredecessorPassthrough()
@@ -670,6 +731,12 @@ func (g *graph) compile(ctx context.Context, opt *graphCompileOptions) (*composa
 		return nil, errors.New("end node not set")
 	}
 
+	for key, node := range g.nodes {
+		if err := checkToolDecider(key, node); err != nil {
+			return nil, err
+		}
+	}
+
 	// toValidateMap isn't empty means there are nodes that cannot infer type
 	for _, v := range g.toValidateMap {
 		if len(v) > 0 {
@@ -691,6 +758,11 @@ func (g *graph) compile(ctx context.Context, opt *graphCompileOptions) (*composa
 		g.handlerPreNode[key] = append(g.handlerPreNode[key], g.getNodeGenericHelper(key).inputFieldMappingConverter)
 	}
 
+	if unreachable, deadEnd := g.unreachableNodes(); len(unreachable)+len(deadEnd) > 0 && (opt == nil || !opt.allowUnreachable) {
+		return nil, fmt.Errorf("graph has nodes unreachable from START: %v, and nodes unable to reach END: %v, "+
+			"check whether edges are missing, or compile with WithAllowUnreachable if it's intended", unreachable, deadEnd)
+	}
+
 	key2SubGraphs := g.beforeChildGraphsCompile(opt)
 	chanSubscribeTo := make(map[string]*chanCall)
 	for name, node := range g.nodes {
@@ -700,6 +772,15 @@ func (g *graph) compile(ctx context.Context, opt *graphCompileOptions) (*composa
 		if err != nil {
 			return nil, err
 		}
+		if opt != nil {
+			r = applyNodeTimeout(name, r, opt)
+			r = applyRateLimiter(r, opt.rateLimiter)
+			r = applyModelCache(opt.graphName, name, r)
+			r = applyNodeMiddlewares(name, r, opt.nodeMiddlewares)
+			r = applyTrace(name, r, node.getGenericHelper())
+			r = applyLogger(name, r)
+			r = applyExecutionPath(name, r)
+		}
 
 		chCall := &chanCall{
 			action:   r,
@@ -856,6 +937,64 @@ func (g *graph) compile(ctx context.Context, opt *graphCompileOptions) (*composa
 	return r.toComposableRunnable(), nil
 }
 
+// unreachableNodes returns the sorted nodes which are unreachable from START, and the ones unable to reach END,
+// following data edges, control edges and the possible end nodes of branches.
+func (g *graph) unreachableNodes() (unreachable, deadEnd []string) {
+	successors := make(map[string][]string)
+	predecessors := make(map[string][]string)
+	link := func(from, to string) {
+		successors[from] = append(successors[from], to)
+		predecessors[to] = append(predecessors[to], from)
+	}
+	for from, tos := range g.dataEdges {
+		for _, to := range tos {
+			link(from, to)
+		}
+	}
+	for from, tos := range g.controlEdges {
+		for _, to := range tos {
+			link(from, to)
+		}
+	}
+	for from, branches := range g.branches {
+		for _, branch := range branches {
+			for to := range branch.endNodes {
+				link(from, to)
+			}
+		}
+	}
+
+	visit := func(from string, next map[string][]string) map[string]bool {
+		visited := map[string]bool{from: true}
+		queue := []string{from}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, n := range next[cur] {
+				if !visited[n] {
+					visited[n] = true
+					queue = append(queue, n)
+				}
+			}
+		}
+		return visited
+	}
+
+	fromStart := visit(START, successors)
+	toEnd := visit(END, predecessors)
+	for key := range g.nodes {
+		if !fromStart[key] {
+			unreachable = append(unreachable, key)
+		}
+		if !toEnd[key] {
+			deadEnd = append(deadEnd, key)
+		}
+	}
+	sort.Strings(unreachable)
+	sort.Strings(deadEnd)
+	return unreachable, deadEnd
+}
+
 func getSuccessors(c *chanCall) []string {
 	ret := make([]string, len(c.writeTo))
 	copy(ret, c.writeTo)
@@ -923,6 +1062,8 @@ func (g *graph) toGraphInfo(opt *graphCompileOptions, key2SubGraphs map[string]*
 		NewGraphOptions: g.newOpts,
 	}
 
+	gInfo.UnreachableNodes, gInfo.DeadEndNodes = g.unreachableNodes()
+
 	for key := range g.nodes {
 		gNode := g.nodes[key]
 		if gNode.executorMeta.component == ComponentOfPassthrough {
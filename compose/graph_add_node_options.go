@@ -44,6 +44,8 @@ type nodeOptions struct {
 	outputKey string
 
 	graphCompileOption []GraphCompileOption // when this node is itself an AnyGraph, this option will be used to compile the node as a nested graph
+
+	toolDecider bool
 }
 
 // WithNodeName sets the name of the node.
@@ -89,6 +91,18 @@ func WithGraphCompileOptions(opts ...GraphCompileOption) GraphAddNodeOpt {
 	}
 }
 
+// WithToolDecider declares the chat model node as the one deciding tool calls, e.g. the model node of a react loop.
+// If the chat model implements model.ToolsAdvertiser and advertises zero bound tools, Compile fails,
+// instead of running a loop that silently never calls tools.
+// e.g.
+//
+//	graph.AddChatModelNode("chat_model_node_key", chatModel, compose.WithToolDecider())
+func WithToolDecider() GraphAddNodeOpt {
+	return func(o *graphAddNodeOpts) {
+		o.nodeOptions.toolDecider = true
+	}
+}
+
 // WithStatePreHandler modify node's input of I according to state S and input or store input information into state, and it's thread-safe.
 // notice: this option requires Graph to be created with WithGenLocalState option.
 // I: input type of the Node like ChatModel, Lambda, Retriever etc.
@@ -28,6 +28,7 @@ import (
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/reranker"
 	"github.com/cloudwego/eino/components/retriever"
 )
 
@@ -89,6 +90,9 @@ type Option struct {
 	writeToCheckPointID *string
 	forceNewRun         bool
 	stateModifier       StateModifier
+	modelCache          ModelCache
+	logger              Logger
+	lambdaValues        map[string]any
 }
 
 func (o Option) deepCopy() Option {
@@ -102,15 +106,26 @@ func (o Option) deepCopy() Option {
 		nPaths[i] = &nPath
 	}
 	return Option{
-		options:     nOptions,
-		handler:     nHandler,
-		paths:       nPaths,
-		maxRunSteps: o.maxRunSteps,
+		options:      nOptions,
+		handler:      nHandler,
+		paths:        nPaths,
+		maxRunSteps:  o.maxRunSteps,
+		lambdaValues: o.lambdaValues,
 	}
 }
 
 // DesignateNode sets the key of the node to which the option will be applied.
 // notice: only effective at the top graph.
+// Options designated to a node take precedence over common options of the same type,
+// regardless of the order in which they are passed, e.g. with
+//
+//	runnable.Invoke(ctx, input,
+//		compose.WithChatModelOption(model.WithTemperature(0.9)).DesignateNode("chat_model_key"),
+//		compose.WithChatModelOption(model.WithTemperature(0.1)),
+//	)
+//
+// the node "chat_model_key" runs with temperature 0.9, while other chat model nodes,
+// including those within subgraphs, run with temperature 0.1.
 // e.g.
 //
 // embeddingOption := compose.WithEmbeddingOption(embedding.WithModel("text-embedding-3-small"))
@@ -125,6 +140,7 @@ func (o Option) DesignateNode(nodeKey ...string) Option {
 
 // DesignateNodeWithPath sets the path of the node(s) to which the option will be applied.
 // You can specify a node in the subgraph through `NodePath` to make the option only take effect at this node.
+// As with DesignateNode, designated options take precedence over common options.
 //
 // e.g.
 // nodePath := NewNodePath("sub_graph_node_key", "node_key_within_sub_graph")
@@ -152,6 +168,15 @@ func WithRetrieverOption(opts ...retriever.Option) Option {
 	return withComponentOption(opts...)
 }
 
+// WithRerankerOption is a functional option type for reranker component.
+// e.g.
+//
+//	rerankerOption := compose.WithRerankerOption(reranker.WithScoreThreshold(0.5))
+//	runnable.Invoke(ctx, "input", rerankerOption)
+func WithRerankerOption(opts ...reranker.Option) Option {
+	return withComponentOption(opts...)
+}
+
 // WithLoaderOption is a functional option type for loader component.
 // e.g.
 //
@@ -176,6 +201,7 @@ func WithIndexerOption(opts ...indexer.Option) Option {
 }
 
 // WithChatModelOption is a functional option type for chat model component.
+// Without designation, the option applies to every chat model node, including those within subgraphs.
 // e.g.
 //
 //	chatModelOption := compose.WithChatModelOption(model.WithTemperature(0.7))
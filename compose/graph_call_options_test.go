@@ -494,3 +494,56 @@ func TestCallOptionInSubGraph(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, result, "input grandparent-1 parent-1 child1-1 child2-1")
 }
+
+type temperatureRecorderModel struct {
+	temperatures []float32
+}
+
+func (t *temperatureRecorderModel) Generate(_ context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	o := model.GetCommonOptions(&model.Options{}, opts...)
+	if o.Temperature != nil {
+		t.temperatures = append(t.temperatures, *o.Temperature)
+	}
+	return schema.AssistantMessage("ok", nil), nil
+}
+
+func (t *temperatureRecorderModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := t.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func TestDesignatedOptionPrecedence(t *testing.T) {
+	ctx := context.Background()
+
+	outer, inner := &temperatureRecorderModel{}, &temperatureRecorderModel{}
+
+	sub := NewGraph[[]*schema.Message, *schema.Message]()
+	assert.NoError(t, sub.AddChatModelNode("inner_model", inner))
+	assert.NoError(t, sub.AddEdge(START, "inner_model"))
+	assert.NoError(t, sub.AddEdge("inner_model", END))
+
+	g := NewGraph[[]*schema.Message, *schema.Message]()
+	assert.NoError(t, g.AddChatModelNode("outer_model", outer))
+	assert.NoError(t, g.AddLambdaNode("to_list", ToList[*schema.Message]()))
+	assert.NoError(t, g.AddGraphNode("sub", sub))
+	assert.NoError(t, g.AddEdge(START, "outer_model"))
+	assert.NoError(t, g.AddEdge("outer_model", "to_list"))
+	assert.NoError(t, g.AddEdge("to_list", "sub"))
+	assert.NoError(t, g.AddEdge("sub", END))
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	common := WithChatModelOption(model.WithTemperature(0.1))
+	designated := WithChatModelOption(model.WithTemperature(0.9)).DesignateNodeWithPath(NewNodePath("sub", "inner_model"))
+
+	_, err = r.Invoke(ctx, []*schema.Message{schema.UserMessage("hi")}, common, designated)
+	assert.NoError(t, err)
+	_, err = r.Invoke(ctx, []*schema.Message{schema.UserMessage("hi")}, designated, common)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []float32{0.1, 0.1}, outer.temperatures)
+	assert.Equal(t, []float32{0.9, 0.9}, inner.temperatures)
+}
@@ -16,6 +16,11 @@
 
 package compose
 
+import (
+	"sort"
+	"time"
+)
+
 type graphCompileOptions struct {
 	maxRunSteps     int
 	graphName       string
@@ -32,6 +37,15 @@ type graphCompileOptions struct {
 
 	eagerDisabled bool
 
+	panicRecoveryDisabled bool
+
+	nodeMiddlewares []NodeMiddleware
+	rateLimiter     RateLimiter
+	nodeTimeout     time.Duration
+	nodeTimeouts    map[string]time.Duration
+
+	allowUnreachable bool
+
 	mergeConfigs map[string]FanInMergeConfig
 }
 
@@ -91,6 +105,33 @@ func WithEagerExecutionDisabled() GraphCompileOption {
 	}
 }
 
+// WithPanicRecovery enables the panic recovery of the graph, which is enabled by default.
+// Panics in the nodes and the branch conditions are recovered and converted into errors
+// carrying the node key and the stack trace, so that Invoke or Stream fails gracefully rather than crashing the process.
+func WithPanicRecovery() GraphCompileOption {
+	return func(o *graphCompileOptions) {
+		o.panicRecoveryDisabled = false
+	}
+}
+
+// WithPanicRecoveryDisabled disables the panic recovery of the graph,
+// so that panics in the nodes and the branch conditions propagate, e.g. to get the full goroutine dump when debugging.
+func WithPanicRecoveryDisabled() GraphCompileOption {
+	return func(o *graphCompileOptions) {
+		o.panicRecoveryDisabled = true
+	}
+}
+
+// WithAllowUnreachable allows the graph to have nodes unreachable from START, or unable to reach END,
+// which fails the compilation by default, as they are usually caused by missing edges.
+// e.g. a node only for side effects, whose output is discarded, can't reach END.
+// The nodes are reported by GraphInfo.UnreachableNodes and GraphInfo.DeadEndNodes to the graph compile callbacks instead.
+func WithAllowUnreachable() GraphCompileOption {
+	return func(o *graphCompileOptions) {
+		o.allowUnreachable = true
+	}
+}
+
 // WithNodeTriggerMode sets the trigger mode for nodes in the graph.
 // The trigger mode determines when a node is triggered during graph execution, ref: https://www.cloudwego.io/docs/eino/core_modules/chain_and_graph_orchestration/orchestration_design_principles/#runtime-engine
 // AnyPredecessor by default.
@@ -112,8 +153,46 @@ func WithGraphCompileCallbacks(cbs ...GraphCompileCallback) GraphCompileOption {
 // StreamMergeWithSourceEOF indicates whether to emit a SourceEOF error for each stream
 // when it ends, before the final merged output is produced. This is useful for
 // tracking the completion of individual input streams in a named stream merge.
+// MergeStrategy decides the order of the inputs passed to the merge function.
 type FanInMergeConfig struct {
 	StreamMergeWithSourceEOF bool //indicates whether to emit a SourceEOF error for each stream
+	MergeStrategy            MergeStrategy
+}
+
+// MergeStrategy decides the order of the inputs from multiple predecessors, e.g. the end nodes of a multi branch or the nodes of a Parallel,
+// passed to the merge function of a fan-in node, so that the merged result is deterministic regardless of the order in which the predecessors finish.
+// Inputs from the predecessors listed in Priority come first in the listed order, followed by the rest sorted by node key,
+// which is the order by default. It matters for the merge functions registered by RegisterValuesMergeFunc whose results depend on the order,
+// e.g. concatenating slices, while maps are merged by key and not affected.
+// Streams are merged chunk by chunk in the order the chunks arrive, which is not affected either.
+// e.g.
+//
+//	r, err := g.Compile(ctx, compose.WithFanInMergeConfig(map[string]compose.FanInMergeConfig{
+//		"rerank": {MergeStrategy: compose.MergeStrategy{Priority: []string{"vector_retriever", "keyword_retriever"}}},
+//	}))
+type MergeStrategy struct {
+	// Priority is the keys of the predecessor nodes, whose inputs are passed to the merge function in this order.
+	Priority []string
+}
+
+// order returns the keys of values ordered by the strategy.
+func (s MergeStrategy) order(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, k := range s.Priority {
+		if _, ok := values[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	n := len(keys)
+	for k := range values {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[n:])
+	return keys
 }
 
 // WithFanInMergeConfig sets the fan-in merge configurations
@@ -268,14 +268,18 @@ type taskManager struct {
 	cancelCh chan *time.Duration
 	canceled bool
 	deadline *time.Time
+
+	recoverPanic bool
 }
 
 func (t *taskManager) execute(currentTask *task) {
 	defer func() {
-		panicInfo := recover()
-		if panicInfo != nil {
-			currentTask.output = nil
-			currentTask.err = safe.NewPanicErr(panicInfo, debug.Stack())
+		if t.recoverPanic {
+			panicInfo := recover()
+			if panicInfo != nil {
+				currentTask.output = nil
+				currentTask.err = safe.NewPanicErr(panicInfo, debug.Stack())
+			}
 		}
 
 		t.done.Send(currentTask)
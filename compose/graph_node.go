@@ -54,6 +54,9 @@ type nodeInfo struct {
 	preProcessor, postProcessor *composableRunnable
 
 	compileOption *graphCompileOptions // if the node is an AnyGraph, it will need compile options of its own
+
+	// passed from WithToolDecider()
+	toolDecider bool
 }
 
 // graphNode the complete information of the node in graph
@@ -173,5 +176,6 @@ func getNodeInfo(opts ...GraphAddNodeOpt) (*nodeInfo, *graphAddNodeOpts) {
 		preProcessor:  opt.processor.statePreHandler,
 		postProcessor: opt.processor.statePostHandler,
 		compileOption: newGraphCompileOptions(opt.nodeOptions.graphCompileOption...),
+		toolDecider:   opt.nodeOptions.toolDecider,
 	}, opt
 }
@@ -21,10 +21,12 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime/debug"
 	"strings"
 
 	"github.com/cloudwego/eino/internal"
 	"github.com/cloudwego/eino/internal/core"
+	"github.com/cloudwego/eino/internal/safe"
 	"github.com/cloudwego/eino/internal/serialization"
 )
 
@@ -115,6 +117,9 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 		if err != nil {
 			ctx, err = onGraphError(ctx, err)
 		} else {
+			if p := getExecutionPath(ctx); p != nil {
+				p.recordNode(graphNodePath(ctx, END))
+			}
 			ctx, result = onGraphEnd(ctx, result, isStream)
 		}
 	}()
@@ -153,6 +158,13 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 	if extractErr != nil {
 		return nil, newGraphRunError(fmt.Errorf("graph extract option fail: %w", extractErr))
 	}
+	ctx = withGraphLambdaValues(ctx, opts...)
+	if cache := getModelCache(opts...); cache != nil {
+		ctx = context.WithValue(ctx, modelCacheKey{}, cache)
+	}
+	if logger := getLoggerOption(opts...); logger != nil {
+		ctx = context.WithValue(ctx, loggerKey{}, logger)
+	}
 
 	// Extract CheckPointID
 	checkPointID, writeToCheckPointID, stateModifier, forceNewRun := getCheckPointInfo(opts...)
@@ -162,6 +174,10 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 
 	// Extract subgraph
 	path, isSubGraph := getNodePath(ctx)
+	ctx = initRunID(ctx, isSubGraph)
+	if p := getExecutionPath(ctx); p != nil {
+		p.recordNode(graphNodePath(ctx, START))
+	}
 
 	// load checkpoint from ctx/store or init graph
 	initialized := false
@@ -278,6 +294,14 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 			}
 		}
 
+		if st, ok := getStopTask(completedTasks); ok {
+			result, err = r.stopWith(st.nodeKey, st.err, isStream)
+			if err != nil {
+				return nil, wrapGraphNodeError(st.nodeKey, err)
+			}
+			return result, nil
+		}
+
 		err = r.resolveInterruptCompletedTasks(tempInfo, completedTasks)
 		if err != nil {
 			return nil, err // err has been wrapped
@@ -533,6 +557,14 @@ func (r *runner) handleInterrupt(
 	if err != nil {
 		return fmt.Errorf("failed to convert checkpoint: %w", err)
 	}
+	for _, key := range intInfo.BeforeNodes {
+		if in, ok := cp.Inputs[key]; ok {
+			if intInfo.BeforeNodesInputs == nil {
+				intInfo.BeforeNodesInputs = make(map[string]any, len(intInfo.BeforeNodes))
+			}
+			intInfo.BeforeNodesInputs[key] = in
+		}
+	}
 	if isSubGraph {
 		return &subGraphInterruptError{
 			Info:       intInfo,
@@ -687,6 +719,10 @@ func (r *runner) handleInterruptWithSubGraphAndRerunNodes(
 func (r *runner) calculateNextTasks(ctx context.Context, completedTasks []*task, isStream bool, cm *channelManager, optMap map[string][]any) ([]*task, any, bool, error) {
 	writeChannelValues, controls, err := r.resolveCompletedTasks(ctx, completedTasks, isStream, cm)
 	if err != nil {
+		if s, ok := getEndWithSignal(err); ok {
+			result, err := r.endWith(s.value, isStream)
+			return nil, result, err == nil, err
+		}
 		return nil, nil, false, err
 	}
 	nodeMap, err := cm.updateAndGet(ctx, writeChannelValues, controls)
@@ -709,6 +745,25 @@ func (r *runner) calculateNextTasks(ctx context.Context, completedTasks []*task,
 	return nextTasks, nil, false, nil
 }
 
+// endWith converts the value passed to EndWith into the output of the graph.
+func (r *runner) endWith(value any, isStream bool) (any, error) {
+	if value == nil && !isStream {
+		return r.genericHelper.outputZeroValue(), nil
+	}
+	if isStream {
+		sr, err := r.genericHelper.outputStreamConvertPair.restoreStream(value)
+		if err != nil {
+			return nil, fmt.Errorf("end with value fail: %w", err)
+		}
+		return sr, nil
+	}
+	v, err := r.genericHelper.outputConverter.invoke(value)
+	if err != nil {
+		return nil, fmt.Errorf("end with value fail: %w", err)
+	}
+	return v, nil
+}
+
 func (r *runner) createTasks(ctx context.Context, nodeMap map[string]any, optMap map[string][]any) ([]*task, error) {
 	var nextTasks []*task
 	for nodeKey, nodeInput := range nodeMap {
@@ -722,7 +777,7 @@ func (r *runner) createTasks(ctx context.Context, nodeMap map[string]any, optMap
 		}
 
 		nextTasks = append(nextTasks, &task{
-			ctx:     AppendAddressSegment(ctx, AddressSegmentNode, nodeKey),
+			ctx:     withLambdaValues(withNodeInfo(AppendAddressSegment(ctx, AddressSegmentNode, nodeKey), nodeKey, call), nodeKey),
 			nodeKey: nodeKey,
 			call:    call,
 			input:   nodeInput,
@@ -782,7 +837,7 @@ func (r *runner) restoreTasks(
 		}
 
 		newTask := &task{
-			ctx:            AppendAddressSegment(ctx, AddressSegmentNode, key),
+			ctx:            withLambdaValues(withNodeInfo(AppendAddressSegment(ctx, AddressSegmentNode, key), key, call), key),
 			nodeKey:        key,
 			call:           call,
 			input:          input,
@@ -836,7 +891,15 @@ func (r *runner) resolveCompletedTasks(ctx context.Context, completedTasks []*ta
 	return writeChannelValues, newDependencies, nil
 }
 
-func (r *runner) calculateBranch(ctx context.Context, curNodeKey string, startChan *chanCall, input []any, isStream bool, cm *channelManager) ([]string, error) {
+func (r *runner) calculateBranch(ctx context.Context, curNodeKey string, startChan *chanCall, input []any, isStream bool, cm *channelManager) (_ []string, err error) {
+	if !r.options.panicRecoveryDisabled {
+		defer func() {
+			if e := recover(); e != nil {
+				err = safe.NewPanicErr(fmt.Errorf("panic in branch: %v", e), debug.Stack())
+			}
+		}()
+	}
+
 	if len(input) < len(startChan.writeToBranches) {
 		// unreachable
 		return nil, errors.New("calculate next input length is shorter than branches")
@@ -867,6 +930,17 @@ func (r *runner) calculateBranch(ctx context.Context, curNodeKey string, startCh
 			}
 		}
 
+		for _, w := range ws {
+			if !branch.endNodes[w] {
+				return nil, fmt.Errorf("%w: branch at node[%s] returned target[%s] which is not a declared successor",
+					ErrUndeclaredBranchTarget, curNodeKey, w)
+			}
+		}
+
+		if p := getExecutionPath(ctx); p != nil {
+			p.recordBranch(graphNodePath(ctx, curNodeKey), ws)
+		}
+
 		for node := range branch.endNodes {
 			skipped := true
 			for _, w := range ws {
@@ -896,7 +970,7 @@ func (r *runner) calculateBranch(ctx context.Context, curNodeKey string, startCh
 		skippedNodeList = append(skippedNodeList, skipped)
 	}
 
-	err := cm.reportBranch(curNodeKey, skippedNodeList)
+	err = cm.reportBranch(curNodeKey, skippedNodeList)
 	if err != nil {
 		return nil, err
 	}
@@ -910,6 +984,7 @@ func (r *runner) initTaskManager(runWrapper runnableCallWrapper, cancelVal *grap
 		needAll:      !r.eager,
 		done:         internal.NewUnboundedChan[*task](),
 		runningTasks: make(map[string]*task),
+		recoverPanic: !r.options.panicRecoveryDisabled,
 	}
 	if cancelVal != nil {
 		tm.cancelCh = cancelVal.ch
@@ -24,11 +24,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/schema"
@@ -354,6 +357,29 @@ func TestValidate(t *testing.T) {
 
 	err = g.AddEdge("1", "2")
 	assert.ErrorContains(t, err, "graph edge[1]-[2]: start node's output type[string] and end node's input type[int] mismatch")
+	assert.ErrorContains(t, err, "consider adding a lambda node in between to convert the type, e.g. InvokableLambda(func(ctx context.Context, in string) (int, error))")
+
+	// test unmatched slice and element, the hint points at a lambda converter
+	g = NewGraph[string, string]()
+	err = g.AddLambdaNode("1", InvokableLambda(func(ctx context.Context, input string) (output []*schema.Message, err error) { return nil, nil }))
+	assert.NoError(t, err)
+
+	err = g.AddLambdaNode("2", InvokableLambda(func(ctx context.Context, input *schema.Message) (output string, err error) { return "", nil }))
+	assert.NoError(t, err)
+
+	err = g.AddEdge("1", "2")
+	assert.ErrorContains(t, err, "graph edge[1]-[2]: start node's output type[[]*schema.Message] and end node's input type[*schema.Message] mismatch")
+	assert.ErrorContains(t, err, "lambda node in between that picks one element, e.g. InvokableLambda(func(ctx context.Context, in []*schema.Message) (*schema.Message, error)), or registering a reducer by WithAutoReduce")
+
+	g = NewGraph[string, string]()
+	err = g.AddLambdaNode("1", InvokableLambda(func(ctx context.Context, input string) (output *schema.Message, err error) { return nil, nil }))
+	assert.NoError(t, err)
+
+	err = g.AddLambdaNode("2", InvokableLambda(func(ctx context.Context, input []*schema.Message) (output string, err error) { return "", nil }))
+	assert.NoError(t, err)
+
+	err = g.AddEdge("1", "2")
+	assert.ErrorContains(t, err, "lambda node in between that wraps the output into a slice")
 
 	// test unmatched passthrough node
 	g = NewGraph[string, string]()
@@ -1324,7 +1350,8 @@ func TestStartWithEnd(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	r, err := g.Compile(context.Background())
+	// node 1 is never chosen by the branch, and has no edge to END
+	r, err := g.Compile(context.Background(), WithAllowUnreachable())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2064,3 +2091,315 @@ func (t *testGraphStateCallbackHandler) OnStartWithStreamInput(ctx context.Conte
 func (t *testGraphStateCallbackHandler) OnEndWithStreamOutput(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
 	return ctx
 }
+
+func TestGraphPanicRecovery(t *testing.T) {
+	ctx := context.Background()
+
+	newGraph := func(panicInNode bool) *Graph[string, string] {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("boom", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			if panicInNode {
+				var m map[string]int
+				m[in] = 1 // nil map access in user code
+			}
+			return in, nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "boom"))
+		assert.NoError(t, g.AddBranch("boom", NewGraphBranch(func(ctx context.Context, in string) (string, error) {
+			if !panicInNode {
+				var m map[string]int
+				m[in] = 1
+			}
+			return END, nil
+		}, map[string]bool{END: true})))
+		return g
+	}
+
+	t.Run("node", func(t *testing.T) {
+		for _, opts := range [][]GraphCompileOption{nil, {WithPanicRecovery()}} {
+			r, err := newGraph(true).Compile(ctx, opts...)
+			assert.NoError(t, err)
+
+			_, err = r.Invoke(ctx, "x")
+			assert.ErrorContains(t, err, "panic error: assignment to entry in nil map")
+			assert.ErrorContains(t, err, "node path: [boom]")
+			assert.ErrorContains(t, err, "goroutine")
+
+			_, err = r.Stream(ctx, "x")
+			assert.ErrorContains(t, err, "panic error: assignment to entry in nil map")
+		}
+	})
+
+	t.Run("branch", func(t *testing.T) {
+		r, err := newGraph(false).Compile(ctx)
+		assert.NoError(t, err)
+
+		_, err = r.Invoke(ctx, "x")
+		assert.ErrorContains(t, err, "node: boom")
+		assert.ErrorContains(t, err, "panic in branch: assignment to entry in nil map")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		for _, panicInNode := range []bool{true, false} {
+			r, err := newGraph(panicInNode).Compile(ctx, WithPanicRecoveryDisabled())
+			assert.NoError(t, err)
+
+			assert.Panics(t, func() {
+				_, _ = r.Invoke(ctx, "x")
+			})
+		}
+	})
+}
+func TestCompiledGraphConcurrentInvoke(t *testing.T) {
+	ctx := context.Background()
+
+	type reqState struct {
+		Visited []string
+	}
+
+	type lambdaOpt struct {
+		suffix string
+	}
+
+	visit := func(key string) StatePreHandler[string, *reqState] {
+		return func(ctx context.Context, in string, state *reqState) (string, error) {
+			state.Visited = append(state.Visited, key)
+			return in, nil
+		}
+	}
+
+	sub := NewGraph[string, string]()
+	assert.NoError(t, sub.AddLambdaNode("upper", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+		return strings.ToUpper(in), nil
+	})))
+	assert.NoError(t, sub.AddEdge(START, "upper"))
+	assert.NoError(t, sub.AddEdge("upper", END))
+
+	g := NewGraph[string, string](WithGenLocalState(func(ctx context.Context) *reqState {
+		return &reqState{}
+	}))
+	assert.NoError(t, g.AddLambdaNode("suffix", InvokableLambdaWithOption(func(ctx context.Context, in string, opts ...lambdaOpt) (string, error) {
+		for _, opt := range opts {
+			in += opt.suffix
+		}
+		return in, nil
+	}), WithStatePreHandler(visit("suffix"))))
+	assert.NoError(t, g.AddGraphNode("sub", sub, WithStatePreHandler(visit("sub")), WithOutputKey("sub")))
+	assert.NoError(t, g.AddLambdaNode("len", InvokableLambda(func(ctx context.Context, in string) (int, error) {
+		return len(in), nil
+	}), WithStatePreHandler(visit("len")), WithOutputKey("len")))
+	assert.NoError(t, g.AddLambdaNode("join", InvokableLambda(func(ctx context.Context, in map[string]any) (string, error) {
+		var visited []string
+		err := ProcessState(ctx, func(ctx context.Context, state *reqState) error {
+			visited = append(visited, state.Visited...)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(visited)
+		return fmt.Sprintf("%v:%v:%v", in["sub"], in["len"], visited), nil
+	})))
+	assert.NoError(t, g.AddEdge(START, "suffix"))
+	assert.NoError(t, g.AddEdge("suffix", "sub"))
+	assert.NoError(t, g.AddEdge("suffix", "len"))
+	assert.NoError(t, g.AddEdge("sub", "join"))
+	assert.NoError(t, g.AddEdge("len", "join"))
+	assert.NoError(t, g.AddEdge("join", END))
+
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	const n = 100
+	outs := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			opt := WithLambdaOption(lambdaOpt{suffix: fmt.Sprintf("-%d", i)}).DesignateNode("suffix")
+			if i%2 == 0 {
+				outs[i], errs[i] = r.Invoke(ctx, fmt.Sprintf("req%d", i), opt)
+				return
+			}
+			var sr *schema.StreamReader[string]
+			sr, errs[i] = r.Stream(ctx, fmt.Sprintf("req%d", i), opt)
+			if errs[i] == nil {
+				outs[i], errs[i] = concatStreamReader(sr)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		assert.NoError(t, errs[i])
+		in := fmt.Sprintf("req%d-%d", i, i)
+		assert.Equal(t, fmt.Sprintf("%s:%d:[len sub suffix]", strings.ToUpper(in), len(in)), outs[i])
+	}
+}
+
+type mergeOrderList []string
+
+func TestFanInMergeStrategy(t *testing.T) {
+	RegisterValuesMergeFunc(func(ls []mergeOrderList) (mergeOrderList, error) {
+		var ret mergeOrderList
+		for _, l := range ls {
+			ret = append(ret, l...)
+		}
+		return ret, nil
+	})
+
+	ctx := context.Background()
+	// the nodes finish in the reverse order of their keys
+	delays := map[string]time.Duration{"a": 30 * time.Millisecond, "b": 20 * time.Millisecond, "c": 10 * time.Millisecond}
+	newGraph := func() *Graph[string, mergeOrderList] {
+		g := NewGraph[string, mergeOrderList]()
+		endNodes := map[string]bool{}
+		for key, delay := range delays {
+			key, delay := key, delay
+			assert.NoError(t, g.AddLambdaNode(key, InvokableLambda(func(ctx context.Context, in string) (mergeOrderList, error) {
+				time.Sleep(delay)
+				return mergeOrderList{key}, nil
+			})))
+			assert.NoError(t, g.AddEdge(key, END))
+			endNodes[key] = true
+		}
+		assert.NoError(t, g.AddBranch(START, NewGraphMultiBranch(func(ctx context.Context, in string) (map[string]bool, error) {
+			return endNodes, nil
+		}, endNodes)))
+		return g
+	}
+
+	for _, mode := range []NodeTriggerMode{AnyPredecessor, AllPredecessor} {
+		t.Run(string(mode), func(t *testing.T) {
+			t.Run("sorted by node key by default", func(t *testing.T) {
+				r, err := newGraph().Compile(ctx, WithNodeTriggerMode(mode))
+				assert.NoError(t, err)
+				for i := 0; i < 3; i++ {
+					out, err := r.Invoke(ctx, "")
+					assert.NoError(t, err)
+					assert.Equal(t, mergeOrderList{"a", "b", "c"}, out)
+				}
+			})
+
+			t.Run("priority", func(t *testing.T) {
+				r, err := newGraph().Compile(ctx, WithNodeTriggerMode(mode), WithFanInMergeConfig(map[string]FanInMergeConfig{
+					END: {MergeStrategy: MergeStrategy{Priority: []string{"c", "unknown", "a"}}},
+				}))
+				assert.NoError(t, err)
+				for i := 0; i < 3; i++ {
+					out, err := r.Invoke(ctx, "")
+					assert.NoError(t, err)
+					assert.Equal(t, mergeOrderList{"c", "a", "b"}, out)
+				}
+			})
+		})
+	}
+}
+
+type echoChatModel struct {
+	mu    sync.Mutex
+	temps []float32
+}
+
+func (e *echoChatModel) Generate(_ context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if input[0].Content == "fail" {
+		return nil, model.ErrBadRequest
+	}
+	if o := model.GetCommonOptions(&model.Options{}, opts...); o.Temperature != nil {
+		e.mu.Lock()
+		e.temps = append(e.temps, *o.Temperature)
+		e.mu.Unlock()
+	}
+	return schema.AssistantMessage("echo "+input[0].Content, nil), nil
+}
+
+func (e *echoChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	out, err := e.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{out}), nil
+}
+
+func TestBatchChatModelNode(t *testing.T) {
+	ctx := context.Background()
+	cm := &echoChatModel{}
+
+	g := NewGraph[[][]*schema.Message, []*schema.Message]()
+	assert.NoError(t, g.AddBatchChatModelNode("batch", cm))
+	assert.NoError(t, g.AddEdge(START, "batch"))
+	assert.NoError(t, g.AddEdge("batch", END))
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	var cbInputs []string
+	cb := callbacks.NewHandlerBuilder().OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+		if info.Component == components.ComponentOfChatModel {
+			mu.Lock()
+			cbInputs = append(cbInputs, model.ConvCallbackInput(input).Messages[0].Content)
+			mu.Unlock()
+		}
+		return ctx
+	}).Build()
+
+	inputs := [][]*schema.Message{
+		{schema.UserMessage("a")},
+		{schema.UserMessage("b")},
+		{schema.UserMessage("c")},
+	}
+	outs, err := r.Invoke(ctx, inputs, WithCallbacks(cb), WithChatModelOption(model.WithTemperature(0.5)))
+	assert.NoError(t, err)
+	assert.Equal(t, []*schema.Message{
+		schema.AssistantMessage("echo a", nil),
+		schema.AssistantMessage("echo b", nil),
+		schema.AssistantMessage("echo c", nil),
+	}, outs)
+	// callbacks of chat model are triggered per input
+	sort.Strings(cbInputs)
+	assert.Equal(t, []string{"a", "b", "c"}, cbInputs)
+	assert.Equal(t, []float32{0.5, 0.5, 0.5}, cm.temps)
+
+	_, err = r.Invoke(ctx, [][]*schema.Message{{schema.UserMessage("a")}, {schema.UserMessage("fail")}})
+	var batchErr *model.BatchError
+	assert.ErrorAs(t, err, &batchErr)
+	assert.Nil(t, batchErr.Errors[0])
+	assert.ErrorIs(t, err, model.ErrBadRequest)
+}
+
+func TestDuplicateNodeAndEdge(t *testing.T) {
+	ctx := context.Background()
+	lambda := InvokableLambda(func(ctx context.Context, in string) (string, error) {
+		return in, nil
+	})
+
+	t.Run("duplicate node", func(t *testing.T) {
+		g := NewGraph[[]*schema.Message, *schema.Message]()
+		assert.NoError(t, g.AddChatModelNode("model", &echoChatModel{}))
+		err := g.AddLambdaNode("model", InvokableLambda(func(ctx context.Context, in []*schema.Message) ([]*schema.Message, error) {
+			return in, nil
+		}))
+		assert.ErrorIs(t, err, ErrDuplicateNode)
+		assert.EqualError(t, err, "duplicate node: node 'model' already present as a ChatModel node, cannot add a Lambda node with the same key")
+
+		// the error is kept until compiling
+		_, err = g.Compile(ctx)
+		assert.ErrorIs(t, err, ErrDuplicateNode)
+	})
+
+	t.Run("duplicate edge", func(t *testing.T) {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("a", lambda))
+		assert.NoError(t, g.AddLambdaNode("b", lambda))
+		assert.NoError(t, g.AddEdge(START, "a"))
+		assert.NoError(t, g.AddEdge("a", "b"))
+		err := g.AddEdge("a", "b")
+		assert.ErrorIs(t, err, ErrDuplicateEdge)
+		assert.EqualError(t, err, "duplicate edge: control edge[a]-[b] has already been added")
+
+		_, err = g.Compile(ctx)
+		assert.ErrorIs(t, err, ErrDuplicateEdge)
+	})
+}
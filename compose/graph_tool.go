@@ -0,0 +1,96 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/internal/generic"
+	"github.com/cloudwego/eino/schema"
+)
+
+// AsTool wraps a Runnable, e.g. a compiled graph, as a tool.InvokableTool, so that a parent agent could call the whole graph as a tool,
+// which enables hierarchical agents.
+// The arguments of the tool call are unmarshaled from JSON into I, except that they are passed as they are if I is string.
+// The output O is returned as the result of the tool, which is the content if O is *schema.Message,
+// O itself if O is string, or O marshaled to JSON otherwise.
+// e.g.
+//
+//	weatherGraph, err := g.Compile(ctx) // Runnable[*WeatherRequest, *schema.Message]
+//	weatherTool, err := compose.AsTool(weatherGraph, &schema.ToolInfo{
+//		Name: "get_weather",
+//		Desc: "get the weather of a city",
+//		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+//			"city": {Type: schema.String, Required: true},
+//		}),
+//	})
+func AsTool[I, O any](r Runnable[I, O], info *schema.ToolInfo) (tool.InvokableTool, error) {
+	if r == nil {
+		return nil, errors.New("runnable is nil")
+	}
+	if err := info.Validate(); err != nil {
+		return nil, err
+	}
+	return &runnableTool[I, O]{r: r, info: info}, nil
+}
+
+type runnableTool[I, O any] struct {
+	r    Runnable[I, O]
+	info *schema.ToolInfo
+}
+
+func (t *runnableTool[I, O]) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+// InvokableRun invokes the runnable with the arguments of the tool call.
+func (t *runnableTool[I, O]) InvokableRun(ctx context.Context, arguments string, _ ...tool.Option) (string, error) {
+	in := generic.NewInstance[I]()
+	if s, ok := any(&in).(*string); ok {
+		*s = arguments
+	} else if err := sonic.UnmarshalString(arguments, &in); err != nil {
+		return "", fmt.Errorf("[AsTool] failed to unmarshal arguments into %v, toolName=%s, err=%w: %w",
+			generic.TypeOf[I](), t.info.Name, ErrInvalidToolArgs, err)
+	}
+
+	out, err := t.r.Invoke(ctx, in)
+	if err != nil {
+		return "", fmt.Errorf("[AsTool] failed to invoke tool, toolName=%s, err=%w", t.info.Name, err)
+	}
+
+	SetTypedToolResult(ctx, out)
+
+	switch o := any(out).(type) {
+	case string:
+		return o, nil
+	case *schema.Message:
+		if o == nil {
+			return "", nil
+		}
+		return o.Content, nil
+	}
+	result, err := sonic.MarshalString(out)
+	if err != nil {
+		return "", fmt.Errorf("[AsTool] failed to marshal output of %v, toolName=%s, err=%w", generic.TypeOf[O](), t.info.Name, err)
+	}
+	return result, nil
+}
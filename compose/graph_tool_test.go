@@ -0,0 +1,121 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestAsTool(t *testing.T) {
+	ctx := context.Background()
+
+	type weatherReq struct {
+		City string `json:"city"`
+		Days int    `json:"days"`
+	}
+	type weatherResp struct {
+		City    string `json:"city"`
+		Weather string `json:"weather"`
+	}
+	info := &schema.ToolInfo{
+		Name: "get_weather",
+		Desc: "get the weather of a city",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"city": {Type: schema.String, Required: true},
+			"days": {Type: schema.Integer},
+		}),
+	}
+
+	t.Run("message output", func(t *testing.T) {
+		g := NewGraph[*weatherReq, *schema.Message]()
+		assert.NoError(t, g.AddLambdaNode("weather", InvokableLambda(func(ctx context.Context, in *weatherReq) (*schema.Message, error) {
+			if in.City == "" {
+				return nil, errors.New("city is required")
+			}
+			return schema.AssistantMessage(in.City+" is sunny", nil), nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "weather"))
+		assert.NoError(t, g.AddEdge("weather", END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		weatherTool, err := AsTool[*weatherReq, *schema.Message](r, info)
+		assert.NoError(t, err)
+		gotInfo, err := weatherTool.Info(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, info, gotInfo)
+
+		out, err := weatherTool.InvokableRun(ctx, `{"city":"Beijing","days":1}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "Beijing is sunny", out)
+
+		_, err = weatherTool.InvokableRun(ctx, `{"city":"Beijing","days":"one"}`)
+		assert.ErrorIs(t, err, ErrInvalidToolArgs)
+		assert.ErrorContains(t, err, "failed to unmarshal arguments into *compose.weatherReq, toolName=get_weather")
+
+		_, err = weatherTool.InvokableRun(ctx, `{}`)
+		assert.ErrorContains(t, err, "city is required")
+	})
+
+	t.Run("struct output", func(t *testing.T) {
+		r, err := NewChain[weatherReq, weatherResp]().
+			AppendLambda(InvokableLambda(func(ctx context.Context, in weatherReq) (weatherResp, error) {
+				return weatherResp{City: in.City, Weather: "sunny"}, nil
+			})).
+			Compile(ctx)
+		assert.NoError(t, err)
+
+		weatherTool, err := AsTool(r, info)
+		assert.NoError(t, err)
+		out, err := weatherTool.InvokableRun(ctx, `{"city":"Beijing"}`)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"city":"Beijing","weather":"sunny"}`, out)
+	})
+
+	t.Run("string input", func(t *testing.T) {
+		r, err := NewChain[string, string]().
+			AppendLambda(InvokableLambda(func(ctx context.Context, in string) (string, error) {
+				return "got " + in, nil
+			})).
+			Compile(ctx)
+		assert.NoError(t, err)
+
+		echoTool, err := AsTool(r, &schema.ToolInfo{Name: "echo", Desc: "echo the arguments"})
+		assert.NoError(t, err)
+		out, err := echoTool.InvokableRun(ctx, `{"a":1}`)
+		assert.NoError(t, err)
+		assert.Equal(t, `got {"a":1}`, out)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := AsTool[string, string](nil, &schema.ToolInfo{Name: "echo"})
+		assert.Error(t, err)
+
+		r, err := NewChain[string, string]().AppendLambda(InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			return in, nil
+		})).Compile(ctx)
+		assert.NoError(t, err)
+		_, err = AsTool(r, nil)
+		assert.Error(t, err)
+	})
+}
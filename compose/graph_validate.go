@@ -0,0 +1,259 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ValidationIssue is a problem found by Validate.
+type ValidationIssue struct {
+	// Node is the path of the node the issue belongs to, which is nil if the issue belongs to the graph itself.
+	Node *NodePath
+	// ToolName is the name of the tool the issue belongs to, if any.
+	ToolName string
+	// Message describes the issue.
+	Message string
+}
+
+func (i *ValidationIssue) String() string {
+	sb := &strings.Builder{}
+	if i.Node != nil {
+		sb.WriteString(fmt.Sprintf("node[%s] ", strings.Join(i.Node.GetPath(), "/")))
+	}
+	if i.ToolName != "" {
+		sb.WriteString(fmt.Sprintf("tool[%s] ", i.ToolName))
+	}
+	sb.WriteString(i.Message)
+	return sb.String()
+}
+
+// ValidationError is the error returned by Validate, which reports all the issues found in the graph.
+type ValidationError struct {
+	Issues []*ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	sb := &strings.Builder{}
+	sb.WriteString(fmt.Sprintf("graph validation failed with %d issue(s):", len(e.Issues)))
+	for _, issue := range e.Issues {
+		sb.WriteString("\n  ")
+		sb.WriteString(issue.String())
+	}
+	return sb.String()
+}
+
+// graphValidator is implemented by graphs which could be validated as sub graphs.
+type graphValidator interface {
+	collectIssues(ctx context.Context, path []string, v *validation)
+}
+
+type validation struct {
+	issues []*ValidationIssue
+	// toolNodes records the node path which each tool name is first seen in
+	toolNodes map[string]string
+}
+
+func (v *validation) addIssue(path []string, toolName, format string, a ...any) {
+	var node *NodePath
+	if len(path) > 0 {
+		node = NewNodePath(path...)
+	}
+	v.issues = append(v.issues, &ValidationIssue{
+		Node:     node,
+		ToolName: toolName,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// Validate checks the graph without running it, i.e. a dry run before hitting the model API, including:
+// every tool of ToolsNodes has a valid ToolInfo and produces a valid JSON schema of parameters,
+// tool names are unique across all ToolsNodes, branches reference existing nodes,
+// and chat models deciding tool calls (declared by WithToolDecider or followed by a ToolsNode) have tools bound,
+// if they implement model.ToolsAdvertiser.
+// Sub graphs are validated as well.
+// All the problems found are reported together by a *ValidationError, rather than failing on the first one.
+// eg:
+//
+//	if err := graph.Validate(ctx); err != nil {
+//		var vErr *compose.ValidationError
+//		if errors.As(err, &vErr) {
+//			for _, issue := range vErr.Issues {
+//				log.Printf("%s", issue)
+//			}
+//		}
+//	}
+func (g *graph) Validate(ctx context.Context) error {
+	return validateGraph(ctx, g)
+}
+
+func validateGraph(ctx context.Context, g graphValidator) error {
+	v := &validation{toolNodes: make(map[string]string)}
+	g.collectIssues(ctx, nil, v)
+	if len(v.issues) == 0 {
+		return nil
+	}
+	return &ValidationError{Issues: v.issues}
+}
+
+func (g *graph) collectIssues(ctx context.Context, path []string, v *validation) {
+	if g.buildError != nil {
+		v.addIssue(path, "", "failed to build graph: %v", g.buildError)
+	}
+
+	keys := make([]string, 0, len(g.nodes))
+	for key := range g.nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		gn := g.nodes[key]
+		nodePath := append(append([]string{}, path...), key)
+
+		if tn, ok := gn.instance.(*ToolsNode); ok {
+			validateTools(ctx, nodePath, tn.tuple.tools, v)
+		}
+		if err := checkToolDecider(key, gn); err != nil {
+			v.addIssue(nodePath, "", "%v", err)
+		} else if tn := g.toolsNodeAfter(key); tn != "" {
+			if tools, ok := boundToolsOf(gn); ok && len(tools) == 0 {
+				v.addIssue(nodePath, "", "node[%s] leads to ToolsNode[%s], but its chat model has no tools bound", key, tn)
+			}
+		}
+		if sg, ok := gn.g.(graphValidator); ok {
+			sg.collectIssues(ctx, nodePath, v)
+		}
+	}
+
+	starts := make([]string, 0, len(g.branches))
+	for start := range g.branches {
+		starts = append(starts, start)
+	}
+	sort.Strings(starts)
+
+	for _, start := range starts {
+		for _, branch := range g.branches[start] {
+			ends := make([]string, 0, len(branch.endNodes))
+			for end := range branch.endNodes {
+				ends = append(ends, end)
+			}
+			sort.Strings(ends)
+
+			for _, end := range ends {
+				if _, ok := g.nodes[end]; !ok && end != END {
+					v.addIssue(path, "", "branch from node[%s] references non-existent node[%s]", start, end)
+				}
+			}
+		}
+	}
+}
+
+// toolsNodeAfter returns the first ToolsNode following the node by edges or branches, or "" if there is none.
+func (g *graph) toolsNodeAfter(key string) string {
+	var nexts []string
+	nexts = append(nexts, g.controlEdges[key]...)
+	for _, branch := range g.branches[key] {
+		for end := range branch.endNodes {
+			nexts = append(nexts, end)
+		}
+	}
+	sort.Strings(nexts)
+
+	for _, next := range nexts {
+		if gn, ok := g.nodes[next]; ok {
+			if _, ok = gn.instance.(*ToolsNode); ok {
+				return next
+			}
+		}
+	}
+	return ""
+}
+
+// boundToolsOf returns the tools bound to the chat model of the node, and false if the model doesn't advertise them.
+func boundToolsOf(gn *graphNode) ([]*schema.ToolInfo, bool) {
+	ta, ok := gn.instance.(model.ToolsAdvertiser)
+	if !ok {
+		return nil, false
+	}
+	return ta.BoundTools(), true
+}
+
+// checkToolDecider fails if the node is declared by WithToolDecider, but its chat model advertises zero bound tools.
+func checkToolDecider(key string, gn *graphNode) error {
+	if gn.nodeInfo == nil || !gn.nodeInfo.toolDecider {
+		return nil
+	}
+	if tools, ok := boundToolsOf(gn); ok && len(tools) == 0 {
+		return fmt.Errorf("node[%s] is declared as the tool decider, but its chat model has no tools bound", key)
+	}
+	return nil
+}
+
+func validateTools(ctx context.Context, path []string, tools []tool.BaseTool, v *validation) {
+	nodePath := strings.Join(path, "/")
+	for i, t := range tools {
+		info, err := t.Info(ctx)
+		if err != nil {
+			v.addIssue(path, "", "failed to get info of tool[%d]: %v", i, err)
+			continue
+		}
+		if info == nil {
+			v.addIssue(path, "", "info of tool[%d] is nil", i)
+			continue
+		}
+
+		if err = info.Validate(); err != nil {
+			v.addIssue(path, info.Name, "%v", err)
+		}
+
+		if info.ParamsOneOf != nil {
+			sc, err := info.ParamsOneOf.ToJSONSchema()
+			if err != nil {
+				v.addIssue(path, info.Name, "failed to convert parameters to JSON schema: %v", err)
+			} else if sc == nil {
+				v.addIssue(path, info.Name, "parameters are set but the JSON schema is empty")
+			} else if sc.Type != string(schema.Object) {
+				v.addIssue(path, info.Name, "type of parameters' JSON schema must be %s, got %q", schema.Object, sc.Type)
+			}
+		}
+
+		if first, ok := v.toolNodes[info.Name]; ok {
+			v.addIssue(path, info.Name, "tool name is duplicated, which is already used in node[%s]", first)
+			continue
+		}
+		v.toolNodes[info.Name] = nodePath
+	}
+}
+
+func (c *Chain[I, O]) collectIssues(ctx context.Context, path []string, v *validation) {
+	if c.err != nil {
+		v.addIssue(path, "", "failed to build chain: %v", c.err)
+	}
+	c.gg.collectIssues(ctx, path, v)
+}
+
+func (wf *Workflow[I, O]) collectIssues(ctx context.Context, path []string, v *validation) {
+	wf.g.collectIssues(ctx, path, v)
+}
@@ -0,0 +1,288 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/eino-contrib/jsonschema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestGraphValidate(t *testing.T) {
+	ctx := context.Background()
+
+	newInfoTool := func(info *schema.ToolInfo) tool.BaseTool {
+		return newTool(info, func(ctx context.Context, in *cmdRequest) (string, error) {
+			return "", nil
+		})
+	}
+	params := schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"cmd": {Type: schema.String, Desc: "the command to run"},
+	})
+	newToolsNode := func(tools ...tool.BaseTool) *ToolsNode {
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{Tools: tools})
+		assert.NoError(t, err)
+		return tn
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		g := NewGraph[*schema.Message, []*schema.Message]()
+		assert.NoError(t, g.AddToolsNode("tools", newToolsNode(
+			newInfoTool(&schema.ToolInfo{Name: "run_cmd", Desc: "run a command", ParamsOneOf: params}),
+			newInfoTool(&schema.ToolInfo{Name: "now", Desc: "get the current time"}),
+		)))
+		assert.NoError(t, g.AddEdge(START, "tools"))
+		assert.NoError(t, g.AddEdge("tools", END))
+
+		assert.NoError(t, g.Validate(ctx))
+	})
+
+	t.Run("aggregate_issues", func(t *testing.T) {
+		sub := NewGraph[*schema.Message, []*schema.Message]()
+		assert.NoError(t, sub.AddToolsNode("sub_tools", newToolsNode(
+			newInfoTool(&schema.ToolInfo{Name: "run_cmd", Desc: "run a command in sub graph", ParamsOneOf: params}),
+		)))
+		assert.NoError(t, sub.AddEdge(START, "sub_tools"))
+		assert.NoError(t, sub.AddEdge("sub_tools", END))
+
+		g := NewGraph[*schema.Message, []*schema.Message]()
+		assert.NoError(t, g.AddToolsNode("tools_a", newToolsNode(
+			newInfoTool(&schema.ToolInfo{Name: "run_cmd", Desc: "run a command", ParamsOneOf: params}),
+			newInfoTool(&schema.ToolInfo{Name: "empty_params", Desc: "tool with empty parameters", ParamsOneOf: &schema.ParamsOneOf{}}),
+			newInfoTool(&schema.ToolInfo{Name: "array_params", Desc: "tool with array parameters",
+				ParamsOneOf: schema.NewParamsOneOfByJSONSchema(&jsonschema.Schema{Type: string(schema.Array)})}),
+		)))
+		assert.NoError(t, g.AddToolsNode("tools_b", newToolsNode(
+			newInfoTool(&schema.ToolInfo{Name: "run cmd", Desc: "tool with invalid name"}),
+			newInfoTool(&schema.ToolInfo{Name: "run_cmd", Desc: "duplicated tool"}),
+		)))
+		assert.NoError(t, g.AddGraphNode("sub", sub))
+		assert.NoError(t, g.AddEdge(START, "tools_a"))
+		assert.NoError(t, g.AddEdge(START, "tools_b"))
+		assert.NoError(t, g.AddEdge(START, "sub"))
+		assert.NoError(t, g.AddEdge("tools_a", END))
+		assert.NoError(t, g.AddEdge("tools_b", END))
+		assert.NoError(t, g.AddEdge("sub", END))
+
+		err := g.Validate(ctx)
+		var vErr *ValidationError
+		assert.True(t, errors.As(err, &vErr))
+
+		var issues []string
+		for _, issue := range vErr.Issues {
+			issues = append(issues, issue.String())
+		}
+		assert.Equal(t, []string{
+			`node[tools_a] tool[run_cmd] tool name is duplicated, which is already used in node[sub/sub_tools]`,
+			`node[tools_a] tool[empty_params] parameters are set but the JSON schema is empty`,
+			`node[tools_a] tool[array_params] type of parameters' JSON schema must be object, got "array"`,
+			`node[tools_b] tool[run cmd] invalid tool name "run cmd": must be 1 to 64 characters of letters, digits, underscores or hyphens`,
+			`node[tools_b] tool[run_cmd] tool name is duplicated, which is already used in node[sub/sub_tools]`,
+		}, issues)
+		assert.Equal(t, []string{"tools_b"}, vErr.Issues[3].Node.GetPath())
+		assert.Equal(t, "run cmd", vErr.Issues[3].ToolName)
+		assert.Contains(t, err.Error(), "graph validation failed with 5 issue(s):")
+
+		// the compiled graph validates the graph it's compiled from
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+		v, ok := r.(Validator)
+		assert.True(t, ok)
+		assert.Equal(t, g.Validate(ctx), v.Validate(ctx))
+
+		chain := NewChain[*schema.Message, []*schema.Message]()
+		chain.AppendGraph(g)
+		cr, err := chain.Compile(ctx)
+		assert.NoError(t, err)
+		err = cr.(Validator).Validate(ctx)
+		assert.True(t, errors.As(err, &vErr))
+		assert.Len(t, vErr.Issues, 5)
+		assert.Equal(t, []string{"node_0", "tools_a"}, vErr.Issues[0].Node.GetPath())
+	})
+
+	t.Run("branch_references_non_existent_node", func(t *testing.T) {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("a", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			return in, nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "a"))
+		assert.NoError(t, g.addBranch("a", NewGraphBranch(func(ctx context.Context, in string) (string, error) {
+			return "b", nil
+		}, map[string]bool{"b": true, END: true}), true))
+
+		err := g.Validate(ctx)
+		var vErr *ValidationError
+		assert.True(t, errors.As(err, &vErr))
+		assert.Len(t, vErr.Issues, 1)
+		assert.Nil(t, vErr.Issues[0].Node)
+		assert.Equal(t, "branch from node[a] references non-existent node[b]", vErr.Issues[0].Message)
+	})
+}
+
+type graphInfoRecorder struct {
+	info *GraphInfo
+}
+
+func (r *graphInfoRecorder) OnFinish(_ context.Context, info *GraphInfo) {
+	r.info = info
+}
+
+func TestUnreachableNodes(t *testing.T) {
+	ctx := context.Background()
+
+	identity := InvokableLambda(func(ctx context.Context, in string) (string, error) {
+		return in, nil
+	})
+
+	t.Run("unreachable from start", func(t *testing.T) {
+		// the edge into node_converter is forgotten
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("node_model", identity))
+		assert.NoError(t, g.AddLambdaNode("node_converter", identity))
+		assert.NoError(t, g.AddEdge(START, "node_model"))
+		assert.NoError(t, g.AddEdge("node_model", END))
+		assert.NoError(t, g.AddEdge("node_converter", END))
+
+		_, err := g.Compile(ctx)
+		assert.ErrorContains(t, err, "nodes unreachable from START: [node_converter], and nodes unable to reach END: []")
+	})
+
+	t.Run("cannot reach end", func(t *testing.T) {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("node_model", identity))
+		assert.NoError(t, g.AddLambdaNode("node_converter", identity))
+		assert.NoError(t, g.AddLambdaNode("node_logger", identity))
+		assert.NoError(t, g.AddEdge(START, "node_model"))
+		assert.NoError(t, g.AddEdge("node_model", "node_logger"))
+		assert.NoError(t, g.AddEdge("node_converter", "node_logger"))
+		assert.NoError(t, g.AddBranch("node_model", NewGraphBranch(func(ctx context.Context, in string) (string, error) {
+			return END, nil
+		}, map[string]bool{END: true, "node_converter": true})))
+
+		_, err := g.Compile(ctx)
+		assert.ErrorContains(t, err, "nodes unreachable from START: [], and nodes unable to reach END: [node_converter node_logger]")
+
+		recorder := &graphInfoRecorder{}
+		r, err := g.Compile(ctx, WithAllowUnreachable(), WithGraphCompileCallbacks(recorder))
+		assert.NoError(t, err)
+		assert.Empty(t, recorder.info.UnreachableNodes)
+		assert.Equal(t, []string{"node_converter", "node_logger"}, recorder.info.DeadEndNodes)
+
+		out, err := r.Invoke(ctx, "in")
+		assert.NoError(t, err)
+		assert.Equal(t, "in", out)
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("node_model", identity))
+		assert.NoError(t, g.AddLambdaNode("node_tools", identity))
+		assert.NoError(t, g.AddEdge(START, "node_model"))
+		assert.NoError(t, g.AddBranch("node_model", NewGraphBranch(func(ctx context.Context, in string) (string, error) {
+			return END, nil
+		}, map[string]bool{END: true, "node_tools": true})))
+		assert.NoError(t, g.AddEdge("node_tools", "node_model"))
+
+		_, err := g.Compile(ctx)
+		assert.NoError(t, err)
+	})
+}
+
+type toolsChatModel struct {
+	echoChatModel
+	tools []*schema.ToolInfo
+}
+
+func (m *toolsChatModel) BoundTools() []*schema.ToolInfo {
+	return m.tools
+}
+
+func TestToolDecider(t *testing.T) {
+	ctx := context.Background()
+
+	newToolsNode := func() *ToolsNode {
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{Tools: []tool.BaseTool{
+			newTool(&schema.ToolInfo{Name: "now", Desc: "get the current time"}, func(ctx context.Context, in *cmdRequest) (string, error) {
+				return "", nil
+			}),
+		}})
+		assert.NoError(t, err)
+		return tn
+	}
+	newGraph := func(cm *toolsChatModel, opts ...GraphAddNodeOpt) *Graph[[]*schema.Message, []*schema.Message] {
+		g := NewGraph[[]*schema.Message, []*schema.Message]()
+		assert.NoError(t, g.AddChatModelNode("model", cm, opts...))
+		assert.NoError(t, g.AddToolsNode("tools", newToolsNode()))
+		assert.NoError(t, g.AddEdge(START, "model"))
+		assert.NoError(t, g.AddEdge("model", "tools"))
+		assert.NoError(t, g.AddEdge("tools", END))
+		return g
+	}
+
+	t.Run("no_tools_bound", func(t *testing.T) {
+		g := newGraph(&toolsChatModel{}, WithToolDecider())
+
+		err := g.Validate(ctx)
+		var vErr *ValidationError
+		assert.True(t, errors.As(err, &vErr))
+		assert.Len(t, vErr.Issues, 1)
+		assert.Equal(t, []string{"model"}, vErr.Issues[0].Node.GetPath())
+		assert.Equal(t, "node[model] is declared as the tool decider, but its chat model has no tools bound", vErr.Issues[0].Message)
+
+		_, err = g.Compile(ctx)
+		assert.ErrorContains(t, err, "node[model] is declared as the tool decider, but its chat model has no tools bound")
+	})
+
+	t.Run("tools_bound", func(t *testing.T) {
+		g := newGraph(&toolsChatModel{tools: []*schema.ToolInfo{{Name: "now", Desc: "get the current time"}}}, WithToolDecider())
+
+		assert.NoError(t, g.Validate(ctx))
+		_, err := g.Compile(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("leads_to_tools_node", func(t *testing.T) {
+		g := newGraph(&toolsChatModel{})
+
+		err := g.Validate(ctx)
+		var vErr *ValidationError
+		assert.True(t, errors.As(err, &vErr))
+		assert.Len(t, vErr.Issues, 1)
+		assert.Equal(t, "node[model] leads to ToolsNode[tools], but its chat model has no tools bound", vErr.Issues[0].Message)
+
+		// without WithToolDecider, only Validate reports the issue
+		_, err = g.Compile(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("not_advertised", func(t *testing.T) {
+		g := NewGraph[[]*schema.Message, *schema.Message]()
+		assert.NoError(t, g.AddChatModelNode("model", &echoChatModel{}, WithToolDecider()))
+		assert.NoError(t, g.AddEdge(START, "model"))
+		assert.NoError(t, g.AddEdge("model", END))
+
+		assert.NoError(t, g.Validate(ctx))
+		_, err := g.Compile(ctx)
+		assert.NoError(t, err)
+	})
+}
@@ -34,6 +34,25 @@ func WithInterruptBeforeNodes(nodes []string) GraphCompileOption {
 	}
 }
 
+// WithInterruptBefore marks the given nodes as interrupt points: the graph pauses right before
+// any of them executes, persists a checkpoint and returns an interrupt error whose InterruptInfo
+// carries the pending inputs of those nodes in BeforeNodesInputs.
+// It must be combined with WithCheckPointStore, and is typically used for approval flows, e.g.
+//
+//	r, _ := g.Compile(ctx, compose.WithCheckPointStore(store), compose.WithInterruptBefore("node_tools"))
+//	_, err := r.Invoke(ctx, input, compose.WithCheckPointID(id))
+//	info, _ := compose.ExtractInterruptInfo(err)
+//	// inspect info.BeforeNodesInputs["node_tools"] and ask for approval, then resume:
+//	out, err := r.Invoke(ctx, input, compose.WithCheckPointID(id))
+//
+// Resuming with the same checkpoint id runs the interrupted nodes without interrupting again.
+// Use WithStateModifier on resume to change the graph state before the nodes run.
+func WithInterruptBefore(nodes ...string) GraphCompileOption {
+	return func(options *graphCompileOptions) {
+		options.interruptBeforeNodes = append(options.interruptBeforeNodes, nodes...)
+	}
+}
+
 // WithInterruptAfterNodes instructs to interrupt after the given nodes.
 func WithInterruptAfterNodes(nodes []string) GraphCompileOption {
 	return func(options *graphCompileOptions) {
@@ -256,8 +275,11 @@ func isInterruptRerunError(err error) (info any, state any, ok bool) {
 
 // InterruptInfo aggregates interrupt metadata for composite or nested runs.
 type InterruptInfo struct {
-	State             any
-	BeforeNodes       []string
+	State       any
+	BeforeNodes []string
+	// BeforeNodesInputs holds the pending inputs of BeforeNodes, keyed by node key,
+	// e.g. the assistant message carrying the tool calls about to be executed by a ToolsNode.
+	BeforeNodesInputs map[string]any
 	AfterNodes        []string
 	RerunNodes        []string
 	RerunNodesExtra   map[string]any
@@ -19,8 +19,10 @@ package compose
 import (
 	"context"
 	"reflect"
+	"sort"
 
 	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/internal/generic"
 )
 
 // GraphNodeInfo the info which end users pass in when they are adding nodes to graph.
@@ -49,9 +51,262 @@ type GraphInfo struct {
 
 	NewGraphOptions []NewGraphOption
 	GenStateFn      func(context.Context) any
+
+	// UnreachableNodes are the sorted nodes unreachable from START, and DeadEndNodes are the sorted nodes unable to reach END,
+	// which are only possible when the graph is compiled WithAllowUnreachable.
+	UnreachableNodes, DeadEndNodes []string
 }
 
 // GraphCompileCallback is the callback which will be called when graph compilation finishes.
 type GraphCompileCallback interface {
 	OnFinish(ctx context.Context, info *GraphInfo)
 }
+
+// GraphIO is implemented by the Runnable compiled from Graph, Chain and Workflow, exposing what the graph expects as input
+// and returns as output, e.g. to build a form for the input in a generic UI, or to validate the input before running the graph.
+// e.g.
+//
+//	r, err := chain.Compile(ctx)
+//	if io, ok := r.(compose.GraphIO); ok {
+//		keys := io.RequiredInputKeys() // e.g. ["context", "question"] for a chain starting with a chat template
+//	}
+type GraphIO interface {
+	// InputType returns the input type of the graph.
+	InputType() reflect.Type
+	// OutputType returns the output type of the graph.
+	OutputType() reflect.Type
+	// RequiredInputKeys returns the sorted keys expected in the input when the input type is map[string]any, or nil otherwise.
+	// The keys are inferred at compile time from the nodes consuming the input of START:
+	// the input key of nodes added WithInputKey, the fields mapped from START in Workflow,
+	// the variables of chat templates (see prompt.DefaultChatTemplate.RequiredVariables) and the required keys of subgraphs.
+	RequiredInputKeys() []string
+}
+
+// Validator is implemented by the Runnable compiled from Graph, Chain and Workflow, to validate the graph it's compiled from
+// without running it, see Graph.Validate for what's checked.
+// Issues failing the compilation, e.g. a tool decider without tools bound, are already reported by Compile,
+// while the others, e.g. invalid tool parameters, are only reported by Validate.
+// e.g.
+//
+//	r, err := graph.Compile(ctx)
+//	if v, ok := r.(compose.Validator); ok {
+//		if err := v.Validate(ctx); err != nil {...}
+//	}
+type Validator interface {
+	Validate(ctx context.Context) error
+}
+
+type compiledGraph[I, O any] struct {
+	Runnable[I, O]
+
+	inputType, outputType reflect.Type
+	requiredInputKeys     []string
+	validator             graphValidator
+}
+
+func (c *compiledGraph[I, O]) InputType() reflect.Type {
+	return c.inputType
+}
+
+func (c *compiledGraph[I, O]) OutputType() reflect.Type {
+	return c.outputType
+}
+
+func (c *compiledGraph[I, O]) RequiredInputKeys() []string {
+	return append([]string(nil), c.requiredInputKeys...)
+}
+
+func (c *compiledGraph[I, O]) Validate(ctx context.Context) error {
+	if c.validator == nil {
+		return nil
+	}
+	return validateGraph(ctx, c.validator)
+}
+
+type requiredInputKeysGetter interface {
+	requiredInputKeys() []string
+}
+
+type requiredVariablesGetter interface {
+	RequiredVariables() []string
+}
+
+func (g *graph) requiredInputKeys() []string {
+	if g.expectedInputType != generic.TypeOf[map[string]any]() {
+		return nil
+	}
+
+	keys := make(map[string]bool)
+	consumers := append(append([]string{}, g.controlEdges[START]...), g.dataEdges[START]...)
+	for _, key := range consumers {
+		node, ok := g.nodes[key]
+		if !ok {
+			continue
+		}
+
+		if mappings := g.startFieldMappings(key); len(mappings) > 0 {
+			for _, m := range mappings {
+				keys[m] = true
+			}
+			continue
+		}
+		if node.nodeInfo != nil && node.nodeInfo.inputKey != "" {
+			keys[node.nodeInfo.inputKey] = true
+			continue
+		}
+
+		var required []string
+		if sub, ok := node.g.(requiredInputKeysGetter); ok {
+			required = sub.requiredInputKeys()
+		} else if tpl, ok := node.instance.(requiredVariablesGetter); ok {
+			required = tpl.RequiredVariables()
+		}
+		for _, k := range required {
+			keys[k] = true
+		}
+	}
+
+	ret := make([]string, 0, len(keys))
+	for k := range keys {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// startFieldMappings returns the top level fields of START mapped to the node, if the node maps fields of START rather than the whole input.
+func (g *graph) startFieldMappings(key string) []string {
+	var fields []string
+	for _, m := range g.fieldMappingRecords[key] {
+		if m.fromNodeKey != START {
+			continue
+		}
+		from := m.FromPath()
+		if len(from) == 0 {
+			// the whole input of START is mapped to a field of the node
+			return nil
+		}
+		fields = append(fields, from[0])
+	}
+	return fields
+}
+
+// GraphEdge is an edge added to the graph.
+// Control means the end node waits for the start node to finish, and Data means the output of the start node is passed to the end node,
+// both of which are true for edges added by AddEdge.
+type GraphEdge struct {
+	From, To string
+	Control  bool
+	Data     bool
+}
+
+// GraphBranchInfo is a branch added to the graph, with the nodes it may route to.
+type GraphBranchInfo struct {
+	From string
+	// EndNodes are the sorted keys of the nodes the branch may route to, including END.
+	EndNodes []string
+}
+
+// Nodes returns the nodes added to the graph so far, keyed by node key, which is useful to inspect a graph before Compile.
+// START and END are not included, and GraphInfo of subgraph nodes is always nil as subgraphs are not compiled yet.
+// The returned map is a copy, modifying it does not affect the graph.
+func (g *Graph[I, O]) Nodes() map[string]GraphNodeInfo {
+	return g.graph.nodeInfos()
+}
+
+// Edges returns the edges added to the graph so far, sorted by From and then To.
+// Branches are not included, see Branches.
+// The returned slice is a copy, modifying it does not affect the graph.
+// e.g.
+//
+//	for _, e := range g.Edges() {
+//		fmt.Printf("%s -> %s\n", e.From, e.To)
+//	}
+func (g *Graph[I, O]) Edges() []GraphEdge {
+	return g.graph.edgeInfos()
+}
+
+// Branches returns the branches added to the graph so far, sorted by From in the order they are added.
+// The returned slice is a copy, modifying it does not affect the graph.
+func (g *Graph[I, O]) Branches() []GraphBranchInfo {
+	return g.graph.branchInfos()
+}
+
+func (g *graph) nodeInfos() map[string]GraphNodeInfo {
+	ret := make(map[string]GraphNodeInfo, len(g.nodes))
+	for key, gNode := range g.nodes {
+		info := GraphNodeInfo{
+			Component:        gNode.executorMeta.component,
+			Instance:         gNode.instance,
+			GraphAddNodeOpts: append([]GraphAddNodeOpt(nil), gNode.opts...),
+			InputType:        gNode.inputType(),
+			OutputType:       gNode.outputType(),
+			Mappings:         append([]*FieldMapping(nil), g.fieldMappingRecords[key]...),
+		}
+		if gNode.nodeInfo != nil {
+			info.Name = gNode.nodeInfo.name
+			info.InputKey = gNode.nodeInfo.inputKey
+			info.OutputKey = gNode.nodeInfo.outputKey
+		}
+		ret[key] = info
+	}
+	return ret
+}
+
+func (g *graph) edgeInfos() []GraphEdge {
+	type edgeKey struct{ from, to string }
+	edges := make(map[edgeKey]*GraphEdge)
+	get := func(from, to string) *GraphEdge {
+		k := edgeKey{from: from, to: to}
+		if e, ok := edges[k]; ok {
+			return e
+		}
+		e := &GraphEdge{From: from, To: to}
+		edges[k] = e
+		return e
+	}
+
+	for from, tos := range g.controlEdges {
+		for _, to := range tos {
+			get(from, to).Control = true
+		}
+	}
+	for from, tos := range g.dataEdges {
+		for _, to := range tos {
+			get(from, to).Data = true
+		}
+	}
+
+	ret := make([]GraphEdge, 0, len(edges))
+	for _, e := range edges {
+		ret = append(ret, *e)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].From != ret[j].From {
+			return ret[i].From < ret[j].From
+		}
+		return ret[i].To < ret[j].To
+	})
+	return ret
+}
+
+func (g *graph) branchInfos() []GraphBranchInfo {
+	froms := make([]string, 0, len(g.branches))
+	for from := range g.branches {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+
+	var ret []GraphBranchInfo
+	for _, from := range froms {
+		for _, b := range g.branches[from] {
+			ends := make([]string, 0, len(b.endNodes))
+			for end := range b.endNodes {
+				ends = append(ends, end)
+			}
+			sort.Strings(ends)
+			ret = append(ret, GraphBranchInfo{From: from, EndNodes: ends})
+		}
+	}
+	return ret
+}
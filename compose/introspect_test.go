@@ -0,0 +1,148 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/internal/generic"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestGraphIO(t *testing.T) {
+	ctx := context.Background()
+
+	tpl := prompt.FromMessages(schema.FString,
+		schema.SystemMessage("answer the question based on the context: {context}"),
+		schema.MessagesPlaceholder("history", true),
+		schema.UserMessage("{question}"))
+
+	t.Run("chain starting with chat template", func(t *testing.T) {
+		c := NewChain[map[string]any, []*schema.Message]()
+		c.AppendChatTemplate(tpl)
+		r, err := c.Compile(ctx)
+		assert.NoError(t, err)
+
+		io, ok := r.(GraphIO)
+		assert.True(t, ok)
+		assert.Equal(t, generic.TypeOf[map[string]any](), io.InputType())
+		assert.Equal(t, generic.TypeOf[[]*schema.Message](), io.OutputType())
+		assert.Equal(t, []string{"context", "question"}, io.RequiredInputKeys())
+	})
+
+	t.Run("graph with input key and subgraph", func(t *testing.T) {
+		sub := NewChain[map[string]any, []*schema.Message]()
+		sub.AppendChatTemplate(prompt.FromMessagesWithRequired(schema.FString, []string{"user"}, schema.UserMessage("hi")))
+
+		g := NewGraph[map[string]any, map[string]any]()
+		assert.NoError(t, g.AddChatTemplateNode("tpl", tpl, WithOutputKey("tpl")))
+		assert.NoError(t, g.AddLambdaNode("echo", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			return in, nil
+		}), WithInputKey("extra"), WithOutputKey("echo")))
+		assert.NoError(t, g.AddGraphNode("sub", sub, WithOutputKey("sub")))
+		for _, key := range []string{"tpl", "echo", "sub"} {
+			assert.NoError(t, g.AddEdge(START, key))
+			assert.NoError(t, g.AddEdge(key, END))
+		}
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"context", "extra", "question", "user"}, r.(GraphIO).RequiredInputKeys())
+	})
+
+	t.Run("workflow with field mapping", func(t *testing.T) {
+		type req struct {
+			Name string
+			Age  int
+		}
+		wf := NewWorkflow[map[string]any, string]()
+		wf.AddLambdaNode("greet", InvokableLambda(func(ctx context.Context, in req) (string, error) {
+			return in.Name, nil
+		})).AddInput(START, MapFields("name", "Name"), MapFields("age", "Age"))
+		wf.End().AddInput("greet")
+		r, err := wf.Compile(ctx)
+		assert.NoError(t, err)
+
+		io := r.(GraphIO)
+		assert.Equal(t, reflect.TypeOf(""), io.OutputType())
+		assert.Equal(t, []string{"age", "name"}, io.RequiredInputKeys())
+	})
+
+	t.Run("non-map input", func(t *testing.T) {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("echo", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			return in, nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "echo"))
+		assert.NoError(t, g.AddEdge("echo", END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		io := r.(GraphIO)
+		assert.Equal(t, reflect.TypeOf(""), io.InputType())
+		assert.Nil(t, io.RequiredInputKeys())
+
+		out, err := r.Invoke(ctx, "hi")
+		assert.NoError(t, err)
+		assert.Equal(t, "hi", out)
+	})
+}
+
+func TestGraphTopology(t *testing.T) {
+	msgLambda := InvokableLambda(func(ctx context.Context, in *schema.Message) (*schema.Message, error) {
+		return in, nil
+	})
+
+	sub := NewChain[*schema.Message, *schema.Message]()
+	sub.AppendLambda(msgLambda)
+
+	g := NewGraph[*schema.Message, *schema.Message]()
+	assert.NoError(t, g.AddLambdaNode("node_model", msgLambda, WithNodeName("model")))
+	assert.NoError(t, g.AddGraphNode("node_tools", sub))
+	assert.NoError(t, g.AddEdge(START, "node_model"))
+	assert.NoError(t, g.AddBranch("node_model", ToolNameBranch(map[string]string{"search": "node_tools"}, END)))
+	assert.NoError(t, g.AddEdge("node_tools", "node_model"))
+
+	nodes := g.Nodes()
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, "model", nodes["node_model"].Name)
+	assert.Equal(t, ComponentOfLambda, nodes["node_model"].Component)
+	assert.Equal(t, generic.TypeOf[*schema.Message](), nodes["node_tools"].InputType)
+	assert.Equal(t, generic.TypeOf[*schema.Message](), nodes["node_tools"].OutputType)
+
+	assert.Equal(t, []GraphEdge{
+		{From: "node_tools", To: "node_model", Control: true, Data: true},
+		{From: START, To: "node_model", Control: true, Data: true},
+	}, g.Edges())
+	assert.Equal(t, []GraphBranchInfo{
+		{From: "node_model", EndNodes: []string{END, "node_tools"}},
+	}, g.Branches())
+
+	// the returned values are copies
+	delete(nodes, "node_model")
+	g.Branches()[0].EndNodes[0] = "modified"
+	assert.Len(t, g.Nodes(), 2)
+	assert.Equal(t, []string{END, "node_tools"}, g.Branches()[0].EndNodes)
+
+	_, err := g.Compile(context.Background())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+)
+
+type graphLambdaValuesKey struct{}
+type lambdaValuesKey struct{}
+
+// WithLambdaNodeOption passes a key-value option to the node of nodeKey, which can be read by GetLambdaNodeOption within the node,
+// so that the behavior of a lambda can be changed per run without recompiling the graph.
+// Unlike WithLambdaOption, the lambda needn't be created with an option type, e.g. by InvokableLambdaWithOption.
+// Options passed to a subgraph node are not visible to the nodes within it,
+// to address a node within a subgraph, designate its path additionally by DesignateNodeWithPath, e.g.
+// WithLambdaNodeOption("sub_graph", "index", 1).DesignateNodeWithPath(NewNodePath("sub_graph", "take_one")).
+// e.g.
+//
+//	takeOne := compose.InvokableLambda(func(ctx context.Context, msgs []*schema.Message) (*schema.Message, error) {
+//		idx, _ := compose.GetLambdaNodeOption[int](ctx, "index")
+//		return msgs[idx], nil
+//	})
+//
+//	runnable.Invoke(ctx, input, compose.WithLambdaNodeOption("take_one", "index", 1))
+func WithLambdaNodeOption(nodeKey, key string, value any) Option {
+	return Option{
+		lambdaValues: map[string]any{key: value},
+		paths:        []*NodePath{NewNodePath(nodeKey)},
+	}
+}
+
+// GetLambdaNodeOption returns the option of key passed to the node being executed by WithLambdaNodeOption.
+// Returns false if the option is not passed, or its value is not of type T.
+func GetLambdaNodeOption[T any](ctx context.Context, key string) (T, bool) {
+	values, _ := ctx.Value(lambdaValuesKey{}).(map[string]any)
+	v, ok := values[key].(T)
+	return v, ok
+}
+
+// withGraphLambdaValues collects the options passed by WithLambdaNodeOption to the nodes of the graph being run.
+// It's always set, so that options of the parent graph are not visible to the nodes of a subgraph.
+func withGraphLambdaValues(ctx context.Context, opts ...Option) context.Context {
+	var values map[string]map[string]any
+	for _, opt := range opts {
+		if len(opt.lambdaValues) == 0 {
+			continue
+		}
+		for _, path := range opt.paths {
+			if len(path.path) != 1 {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]map[string]any)
+			}
+			nodeKey := path.path[0]
+			if values[nodeKey] == nil {
+				values[nodeKey] = make(map[string]any)
+			}
+			for k, v := range opt.lambdaValues {
+				values[nodeKey][k] = v
+			}
+		}
+	}
+	if values == nil && ctx.Value(graphLambdaValuesKey{}) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, graphLambdaValuesKey{}, values)
+}
+
+// withLambdaValues is called on the ctx of a task, to make the options passed to the node readable by GetLambdaNodeOption.
+func withLambdaValues(ctx context.Context, nodeKey string) context.Context {
+	values, _ := ctx.Value(graphLambdaValuesKey{}).(map[string]map[string]any)
+	if values[nodeKey] == nil && ctx.Value(lambdaValuesKey{}) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, lambdaValuesKey{}, values[nodeKey])
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestLambdaNodeOption(t *testing.T) {
+	ctx := context.Background()
+
+	takeOne := InvokableLambda(func(ctx context.Context, msgs []*schema.Message) (*schema.Message, error) {
+		idx, _ := GetLambdaNodeOption[int](ctx, "index")
+		return msgs[idx], nil
+	})
+	msgs := []*schema.Message{schema.UserMessage("first"), schema.UserMessage("second")}
+
+	g := NewGraph[[]*schema.Message, *schema.Message]()
+	assert.NoError(t, g.AddLambdaNode("take_one", takeOne))
+	assert.NoError(t, g.AddEdge(START, "take_one"))
+	assert.NoError(t, g.AddEdge("take_one", END))
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	t.Run("default", func(t *testing.T) {
+		out, err := r.Invoke(ctx, msgs)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", out.Content)
+	})
+
+	t.Run("change index", func(t *testing.T) {
+		out, err := r.Invoke(ctx, msgs, WithLambdaNodeOption("take_one", "index", 1))
+		assert.NoError(t, err)
+		assert.Equal(t, "second", out.Content)
+
+		sr, err := r.Stream(ctx, msgs, WithLambdaNodeOption("take_one", "index", 1))
+		assert.NoError(t, err)
+		out, err = concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "second", out.Content)
+	})
+
+	t.Run("unknown node", func(t *testing.T) {
+		_, err := r.Invoke(ctx, msgs, WithLambdaNodeOption("unknown", "index", 1))
+		assert.ErrorContains(t, err, "option has designated an unknown node")
+	})
+
+	t.Run("node of subgraph", func(t *testing.T) {
+		var outerValues []bool
+		outer := NewGraph[[]*schema.Message, *schema.Message]()
+		assert.NoError(t, outer.AddLambdaNode("before", InvokableLambda(func(ctx context.Context, in []*schema.Message) ([]*schema.Message, error) {
+			_, ok := GetLambdaNodeOption[int](ctx, "index")
+			outerValues = append(outerValues, ok)
+			return in, nil
+		})))
+		assert.NoError(t, outer.AddGraphNode("sub", g))
+		assert.NoError(t, outer.AddEdge(START, "before"))
+		assert.NoError(t, outer.AddEdge("before", "sub"))
+		assert.NoError(t, outer.AddEdge("sub", END))
+		or, err := outer.Compile(ctx)
+		assert.NoError(t, err)
+
+		out, err := or.Invoke(ctx, msgs,
+			WithLambdaNodeOption("sub", "index", 1).DesignateNodeWithPath(NewNodePath("sub", "take_one")))
+		assert.NoError(t, err)
+		assert.Equal(t, "second", out.Content)
+
+		// options passed to a node are not visible to the nodes of the subgraph
+		outerValues = nil
+		out, err = or.Invoke(ctx, msgs, WithLambdaNodeOption("before", "index", 1), WithLambdaNodeOption("sub", "index", 1))
+		assert.NoError(t, err)
+		assert.Equal(t, "first", out.Content)
+		assert.Equal(t, []bool{true}, outerValues)
+	})
+}
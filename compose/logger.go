@@ -0,0 +1,160 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Logger is the leveled logger used by the graph runner for quick diagnostics, which is lighter-weight than callbacks.
+// The runner logs the start and end of each node and tool call at debug level, interrupts at info level and errors at error level,
+// see WithLogger. Warnf is reserved for recoverable issues.
+type Logger interface {
+	Debugf(format string, v ...any)
+	Infof(format string, v ...any)
+	Warnf(format string, v ...any)
+	Errorf(format string, v ...any)
+}
+
+// WithLogger sets the Logger for the run, which applies to the subgraphs as well. Nothing is logged by default.
+// eg:
+//
+//	out, err := r.Invoke(ctx, input, compose.WithLogger(compose.NewSlogLogger(slog.Default())))
+//	// node[chat] start
+//	// node[chat] end, cost: 1.2s
+//	// tool[get_weather] start, call id: call_1, arguments: {"city":"beijing"}
+func WithLogger(logger Logger) Option {
+	return Option{
+		logger: logger,
+	}
+}
+
+// NewSlogLogger adapts a slog.Logger to Logger.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return &slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s *slogLogger) Debugf(format string, v ...any) {
+	s.log(slog.LevelDebug, format, v...)
+}
+
+func (s *slogLogger) Infof(format string, v ...any) {
+	s.log(slog.LevelInfo, format, v...)
+}
+
+func (s *slogLogger) Warnf(format string, v ...any) {
+	s.log(slog.LevelWarn, format, v...)
+}
+
+func (s *slogLogger) Errorf(format string, v ...any) {
+	s.log(slog.LevelError, format, v...)
+}
+
+func (s *slogLogger) log(level slog.Level, format string, v ...any) {
+	ctx := context.Background()
+	if !s.l.Enabled(ctx, level) {
+		return
+	}
+	s.l.Log(ctx, level, fmt.Sprintf(format, v...))
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Debugf(string, ...any) {}
+func (noopLogger) Infof(string, ...any)  {}
+func (noopLogger) Warnf(string, ...any)  {}
+func (noopLogger) Errorf(string, ...any) {}
+
+type loggerKey struct{}
+
+func getLoggerOption(opts ...Option) Logger {
+	var logger Logger
+	for _, opt := range opts {
+		if opt.logger != nil {
+			logger = opt.logger
+		}
+	}
+	return logger
+}
+
+func getLogger(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return logger
+	}
+	return noopLogger{}
+}
+
+func logNodeEnd(logger Logger, node string, start time.Time, err error) {
+	switch {
+	case err == nil:
+		logger.Debugf("node[%s] end, cost: %s", node, time.Since(start))
+	case isInterruptError(err):
+		logger.Infof("node[%s] interrupted, cost: %s", node, time.Since(start))
+	default:
+		logger.Errorf("node[%s] failed, cost: %s, error: %v", node, time.Since(start), err)
+	}
+}
+
+func applyLogger(key string, r *composableRunnable) *composableRunnable {
+	if r.isPassthrough {
+		return r
+	}
+
+	nodeName := func(ctx context.Context) string {
+		if path, ok := getNodePath(ctx); ok {
+			return strings.Join(path.path, ".")
+		}
+		return key
+	}
+
+	i, t := r.i, r.t
+	wrapper := *r
+	wrapper.i = func(ctx context.Context, input any, opts ...any) (any, error) {
+		logger := getLogger(ctx)
+		if _, ok := logger.(noopLogger); ok {
+			return i(ctx, input, opts...)
+		}
+
+		node, start := nodeName(ctx), time.Now()
+		logger.Debugf("node[%s] start", node)
+		out, err := i(ctx, input, opts...)
+		logNodeEnd(logger, node, start, err)
+		return out, err
+	}
+	wrapper.t = func(ctx context.Context, input streamReader, opts ...any) (streamReader, error) {
+		logger := getLogger(ctx)
+		if _, ok := logger.(noopLogger); ok {
+			return t(ctx, input, opts...)
+		}
+
+		// the end of a streaming node is when it returns the output stream, rather than when the stream ends.
+		node, start := nodeName(ctx), time.Now()
+		logger.Debugf("node[%s] start", node)
+		out, err := t(ctx, input, opts...)
+		logNodeEnd(logger, node, start, err)
+		return out, err
+	}
+	return &wrapper
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+type recordLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (r *recordLogger) record(level, format string, v ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// drop the cost, which varies between runs
+	msg := fmt.Sprintf(format, v...)
+	if idx := strings.Index(msg, ", cost: "); idx >= 0 {
+		rest := msg[idx+len(", cost: "):]
+		if end := strings.Index(rest, ", "); end >= 0 {
+			msg = msg[:idx] + rest[end:]
+		} else {
+			msg = msg[:idx]
+		}
+	}
+	r.logs = append(r.logs, level+" "+msg)
+}
+
+func (r *recordLogger) Debugf(format string, v ...any) { r.record("DEBUG", format, v...) }
+func (r *recordLogger) Infof(format string, v ...any)  { r.record("INFO", format, v...) }
+func (r *recordLogger) Warnf(format string, v ...any)  { r.record("WARN", format, v...) }
+func (r *recordLogger) Errorf(format string, v ...any) { r.record("ERROR", format, v...) }
+
+func TestLogger(t *testing.T) {
+	ctx := context.Background()
+
+	sub := NewGraph[string, string]()
+	assert.NoError(t, sub.AddLambdaNode("upper", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+		if in == "fail" {
+			return "", errors.New("boom")
+		}
+		return strings.ToUpper(in), nil
+	})))
+	assert.NoError(t, sub.AddEdge(START, "upper"))
+	assert.NoError(t, sub.AddEdge("upper", END))
+
+	tn, err := NewToolNode(ctx, &ToolsNodeConfig{Tools: []tool.BaseTool{&mockTool{}}})
+	assert.NoError(t, err)
+
+	g := NewGraph[string, []*schema.Message]()
+	assert.NoError(t, g.AddGraphNode("sub", sub))
+	assert.NoError(t, g.AddLambdaNode("to_call", InvokableLambda(func(ctx context.Context, in string) (*schema.Message, error) {
+		return schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{
+			Name: "mock_tool", Arguments: fmt.Sprintf(`{"name":"%s"}`, in),
+		}}}), nil
+	})))
+	assert.NoError(t, g.AddToolsNode("tools", tn))
+	assert.NoError(t, g.AddEdge(START, "sub"))
+	assert.NoError(t, g.AddEdge("sub", "to_call"))
+	assert.NoError(t, g.AddEdge("to_call", "tools"))
+	assert.NoError(t, g.AddEdge("tools", END))
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	t.Run("node and tool calls", func(t *testing.T) {
+		logger := &recordLogger{}
+		_, err := r.Invoke(ctx, "eino", WithLogger(logger))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"DEBUG node[sub] start",
+			"DEBUG node[sub.upper] start",
+			"DEBUG node[sub.upper] end",
+			"DEBUG node[sub] end",
+			"DEBUG node[to_call] start",
+			"DEBUG node[to_call] end",
+			"DEBUG node[tools] start",
+			`DEBUG tool[mock_tool] start, call id: 1, arguments: {"name":"EINO"}`,
+			"DEBUG tool[mock_tool] end, call id: 1",
+			"DEBUG node[tools] end",
+		}, logger.logs)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		logger := &recordLogger{}
+		_, err := r.Invoke(ctx, "fail", WithLogger(logger))
+		assert.Error(t, err)
+		assert.Contains(t, logger.logs, "ERROR node[sub.upper] failed, error: boom")
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		logger := &recordLogger{}
+		sr, err := r.Stream(ctx, "eino", WithLogger(logger))
+		assert.NoError(t, err)
+		sr.Close()
+		assert.Contains(t, logger.logs, "DEBUG node[tools] end")
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError})))
+		_, err := r.Invoke(ctx, "fail", WithLogger(logger))
+		assert.Error(t, err)
+		assert.Contains(t, buf.String(), "level=ERROR")
+		assert.Contains(t, buf.String(), "node[sub.upper] failed")
+		assert.NotContains(t, buf.String(), "level=DEBUG")
+	})
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// FileMemoryConfig is the config for FileMemory.
+type FileMemoryConfig struct {
+	// Dir is the directory to store the history files, required, it will be created if not exists.
+	Dir string
+	// TrimPolicy limits the history kept for each session, no limit if not set.
+	TrimPolicy *TrimPolicy
+}
+
+// FileMemory is a Memory persisting the history of each session into "{Dir}/{sessionID}.jsonl",
+// one json encoded message per line.
+// It is safe for concurrent use within a process, but not across processes sharing the same Dir.
+type FileMemory struct {
+	dir        string
+	trimPolicy *TrimPolicy
+
+	mu sync.Mutex
+}
+
+// NewFileMemory creates a new FileMemory.
+func NewFileMemory(ctx context.Context, conf *FileMemoryConfig) (*FileMemory, error) {
+	if conf == nil || len(conf.Dir) == 0 {
+		return nil, errors.New("dir of file memory is required")
+	}
+
+	if err := os.MkdirAll(conf.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create memory dir failed: %w", err)
+	}
+
+	return &FileMemory{
+		dir:        conf.Dir,
+		trimPolicy: conf.TrimPolicy,
+	}, nil
+}
+
+// Load reads the history messages of the session, returns empty history if the session has no file yet.
+func (m *FileMemory) Load(_ context.Context, sessionID string) ([]*schema.Message, error) {
+	path, err := m.path(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return readMessages(path)
+}
+
+// Append appends messages to the history file of the session, and trims the history by the TrimPolicy.
+// The file is rewritten through a temporary file, so that it won't be left half written.
+func (m *FileMemory) Append(ctx context.Context, sessionID string, msgs ...*schema.Message) error {
+	path, err := m.path(sessionID)
+	if err != nil {
+		return err
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history, err := readMessages(path)
+	if err != nil {
+		return err
+	}
+	history, err = m.trimPolicy.trim(ctx, append(history, msgs...))
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for _, msg := range history {
+		if err = encoder.Encode(msg); err != nil {
+			return fmt.Errorf("encode message failed: %w", err)
+		}
+	}
+
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write memory file failed: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename memory file failed: %w", err)
+	}
+
+	return nil
+}
+
+func (m *FileMemory) path(sessionID string) (string, error) {
+	if len(sessionID) == 0 || sessionID == "." || sessionID == ".." || filepath.Base(sessionID) != sessionID {
+		return "", fmt.Errorf("invalid session id for file memory: %q", sessionID)
+	}
+	return filepath.Join(m.dir, sessionID+".jsonl"), nil
+}
+
+func readMessages(path string) ([]*schema.Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*schema.Message{}, nil
+		}
+		return nil, fmt.Errorf("open memory file failed: %w", err)
+	}
+	defer f.Close()
+
+	var msgs []*schema.Message
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		msg := &schema.Message{}
+		if err = json.Unmarshal(scanner.Bytes(), msg); err != nil {
+			return nil, fmt.Errorf("decode message of %s failed: %w", path, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read memory file failed: %w", err)
+	}
+
+	return msgs, nil
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// InMemoryConfig is the config for InMemory.
+type InMemoryConfig struct {
+	// TrimPolicy limits the history kept for each session, no limit if not set.
+	TrimPolicy *TrimPolicy
+}
+
+// InMemory is a Memory keeping the history in process memory, which is lost on restart.
+// It is safe for concurrent use.
+type InMemory struct {
+	trimPolicy *TrimPolicy
+
+	mu       sync.RWMutex
+	sessions map[string][]*schema.Message
+}
+
+// NewInMemory creates a new InMemory.
+func NewInMemory(ctx context.Context, conf *InMemoryConfig) (*InMemory, error) {
+	if conf == nil {
+		conf = &InMemoryConfig{}
+	}
+
+	return &InMemory{
+		trimPolicy: conf.TrimPolicy,
+		sessions:   make(map[string][]*schema.Message),
+	}, nil
+}
+
+// Load returns a copy of the history messages of the session.
+func (m *InMemory) Load(_ context.Context, sessionID string) ([]*schema.Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	msgs := m.sessions[sessionID]
+	ret := make([]*schema.Message, len(msgs))
+	copy(ret, msgs)
+	return ret, nil
+}
+
+// Append appends messages to the history of the session, and trims the history by the TrimPolicy.
+func (m *InMemory) Append(ctx context.Context, sessionID string, msgs ...*schema.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history, err := m.trimPolicy.trim(ctx, append(m.sessions[sessionID], msgs...))
+	if err != nil {
+		return err
+	}
+	m.sessions[sessionID] = history
+	return nil
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+// NewHistoryLoader returns a lambda to be placed before the chat template node, which loads the history of
+// the session bound by WithSession, and sets it into the template variables with historyKey,
+// to fill the MessagesPlaceholder(historyKey, true) of the template.
+// The variables are passed through unchanged if no session is bound, or the variables already contain historyKey.
+// e.g.
+//
+//	_ = graph.AddLambdaNode("load_history", memory.NewHistoryLoader(mem, "chat_history"))
+//	_ = graph.AddChatTemplateNode("template", prompt.FromMessages(schema.FString,
+//		schema.SystemMessage("you are a helpful assistant."),
+//		schema.MessagesPlaceholder("chat_history", true),
+//		schema.UserMessage("question: {question}"),
+//	))
+//	_ = graph.AddEdge(compose.START, "load_history")
+//	_ = graph.AddEdge("load_history", "template")
+func NewHistoryLoader(mem Memory, historyKey string) *compose.Lambda {
+	return compose.InvokableLambda(func(ctx context.Context, vs map[string]any) (map[string]any, error) {
+		s := getSession(ctx)
+		if s == nil {
+			return vs, nil
+		}
+		if _, ok := vs[historyKey]; ok {
+			return vs, nil
+		}
+
+		history, err := mem.Load(ctx, s.id)
+		if err != nil {
+			return nil, fmt.Errorf("load history of session[%s] failed: %w", s.id, err)
+		}
+
+		s.mu.Lock()
+		s.known = make(map[*schema.Message]bool, len(history))
+		for _, msg := range history {
+			s.known[msg] = true
+		}
+		s.mu.Unlock()
+
+		ret := make(map[string]any, len(vs)+1)
+		for k, v := range vs {
+			ret[k] = v
+		}
+		ret[historyKey] = history
+		return ret, nil
+	})
+}
+
+// NewMessagesSaver returns a passthrough lambda which saves messages into the history of the session bound by WithSession.
+// Place it after the chat template node to save the new user input, or after the tools node to save the tool results.
+// System messages and messages loaded by NewHistoryLoader or already saved in the same run are skipped.
+func NewMessagesSaver(mem Memory) *compose.Lambda {
+	return compose.InvokableLambda(func(ctx context.Context, msgs []*schema.Message) ([]*schema.Message, error) {
+		if err := save(ctx, mem, msgs...); err != nil {
+			return nil, err
+		}
+		return msgs, nil
+	})
+}
+
+// NewMessageSaver returns a passthrough lambda which saves the message into the history of the session bound by WithSession.
+// Place it after the chat model node to save the model output.
+// When streaming, chunks are passed through as they arrive, and the concatenated message is saved at the end of the stream.
+func NewMessageSaver(mem Memory) *compose.Lambda {
+	invoke := func(ctx context.Context, msg *schema.Message, _ ...any) (*schema.Message, error) {
+		if err := save(ctx, mem, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+
+	transform := func(ctx context.Context, input *schema.StreamReader[*schema.Message], _ ...any) (*schema.StreamReader[*schema.Message], error) {
+		if getSession(ctx) == nil {
+			return input, nil
+		}
+
+		sr, sw := schema.Pipe[*schema.Message](0)
+		go func() {
+			var err error
+			defer func() {
+				if e := recover(); e != nil {
+					err = safe.NewPanicErr(e, debug.Stack())
+				}
+				input.Close()
+				sw.CloseWithError(err)
+			}()
+
+			var chunks []*schema.Message
+			for {
+				chunk, e := input.Recv()
+				if errors.Is(e, io.EOF) {
+					break
+				}
+				if e != nil {
+					err = e
+					return
+				}
+				chunks = append(chunks, chunk)
+				if closed := sw.Send(chunk, nil); closed {
+					return
+				}
+			}
+
+			msg, e := schema.ConcatMessages(chunks)
+			if e != nil {
+				err = fmt.Errorf("concat message chunks failed: %w", e)
+				return
+			}
+			err = save(ctx, mem, msg)
+		}()
+
+		return sr, nil
+	}
+
+	l, _ := compose.AnyLambda(invoke, nil, nil, transform)
+	return l
+}
+
+func save(ctx context.Context, mem Memory, msgs ...*schema.Message) error {
+	s := getSession(ctx)
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	toSave := make([]*schema.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg == nil || msg.Role == schema.System || s.known[msg] {
+			continue
+		}
+		s.known[msg] = true
+		toSave = append(toSave, msg)
+	}
+	s.mu.Unlock()
+
+	if len(toSave) == 0 {
+		return nil
+	}
+
+	if err := mem.Append(ctx, s.id, toSave...); err != nil {
+		return fmt.Errorf("save history of session[%s] failed: %w", s.id, err)
+	}
+	return nil
+}
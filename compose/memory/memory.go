@@ -0,0 +1,156 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package memory provides conversation memory, which persists the messages of a session and
+// fills them into the chat template as history on the next run, and trims messages to fit the token budget of models.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Memory stores the messages of conversation sessions.
+type Memory interface {
+	// Load returns the history messages of the session, in chronological order.
+	Load(ctx context.Context, sessionID string) ([]*schema.Message, error)
+	// Append appends messages to the history of the session.
+	Append(ctx context.Context, sessionID string, msgs ...*schema.Message) error
+}
+
+// TrimPolicy limits the history kept by a Memory, the oldest messages are dropped first.
+// Tool messages whose tool call has been dropped are dropped too, wherever they are in the history,
+// so that an assistant message with tool calls and its tool messages are always dropped as a unit,
+// even if they are interleaved with other messages.
+type TrimPolicy struct {
+	// MaxMessages is the max number of messages kept, no limit if not positive.
+	MaxMessages int
+	// MaxTokens is the max number of tokens kept, counted by TokenCounter, no limit if not positive.
+	MaxTokens int
+	// TokenCounter counts the tokens of a message.
+	// Default is ApproximateTokenCounter if not set.
+	TokenCounter TokenCounter
+}
+
+func (p *TrimPolicy) trim(ctx context.Context, msgs []*schema.Message) ([]*schema.Message, error) {
+	if p == nil {
+		return msgs, nil
+	}
+
+	if p.MaxMessages > 0 && len(msgs) > p.MaxMessages {
+		msgs = msgs[len(msgs)-p.MaxMessages:]
+	}
+
+	if p.MaxTokens > 0 {
+		counter := p.TokenCounter
+		if counter == nil {
+			counter = ApproximateTokenCounter
+		}
+
+		total := 0
+		start := len(msgs)
+		for start > 0 {
+			n, err := counter.CountTokens(ctx, msgs[start-1])
+			if err != nil {
+				return nil, fmt.Errorf("count tokens failed: %w", err)
+			}
+			total += n
+			if total > p.MaxTokens {
+				break
+			}
+			start--
+		}
+		msgs = msgs[start:]
+	}
+
+	return dropOrphanToolMessages(msgs), nil
+}
+
+// dropOrphanToolMessages drops the tool messages which answer no tool call of the assistant messages before them.
+// A tool message without ToolCallID is taken as answering the latest assistant message with tool calls.
+func dropOrphanToolMessages(msgs []*schema.Message) []*schema.Message {
+	callIDs := make(map[string]bool)
+	hasToolCalls := false
+	var ret []*schema.Message
+	for i, msg := range msgs {
+		switch {
+		case msg.Role == schema.Assistant && len(msg.ToolCalls) > 0:
+			hasToolCalls = true
+			for _, tc := range msg.ToolCalls {
+				callIDs[tc.ID] = true
+			}
+		case msg.Role == schema.Tool:
+			orphan := !hasToolCalls
+			if msg.ToolCallID != "" {
+				orphan = !callIDs[msg.ToolCallID]
+			}
+			if orphan {
+				if ret == nil {
+					ret = append(make([]*schema.Message, 0, len(msgs)-1), msgs[:i]...)
+				}
+				continue
+			}
+		}
+		if ret != nil {
+			ret = append(ret, msg)
+		}
+	}
+
+	if ret == nil {
+		return msgs
+	}
+	return ret
+}
+
+type sessionKey struct{}
+
+// session is bound to the context of a run, it records the messages already loaded from or saved into memory
+// during the run, so that they won't be saved again.
+type session struct {
+	id string
+
+	mu    sync.Mutex
+	known map[*schema.Message]bool
+}
+
+// WithSession binds a session to the context, pass the context to the run of a graph
+// built with NewHistoryLoader and NewMessageSaver / NewMessagesSaver, to load and save the history of the session.
+// e.g.
+//
+//	out, err := runnable.Invoke(memory.WithSession(ctx, "user_1"), map[string]any{"question": "how's weather"})
+func WithSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{
+		id:    sessionID,
+		known: make(map[*schema.Message]bool),
+	})
+}
+
+// GetSessionID returns the session bound by WithSession, and whether there is one.
+func GetSessionID(ctx context.Context) (string, bool) {
+	s := getSession(ctx)
+	if s == nil {
+		return "", false
+	}
+	return s.id, true
+}
+
+func getSession(ctx context.Context) *session {
+	s, _ := ctx.Value(sessionKey{}).(*session)
+	return s
+}
@@ -0,0 +1,251 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestTrimPolicy(t *testing.T) {
+	msgs := []*schema.Message{
+		schema.UserMessage("1234"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "tool"}}}),
+		schema.ToolMessage("12345678", "1"),
+		schema.AssistantMessage("1234", nil),
+		schema.UserMessage("12345678"),
+	}
+
+	ctx := context.Background()
+
+	var p *TrimPolicy
+	trimmed, err := p.trim(ctx, msgs)
+	assert.NoError(t, err)
+	assert.Equal(t, msgs, trimmed)
+
+	p = &TrimPolicy{MaxMessages: 3}
+	// the tool message is dropped together with its tool call
+	trimmed, err = p.trim(ctx, msgs)
+	assert.NoError(t, err)
+	assert.Equal(t, msgs[3:], trimmed)
+
+	p = &TrimPolicy{MaxTokens: 4, TokenCounter: contentLengthCounter}
+	trimmed, err = p.trim(ctx, msgs)
+	assert.NoError(t, err)
+	assert.Equal(t, msgs[3:], trimmed)
+
+	p = &TrimPolicy{MaxTokens: 8}
+	trimmed, err = p.trim(ctx, msgs)
+	assert.NoError(t, err)
+	assert.Equal(t, msgs[3:], trimmed)
+
+	p = &TrimPolicy{MaxTokens: 8, TokenCounter: TokenCounterFunc(func(ctx context.Context, msg *schema.Message) (int, error) {
+		return 0, errors.New("tokenizer unavailable")
+	})}
+	_, err = p.trim(ctx, msgs)
+	assert.ErrorContains(t, err, "tokenizer unavailable")
+}
+
+func TestTrimPolicyInterleavedToolCalls(t *testing.T) {
+	ctx := context.Background()
+
+	// tool calls of two assistant messages are answered after both of them, e.g. by an agent running tools in the background
+	msgs := []*schema.Message{
+		schema.UserMessage("weather and files?"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "weather", Function: schema.FunctionCall{Name: "get_weather"}}}),
+		schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "find", Function: schema.FunctionCall{Name: "find_file"}},
+			{ID: "cat", Function: schema.FunctionCall{Name: "cat_file"}},
+		}),
+		schema.ToolMessage("sunny", "weather"),
+		schema.ToolMessage("a.txt", "find"),
+		schema.AssistantMessage("still working", nil),
+		schema.ToolMessage("content of a.txt", "cat"),
+		schema.AssistantMessage("done", nil),
+	}
+
+	for maxMessages, expected := range map[int][]*schema.Message{
+		8: msgs,
+		7: msgs[1:],
+		// the first tool call is dropped with its tool message in the middle of the history
+		6: {msgs[2], msgs[4], msgs[5], msgs[6], msgs[7]},
+		// both tool calls are dropped, so are all their tool messages
+		5: {msgs[5], msgs[7]},
+		3: {msgs[5], msgs[7]},
+	} {
+		trimmed, err := (&TrimPolicy{MaxMessages: maxMessages}).trim(ctx, msgs)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, trimmed, "max messages: %d", maxMessages)
+	}
+
+	t.Run("tool message without call id", func(t *testing.T) {
+		trimmed := dropOrphanToolMessages([]*schema.Message{
+			schema.ToolMessage("orphan", ""),
+			schema.AssistantMessage("", []schema.ToolCall{{ID: "1"}}),
+			schema.ToolMessage("result", ""),
+		})
+		assert.Len(t, trimmed, 2)
+		assert.Equal(t, "result", trimmed[1].Content)
+	})
+}
+
+// contentLengthCounter counts one token per four bytes of content.
+var contentLengthCounter = TokenCounterFunc(func(ctx context.Context, msg *schema.Message) (int, error) {
+	return len(msg.Content) / 4, nil
+})
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+
+	inMemory, err := NewInMemory(ctx, &InMemoryConfig{TrimPolicy: &TrimPolicy{MaxMessages: 2}})
+	assert.NoError(t, err)
+	fileMemory, err := NewFileMemory(ctx, &FileMemoryConfig{Dir: t.TempDir(), TrimPolicy: &TrimPolicy{MaxMessages: 2}})
+	assert.NoError(t, err)
+
+	for name, mem := range map[string]Memory{"in memory": inMemory, "file": fileMemory} {
+		t.Run(name, func(t *testing.T) {
+			history, err := mem.Load(ctx, "session_1")
+			assert.NoError(t, err)
+			assert.Empty(t, history)
+
+			assert.NoError(t, mem.Append(ctx, "session_1", schema.UserMessage("hi"), schema.AssistantMessage("hello", nil)))
+			assert.NoError(t, mem.Append(ctx, "session_1", schema.UserMessage("bye")))
+			assert.NoError(t, mem.Append(ctx, "session_2", schema.UserMessage("other")))
+
+			history, err = mem.Load(ctx, "session_1")
+			assert.NoError(t, err)
+			assert.Equal(t, []*schema.Message{schema.AssistantMessage("hello", nil), schema.UserMessage("bye")}, history)
+
+			history, err = mem.Load(ctx, "session_2")
+			assert.NoError(t, err)
+			assert.Equal(t, []*schema.Message{schema.UserMessage("other")}, history)
+		})
+	}
+
+	t.Run("file persistence", func(t *testing.T) {
+		dir := t.TempDir()
+		mem, err := NewFileMemory(ctx, &FileMemoryConfig{Dir: dir})
+		assert.NoError(t, err)
+		assert.NoError(t, mem.Append(ctx, "session", schema.UserMessage("hi")))
+
+		reopened, err := NewFileMemory(ctx, &FileMemoryConfig{Dir: dir})
+		assert.NoError(t, err)
+		history, err := reopened.Load(ctx, "session")
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.Message{schema.UserMessage("hi")}, history)
+
+		_, err = reopened.Load(ctx, "../session")
+		assert.ErrorContains(t, err, "invalid session id")
+
+		_, err = NewFileMemory(ctx, &FileMemoryConfig{})
+		assert.ErrorContains(t, err, "dir of file memory is required")
+	})
+}
+
+// countingModel answers with the number of messages it receives.
+type countingModel struct {
+	inputs [][]*schema.Message
+}
+
+func (c *countingModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	c.inputs = append(c.inputs, input)
+	return schema.AssistantMessage(fmt.Sprintf("received %d messages", len(input)), nil), nil
+}
+
+func (c *countingModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	c.inputs = append(c.inputs, input)
+	return schema.StreamReaderFromArray([]*schema.Message{
+		schema.AssistantMessage("received ", nil),
+		schema.AssistantMessage(fmt.Sprintf("%d messages", len(input)), nil),
+	}), nil
+}
+
+func TestHistoryInGraph(t *testing.T) {
+	ctx := context.Background()
+
+	mem, err := NewInMemory(ctx, nil)
+	assert.NoError(t, err)
+	cm := &countingModel{}
+
+	g := compose.NewGraph[map[string]any, *schema.Message]()
+	assert.NoError(t, g.AddLambdaNode("load_history", NewHistoryLoader(mem, "chat_history")))
+	assert.NoError(t, g.AddChatTemplateNode("template", prompt.FromMessages(schema.FString,
+		schema.SystemMessage("you are a helpful assistant."),
+		schema.MessagesPlaceholder("chat_history", true),
+		schema.UserMessage("question: {question}"),
+	)))
+	assert.NoError(t, g.AddLambdaNode("save_input", NewMessagesSaver(mem)))
+	assert.NoError(t, g.AddChatModelNode("model", cm))
+	assert.NoError(t, g.AddLambdaNode("save_output", NewMessageSaver(mem)))
+	assert.NoError(t, g.AddEdge(compose.START, "load_history"))
+	assert.NoError(t, g.AddEdge("load_history", "template"))
+	assert.NoError(t, g.AddEdge("template", "save_input"))
+	assert.NoError(t, g.AddEdge("save_input", "model"))
+	assert.NoError(t, g.AddEdge("model", "save_output"))
+	assert.NoError(t, g.AddEdge("save_output", compose.END))
+
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	out, err := r.Invoke(WithSession(ctx, "session"), map[string]any{"question": "first"})
+	assert.NoError(t, err)
+	assert.Equal(t, "received 2 messages", out.Content)
+
+	sr, err := r.Stream(WithSession(ctx, "session"), map[string]any{"question": "second"})
+	assert.NoError(t, err)
+	var chunks []*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		chunks = append(chunks, chunk)
+	}
+	assert.Len(t, chunks, 2)
+	out, err = schema.ConcatMessages(chunks)
+	assert.NoError(t, err)
+	assert.Equal(t, "received 4 messages", out.Content)
+
+	history, err := mem.Load(ctx, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, []*schema.Message{
+		schema.UserMessage("question: first"),
+		schema.AssistantMessage("received 2 messages", nil),
+		schema.UserMessage("question: second"),
+		schema.AssistantMessage("received 4 messages", nil),
+	}, history)
+	assert.Equal(t, history[:2], cm.inputs[1][1:3])
+
+	// without session, nothing is loaded or saved
+	out, err = r.Invoke(ctx, map[string]any{"question": "third"})
+	assert.NoError(t, err)
+	assert.Equal(t, "received 2 messages", out.Content)
+	history, err = mem.Load(ctx, "session")
+	assert.NoError(t, err)
+	assert.Len(t, history, 4)
+}
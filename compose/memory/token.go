@@ -0,0 +1,140 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// TokenCounter counts the tokens of a message, implement it with the tokenizer of the model for accurate counting.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, msg *schema.Message) (int, error)
+}
+
+// TokenCounterFunc is an adapter to use an ordinary function as TokenCounter.
+type TokenCounterFunc func(ctx context.Context, msg *schema.Message) (int, error)
+
+// CountTokens calls f(ctx, msg).
+func (f TokenCounterFunc) CountTokens(ctx context.Context, msg *schema.Message) (int, error) {
+	return f(ctx, msg)
+}
+
+// ApproximateTokenCounter approximates one token per four characters of the text in a message,
+// plus one token of overhead per message. It needs no tokenizer, but may be far from the count of a real one.
+var ApproximateTokenCounter TokenCounter = TokenCounterFunc(approximateTokens)
+
+func approximateTokens(_ context.Context, msg *schema.Message) (int, error) {
+	n := utf8.RuneCountInString(msg.Content) + utf8.RuneCountInString(msg.ReasoningContent)
+	for _, part := range msg.UserInputMultiContent {
+		n += utf8.RuneCountInString(part.Text)
+	}
+	for _, tc := range msg.ToolCalls {
+		n += utf8.RuneCountInString(tc.Function.Name) + utf8.RuneCountInString(tc.Function.Arguments)
+	}
+	return (n+3)/4 + 1, nil
+}
+
+// TrimMessages trims messages to fit in maxTokens, and returns the trimmed messages with their token count.
+// System messages and the latest user turn, i.e. the last user message and all messages after it, are always kept.
+// Other messages are dropped by turns from the oldest, where a turn starts from a user message and ends before the next one,
+// so that a tool call is never separated from its tool result.
+// If there is no user message, e.g. the messages are generated by an agent, every message other than a tool result starts a turn,
+// so the last message, along with the tool call it's the result of, is always kept.
+// The returned count may still exceed maxTokens if the kept messages alone exceed it.
+// No message is dropped if maxTokens is not positive. counter defaults to ApproximateTokenCounter if nil.
+func TrimMessages(ctx context.Context, msgs []*schema.Message, maxTokens int, counter TokenCounter) ([]*schema.Message, int, error) {
+	if counter == nil {
+		counter = ApproximateTokenCounter
+	}
+
+	counts := make([]int, len(msgs))
+	total := 0
+	for i, msg := range msgs {
+		n, err := counter.CountTokens(ctx, msg)
+		if err != nil {
+			return nil, 0, fmt.Errorf("count tokens of message[%d] failed: %w", i, err)
+		}
+		counts[i] = n
+		total += n
+	}
+
+	if maxTokens <= 0 || total <= maxTokens {
+		return msgs, total, nil
+	}
+
+	// without user messages, a turn starts from any message other than a tool result,
+	// i.e. the last message with the tool results following the tool calls is kept as the latest turn.
+	isTurnStart := func(msg *schema.Message) bool {
+		return msg.Role != schema.Tool
+	}
+	for _, msg := range msgs {
+		if msg.Role == schema.User {
+			isTurnStart = func(msg *schema.Message) bool {
+				return msg.Role == schema.User
+			}
+			break
+		}
+	}
+
+	latest := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if isTurnStart(msgs[i]) {
+			latest = i
+			break
+		}
+	}
+
+	dropped := make([]bool, len(msgs))
+	for start := 0; start < latest && total > maxTokens; {
+		end := start + 1
+		for end < latest && !isTurnStart(msgs[end]) {
+			end++
+		}
+		for i := start; i < end; i++ {
+			if msgs[i].Role == schema.System {
+				continue
+			}
+			dropped[i] = true
+			total -= counts[i]
+		}
+		start = end
+	}
+
+	ret := make([]*schema.Message, 0, len(msgs))
+	for i, msg := range msgs {
+		if !dropped[i] {
+			ret = append(ret, msg)
+		}
+	}
+
+	return ret, total, nil
+}
+
+// NewMessagesTrimmer returns a lambda to be placed before the chat model node, which trims the input messages
+// to fit in maxTokens by TrimMessages.
+// To trim inside a state pre handler instead, call TrimMessages directly.
+func NewMessagesTrimmer(maxTokens int, counter TokenCounter) *compose.Lambda {
+	return compose.InvokableLambda(func(ctx context.Context, msgs []*schema.Message) ([]*schema.Message, error) {
+		ret, _, err := TrimMessages(ctx, msgs, maxTokens, counter)
+		return ret, err
+	})
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestTrimMessages(t *testing.T) {
+	ctx := context.Background()
+
+	msgs := []*schema.Message{
+		schema.SystemMessage("1234"),
+		schema.UserMessage("1234"),
+		schema.AssistantMessage("12345678", nil),
+		schema.UserMessage("1234"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "tool"}}}),
+		schema.ToolMessage("12345678", "1"),
+		schema.AssistantMessage("1234", nil),
+		schema.UserMessage("12345678"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "2", Function: schema.FunctionCall{Name: "tool"}}}),
+		schema.ToolMessage("1234", "2"),
+	}
+
+	t.Run("within budget", func(t *testing.T) {
+		trimmed, n, err := TrimMessages(ctx, msgs, 11, contentLengthCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, msgs, trimmed)
+		assert.Equal(t, 11, n)
+
+		trimmed, n, err = TrimMessages(ctx, msgs, 0, contentLengthCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, msgs, trimmed)
+		assert.Equal(t, 11, n)
+	})
+
+	t.Run("drop oldest turns", func(t *testing.T) {
+		trimmed, n, err := TrimMessages(ctx, msgs, 10, contentLengthCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, append([]*schema.Message{msgs[0]}, msgs[3:]...), trimmed)
+		assert.Equal(t, 8, n)
+
+		// the tool call turn is dropped as a whole
+		trimmed, n, err = TrimMessages(ctx, msgs, 7, contentLengthCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, append([]*schema.Message{msgs[0]}, msgs[7:]...), trimmed)
+		assert.Equal(t, 4, n)
+	})
+
+	t.Run("keep system and latest turn beyond budget", func(t *testing.T) {
+		trimmed, n, err := TrimMessages(ctx, msgs, 1, contentLengthCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, append([]*schema.Message{msgs[0]}, msgs[7:]...), trimmed)
+		assert.Equal(t, 4, n)
+	})
+
+	t.Run("keep trailing turn without user message", func(t *testing.T) {
+		agentMsgs := []*schema.Message{
+			schema.SystemMessage("1234"),
+			schema.AssistantMessage("12345678", nil),
+			schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "tool"}}}),
+			schema.ToolMessage("12345678", "1"),
+			schema.AssistantMessage("", []schema.ToolCall{{ID: "2", Function: schema.FunctionCall{Name: "tool"}}}),
+			schema.ToolMessage("1234", "2"),
+		}
+
+		trimmed, n, err := TrimMessages(ctx, agentMsgs, 4, contentLengthCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.Message{agentMsgs[0], agentMsgs[2], agentMsgs[3], agentMsgs[4], agentMsgs[5]}, trimmed)
+		assert.Equal(t, 4, n)
+
+		trimmed, n, err = TrimMessages(ctx, agentMsgs, 1, contentLengthCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.Message{agentMsgs[0], agentMsgs[4], agentMsgs[5]}, trimmed)
+		assert.Equal(t, 2, n)
+
+		trimmed, n, err = TrimMessages(ctx, agentMsgs[:2], 1, contentLengthCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, agentMsgs[:2], trimmed)
+		assert.Equal(t, 3, n)
+	})
+
+	t.Run("default counter", func(t *testing.T) {
+		trimmed, n, err := TrimMessages(ctx, msgs, 15, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, append([]*schema.Message{msgs[0]}, msgs[7:]...), trimmed)
+		assert.Equal(t, 9, n)
+	})
+
+	t.Run("lambda", func(t *testing.T) {
+		r, err := compose.NewChain[[]*schema.Message, []*schema.Message]().
+			AppendLambda(NewMessagesTrimmer(7, contentLengthCounter)).
+			Compile(ctx)
+		assert.NoError(t, err)
+		trimmed, err := r.Invoke(ctx, msgs)
+		assert.NoError(t, err)
+		assert.Len(t, trimmed, 4)
+	})
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"sync"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ModelCache stores the outputs of chat model calls, see WithModelCache.
+// Implementations must be safe for concurrent use.
+type ModelCache interface {
+	Get(ctx context.Context, key string) (*schema.Message, bool)
+	Set(ctx context.Context, key string, msg *schema.Message)
+}
+
+// WithModelCache sets a ModelCache for the run, so that a chat model node called with the same input messages and options
+// as a previous call, e.g. by a retry or a parallel branch, reuses the cached output instead of calling the model again.
+// The key is the hash of the graph name, the node key, the input messages and the common options of the model, including tools,
+// the implementation specific options are not part of the key.
+// The output streams are cached only after being read to the end, a stream closed earlier or failing isn't cached.
+// It applies to the chat model nodes of the subgraphs as well, and the cached messages are shared, don't modify them.
+// It's opt-in because reusing outputs makes a sampling model deterministic within the scope of the cache.
+// eg:
+//
+//	out, err := r.Invoke(ctx, input, compose.WithModelCache(compose.NewInMemoryModelCache()))
+func WithModelCache(cache ModelCache) Option {
+	return Option{
+		modelCache: cache,
+	}
+}
+
+// InMemoryModelCache is a ModelCache keeping all the entries in memory, which is meant to be used within a single run.
+type InMemoryModelCache struct {
+	mu   sync.RWMutex
+	msgs map[string]*schema.Message
+}
+
+// NewInMemoryModelCache creates an empty InMemoryModelCache.
+func NewInMemoryModelCache() *InMemoryModelCache {
+	return &InMemoryModelCache{msgs: make(map[string]*schema.Message)}
+}
+
+// Get returns the cached message of the key.
+func (c *InMemoryModelCache) Get(_ context.Context, key string) (*schema.Message, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	msg, ok := c.msgs[key]
+	return msg, ok
+}
+
+// Set caches the message with the key.
+func (c *InMemoryModelCache) Set(_ context.Context, key string, msg *schema.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.msgs[key] = msg
+}
+
+type modelCacheKey struct{}
+
+func getModelCache(opts ...Option) ModelCache {
+	var cache ModelCache
+	for _, opt := range opts {
+		if opt.modelCache != nil {
+			cache = opt.modelCache
+		}
+	}
+	return cache
+}
+
+func applyModelCache(graphName, key string, r *composableRunnable) *composableRunnable {
+	if r.meta == nil || r.meta.component != components.ComponentOfChatModel {
+		return r
+	}
+
+	i, t := r.i, r.t
+	wrapper := *r
+	wrapper.i = func(ctx context.Context, input any, opts ...any) (any, error) {
+		cache, _ := ctx.Value(modelCacheKey{}).(ModelCache)
+		if cache == nil {
+			return i(ctx, input, opts...)
+		}
+		cacheKey, ok := modelCallKey(graphName, key, input, opts)
+		if !ok {
+			return i(ctx, input, opts...)
+		}
+		if msg, hit := cache.Get(ctx, cacheKey); hit {
+			return msg, nil
+		}
+
+		out, err := i(ctx, input, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if msg, ok := out.(*schema.Message); ok && msg != nil {
+			cache.Set(ctx, cacheKey, msg)
+		}
+		return out, nil
+	}
+	wrapper.t = func(ctx context.Context, input streamReader, opts ...any) (streamReader, error) {
+		cache, _ := ctx.Value(modelCacheKey{}).(ModelCache)
+		if cache == nil {
+			return t(ctx, input, opts...)
+		}
+		in, ok := unpackStreamReader[[]*schema.Message](input)
+		if !ok {
+			return t(ctx, input, opts...)
+		}
+		// the model concatenates the input stream before being called anyway, so the key is computed on the concatenated input.
+		msgs, err := concatStreamReader(in)
+		if err != nil {
+			return nil, err
+		}
+		input = packStreamReader(schema.StreamReaderFromArray([][]*schema.Message{msgs}))
+		cacheKey, ok := modelCallKey(graphName, key, msgs, opts)
+		if !ok {
+			return t(ctx, input, opts...)
+		}
+		if msg, hit := cache.Get(ctx, cacheKey); hit {
+			return packStreamReader(schema.StreamReaderFromArray([]*schema.Message{msg})), nil
+		}
+
+		out, err := t(ctx, input, opts...)
+		if err != nil {
+			return nil, err
+		}
+		sr, ok := unpackStreamReader[*schema.Message](out)
+		if !ok {
+			return out, nil
+		}
+		return packStreamReader(cacheOnEOF(ctx, sr, cache, cacheKey)), nil
+	}
+	return &wrapper
+}
+
+// cacheOnEOF forwards the chunks of sr, and caches the concatenated message once sr is read to the end.
+// Reading sr stops as soon as the returned stream is closed, leaving nothing cached.
+func cacheOnEOF(ctx context.Context, sr *schema.StreamReader[*schema.Message], cache ModelCache, key string) *schema.StreamReader[*schema.Message] {
+	reader, writer := schema.Pipe[*schema.Message](0)
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				writer.Send(nil, safe.NewPanicErr(fmt.Errorf("panic in caching model output: %v", e), debug.Stack()))
+			}
+			sr.Close()
+			writer.Close()
+		}()
+
+		var chunks []*schema.Message
+		for {
+			chunk, err := sr.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				writer.Send(nil, err)
+				return
+			}
+			if closed := writer.Send(chunk, nil); closed {
+				return
+			}
+			chunks = append(chunks, chunk)
+		}
+
+		if msg, err := schema.ConcatMessages(chunks); err == nil {
+			cache.Set(ctx, key, msg)
+		}
+	}()
+	return reader
+}
+
+// modelCallKey hashes the input messages and the common options of a chat model call,
+// it returns false if they can't be hashed, e.g. an input or option of unexpected type.
+func modelCallKey(graphName, nodeKey string, input any, opts []any) (string, bool) {
+	msgs, ok := input.([]*schema.Message)
+	if !ok {
+		return "", false
+	}
+	modelOpts := make([]model.Option, 0, len(opts))
+	for _, opt := range opts {
+		o, ok := opt.(model.Option)
+		if !ok {
+			return "", false
+		}
+		modelOpts = append(modelOpts, o)
+	}
+
+	options := model.GetCommonOptions(&model.Options{}, modelOpts...)
+	// tool parameters are unexported fields of schema.ToolInfo, so tools are hashed in the form sent to the models.
+	tools := make([]map[string]any, 0, len(options.Tools))
+	for _, info := range options.Tools {
+		tool, err := schema.ToOpenAITool(info)
+		if err != nil {
+			return "", false
+		}
+		tools = append(tools, tool)
+	}
+	options.Tools = nil
+
+	b, err := sonic.ConfigStd.Marshal(struct {
+		Graph    string
+		Node     string
+		Messages []*schema.Message
+		Options  *model.Options
+		Tools    []map[string]any
+	}{graphName, nodeKey, msgs, options, tools})
+	if err != nil {
+		return "", false
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), true
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	cmodel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestModelCache(t *testing.T) {
+	ctx := context.Background()
+	input := []*schema.Message{schema.UserMessage("hi")}
+
+	build := func(t *testing.T) (Runnable[[]*schema.Message, *schema.Message], *atomic.Int32) {
+		calls := &atomic.Int32{}
+		ctrl := gomock.NewController(t)
+		cm := model.NewMockChatModel(ctrl)
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, input []*schema.Message, opts ...cmodel.Option) (*schema.Message, error) {
+				return schema.AssistantMessage(fmt.Sprintf("answer %d", calls.Add(1)), nil), nil
+			}).AnyTimes()
+		cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, input []*schema.Message, opts ...cmodel.Option) (*schema.StreamReader[*schema.Message], error) {
+				return schema.StreamReaderFromArray([]*schema.Message{
+					schema.AssistantMessage("answer ", nil),
+					schema.AssistantMessage(fmt.Sprint(calls.Add(1)), nil),
+				}), nil
+			}).AnyTimes()
+
+		g := NewGraph[[]*schema.Message, *schema.Message]()
+		assert.NoError(t, g.AddChatModelNode("model", cm))
+		assert.NoError(t, g.AddEdge(START, "model"))
+		assert.NoError(t, g.AddEdge("model", END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+		return r, calls
+	}
+
+	t.Run("identical call hits cache", func(t *testing.T) {
+		r, calls := build(t)
+		cache := NewInMemoryModelCache()
+
+		out1, err := r.Invoke(ctx, input, WithModelCache(cache))
+		assert.NoError(t, err)
+		out2, err := r.Invoke(ctx, input, WithModelCache(cache))
+		assert.NoError(t, err)
+		assert.Equal(t, "answer 1", out2.Content)
+		assert.Equal(t, out1, out2)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("different input or options miss cache", func(t *testing.T) {
+		r, calls := build(t)
+		cache := NewInMemoryModelCache()
+
+		_, err := r.Invoke(ctx, input, WithModelCache(cache))
+		assert.NoError(t, err)
+		out, err := r.Invoke(ctx, []*schema.Message{schema.UserMessage("hello")}, WithModelCache(cache))
+		assert.NoError(t, err)
+		assert.Equal(t, "answer 2", out.Content)
+		out, err = r.Invoke(ctx, input, WithModelCache(cache), WithChatModelOption(cmodel.WithTemperature(0.5)))
+		assert.NoError(t, err)
+		assert.Equal(t, "answer 3", out.Content)
+		out, err = r.Invoke(ctx, input, WithModelCache(cache), WithChatModelOption(cmodel.WithTools([]*schema.ToolInfo{{Name: "now"}})))
+		assert.NoError(t, err)
+		assert.Equal(t, "answer 4", out.Content)
+		assert.Equal(t, int32(4), calls.Load())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		r, calls := build(t)
+
+		_, err := r.Invoke(ctx, input)
+		assert.NoError(t, err)
+		_, err = r.Invoke(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("stream cached after read to the end", func(t *testing.T) {
+		r, calls := build(t)
+		cache := NewInMemoryModelCache()
+
+		sr, err := r.Stream(ctx, input, WithModelCache(cache))
+		assert.NoError(t, err)
+		out, err := schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "answer 1", out.Content)
+
+		sr, err = r.Stream(ctx, input, WithModelCache(cache))
+		assert.NoError(t, err)
+		out, err = schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "answer 1", out.Content)
+
+		out, err = r.Invoke(ctx, input, WithModelCache(cache))
+		assert.NoError(t, err)
+		assert.Equal(t, "answer 1", out.Content)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("stream closed early not cached", func(t *testing.T) {
+		r, calls := build(t)
+		cache := NewInMemoryModelCache()
+
+		sr, err := r.Stream(ctx, input, WithModelCache(cache))
+		assert.NoError(t, err)
+		sr.Close()
+
+		out, err := r.Invoke(ctx, input, WithModelCache(cache))
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, "answer 2", out.Content)
+	})
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// NodeInfo describes the graph node being executed, which can be read by GetNodeInfo within the node,
+// e.g. within a lambda, or within a tool called by a ToolsNode, to tag logs, metrics or outbound requests.
+type NodeInfo struct {
+	// Key is the key of the node in the graph it belongs to.
+	Key string
+	// Name is the name of the node set by WithNodeName, empty if not set.
+	Name string
+	// Path is the path of the node from the root graph, e.g. [sub_graph_node, node] for a node of a subgraph.
+	Path *NodePath
+	// RunID identifies a run of the root graph, which is shared by the nodes of its subgraphs.
+	RunID string
+}
+
+type nodeInfoKey struct{}
+type runIDKey struct{}
+
+// GetNodeInfo returns the info of the graph node being executed.
+// It's set per node execution, so it's always the innermost node, and it's not passed from a node to its successors.
+// Returns false if ctx is not within a graph node.
+func GetNodeInfo(ctx context.Context) (*NodeInfo, bool) {
+	info, ok := ctx.Value(nodeInfoKey{}).(*NodeInfo)
+	return info, ok
+}
+
+// initRunID generates the run ID for a root graph run, a subgraph reuses the one of its parent.
+func initRunID(ctx context.Context, isSubGraph bool) context.Context {
+	if _, ok := ctx.Value(runIDKey{}).(string); ok && isSubGraph {
+		return ctx
+	}
+	return context.WithValue(ctx, runIDKey{}, uuid.NewString())
+}
+
+// withNodeInfo is called on the ctx of a task, after the address segment of the node is appended.
+func withNodeInfo(ctx context.Context, key string, call *chanCall) context.Context {
+	info := &NodeInfo{Key: key}
+	if call.action.nodeInfo != nil {
+		info.Name = call.action.nodeInfo.name
+	}
+	if path, ok := getNodePath(ctx); ok {
+		info.Path = path
+	} else {
+		info.Path = NewNodePath(key)
+	}
+	info.RunID, _ = ctx.Value(runIDKey{}).(string)
+	return context.WithValue(ctx, nodeInfoKey{}, info)
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeInfo(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := GetNodeInfo(ctx)
+	assert.False(t, ok)
+
+	var mu sync.Mutex
+	infos := map[string]NodeInfo{}
+	record := func(ctx context.Context, input string) (string, error) {
+		info, ok := GetNodeInfo(ctx)
+		assert.True(t, ok)
+		mu.Lock()
+		infos[info.Key] = *info
+		mu.Unlock()
+		return input, nil
+	}
+
+	sub := NewGraph[string, string]()
+	assert.NoError(t, sub.AddLambdaNode("inner", InvokableLambda(record)))
+	assert.NoError(t, sub.AddEdge(START, "inner"))
+	assert.NoError(t, sub.AddEdge("inner", END))
+
+	g := NewGraph[string, string]()
+	assert.NoError(t, g.AddLambdaNode("a", InvokableLambda(record), WithNodeName("lambda_a")))
+	assert.NoError(t, g.AddLambdaNode("b", InvokableLambda(record)))
+	assert.NoError(t, g.AddGraphNode("sub", sub))
+	assert.NoError(t, g.AddEdge(START, "a"))
+	assert.NoError(t, g.AddEdge("a", "b"))
+	assert.NoError(t, g.AddEdge("b", "sub"))
+	assert.NoError(t, g.AddEdge("sub", END))
+
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	_, err = r.Invoke(ctx, "hi")
+	assert.NoError(t, err)
+
+	runID := infos["a"].RunID
+	assert.NotEmpty(t, runID)
+	assert.Equal(t, NodeInfo{Key: "a", Name: "lambda_a", Path: NewNodePath("a"), RunID: runID}, infos["a"])
+	assert.Equal(t, NodeInfo{Key: "b", Path: NewNodePath("b"), RunID: runID}, infos["b"])
+	assert.Equal(t, NodeInfo{Key: "inner", Path: NewNodePath("sub", "inner"), RunID: runID}, infos["inner"])
+
+	_, err = r.Invoke(ctx, "hi")
+	assert.NoError(t, err)
+	assert.NotEqual(t, runID, infos["a"].RunID)
+	assert.Equal(t, infos["a"].RunID, infos["inner"].RunID)
+}
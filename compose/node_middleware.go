@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/schema"
+)
+
+// NodeMiddlewareInfo is the info of the node that a NodeMiddleware wraps.
+type NodeMiddlewareInfo struct {
+	// Key is the key of the node in the graph.
+	Key string
+	// Name is the name of the node, set by WithNodeName.
+	Name string
+	// Component is the component type of the node, e.g. components.ComponentOfChatModel, ComponentOfLambda.
+	Component components.Component
+	// Type is the implementation type of the node, e.g. "OpenAI" for an OpenAI chat model.
+	Type string
+}
+
+// NodeHandler runs a node, with the input and output erased to any.
+// Invoke is used when the node is run with a non-stream input, and Transform with a stream input.
+// The opts are the call options designated to the node.
+type NodeHandler struct {
+	Invoke    func(ctx context.Context, input any, opts ...any) (output any, err error)
+	Transform func(ctx context.Context, input *schema.StreamReader[any], opts ...any) (output *schema.StreamReader[any], err error)
+}
+
+// NodeMiddleware wraps the NodeHandler of a node into a new one.
+// Different from callbacks, a middleware is able to modify the input and output, short-circuit the node or transform its error,
+// so the values it passes on and returns MUST keep the input and output types of the node.
+type NodeMiddleware func(info *NodeMiddlewareInfo, next NodeHandler) NodeHandler
+
+// WithNodeMiddleware wraps every node of the graph, except passthrough nodes, with the middlewares.
+// The middlewares passed in first are the outermost, i.e. WithNodeMiddleware(a, b) runs a, then b, then the node.
+// Middlewares are applied outside of the node's callbacks and state handlers are applied outside of the middlewares.
+// Nodes inside subgraphs are not wrapped, pass the option to the subgraph by WithGraphCompileOptions if needed.
+func WithNodeMiddleware(mws ...NodeMiddleware) GraphCompileOption {
+	return func(o *graphCompileOptions) {
+		o.nodeMiddlewares = append(o.nodeMiddlewares, mws...)
+	}
+}
+
+func applyNodeMiddlewares(key string, r *composableRunnable, mws []NodeMiddleware) *composableRunnable {
+	if len(mws) == 0 || r.isPassthrough {
+		return r
+	}
+
+	info := &NodeMiddlewareInfo{Key: key}
+	if r.nodeInfo != nil {
+		info.Name = r.nodeInfo.name
+	}
+	if r.meta != nil {
+		info.Component = r.meta.component
+		info.Type = r.meta.componentImplType
+	}
+
+	i, t := r.i, r.t
+	h := NodeHandler{
+		Invoke: func(ctx context.Context, input any, opts ...any) (any, error) {
+			return i(ctx, input, opts...)
+		},
+		Transform: func(ctx context.Context, input *schema.StreamReader[any], opts ...any) (*schema.StreamReader[any], error) {
+			out, err := t(ctx, r.inputConverter.transform(packStreamReader(input)), opts...)
+			if err != nil {
+				return nil, err
+			}
+			return out.toAnyStreamReader(), nil
+		},
+	}
+	for j := len(mws) - 1; j >= 0; j-- {
+		h = mws[j](info, h)
+	}
+
+	wrapper := *r
+	wrapper.i = func(ctx context.Context, input any, opts ...any) (any, error) {
+		out, err := h.Invoke(ctx, input, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if out != nil && r.outputType != nil && !reflect.TypeOf(out).AssignableTo(r.outputType) {
+			return nil, fmt.Errorf("node middleware returns output of type[%T], which is not assignable to the output type[%s] of node[%s]",
+				out, r.outputType, key)
+		}
+		return out, nil
+	}
+	wrapper.t = func(ctx context.Context, input streamReader, opts ...any) (streamReader, error) {
+		out, err := h.Transform(ctx, input.toAnyStreamReader(), opts...)
+		if err != nil {
+			return nil, err
+		}
+		return r.outputConverter.transform(packStreamReader(out)), nil
+	}
+
+	return &wrapper
+}
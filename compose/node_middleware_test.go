@@ -0,0 +1,174 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestNodeMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	build := func(t *testing.T, opts ...GraphCompileOption) Runnable[string, string] {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("upper", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			if in == "boom" {
+				return "", errors.New("boom")
+			}
+			return strings.ToUpper(in), nil
+		}), WithNodeName("upper_node")))
+		assert.NoError(t, g.AddLambdaNode("suffix", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			return in + "!", nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "upper"))
+		assert.NoError(t, g.AddEdge("upper", "suffix"))
+		assert.NoError(t, g.AddEdge("suffix", END))
+		r, err := g.Compile(ctx, opts...)
+		assert.NoError(t, err)
+		return r
+	}
+
+	t.Run("order", func(t *testing.T) {
+		var trace []string
+		record := func(name string) NodeMiddleware {
+			return func(info *NodeMiddlewareInfo, next NodeHandler) NodeHandler {
+				return NodeHandler{
+					Invoke: func(ctx context.Context, input any, opts ...any) (any, error) {
+						trace = append(trace, fmt.Sprintf("%s before %s", name, info.Key))
+						out, err := next.Invoke(ctx, input, opts...)
+						trace = append(trace, fmt.Sprintf("%s after %s", name, info.Key))
+						return out, err
+					},
+					Transform: next.Transform,
+				}
+			}
+		}
+
+		out, err := build(t, WithNodeMiddleware(record("a"), record("b"))).Invoke(ctx, "hi")
+		assert.NoError(t, err)
+		assert.Equal(t, "HI!", out)
+		assert.Equal(t, []string{
+			"a before upper", "b before upper", "b after upper", "a after upper",
+			"a before suffix", "b before suffix", "b after suffix", "a after suffix",
+		}, trace)
+	})
+
+	t.Run("info", func(t *testing.T) {
+		infos := map[string]NodeMiddlewareInfo{}
+		mw := func(info *NodeMiddlewareInfo, next NodeHandler) NodeHandler {
+			infos[info.Key] = *info
+			return next
+		}
+
+		_, err := build(t, WithNodeMiddleware(mw)).Invoke(ctx, "hi")
+		assert.NoError(t, err)
+		assert.Equal(t, "upper_node", infos["upper"].Name)
+		assert.Equal(t, components.Component(ComponentOfLambda), infos["upper"].Component)
+		assert.Equal(t, "", infos["suffix"].Name)
+	})
+
+	t.Run("modify, short-circuit and transform error", func(t *testing.T) {
+		errWrapped := errors.New("wrapped")
+		mw := func(info *NodeMiddlewareInfo, next NodeHandler) NodeHandler {
+			if info.Key != "upper" {
+				return next
+			}
+			return NodeHandler{
+				Invoke: func(ctx context.Context, input any, opts ...any) (any, error) {
+					if input.(string) == "skip" {
+						return "skipped", nil
+					}
+					out, err := next.Invoke(ctx, "<"+input.(string)+">", opts...)
+					if err != nil {
+						return nil, fmt.Errorf("%w: %v", errWrapped, err)
+					}
+					return out, nil
+				},
+				Transform: next.Transform,
+			}
+		}
+		r := build(t, WithNodeMiddleware(mw))
+
+		out, err := r.Invoke(ctx, "hi")
+		assert.NoError(t, err)
+		assert.Equal(t, "<HI>!", out)
+
+		out, err = r.Invoke(ctx, "skip")
+		assert.NoError(t, err)
+		assert.Equal(t, "skipped!", out)
+
+		_, err = build(t, WithNodeMiddleware(func(info *NodeMiddlewareInfo, next NodeHandler) NodeHandler {
+			return NodeHandler{
+				Invoke: func(ctx context.Context, input any, opts ...any) (any, error) {
+					out, err := next.Invoke(ctx, input, opts...)
+					if err != nil {
+						return nil, fmt.Errorf("%w: %v", errWrapped, err)
+					}
+					return out, nil
+				},
+				Transform: next.Transform,
+			}
+		})).Invoke(ctx, "boom")
+		assert.ErrorIs(t, err, errWrapped)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		mw := func(info *NodeMiddlewareInfo, next NodeHandler) NodeHandler {
+			return NodeHandler{
+				Invoke: next.Invoke,
+				Transform: func(ctx context.Context, input *schema.StreamReader[any], opts ...any) (*schema.StreamReader[any], error) {
+					out, err := next.Transform(ctx, input, opts...)
+					if err != nil {
+						return nil, err
+					}
+					return schema.StreamReaderWithConvert(out, func(v any) (any, error) {
+						return "[" + v.(string) + "]", nil
+					}), nil
+				},
+			}
+		}
+
+		sr, err := build(t, WithNodeMiddleware(mw)).Stream(ctx, "hi")
+		assert.NoError(t, err)
+		out, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "[[HI]!]", out)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		mw := func(info *NodeMiddlewareInfo, next NodeHandler) NodeHandler {
+			return NodeHandler{
+				Invoke: func(ctx context.Context, input any, opts ...any) (any, error) {
+					return 1, nil
+				},
+				Transform: next.Transform,
+			}
+		}
+
+		_, err := build(t, WithNodeMiddleware(mw)).Invoke(ctx, "hi")
+		assert.ErrorContains(t, err, "not assignable to the output type[string]")
+	})
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// WithNodeTimeout sets the default timeout of each node of the graph, which fails the node with ErrNodeTimeout once exceeded.
+// The context passed to the node is cancelled at the timeout, while the node is given up even if it doesn't honor the context,
+// e.g. a hung stream of a chat model. For the nodes outputting streams, the timeout covers the whole output stream.
+// Nodes inside subgraphs are not affected, pass the option to the subgraph by WithGraphCompileOptions if needed.
+func WithNodeTimeout(timeout time.Duration) GraphCompileOption {
+	return func(o *graphCompileOptions) {
+		o.nodeTimeout = timeout
+	}
+}
+
+// WithNodeTimeouts sets the timeouts of the given nodes, which override the default one set by WithNodeTimeout.
+// A non-positive timeout disables the timeout of the node.
+// eg:
+//
+//	r, err := g.Compile(ctx,
+//		compose.WithNodeTimeout(10*time.Second),
+//		compose.WithNodeTimeouts(map[string]time.Duration{"chat_model": time.Minute}))
+func WithNodeTimeouts(timeouts map[string]time.Duration) GraphCompileOption {
+	return func(o *graphCompileOptions) {
+		if o.nodeTimeouts == nil {
+			o.nodeTimeouts = make(map[string]time.Duration, len(timeouts))
+		}
+		for k, v := range timeouts {
+			o.nodeTimeouts[k] = v
+		}
+	}
+}
+
+func applyNodeTimeout(key string, r *composableRunnable, opt *graphCompileOptions) *composableRunnable {
+	timeout := opt.nodeTimeout
+	if t, ok := opt.nodeTimeouts[key]; ok {
+		timeout = t
+	}
+	if timeout <= 0 || r.isPassthrough {
+		return r
+	}
+
+	timeoutErr := func() error {
+		return fmt.Errorf("%w: node[%s] exceeds %s", ErrNodeTimeout, key, timeout)
+	}
+
+	i, t := r.i, r.t
+	wrapper := *r
+	wrapper.i = func(ctx context.Context, input any, opts ...any) (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		out, err, timedOut := runWithTimeout(ctx, func() (any, error) {
+			return i(ctx, input, opts...)
+		})
+		if timedOut {
+			return nil, timeoutErr()
+		}
+		return out, err
+	}
+	wrapper.t = func(ctx context.Context, input streamReader, opts ...any) (streamReader, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+
+		out, err, timedOut := runWithTimeout(ctx, func() (streamReader, error) {
+			return t(ctx, input, opts...)
+		})
+		if timedOut {
+			cancel()
+			return nil, timeoutErr()
+		}
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		sr := forwardWithTimeout(ctx, cancel, out.toAnyStreamReader(), timeoutErr)
+		return r.outputConverter.transform(packStreamReader(sr)), nil
+	}
+
+	return &wrapper
+}
+
+// runWithTimeout runs fn in another goroutine, and gives it up once ctx exceeds its deadline.
+// a panic of fn is re-panicked in the calling goroutine, so that it's recovered by the graph as usual.
+func runWithTimeout[T any](ctx context.Context, fn func() (T, error)) (T, error, bool) {
+	type result struct {
+		out       T
+		err       error
+		panicInfo any
+	}
+
+	// buffered, so that the goroutine given up exits once fn returns
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		defer func() {
+			res.panicInfo = recover()
+			done <- res
+		}()
+		res.out, res.err = fn()
+	}()
+
+	select {
+	case res := <-done:
+		if res.panicInfo != nil {
+			panic(res.panicInfo)
+		}
+		return res.out, res.err, res.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err(), errors.Is(ctx.Err(), context.DeadlineExceeded)
+	}
+}
+
+// forwardWithTimeout forwards the chunks of sr, and ends the returned reader with the timeout error once ctx exceeds its deadline.
+// cancel is called once sr ends or the returned reader is closed.
+func forwardWithTimeout(ctx context.Context, cancel context.CancelFunc, sr *schema.StreamReader[any],
+	timeoutErr func() error) *schema.StreamReader[any] {
+
+	type chunk struct {
+		v   any
+		err error
+	}
+
+	out, sw := schema.Pipe[any](0)
+	go func() {
+		defer func() {
+			cancel()
+			sw.Close()
+		}()
+
+		chunks := make(chan chunk)
+		go func() {
+			defer func() {
+				sr.Close()
+				close(chunks)
+			}()
+			for {
+				v, err := sr.Recv()
+				if errors.Is(err, io.EOF) {
+					return
+				}
+				select {
+				case chunks <- chunk{v: v, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case c, ok := <-chunks:
+				if !ok {
+					return
+				}
+				if closed := sw.Send(c.v, c.err); closed {
+					return
+				}
+			case <-ctx.Done():
+				err := ctx.Err()
+				if errors.Is(err, context.DeadlineExceeded) {
+					err = timeoutErr()
+				}
+				sw.Send(nil, err)
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestNodeTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	// slow ignores the context on purpose, so that the node is given up rather than returning by itself
+	slow := func(d time.Duration) *Lambda {
+		return InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			time.Sleep(d)
+			return in + "_slow", nil
+		})
+	}
+
+	newGraph := func(d time.Duration) *Graph[string, string] {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("fast", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			return in + "_fast", nil
+		})))
+		assert.NoError(t, g.AddLambdaNode("slow", slow(d)))
+		assert.NoError(t, g.AddEdge(START, "fast"))
+		assert.NoError(t, g.AddEdge("fast", "slow"))
+		assert.NoError(t, g.AddEdge("slow", END))
+		return g
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		r, err := newGraph(time.Second).Compile(ctx, WithNodeTimeout(50*time.Millisecond))
+		assert.NoError(t, err)
+
+		start := time.Now()
+		_, err = r.Invoke(ctx, "in")
+		assert.ErrorIs(t, err, ErrNodeTimeout)
+		assert.ErrorContains(t, err, "node[slow]")
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("not timeout", func(t *testing.T) {
+		r, err := newGraph(10*time.Millisecond).Compile(ctx, WithNodeTimeout(time.Second))
+		assert.NoError(t, err)
+
+		out, err := r.Invoke(ctx, "in")
+		assert.NoError(t, err)
+		assert.Equal(t, "in_fast_slow", out)
+	})
+
+	t.Run("override per node", func(t *testing.T) {
+		r, err := newGraph(100*time.Millisecond).Compile(ctx,
+			WithNodeTimeout(50*time.Millisecond),
+			WithNodeTimeouts(map[string]time.Duration{"slow": time.Second}))
+		assert.NoError(t, err)
+
+		out, err := r.Invoke(ctx, "in")
+		assert.NoError(t, err)
+		assert.Equal(t, "in_fast_slow", out)
+
+		r, err = newGraph(time.Second).Compile(ctx,
+			WithNodeTimeouts(map[string]time.Duration{"slow": 50 * time.Millisecond}))
+		assert.NoError(t, err)
+
+		_, err = r.Invoke(ctx, "in")
+		assert.ErrorIs(t, err, ErrNodeTimeout)
+	})
+
+	t.Run("hung stream", func(t *testing.T) {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("stream", StreamableLambda(func(ctx context.Context, in string) (*schema.StreamReader[string], error) {
+			sr, sw := schema.Pipe[string](0)
+			go func() {
+				sw.Send(in, nil)
+				// never ends the stream
+				time.Sleep(time.Second)
+				sw.Close()
+			}()
+			return sr, nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "stream"))
+		assert.NoError(t, g.AddEdge("stream", END))
+
+		r, err := g.Compile(ctx, WithNodeTimeout(50*time.Millisecond))
+		assert.NoError(t, err)
+
+		sr, err := r.Stream(ctx, "in")
+		assert.NoError(t, err)
+		defer sr.Close()
+
+		chunk, err := sr.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, "in", chunk)
+
+		_, err = sr.Recv()
+		assert.ErrorIs(t, err, ErrNodeTimeout)
+		assert.False(t, errors.Is(err, io.EOF))
+	})
+}
@@ -29,7 +29,7 @@ type pregelChannel struct {
 }
 
 func (ch *pregelChannel) setMergeConfig(cfg FanInMergeConfig) {
-	ch.mergeConfig.StreamMergeWithSourceEOF = cfg.StreamMergeWithSourceEOF
+	ch.mergeConfig = cfg
 }
 
 func (ch *pregelChannel) load(c channel) error {
@@ -58,17 +58,14 @@ func (ch *pregelChannel) get(isStream bool, name string, edgeHandler *edgeHandle
 		return nil, false, nil
 	}
 	defer func() { ch.Values = map[string]any{} }()
-	values := make([]any, len(ch.Values))
-	names := make([]string, len(ch.Values))
-	i := 0
-	for k, v := range ch.Values {
-		resolvedV, err := edgeHandler.handle(k, name, v, isStream)
+	names := ch.mergeConfig.MergeStrategy.order(ch.Values)
+	values := make([]any, len(names))
+	for i, k := range names {
+		resolvedV, err := edgeHandler.handle(k, name, ch.Values[k], isStream)
 		if err != nil {
 			return nil, false, err
 		}
 		values[i] = resolvedV
-		names[i] = k
-		i++
 	}
 
 	if len(values) == 1 {
@@ -0,0 +1,145 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components"
+)
+
+// RateLimiter limits the rate of invocations, e.g. to stay within the RPM limits of a model provider.
+// Implementations must be safe for concurrent use.
+type RateLimiter interface {
+	// Wait blocks until an invocation is allowed, or returns the error of ctx if ctx is done before that.
+	Wait(ctx context.Context) error
+}
+
+// WithRateLimiter sets a RateLimiter, which is waited for before each invocation of the chat model nodes of the graph.
+// The limiter can be shared by multiple graphs, so that all their chat model nodes are limited together.
+// Chat models inside subgraphs or lambdas are not limited, pass the option to the subgraph by WithGraphCompileOptions if needed.
+func WithRateLimiter(limiter RateLimiter) GraphCompileOption {
+	return func(o *graphCompileOptions) {
+		o.rateLimiter = limiter
+	}
+}
+
+func applyRateLimiter(r *composableRunnable, limiter RateLimiter) *composableRunnable {
+	if limiter == nil || r.meta == nil || r.meta.component != components.ComponentOfChatModel {
+		return r
+	}
+
+	i, t := r.i, r.t
+	wrapper := *r
+	wrapper.i = func(ctx context.Context, input any, opts ...any) (any, error) {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		return i(ctx, input, opts...)
+	}
+	wrapper.t = func(ctx context.Context, input streamReader, opts ...any) (streamReader, error) {
+		if err := limiter.Wait(ctx); err != nil {
+			input.close()
+			return nil, err
+		}
+		return t(ctx, input, opts...)
+	}
+	return &wrapper
+}
+
+// TokenBucketLimiter is a RateLimiter implemented by token bucket,
+// which allows bursts of up to burst invocations, and refills the bucket at rate tokens per second.
+type TokenBucketLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucketLimiter creates a TokenBucketLimiter with a full bucket.
+// eg:
+//
+//	// 60 requests per minute, at most 5 requests at a time
+//	limiter := compose.NewTokenBucketLimiter(1, 5)
+//	r, err := g.Compile(ctx, compose.WithRateLimiter(limiter))
+func NewTokenBucketLimiter(rate float64, burst int) *TokenBucketLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucketLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait takes a token from the bucket, waiting for it to be refilled if it's empty.
+// The token is given back if ctx is done before the waiting ends.
+func (l *TokenBucketLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, which may make the bucket owe tokens, and returns how long to wait for the debt to be paid.
+func (l *TokenBucketLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.rate > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	if l.rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+func (l *TokenBucketLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = math.Min(l.burst, l.tokens+1)
+}
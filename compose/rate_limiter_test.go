@@ -0,0 +1,146 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	cmodel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+type countingLimiter struct {
+	waits atomic.Int32
+	err   error
+}
+
+func (c *countingLimiter) Wait(ctx context.Context) error {
+	c.waits.Add(1)
+	return c.err
+}
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	build := func(t *testing.T, limiter RateLimiter) Runnable[[]*schema.Message, []*schema.Message] {
+		ctrl := gomock.NewController(t)
+		cm := model.NewMockChatModel(ctrl)
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).Return(schema.AssistantMessage("ok", nil), nil).AnyTimes()
+		cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, input []*schema.Message, opts ...cmodel.Option) (*schema.StreamReader[*schema.Message], error) {
+				return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("ok", nil)}), nil
+			}).AnyTimes()
+
+		g := NewGraph[[]*schema.Message, []*schema.Message]()
+		assert.NoError(t, g.AddChatModelNode("model1", cm))
+		assert.NoError(t, g.AddChatModelNode("model2", cm))
+		assert.NoError(t, g.AddLambdaNode("to_list", InvokableLambda(func(ctx context.Context, in *schema.Message) ([]*schema.Message, error) {
+			return []*schema.Message{in}, nil
+		})))
+		assert.NoError(t, g.AddLambdaNode("collect", InvokableLambda(func(ctx context.Context, in *schema.Message) ([]*schema.Message, error) {
+			return []*schema.Message{in}, nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "model1"))
+		assert.NoError(t, g.AddEdge("model1", "to_list"))
+		assert.NoError(t, g.AddEdge("to_list", "model2"))
+		assert.NoError(t, g.AddEdge("model2", "collect"))
+		assert.NoError(t, g.AddEdge("collect", END))
+
+		r, err := g.Compile(ctx, WithRateLimiter(limiter))
+		assert.NoError(t, err)
+		return r
+	}
+
+	t.Run("wait before model nodes", func(t *testing.T) {
+		limiter := &countingLimiter{}
+		r := build(t, limiter)
+
+		_, err := r.Invoke(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), limiter.waits.Load())
+
+		sr, err := r.Stream(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+		sr.Close()
+		assert.Equal(t, int32(4), limiter.waits.Load())
+	})
+
+	t.Run("wait error", func(t *testing.T) {
+		limiter := &countingLimiter{err: context.DeadlineExceeded}
+		_, err := build(t, limiter).Invoke(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int32(1), limiter.waits.Load())
+	})
+}
+
+func TestTokenBucketLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("burst", func(t *testing.T) {
+		l := NewTokenBucketLimiter(10, 3)
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, l.Wait(ctx))
+		}
+		assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+		// the 4th invocation waits for a token to be refilled, i.e. 100ms at 10 tokens per second
+		assert.NoError(t, l.Wait(ctx))
+		assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		l := NewTokenBucketLimiter(100, 5)
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, l.Wait(ctx))
+			}()
+		}
+		wg.Wait()
+		// 5 of them wait for tokens refilled at 10ms each
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		l := NewTokenBucketLimiter(1, 1)
+		assert.NoError(t, l.Wait(ctx))
+
+		cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, l.Wait(cctx), context.DeadlineExceeded)
+
+		// the token reserved by the cancelled waiting is given back
+		l.mu.Lock()
+		assert.Greater(t, l.tokens, -0.5)
+		l.mu.Unlock()
+
+		cancel()
+		assert.ErrorIs(t, l.Wait(cctx), context.DeadlineExceeded)
+	})
+}
@@ -29,6 +29,8 @@ import (
 // runnable is the core conception of eino, we do downgrade compatibility for four data flow patterns,
 // and can automatically connect components that only implement one or more methods.
 // eg, if a component only implements Stream() method, you can still call Invoke() to convert stream output to invoke output.
+// A compiled Runnable is safe for concurrent use, the channels, the state and the options of each run are allocated per call,
+// so it's expected to be compiled once and shared, as long as the components of its nodes are safe for concurrent use.
 type Runnable[I, O any] interface {
 	Invoke(ctx context.Context, input I, opts ...Option) (output O, err error)
 	Stream(ctx context.Context, input I, opts ...Option) (output *schema.StreamReader[O], err error)
@@ -338,6 +340,19 @@ func newRunnablePacker[I, O, TOption any](i Invoke[I, O, TOption], s Stream[I, O
 
 	r := &runnablePacker[I, O, TOption]{}
 
+	if i != nil {
+		i = invokeWithStop(i)
+	}
+	if s != nil {
+		s = streamWithStop(s)
+	}
+	if c != nil {
+		c = collectWithStop(c)
+	}
+	if t != nil {
+		t = transformWithStop(t)
+	}
+
 	if enableCallback {
 		if i != nil {
 			i = invokeWithCallbacks(i)
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrStop can be returned by a node to end the run of the graph successfully, with the output returned along with it
+// as the output of the graph, e.g. a lambda ending the conversation when the user says goodbye.
+// The error can be wrapped, and the output must be of the output type of the graph.
+// If the output is nil, the run fails instead, as there is no output to end with.
+// A tool can return ErrStop too, with its result as the content of its tool message,
+// and the ToolsNode stops with the tool messages of the tool calls that have succeeded so far.
+// Like EndWith, the nodes running concurrently are left to finish in the background,
+// and if a node of a subgraph stops, the node of the subgraph outputs the output.
+// e.g.
+//
+//	lambda := compose.InvokableLambda(func(ctx context.Context, msg *schema.Message) (*schema.Message, error) {
+//		if msg.Content == "goodbye" {
+//			return schema.AssistantMessage("see you", nil), compose.ErrStop
+//		}
+//		return msg, nil
+//	})
+var ErrStop = errors.New("stop")
+
+// stopSignal keeps the output returned along with ErrStop,
+// as the output is dropped by the error handling of the layers between the node and the runner.
+type stopSignal struct {
+	err    error
+	value  func() (any, error)
+	stream func() streamReader
+}
+
+func (s *stopSignal) Error() string {
+	return s.err.Error()
+}
+
+func (s *stopSignal) Unwrap() error {
+	return s.err
+}
+
+func needStopSignal[O any](err error, output O) bool {
+	if !errors.Is(err, ErrStop) || isNilOutput(output) {
+		return false
+	}
+	var s *stopSignal
+	return !errors.As(err, &s)
+}
+
+func isNilOutput[O any](output O) bool {
+	rv := reflect.ValueOf(&output).Elem()
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface, reflect.Chan, reflect.Func:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+func newValueStopSignal[O any](err error, output O) error {
+	return &stopSignal{
+		err: err,
+		value: func() (any, error) {
+			return output, nil
+		},
+		stream: func() streamReader {
+			return packStreamReader(schema.StreamReaderFromArray([]O{output}))
+		},
+	}
+}
+
+func newStreamStopSignal[O any](err error, output *schema.StreamReader[O]) error {
+	return &stopSignal{
+		err: err,
+		value: func() (any, error) {
+			v, err := concatStreamReader(output)
+			if err != nil {
+				if errors.Is(err, emptyStreamConcatErr) {
+					return nil, nil
+				}
+				return nil, err
+			}
+			return v, nil
+		},
+		stream: func() streamReader {
+			return packStreamReader(output)
+		},
+	}
+}
+
+func invokeWithStop[I, O, TOption any](i Invoke[I, O, TOption]) Invoke[I, O, TOption] {
+	return func(ctx context.Context, input I, opts ...TOption) (output O, err error) {
+		output, err = i(ctx, input, opts...)
+		if needStopSignal(err, output) {
+			return output, newValueStopSignal(err, output)
+		}
+		return output, err
+	}
+}
+
+func streamWithStop[I, O, TOption any](s Stream[I, O, TOption]) Stream[I, O, TOption] {
+	return func(ctx context.Context, input I, opts ...TOption) (output *schema.StreamReader[O], err error) {
+		output, err = s(ctx, input, opts...)
+		if needStopSignal(err, output) {
+			return output, newStreamStopSignal(err, output)
+		}
+		return output, err
+	}
+}
+
+func collectWithStop[I, O, TOption any](c Collect[I, O, TOption]) Collect[I, O, TOption] {
+	return func(ctx context.Context, input *schema.StreamReader[I], opts ...TOption) (output O, err error) {
+		output, err = c(ctx, input, opts...)
+		if needStopSignal(err, output) {
+			return output, newValueStopSignal(err, output)
+		}
+		return output, err
+	}
+}
+
+func transformWithStop[I, O, TOption any](t Transform[I, O, TOption]) Transform[I, O, TOption] {
+	return func(ctx context.Context, input *schema.StreamReader[I], opts ...TOption) (output *schema.StreamReader[O], err error) {
+		output, err = t(ctx, input, opts...)
+		if needStopSignal(err, output) {
+			return output, newStreamStopSignal(err, output)
+		}
+		return output, err
+	}
+}
+
+// getStopTask returns the first completed task which returned ErrStop.
+func getStopTask(completedTasks []*task) (*task, bool) {
+	for _, t := range completedTasks {
+		if t.err != nil && errors.Is(t.err, ErrStop) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// stopWith converts the output returned along with ErrStop into the output of the graph.
+func (r *runner) stopWith(nodeKey string, err error, isStream bool) (any, error) {
+	var s *stopSignal
+	if !errors.As(err, &s) {
+		return nil, fmt.Errorf("ErrStop returned without its output from node[%s]: %w", nodeKey, err)
+	}
+
+	if isStream {
+		return r.genericHelper.outputConverter.transform(s.stream()), nil
+	}
+	value, err := s.value()
+	if err != nil {
+		return nil, fmt.Errorf("stop with output fail: %w", err)
+	}
+	if value == nil {
+		return r.genericHelper.outputZeroValue(), nil
+	}
+	value, err = r.genericHelper.outputConverter.invoke(value)
+	if err != nil {
+		return nil, fmt.Errorf("stop with output fail: %w", err)
+	}
+	return value, nil
+}
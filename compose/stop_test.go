@@ -0,0 +1,171 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestErrStop(t *testing.T) {
+	ctx := context.Background()
+
+	newGraph := func(node *Lambda) *Graph[string, string] {
+		var afterRun bool
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("node", node))
+		assert.NoError(t, g.AddLambdaNode("after", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			afterRun = true
+			return in + " after", nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "node"))
+		assert.NoError(t, g.AddEdge("node", "after"))
+		assert.NoError(t, g.AddEdge("after", END))
+		t.Cleanup(func() {
+			assert.False(t, afterRun)
+		})
+		return g
+	}
+
+	goodbye := InvokableLambda(func(ctx context.Context, in string) (string, error) {
+		if in == "goodbye" {
+			return "see you", ErrStop
+		}
+		return in, nil
+	})
+
+	t.Run("invoke", func(t *testing.T) {
+		r, err := newGraph(goodbye).Compile(ctx)
+		assert.NoError(t, err)
+		out, err := r.Invoke(ctx, "goodbye")
+		assert.NoError(t, err)
+		assert.Equal(t, "see you", out)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		r, err := newGraph(goodbye).Compile(ctx)
+		assert.NoError(t, err)
+		sr, err := r.Stream(ctx, "goodbye")
+		assert.NoError(t, err)
+		out, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "see you", out)
+	})
+
+	t.Run("wrapped by streamable lambda", func(t *testing.T) {
+		node := StreamableLambda(func(ctx context.Context, in string) (*schema.StreamReader[string], error) {
+			return schema.StreamReaderFromArray([]string{"see ", "you"}), fmt.Errorf("user said %s: %w", in, ErrStop)
+		})
+		r, err := newGraph(node).Compile(ctx)
+		assert.NoError(t, err)
+		out, err := r.Invoke(ctx, "goodbye")
+		assert.NoError(t, err)
+		assert.Equal(t, "see you", out)
+	})
+
+	t.Run("in subgraph", func(t *testing.T) {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddGraphNode("sub", newGraph(goodbye)))
+		assert.NoError(t, g.AddLambdaNode("outer", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			return in + "!", nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "sub"))
+		assert.NoError(t, g.AddEdge("sub", "outer"))
+		assert.NoError(t, g.AddEdge("outer", END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+		out, err := r.Invoke(ctx, "goodbye")
+		assert.NoError(t, err)
+		assert.Equal(t, "see you!", out)
+	})
+
+	t.Run("unexpected output type", func(t *testing.T) {
+		g := NewGraph[string, int]()
+		assert.NoError(t, g.AddLambdaNode("node", goodbye))
+		assert.NoError(t, g.AddLambdaNode("count", InvokableLambda(func(ctx context.Context, in string) (int, error) {
+			return len(in), nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "node"))
+		assert.NoError(t, g.AddEdge("node", "count"))
+		assert.NoError(t, g.AddEdge("count", END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+		_, err = r.Invoke(ctx, "goodbye")
+		assert.ErrorContains(t, err, "stop with output fail")
+		assert.False(t, errors.Is(err, ErrStop))
+	})
+	t.Run("without output", func(t *testing.T) {
+		node := InvokableLambda(func(ctx context.Context, in string) (*schema.Message, error) {
+			return nil, ErrStop
+		})
+		g := NewGraph[string, *schema.Message]()
+		assert.NoError(t, g.AddLambdaNode("node", node))
+		assert.NoError(t, g.AddEdge(START, "node"))
+		assert.NoError(t, g.AddEdge("node", END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+		out, err := r.Invoke(ctx, "goodbye")
+		assert.ErrorContains(t, err, "ErrStop returned without its output from node[node]")
+		assert.Nil(t, out)
+	})
+
+	t.Run("returned by tool", func(t *testing.T) {
+		type goodbyeReq struct {
+			Name string `json:"name"`
+		}
+		goodbyeTool := newTool(&schema.ToolInfo{Name: "say_goodbye"}, func(ctx context.Context, in *goodbyeReq) (string, error) {
+			return "see you, " + in.Name, ErrStop
+		})
+		greetTool := newTool(&schema.ToolInfo{Name: "greet"}, func(ctx context.Context, in *goodbyeReq) (string, error) {
+			return "hello, " + in.Name, nil
+		})
+		toolsNode, err := NewToolNode(ctx, &ToolsNodeConfig{Tools: []tool.BaseTool{greetTool, goodbyeTool}})
+		assert.NoError(t, err)
+
+		g := NewGraph[*schema.Message, []*schema.Message]()
+		assert.NoError(t, g.AddToolsNode("tools", toolsNode))
+		assert.NoError(t, g.AddEdge(START, "tools"))
+		assert.NoError(t, g.AddEdge("tools", END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		input := schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "call_1", Function: schema.FunctionCall{Name: "greet", Arguments: `{"name":"eino"}`}},
+			{ID: "call_2", Function: schema.FunctionCall{Name: "say_goodbye", Arguments: `{"name":"eino"}`}},
+		})
+		expected := []*schema.Message{
+			schema.ToolMessage(`"hello, eino"`, "call_1", schema.WithToolName("greet")),
+			schema.ToolMessage(`"see you, eino"`, "call_2", schema.WithToolName("say_goodbye")),
+		}
+		out, err := r.Invoke(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out)
+
+		sr, err := r.Stream(ctx, input)
+		assert.NoError(t, err)
+		out, err = concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out)
+	})
+}
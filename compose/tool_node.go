@@ -18,10 +18,16 @@ package compose
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"runtime/debug"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/cloudwego/eino/callbacks"
 	"github.com/cloudwego/eino/components"
@@ -60,13 +66,28 @@ func WithToolList(tool ...tool.BaseTool) ToolsNodeOption {
 //
 // Input: An AssistantMessage containing ToolCalls
 // Output: An array of ToolMessage where the order of elements corresponds to the order of ToolCalls in the input
+//
+// Each tool call runs with a child context of the node, which is cancelled once the tool call returns
+// (or its output stream ends or is closed), or once another tool call fails the ToolsNode.
+// Tools must respect ctx and release what they started, e.g. by exec.CommandContext for external commands,
+// otherwise utils.WrapToolWithCancellation could be used to return on cancellation regardless of the tool.
 type ToolsNode struct {
+	mu                        sync.RWMutex
+	registerMu                sync.Mutex
 	tuple                     *toolsTuple
+	onToolsChange             func(ctx context.Context, tools []*schema.ToolInfo)
 	unknownToolHandler        func(ctx context.Context, name, input string) (string, error)
 	executeSequentially       bool
 	toolArgumentsHandler      func(ctx context.Context, name, input string) (string, error)
 	toolCallMiddlewares       []InvokableToolMiddleware
 	streamToolCallMiddlewares []StreamableToolMiddleware
+	preserveTypedResults      bool
+	continueOnToolError       bool
+	onUnknownTool             UnknownToolMode
+	resultFormatter           func(toolName string, result any) (string, error)
+	preferStreamable          bool
+	maxResultBytes            int
+	beforeToolCall            func(ctx context.Context, toolName string, args json.RawMessage) error
 }
 
 // ToolInput represents the input parameters for a tool call execution.
@@ -136,6 +157,12 @@ type ToolsNodeConfig struct {
 	//   - error: Any error that occurred during handling
 	UnknownToolsHandler func(ctx context.Context, name, input string) (string, error)
 
+	// OnUnknownTool determines how tool calls for non-existent tools are handled when UnknownToolsHandler is not set.
+	// UnknownToolError (default) fails the ToolsNode with ErrToolNotFound.
+	// UnknownToolFeedback answers the hallucinated tool call with a tool message, which tells the tool doesn't exist
+	// and lists the available tools, so that the model can retry in the next turn rather than aborting the graph.
+	OnUnknownTool UnknownToolMode
+
 	// ExecuteSequentially determines whether tool calls should be executed sequentially (in order) or in parallel.
 	// When set to true, tool calls will be executed one after another in the order they appear in the input message.
 	// When set to false (default), tool calls will be executed in parallel.
@@ -157,8 +184,67 @@ type ToolsNodeConfig struct {
 	// Invokable middleware only applies to tools implementing InvokableTool interface.
 	// Streamable middleware only applies to tools implementing StreamableTool interface.
 	ToolCallMiddlewares []ToolMiddleware
+
+	// PreserveTypedResults attaches the raw Go value of each tool result to the output tool message,
+	// so that downstream nodes can consume it by GetTypedToolResult without re-parsing the result string.
+	// Only tools that report the value by SetTypedToolResult, such as the ones created by utils.InferTool, have typed results.
+	// NOTE: typed results are kept in memory only, they are not restored for tools already executed before an interrupt and rerun.
+	PreserveTypedResults bool
+
+	// ContinueOnToolError determines whether a failed tool call fails the whole ToolsNode.
+	// When set to true, the failed tool call produces a tool message describing the failure, including the tool name and the error,
+	// while the results of the other tool calls are returned as usual, so that the model can see both the successes and the failures.
+	// The tool message of the failure is flagged by schema.WithToolError, which is told by schema.IsToolError.
+	// When set to false (default), any failed tool call fails the ToolsNode.
+	// NOTE: interrupt and rerun errors are always returned as is.
+	ContinueOnToolError bool
+
+	// ResultFormatter formats the result of each tool call into the content of the tool message,
+	// e.g. to truncate a long file content, or to pretty-print a struct.
+	// The result is the raw Go value reported by SetTypedToolResult if any, such as the output of a tool created by utils.InferTool,
+	// otherwise it's the result string of the tool, which is fully received before formatting for a streamable tool.
+	// A failed formatting produces a tool message describing the failure instead of failing the ToolsNode.
+	// It's called concurrently when tool calls are executed in parallel.
+	// Optional. By default, the result string of the tool, which is JSON marshaled for the tools created by utils.InferTool, is used as is.
+	ResultFormatter func(toolName string, result any) (string, error)
+
+	// OnToolsChange is called with the infos of all the tools, in the order they are registered,
+	// after a tool is added by ToolsNode.RegisterTool, e.g. to update the tools advertised to the chat model,
+	// which should be bound to the same tools as the ToolsNode.
+	// Optional.
+	OnToolsChange func(ctx context.Context, tools []*schema.ToolInfo)
+
+	// MaxResultBytes caps the size of the result of each tool call in bytes, e.g. to keep a tool reading a large file from blowing the context window.
+	// The result exceeding it is cut at a UTF-8 character boundary, followed by a marker like "…[truncated 1024 bytes]", which is not counted in the cap.
+	// It applies after ResultFormatter, and the result of a streamable tool is fully received before truncation.
+	// Optional. By default, results are not truncated.
+	MaxResultBytes int
+
+	// PreferStreamableTools runs the tools implementing both InvokableTool and StreamableTool by StreamableRun in Invoke mode as well,
+	// so that their partial results are observable while they run, e.g. by the callbacks of the tools.
+	// The output stream is fully received and concatenated into the tool message.
+	// When set to false (default), such tools are run by InvokableRun in Invoke mode.
+	PreferStreamableTools bool
+
+	// BeforeToolCall is called before each tool call executes, e.g. to check the permission of the call by its arguments.
+	// Returning an error denies the call: the tool isn't executed, and the tool call is answered with a tool message
+	// telling the denial and the error, which is flagged by schema.WithToolError, so that the model can see why and adjust.
+	// Returning an interrupt error, e.g. by Interrupt, interrupts the ToolsNode as the tool itself does.
+	// It's called concurrently when tool calls are executed in parallel, and isn't called for the tool calls restored from an interrupt.
+	// Optional.
+	BeforeToolCall func(ctx context.Context, toolName string, args json.RawMessage) error
 }
 
+// UnknownToolMode is the way ToolsNode handles tool calls for non-existent tools, see ToolsNodeConfig.OnUnknownTool.
+type UnknownToolMode uint8
+
+const (
+	// UnknownToolError fails the ToolsNode with ErrToolNotFound.
+	UnknownToolError UnknownToolMode = iota
+	// UnknownToolFeedback answers the tool call with a tool message telling the tool doesn't exist and listing the available tools.
+	UnknownToolFeedback
+)
+
 // NewToolNode creates a new ToolsNode.
 // e.g.
 //
@@ -178,7 +264,7 @@ func NewToolNode(ctx context.Context, conf *ToolsNodeConfig) (*ToolsNode, error)
 		}
 	}
 
-	tuple, err := convTools(ctx, conf.Tools, middlewares, streamMiddlewares)
+	tuple, err := convTools(ctx, conf.Tools, middlewares, streamMiddlewares, conf.PreferStreamableTools)
 	if err != nil {
 		return nil, err
 	}
@@ -190,9 +276,60 @@ func NewToolNode(ctx context.Context, conf *ToolsNodeConfig) (*ToolsNode, error)
 		toolArgumentsHandler:      conf.ToolArgumentsHandler,
 		toolCallMiddlewares:       middlewares,
 		streamToolCallMiddlewares: streamMiddlewares,
+		preserveTypedResults:      conf.PreserveTypedResults,
+		continueOnToolError:       conf.ContinueOnToolError,
+		onUnknownTool:             conf.OnUnknownTool,
+		resultFormatter:           conf.ResultFormatter,
+		onToolsChange:             conf.OnToolsChange,
+		preferStreamable:          conf.PreferStreamableTools,
+		maxResultBytes:            conf.MaxResultBytes,
+		beforeToolCall:            conf.BeforeToolCall,
 	}, nil
 }
 
+// RegisterTool adds a tool to the ToolsNode, which is callable from the next invocation on, without recompiling the graph,
+// e.g. for the tools discovered at runtime from an MCP server. It's safe to call concurrently with the invocations.
+// After the tool is added, ToolsNodeConfig.OnToolsChange is called, so that the chat model can be told about the new tool.
+// OnToolsChange isn't called concurrently.
+// NOTE: an invocation already running when the tool is added keeps using the tools at its start,
+// while the tools are updated before the chat model is, so that the model never calls a tool unknown to the ToolsNode.
+func (tn *ToolsNode) RegisterTool(ctx context.Context, t tool.BaseTool) error {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get info of the tool to register: %w", err)
+	}
+
+	// registrations are serialized until OnToolsChange returns, so that it's called in the order the tools are registered.
+	tn.registerMu.Lock()
+	defer tn.registerMu.Unlock()
+
+	current := tn.getTuple()
+	if _, ok := current.indexes[info.Name]; ok {
+		return fmt.Errorf("tool[%s] is already registered", info.Name)
+	}
+	tools := make([]tool.BaseTool, 0, len(current.tools)+1)
+	tools = append(append(tools, current.tools...), t)
+	tuple, err := convTools(ctx, tools, tn.toolCallMiddlewares, tn.streamToolCallMiddlewares, tn.preferStreamable)
+	if err != nil {
+		return err
+	}
+
+	tn.mu.Lock()
+	tn.tuple = tuple
+	tn.mu.Unlock()
+
+	if tn.onToolsChange != nil {
+		tn.onToolsChange(ctx, append([]*schema.ToolInfo{}, tuple.infos...))
+	}
+	return nil
+}
+
+func (tn *ToolsNode) getTuple() *toolsTuple {
+	tn.mu.RLock()
+	defer tn.mu.RUnlock()
+	return tn.tuple
+}
+
 // ToolsInterruptAndRerunExtra carries interrupt metadata for ToolsNode reruns.
 type ToolsInterruptAndRerunExtra struct {
 	ToolCalls     []schema.ToolCall
@@ -213,14 +350,19 @@ type toolsInterruptAndRerunState struct {
 }
 
 type toolsTuple struct {
+	tools           []tool.BaseTool
+	infos           []*schema.ToolInfo
 	indexes         map[string]int
 	meta            []*executorMeta
 	endpoints       []InvokableToolEndpoint
 	streamEndpoints []StreamableToolEndpoint
 }
 
-func convTools(ctx context.Context, tools []tool.BaseTool, ms []InvokableToolMiddleware, sms []StreamableToolMiddleware) (*toolsTuple, error) {
+func convTools(ctx context.Context, tools []tool.BaseTool, ms []InvokableToolMiddleware, sms []StreamableToolMiddleware,
+	preferStreamable bool) (*toolsTuple, error) {
 	ret := &toolsTuple{
+		tools:           tools,
+		infos:           make([]*schema.ToolInfo, len(tools)),
 		indexes:         make(map[string]int),
 		meta:            make([]*executorMeta, len(tools)),
 		endpoints:       make([]InvokableToolEndpoint, len(tools)),
@@ -261,11 +403,12 @@ func convTools(ctx context.Context, tools []tool.BaseTool, ms []InvokableToolMid
 		if streamable == nil {
 			streamable = invokableToStreamable(invokable)
 		}
-		if invokable == nil {
+		if invokable == nil || (preferStreamable && st != nil) {
 			invokable = streamableToInvokable(streamable)
 		}
 
 		ret.indexes[toolName] = idx
+		ret.infos[idx] = tl
 		ret.meta[idx] = meta
 		ret.endpoints[idx] = invokable
 		ret.streamEndpoints[idx] = streamable
@@ -286,6 +429,10 @@ func wrapToolCall(it tool.InvokableTool, middlewares []InvokableToolMiddleware,
 	return middleware(func(ctx context.Context, input *ToolInput) (*ToolOutput, error) {
 		result, err := it.InvokableRun(ctx, input.Arguments, input.CallOptions...)
 		if err != nil {
+			if errors.Is(err, ErrStop) {
+				// the result returned along with ErrStop is the tool message the ToolsNode stops with
+				return &ToolOutput{Result: result}, err
+			}
 			return nil, err
 		}
 		return &ToolOutput{Result: result}, nil
@@ -305,6 +452,9 @@ func wrapStreamToolCall(st tool.StreamableTool, middlewares []StreamableToolMidd
 	return middleware(func(ctx context.Context, input *ToolInput) (*StreamToolOutput, error) {
 		result, err := st.StreamableRun(ctx, input.Arguments, input.CallOptions...)
 		if err != nil {
+			if errors.Is(err, ErrStop) && result != nil {
+				return &StreamToolOutput{Result: result}, err
+			}
 			return nil, err
 		}
 		return &StreamToolOutput{Result: result}, nil
@@ -339,6 +489,13 @@ func streamableToInvokable(e StreamableToolEndpoint) InvokableToolEndpoint {
 	return func(ctx context.Context, input *ToolInput) (*ToolOutput, error) {
 		so, err := e(ctx, input)
 		if err != nil {
+			if errors.Is(err, ErrStop) && so != nil && so.Result != nil {
+				o, cErr := concatStreamReader(so.Result)
+				if cErr != nil {
+					return nil, fmt.Errorf("failed to concat StreamableTool output message stream: %w", cErr)
+				}
+				return &ToolOutput{Result: o}, err
+			}
 			return nil, err
 		}
 		o, err := concatStreamReader(so.Result)
@@ -353,6 +510,9 @@ func invokableToStreamable(e InvokableToolEndpoint) StreamableToolEndpoint {
 	return func(ctx context.Context, input *ToolInput) (*StreamToolOutput, error) {
 		o, err := e(ctx, input)
 		if err != nil {
+			if errors.Is(err, ErrStop) && o != nil {
+				return &StreamToolOutput{Result: schema.StreamReaderFromArray([]string{o.Result})}, err
+			}
 			return nil, err
 		}
 		return &StreamToolOutput{Result: schema.StreamReaderFromArray([]string{o.Result})}, nil
@@ -373,6 +533,12 @@ type toolCallTask struct {
 	output   string
 	sOutput  *schema.StreamReader[string]
 	err      error
+	info     *toolCallInfo
+	// failed indicates the output describes the failure of the tool call, when ContinueOnToolError is set,
+	// or the denial of the tool call by BeforeToolCall.
+	failed bool
+	// stopped indicates the tool returned ErrStop along with the output.
+	stopped bool
 }
 
 func (tn *ToolsNode) genToolCallTasks(ctx context.Context, tuple *toolsTuple,
@@ -405,10 +571,14 @@ func (tn *ToolsNode) genToolCallTasks(ctx context.Context, tuple *toolsTuple,
 		}
 		index, ok := tuple.indexes[toolCall.Function.Name]
 		if !ok {
-			if tn.unknownToolHandler == nil {
-				return nil, fmt.Errorf("tool %s not found in toolsNode indexes", toolCall.Function.Name)
+			handler := tn.unknownToolHandler
+			if handler == nil && tn.onUnknownTool == UnknownToolFeedback {
+				handler = unknownToolFeedback(tuple)
 			}
-			toolCallTasks[i] = newUnknownToolTask(toolCall.Function.Name, toolCall.Function.Arguments, toolCall.ID, tn.unknownToolHandler)
+			if handler == nil {
+				return nil, fmt.Errorf("%w in toolsNode indexes, name=%s", ErrToolNotFound, toolCall.Function.Name)
+			}
+			toolCallTasks[i] = newUnknownToolTask(toolCall.Function.Name, toolCall.Function.Arguments, toolCall.ID, handler)
 		} else {
 			toolCallTasks[i].endpoint = tuple.endpoints[index]
 			toolCallTasks[i].streamEndpoint = tuple.streamEndpoints[index]
@@ -425,11 +595,32 @@ func (tn *ToolsNode) genToolCallTasks(ctx context.Context, tuple *toolsTuple,
 				toolCallTasks[i].arg = toolCall.Function.Arguments
 			}
 		}
+		toolCallTasks[i].info = &toolCallInfo{
+			toolCallID:          toolCall.ID,
+			preserveTypedResult: tn.preserveTypedResults,
+			formatTypedResult:   tn.resultFormatter != nil,
+		}
 	}
 
 	return toolCallTasks, nil
 }
 
+// unknownToolFeedback returns the unknown tool handler of UnknownToolFeedback, which lists the tools of tuple in the order they are registered.
+func unknownToolFeedback(tuple *toolsTuple) func(ctx context.Context, name, input string) (string, error) {
+	names := make([]string, 0, len(tuple.indexes))
+	for name := range tuple.indexes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return tuple.indexes[names[i]] < tuple.indexes[names[j]]
+	})
+
+	return func(ctx context.Context, name, input string) (string, error) {
+		return fmt.Sprintf("tool %s does not exist, available tools: [%s], please call one of the available tools instead",
+			name, strings.Join(names, ", ")), nil
+	}
+}
+
 func newUnknownToolTask(name, arg, callID string, unknownToolHandler func(ctx context.Context, name, input string) (string, error)) toolCallTask {
 	endpoint := func(ctx context.Context, input *ToolInput) (*ToolOutput, error) {
 		result, err := unknownToolHandler(ctx, input.Name, input.Arguments)
@@ -464,16 +655,28 @@ func runToolCallTaskByInvoke(ctx context.Context, task *toolCallTask, opts ...to
 		Component: task.meta.component,
 	})
 
-	ctx = setToolCallInfo(ctx, &toolCallInfo{toolCallID: task.callID})
+	// each tool call runs with its own child context, which is cancelled once the call returns,
+	// so that anything started by the tool with the context, e.g. an external command, is cleaned up.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ctx = setToolCallInfo(ctx, task.info)
 	ctx = appendToolAddressSegment(ctx, task.name, task.callID)
+	logger, start := getLogger(ctx), time.Now()
+	logger.Debugf("tool[%s] start, call id: %s, arguments: %s", task.name, task.callID, task.arg)
 	output, err := task.endpoint(ctx, &ToolInput{
 		Name:        task.name,
 		Arguments:   task.arg,
 		CallID:      task.callID,
 		CallOptions: opts,
 	})
+	logToolCallEnd(logger, task, start, err)
 	if err != nil {
 		task.err = err
+		if errors.Is(err, ErrStop) && output != nil {
+			task.output = output.Result
+			task.stopped = true
+		}
 	} else {
 		task.output = output.Result
 		task.executed = true
@@ -487,22 +690,217 @@ func runToolCallTaskByStream(ctx context.Context, task *toolCallTask, opts ...to
 		Component: task.meta.component,
 	})
 
-	ctx = setToolCallInfo(ctx, &toolCallInfo{toolCallID: task.callID})
+	// the child context of a streamed tool call lives until its output stream ends or is closed.
+	ctx, cancel := context.WithCancel(ctx)
+
+	ctx = setToolCallInfo(ctx, task.info)
 	ctx = appendToolAddressSegment(ctx, task.name, task.callID)
+	logger, start := getLogger(ctx), time.Now()
+	logger.Debugf("tool[%s] start, call id: %s, arguments: %s", task.name, task.callID, task.arg)
 	output, err := task.streamEndpoint(ctx, &ToolInput{
 		Name:        task.name,
 		Arguments:   task.arg,
 		CallID:      task.callID,
 		CallOptions: opts,
 	})
+	logToolCallEnd(logger, task, start, err)
 	if err != nil {
 		task.err = err
+		if errors.Is(err, ErrStop) && output != nil && output.Result != nil {
+			task.sOutput = cancelOnStreamEnd(output.Result, cancel)
+			task.stopped = true
+		} else {
+			cancel()
+		}
 	} else {
-		task.sOutput = output.Result
+		task.sOutput = cancelOnStreamEnd(output.Result, cancel)
 		task.executed = true
 	}
 }
 
+func logToolCallEnd(logger Logger, task *toolCallTask, start time.Time, err error) {
+	switch {
+	case err == nil:
+		logger.Debugf("tool[%s] end, call id: %s, cost: %s", task.name, task.callID, time.Since(start))
+	case isInterruptError(err):
+		logger.Infof("tool[%s] interrupted, call id: %s, cost: %s", task.name, task.callID, time.Since(start))
+	default:
+		logger.Errorf("tool[%s] failed, call id: %s, cost: %s, error: %v", task.name, task.callID, time.Since(start), err)
+	}
+}
+
+// cancelOnStreamEnd forwards the chunks of sr, and calls cancel once sr ends or the returned reader is closed.
+func cancelOnStreamEnd[T any](sr *schema.StreamReader[T], cancel context.CancelFunc) *schema.StreamReader[T] {
+	out, sw := schema.Pipe[T](0)
+	go func() {
+		defer func() {
+			sr.Close()
+			sw.Close()
+			cancel()
+		}()
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if closed := sw.Send(chunk, err); closed {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// closeToolOutputs closes the unconsumed output streams of tool calls, which cancels their contexts.
+func closeToolOutputs(tasks []toolCallTask) {
+	for i := range tasks {
+		if tasks[i].sOutput != nil {
+			tasks[i].sOutput.Close()
+		}
+	}
+}
+
+// formatResult wraps run to format the result of the tool call by ResultFormatter once it succeeds.
+// the results of the tool calls restored from an interrupt have been formatted before.
+func (tn *ToolsNode) formatResult(run func(ctx context.Context, task *toolCallTask, opts ...tool.Option),
+	isStream bool) func(ctx context.Context, task *toolCallTask, opts ...tool.Option) {
+
+	if tn.resultFormatter == nil {
+		return run
+	}
+
+	return func(ctx context.Context, task *toolCallTask, opts ...tool.Option) {
+		restored := task.executed
+		run(ctx, task, opts...)
+		if restored || task.err != nil {
+			return
+		}
+
+		output := task.output
+		if isStream {
+			var err error
+			if output, err = concatStreamReader(task.sOutput); err != nil {
+				task.err = err
+				task.sOutput = nil
+				task.executed = false
+				return
+			}
+		}
+
+		var result any = output
+		if task.info != nil && task.info.hasTypedResult {
+			result = task.info.typedResult
+		}
+		formatted, err := tn.resultFormatter(task.name, result)
+		if err != nil {
+			formatted = fmt.Sprintf("failed to format result of tool[name:%s]: %v", task.name, err)
+		}
+
+		if isStream {
+			task.sOutput = schema.StreamReaderFromArray([]string{formatted})
+		} else {
+			task.output = formatted
+		}
+	}
+}
+
+// checkPermission wraps run to call BeforeToolCall before the tool call executes,
+// a denied tool call isn't executed, but answered with the denial as if it's the result of the tool.
+func (tn *ToolsNode) checkPermission(run func(ctx context.Context, task *toolCallTask, opts ...tool.Option),
+	isStream bool) func(ctx context.Context, task *toolCallTask, opts ...tool.Option) {
+
+	if tn.beforeToolCall == nil {
+		return run
+	}
+
+	return func(ctx context.Context, task *toolCallTask, opts ...tool.Option) {
+		if task.executed {
+			run(ctx, task, opts...)
+			return
+		}
+
+		err := tn.beforeToolCall(ctx, task.name, json.RawMessage(task.arg))
+		if err == nil {
+			run(ctx, task, opts...)
+			return
+		}
+		if isInterruptError(err) {
+			task.err = err
+			return
+		}
+
+		denial := fmt.Sprintf("call of tool[name:%s] is denied: %v", task.name, err)
+		if isStream {
+			task.sOutput = schema.StreamReaderFromArray([]string{denial})
+		} else {
+			task.output = denial
+		}
+		task.executed = true
+		task.failed = true
+	}
+}
+
+// truncateResult wraps run to truncate the result of the tool call to MaxResultBytes once it succeeds.
+// the results of the tool calls restored from an interrupt have been truncated before.
+func (tn *ToolsNode) truncateResult(run func(ctx context.Context, task *toolCallTask, opts ...tool.Option),
+	isStream bool) func(ctx context.Context, task *toolCallTask, opts ...tool.Option) {
+
+	if tn.maxResultBytes <= 0 {
+		return run
+	}
+
+	return func(ctx context.Context, task *toolCallTask, opts ...tool.Option) {
+		restored := task.executed
+		run(ctx, task, opts...)
+		if restored || task.err != nil {
+			return
+		}
+
+		if !isStream {
+			task.output = truncateUTF8(task.output, tn.maxResultBytes)
+			return
+		}
+
+		output, err := concatStreamReader(task.sOutput)
+		if err != nil {
+			task.err = err
+			task.sOutput = nil
+			task.executed = false
+			return
+		}
+		task.sOutput = schema.StreamReaderFromArray([]string{truncateUTF8(output, tn.maxResultBytes)})
+	}
+}
+
+// truncateUTF8 cuts s to at most maxBytes bytes without splitting a UTF-8 character, followed by a marker telling the bytes truncated.
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	n := maxBytes
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s…[truncated %d bytes]", s[:n], len(s)-n)
+}
+
+// abortOnError wraps run to abort the other tool calls once a tool call fails with an error which fails the ToolsNode.
+func (tn *ToolsNode) abortOnError(run func(ctx context.Context, task *toolCallTask, opts ...tool.Option),
+	abort context.CancelFunc) func(ctx context.Context, task *toolCallTask, opts ...tool.Option) {
+
+	return func(ctx context.Context, task *toolCallTask, opts ...tool.Option) {
+		run(ctx, task, opts...)
+		// a tool stopping the run doesn't fail the other tool calls, whose results are returned along with it
+		if task.err == nil || tn.continueOnToolError || errors.Is(task.err, ErrStop) {
+			return
+		}
+		if _, ok := IsInterruptRerunError(task.err); ok {
+			return
+		}
+		abort()
+	}
+}
+
 func sequentialRunToolCall(ctx context.Context,
 	run func(ctx2 context.Context, callTask *toolCallTask, opts ...tool.Option),
 	tasks []toolCallTask, opts ...tool.Option) {
@@ -555,10 +953,10 @@ func (tn *ToolsNode) Invoke(ctx context.Context, input *schema.Message,
 	opts ...ToolsNodeOption) ([]*schema.Message, error) {
 
 	opt := getToolsNodeOptions(opts...)
-	tuple := tn.tuple
+	tuple := tn.getTuple()
 	if opt.ToolList != nil {
 		var err error
-		tuple, err = convTools(ctx, opt.ToolList, tn.toolCallMiddlewares, tn.streamToolCallMiddlewares)
+		tuple, err = convTools(ctx, opt.ToolList, tn.toolCallMiddlewares, tn.streamToolCallMiddlewares, tn.preferStreamable)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert tool list from call option: %w", err)
 		}
@@ -577,10 +975,19 @@ func (tn *ToolsNode) Invoke(ctx context.Context, input *schema.Message,
 		return nil, err
 	}
 
+	// tool calls still running are aborted once one of them fails the ToolsNode
+	runCtx, abort := context.WithCancel(ctx)
+	defer abort()
+	run := tn.abortOnError(tn.checkPermission(tn.truncateResult(tn.formatResult(runToolCallTaskByInvoke, false), false), false), abort)
+
 	if tn.executeSequentially {
-		sequentialRunToolCall(ctx, runToolCallTaskByInvoke, tasks, opt.ToolOptions...)
+		sequentialRunToolCall(runCtx, run, tasks, opt.ToolOptions...)
 	} else {
-		parallelRunToolCall(ctx, runToolCallTaskByInvoke, tasks, opt.ToolOptions...)
+		parallelRunToolCall(runCtx, run, tasks, opt.ToolOptions...)
+	}
+
+	if stop, ok := getStopToolCall(tasks); ok {
+		return stopMessages(tasks), fmt.Errorf("tool[name:%s id:%s] stops: %w", stop.name, stop.callID, stop.err)
 	}
 
 	n := len(tasks)
@@ -600,6 +1007,12 @@ func (tn *ToolsNode) Invoke(ctx context.Context, input *schema.Message,
 	for i := 0; i < n; i++ {
 		if tasks[i].err != nil {
 			info, ok := IsInterruptRerunError(tasks[i].err)
+			if !ok && tn.continueOnToolError {
+				if len(errs) == 0 {
+					output[i] = schema.ToolMessage(toolErrorContent(&tasks[i]), tasks[i].callID, schema.WithToolName(tasks[i].name), schema.WithToolError())
+				}
+				continue
+			}
 			if !ok {
 				return nil, fmt.Errorf("failed to invoke tool[name:%s id:%s]: %w", tasks[i].name, tasks[i].callID, tasks[i].err)
 			}
@@ -620,7 +1033,12 @@ func (tn *ToolsNode) Invoke(ctx context.Context, input *schema.Message,
 			rerunState.ExecutedTools[tasks[i].callID] = tasks[i].output
 		}
 		if len(errs) == 0 {
-			output[i] = schema.ToolMessage(tasks[i].output, tasks[i].callID, schema.WithToolName(tasks[i].name))
+			msgOpts := []schema.ToolMessageOption{schema.WithToolName(tasks[i].name)}
+			if tasks[i].failed {
+				msgOpts = append(msgOpts, schema.WithToolError())
+			}
+			output[i] = schema.ToolMessage(tasks[i].output, tasks[i].callID, msgOpts...)
+			tasks[i].attachTypedResult(output[i])
 		}
 	}
 	if len(errs) > 0 {
@@ -636,10 +1054,10 @@ func (tn *ToolsNode) Stream(ctx context.Context, input *schema.Message,
 	opts ...ToolsNodeOption) (*schema.StreamReader[[]*schema.Message], error) {
 
 	opt := getToolsNodeOptions(opts...)
-	tuple := tn.tuple
+	tuple := tn.getTuple()
 	if opt.ToolList != nil {
 		var err error
-		tuple, err = convTools(ctx, opt.ToolList, tn.toolCallMiddlewares, tn.streamToolCallMiddlewares)
+		tuple, err = convTools(ctx, opt.ToolList, tn.toolCallMiddlewares, tn.streamToolCallMiddlewares, tn.preferStreamable)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert tool list from call option: %w", err)
 		}
@@ -658,10 +1076,25 @@ func (tn *ToolsNode) Stream(ctx context.Context, input *schema.Message,
 		return nil, err
 	}
 
+	run := tn.checkPermission(tn.truncateResult(tn.formatResult(runToolCallTaskByStream, true), true), true)
 	if tn.executeSequentially {
-		sequentialRunToolCall(ctx, runToolCallTaskByStream, tasks, opt.ToolOptions...)
+		sequentialRunToolCall(ctx, run, tasks, opt.ToolOptions...)
 	} else {
-		parallelRunToolCall(ctx, runToolCallTaskByStream, tasks, opt.ToolOptions...)
+		parallelRunToolCall(ctx, run, tasks, opt.ToolOptions...)
+	}
+
+	if stop, ok := getStopToolCall(tasks); ok {
+		var stopTasks []toolCallTask
+		for i := range tasks {
+			if tasks[i].err == nil || tasks[i].stopped {
+				stopTasks = append(stopTasks, tasks[i])
+			}
+		}
+		err = fmt.Errorf("tool[name:%s id:%s] stops: %w", stop.name, stop.callID, stop.err)
+		if len(stopTasks) == 0 {
+			return schema.StreamReaderFromArray([][]*schema.Message{{}}), err
+		}
+		return toolMessageStream(stopTasks), err
 	}
 
 	n := len(tasks)
@@ -680,7 +1113,14 @@ func (tn *ToolsNode) Stream(ctx context.Context, input *schema.Message,
 	for i := 0; i < n; i++ {
 		if tasks[i].err != nil {
 			info, ok := IsInterruptRerunError(tasks[i].err)
+			if !ok && tn.continueOnToolError {
+				tasks[i].sOutput = schema.StreamReaderFromArray([]string{toolErrorContent(&tasks[i])})
+				tasks[i].err = nil
+				tasks[i].failed = true
+				continue
+			}
 			if !ok {
+				closeToolOutputs(tasks)
 				return nil, fmt.Errorf("failed to stream tool call %s: %w", tasks[i].callID, tasks[i].err)
 			}
 
@@ -698,10 +1138,11 @@ func (tn *ToolsNode) Stream(ctx context.Context, input *schema.Message,
 
 	if len(errs) > 0 {
 		// concat and save tool output
-		for _, t := range tasks {
+		for i, t := range tasks {
 			if t.executed {
 				o, err_ := concatStreamReader(t.sOutput)
 				if err_ != nil {
+					closeToolOutputs(tasks[i+1:])
 					return nil, fmt.Errorf("failed to concat tool[name:%s id:%s]'s stream output: %w", t.name, t.callID, err_)
 				}
 				rerunExtra.ExecutedTools[t.callID] = o
@@ -711,22 +1152,69 @@ func (tn *ToolsNode) Stream(ctx context.Context, input *schema.Message,
 		return nil, CompositeInterrupt(ctx, rerunExtra, rerunState, errs...)
 	}
 
-	// common return
+	return toolMessageStream(tasks), nil
+}
+
+// toolMessageStream merges the output streams of the tasks into a stream of tool messages, one per task.
+func toolMessageStream(tasks []toolCallTask) *schema.StreamReader[[]*schema.Message] {
+	n := len(tasks)
 	sOutput := make([]*schema.StreamReader[[]*schema.Message], n)
 	for i := 0; i < n; i++ {
 		index := i
-		callID := tasks[i].callID
-		callName := tasks[i].name
+		task := &tasks[i]
+		first := true
 		cvt := func(s string) ([]*schema.Message, error) {
 			ret := make([]*schema.Message, n)
-			ret[index] = schema.ToolMessage(s, callID, schema.WithToolName(callName))
+			// typed result and error flag are attached to the first chunk only, so that they're kept as is when chunks are concatenated
+			if first {
+				opts := []schema.ToolMessageOption{schema.WithToolName(task.name)}
+				if task.failed {
+					opts = append(opts, schema.WithToolError())
+				}
+				ret[index] = schema.ToolMessage(s, task.callID, opts...)
+				task.attachTypedResult(ret[index])
+				first = false
+			} else {
+				ret[index] = schema.ToolMessage(s, task.callID, schema.WithToolName(task.name))
+			}
 
 			return ret, nil
 		}
 
 		sOutput[i] = schema.StreamReaderWithConvert(tasks[i].sOutput, cvt)
 	}
-	return schema.MergeStreamReaders(sOutput), nil
+	return schema.MergeStreamReaders(sOutput)
+}
+
+// getStopToolCall returns the first tool call which returned ErrStop.
+func getStopToolCall(tasks []toolCallTask) (*toolCallTask, bool) {
+	for i := range tasks {
+		if tasks[i].err != nil && errors.Is(tasks[i].err, ErrStop) {
+			return &tasks[i], true
+		}
+	}
+	return nil, false
+}
+
+// stopMessages returns the tool messages the ToolsNode stops with, i.e. those of the tool calls that succeeded,
+// and of the tool calls that returned an output along with ErrStop.
+func stopMessages(tasks []toolCallTask) []*schema.Message {
+	output := make([]*schema.Message, 0, len(tasks))
+	for i := range tasks {
+		switch {
+		case tasks[i].stopped:
+			output = append(output, schema.ToolMessage(tasks[i].output, tasks[i].callID, schema.WithToolName(tasks[i].name)))
+		case tasks[i].err == nil:
+			msgOpts := []schema.ToolMessageOption{schema.WithToolName(tasks[i].name)}
+			if tasks[i].failed {
+				msgOpts = append(msgOpts, schema.WithToolError())
+			}
+			msg := schema.ToolMessage(tasks[i].output, tasks[i].callID, msgOpts...)
+			tasks[i].attachTypedResult(msg)
+			output = append(output, msg)
+		}
+	}
+	return output
 }
 
 // GetType returns the component type string for the Tools node.
@@ -734,6 +1222,11 @@ func (tn *ToolsNode) GetType() string {
 	return ""
 }
 
+// toolErrorContent is the content of the tool message for a failed tool call when ContinueOnToolError is set.
+func toolErrorContent(task *toolCallTask) string {
+	return fmt.Sprintf("failed to call tool[name:%s]: %v", task.name, task.err)
+}
+
 func getToolsNodeOptions(opts ...ToolsNodeOption) *toolsNodeOptions {
 	o := &toolsNodeOptions{
 		ToolOptions: make([]tool.Option, 0),
@@ -747,6 +1240,58 @@ func getToolsNodeOptions(opts ...ToolsNodeOption) *toolsNodeOptions {
 type toolCallInfoKey struct{}
 type toolCallInfo struct {
 	toolCallID string
+
+	preserveTypedResult bool
+	// formatTypedResult keeps the typed result for ResultFormatter, which is not attached to the tool message unless preserveTypedResult is set.
+	formatTypedResult bool
+	typedResult       any
+	hasTypedResult    bool
+}
+
+// typedToolResultsExtraKey is the key of Message.Extra holding the typed results, which is a map keyed by tool call id.
+const typedToolResultsExtraKey = "_eino_tool_typed_results"
+
+func (t *toolCallTask) attachTypedResult(msg *schema.Message) {
+	if t.info == nil || !t.info.preserveTypedResult || !t.info.hasTypedResult {
+		return
+	}
+	if msg.Extra == nil {
+		msg.Extra = make(map[string]any)
+	}
+	msg.Extra[typedToolResultsExtraKey] = map[string]any{t.callID: t.info.typedResult}
+}
+
+// SetTypedToolResult reports the raw Go value of the result of the current tool call,
+// which is attached to the output tool message if ToolsNodeConfig.PreserveTypedResults is set.
+// Tool implementations call it before marshaling the value into the result string, it's a no-op outside a ToolsNode.
+func SetTypedToolResult(ctx context.Context, result any) {
+	info, ok := ctx.Value(toolCallInfoKey{}).(*toolCallInfo)
+	if !ok || info == nil || !(info.preserveTypedResult || info.formatTypedResult) {
+		return
+	}
+	info.typedResult = result
+	info.hasTypedResult = true
+}
+
+// GetTypedToolResult gets the typed result of the tool call with the given id from the tool message output by ToolsNode.
+// e.g.
+//
+//	resp, ok := compose.GetTypedToolResult[*WeatherResp](msg, msg.ToolCallID)
+func GetTypedToolResult[T any](msg *schema.Message, toolCallID string) (T, bool) {
+	var t T
+	if msg == nil {
+		return t, false
+	}
+	results, ok := msg.Extra[typedToolResultsExtraKey].(map[string]any)
+	if !ok {
+		return t, false
+	}
+	v, ok := results[toolCallID]
+	if !ok {
+		return t, false
+	}
+	t, ok = v.(T)
+	return t, ok
 }
 
 func setToolCallInfo(ctx context.Context, toolCallInfo *toolCallInfo) context.Context {
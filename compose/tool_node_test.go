@@ -18,11 +18,16 @@ package compose
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/bytedance/sonic"
 	"github.com/stretchr/testify/assert"
@@ -744,6 +749,58 @@ func TestUnknownTool(t *testing.T) {
 		}
 	}
 	assert.Equal(t, expected, result)
+
+	t.Run("without handler", func(t *testing.T) {
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{})
+		assert.NoError(t, err)
+
+		_, err = tn.Invoke(ctx, input)
+		assert.ErrorIs(t, err, ErrToolNotFound)
+		assert.ErrorContains(t, err, "name=unknown1")
+
+		_, err = tn.Stream(ctx, input)
+		assert.ErrorIs(t, err, ErrToolNotFound)
+	})
+
+	t.Run("feedback mode", func(t *testing.T) {
+		echo := func(ctx context.Context, in *cmdRequest) (string, error) { return in.Cmd, nil }
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+			Tools: []tool.BaseTool{
+				newTool(&schema.ToolInfo{Name: "search"}, echo),
+				newTool(&schema.ToolInfo{Name: "calculator"}, echo),
+			},
+			OnUnknownTool: UnknownToolFeedback,
+		})
+		assert.NoError(t, err)
+
+		in := &schema.Message{
+			Role: schema.Assistant,
+			ToolCalls: []schema.ToolCall{
+				{ID: "call_1", Function: schema.FunctionCall{Name: "search", Arguments: `{"Cmd":"eino"}`}},
+				{ID: "call_2", Function: schema.FunctionCall{Name: "web_browser", Arguments: `{}`}},
+			},
+		}
+		feedback := "tool web_browser does not exist, available tools: [search, calculator], please call one of the available tools instead"
+
+		result, err := tn.Invoke(ctx, in)
+		assert.NoError(t, err)
+		assert.Equal(t, []*schema.Message{
+			schema.ToolMessage(`"eino"`, "call_1", schema.WithToolName("search")),
+			schema.ToolMessage(feedback, "call_2", schema.WithToolName("web_browser")),
+		}, result)
+
+		sr, err := tn.Stream(ctx, in)
+		assert.NoError(t, err)
+		msgs, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "call_2", msgs[1].ToolCallID)
+		assert.Equal(t, feedback, msgs[1].Content)
+
+		// only the tools enabled for this call are listed
+		result, err = tn.Invoke(ctx, in, WithToolList(newTool(&schema.ToolInfo{Name: "search"}, echo)))
+		assert.NoError(t, err)
+		assert.Equal(t, "tool web_browser does not exist, available tools: [search], please call one of the available tools instead", result[1].Content)
+	})
 }
 
 func TestToolRerun(t *testing.T) {
@@ -984,10 +1041,14 @@ func (f *invokableTool[I, O]) InvokableRun(ctx context.Context, argumentsInJSON
 		return "", err
 	}
 	o, err := f.fn(ctx, t)
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrStop) {
 		return "", err
 	}
-	return sonic.MarshalString(o)
+	result, mErr := sonic.MarshalString(o)
+	if mErr != nil {
+		return "", mErr
+	}
+	return result, err
 }
 
 func newStreamableTool[I, O any](info *schema.ToolInfo, f func(ctx context.Context, in I) (*schema.StreamReader[O], error)) tool.StreamableTool {
@@ -1019,3 +1080,534 @@ func (f *streamableTool[I, O]) StreamableRun(ctx context.Context, argumentsInJSO
 		return sonic.MarshalString(o)
 	}), nil
 }
+
+type typedResultTool struct{}
+
+type typedWeather struct {
+	City string
+	Temp int
+}
+
+func (typedResultTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "get_weather"}, nil
+}
+
+func (typedResultTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	resp := &typedWeather{City: argumentsInJSON, Temp: 25}
+	SetTypedToolResult(ctx, resp)
+	return sonic.MarshalString(resp)
+}
+
+func TestToolsNodePreserveTypedResults(t *testing.T) {
+	ctx := context.Background()
+
+	input := &schema.Message{
+		Role: schema.Assistant,
+		ToolCalls: []schema.ToolCall{
+			{ID: "1", Function: schema.FunctionCall{Name: "get_weather", Arguments: "beijing"}},
+			{ID: "2", Function: schema.FunctionCall{Name: "get_weather", Arguments: "shanghai"}},
+			{ID: "3", Function: schema.FunctionCall{Name: "unknown", Arguments: "x"}},
+		},
+	}
+
+	newToolsNode := func(preserve bool) *ToolsNode {
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+			Tools: []tool.BaseTool{typedResultTool{}},
+			UnknownToolsHandler: func(ctx context.Context, name, input string) (string, error) {
+				return "unknown", nil
+			},
+			PreserveTypedResults: preserve,
+		})
+		assert.NoError(t, err)
+		return tn
+	}
+
+	t.Run("invoke", func(t *testing.T) {
+		out, err := newToolsNode(true).Invoke(ctx, input)
+		assert.NoError(t, err)
+		assert.Len(t, out, 3)
+
+		resp, ok := GetTypedToolResult[*typedWeather](out[0], "1")
+		assert.True(t, ok)
+		assert.Equal(t, &typedWeather{City: "beijing", Temp: 25}, resp)
+
+		resp, ok = GetTypedToolResult[*typedWeather](out[1], out[1].ToolCallID)
+		assert.True(t, ok)
+		assert.Equal(t, "shanghai", resp.City)
+
+		_, ok = GetTypedToolResult[*typedWeather](out[1], "1")
+		assert.False(t, ok)
+		_, ok = GetTypedToolResult[typedWeather](out[1], "2")
+		assert.False(t, ok)
+		_, ok = GetTypedToolResult[*typedWeather](out[2], "3")
+		assert.False(t, ok)
+		assert.Nil(t, out[2].Extra)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		sr, err := newToolsNode(true).Stream(ctx, input)
+		assert.NoError(t, err)
+
+		out, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Len(t, out, 3)
+
+		resp, ok := GetTypedToolResult[*typedWeather](out[0], "1")
+		assert.True(t, ok)
+		assert.Equal(t, "beijing", resp.City)
+	})
+
+	t.Run("not preserved", func(t *testing.T) {
+		out, err := newToolsNode(false).Invoke(ctx, input)
+		assert.NoError(t, err)
+		assert.Nil(t, out[0].Extra)
+
+		_, ok := GetTypedToolResult[*typedWeather](out[0], "1")
+		assert.False(t, ok)
+	})
+}
+
+type failingTool struct {
+	name string
+	err  error
+}
+
+func (f failingTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: f.name}, nil
+}
+
+func (f failingTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return "content of " + argumentsInJSON, nil
+}
+
+func TestToolsNodeContinueOnToolError(t *testing.T) {
+	ctx := context.Background()
+
+	input := &schema.Message{
+		Role: schema.Assistant,
+		ToolCalls: []schema.ToolCall{
+			{ID: "call_find", Function: schema.FunctionCall{Name: "find_file", Arguments: "a.txt"}},
+			{ID: "call_cat", Function: schema.FunctionCall{Name: "cat_file", Arguments: "a.txt"}},
+		},
+	}
+
+	newToolsNode := func(continueOnToolError, sequential bool) *ToolsNode {
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+			Tools: []tool.BaseTool{
+				failingTool{name: "find_file"},
+				failingTool{name: "cat_file", err: fmt.Errorf("permission denied")},
+			},
+			ExecuteSequentially: sequential,
+			ContinueOnToolError: continueOnToolError,
+		})
+		assert.NoError(t, err)
+		return tn
+	}
+
+	expected := []*schema.Message{
+		schema.ToolMessage("content of a.txt", "call_find", schema.WithToolName("find_file")),
+		schema.ToolMessage("failed to call tool[name:cat_file]: permission denied", "call_cat", schema.WithToolName("cat_file"), schema.WithToolError()),
+	}
+	assert.False(t, schema.IsToolError(expected[0]))
+	assert.True(t, schema.IsToolError(expected[1]))
+
+	for _, sequential := range []bool{false, true} {
+		t.Run(fmt.Sprintf("invoke sequential=%v", sequential), func(t *testing.T) {
+			out, err := newToolsNode(true, sequential).Invoke(ctx, input)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, out)
+		})
+
+		t.Run(fmt.Sprintf("stream sequential=%v", sequential), func(t *testing.T) {
+			sr, err := newToolsNode(true, sequential).Stream(ctx, input)
+			assert.NoError(t, err)
+			out, err := concatStreamReader(sr)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, out)
+		})
+	}
+
+	t.Run("fail by default", func(t *testing.T) {
+		_, err := newToolsNode(false, false).Invoke(ctx, input)
+		assert.ErrorContains(t, err, "failed to invoke tool[name:cat_file id:call_cat]: permission denied")
+
+		_, err = newToolsNode(false, false).Stream(ctx, input)
+		assert.ErrorContains(t, err, "failed to stream tool call call_cat: permission denied")
+	})
+
+	t.Run("interrupt is not swallowed", func(t *testing.T) {
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+			Tools: []tool.BaseTool{
+				failingTool{name: "find_file"},
+				failingTool{name: "cat_file", err: InterruptAndRerun},
+			},
+			ContinueOnToolError: true,
+		})
+		assert.NoError(t, err)
+
+		out, err := tn.Invoke(ctx, input)
+		assert.Error(t, err)
+		assert.Nil(t, out)
+	})
+}
+
+func TestToolsNodeRegisterTool(t *testing.T) {
+	ctx := context.Background()
+
+	var advertised []string
+	tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+		Tools: []tool.BaseTool{typedResultTool{}},
+		OnToolsChange: func(ctx context.Context, tools []*schema.ToolInfo) {
+			advertised = advertised[:0]
+			for _, info := range tools {
+				advertised = append(advertised, info.Name)
+			}
+		},
+	})
+	assert.NoError(t, err)
+
+	g := NewGraph[*schema.Message, []*schema.Message]()
+	assert.NoError(t, g.AddToolsNode("tools", tn))
+	assert.NoError(t, g.AddEdge(START, "tools"))
+	assert.NoError(t, g.AddEdge("tools", END))
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	input := &schema.Message{
+		Role:      schema.Assistant,
+		ToolCalls: []schema.ToolCall{{ID: "call_cat", Function: schema.FunctionCall{Name: "cat_file", Arguments: "a.txt"}}},
+	}
+
+	_, err = r.Invoke(ctx, input)
+	assert.ErrorIs(t, err, ErrToolNotFound)
+
+	assert.NoError(t, tn.RegisterTool(ctx, failingTool{name: "cat_file"}))
+	assert.Equal(t, []string{"get_weather", "cat_file"}, advertised)
+
+	out, err := r.Invoke(ctx, input)
+	assert.NoError(t, err)
+	assert.Equal(t, []*schema.Message{schema.ToolMessage("content of a.txt", "call_cat", schema.WithToolName("cat_file"))}, out)
+
+	err = tn.RegisterTool(ctx, failingTool{name: "cat_file"})
+	assert.ErrorContains(t, err, "tool[cat_file] is already registered")
+	assert.Equal(t, []string{"get_weather", "cat_file"}, advertised)
+
+	t.Run("concurrent with invocations", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, tn.RegisterTool(ctx, failingTool{name: fmt.Sprintf("tool_%d", i)}))
+			}(i)
+			go func() {
+				defer wg.Done()
+				_, err := r.Invoke(ctx, input)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestToolsNodeResultFormatter(t *testing.T) {
+	ctx := context.Background()
+
+	input := &schema.Message{
+		Role: schema.Assistant,
+		ToolCalls: []schema.ToolCall{
+			{ID: "call_weather", Function: schema.FunctionCall{Name: "get_weather", Arguments: "beijing"}},
+			{ID: "call_cat", Function: schema.FunctionCall{Name: "cat_file", Arguments: "a_very_long_file.txt"}},
+			{ID: "call_fail", Function: schema.FunctionCall{Name: "find_file", Arguments: "a.txt"}},
+		},
+	}
+
+	tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+		Tools: []tool.BaseTool{typedResultTool{}, failingTool{name: "cat_file"}, failingTool{name: "find_file"}},
+		ResultFormatter: func(toolName string, result any) (string, error) {
+			switch r := result.(type) {
+			case *typedWeather:
+				return fmt.Sprintf("%s: %d°C", r.City, r.Temp), nil
+			case string:
+				if toolName == "find_file" {
+					return "", fmt.Errorf("unexpected result")
+				}
+				if len(r) > 10 {
+					return r[:10] + "...", nil
+				}
+				return r, nil
+			}
+			return "", fmt.Errorf("unexpected result type %T", result)
+		},
+	})
+	assert.NoError(t, err)
+
+	expected := []*schema.Message{
+		schema.ToolMessage("beijing: 25°C", "call_weather", schema.WithToolName("get_weather")),
+		schema.ToolMessage("content of...", "call_cat", schema.WithToolName("cat_file")),
+		schema.ToolMessage("failed to format result of tool[name:find_file]: unexpected result", "call_fail", schema.WithToolName("find_file")),
+	}
+
+	t.Run("invoke", func(t *testing.T) {
+		out, err := tn.Invoke(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		sr, err := tn.Stream(ctx, input)
+		assert.NoError(t, err)
+		out, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out)
+	})
+}
+
+type cmdRequest struct {
+	Cmd string `json:"cmd"`
+}
+
+func TestToolsNodeCancellation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invoke_cancels_ctx_after_call", func(t *testing.T) {
+		var toolCtx context.Context
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+			Tools: []tool.BaseTool{newTool(&schema.ToolInfo{Name: "run_cmd"},
+				func(ctx context.Context, in *cmdRequest) (string, error) {
+					toolCtx = ctx
+					return "ok", nil
+				})},
+		})
+		assert.NoError(t, err)
+
+		_, err = tn.Invoke(ctx, &schema.Message{
+			Role:      schema.Assistant,
+			ToolCalls: []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "run_cmd", Arguments: "{}"}}},
+		})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, toolCtx.Err(), context.Canceled)
+	})
+
+	t.Run("stream_cancels_ctx_after_stream_closed", func(t *testing.T) {
+		var toolCtx context.Context
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+			Tools: []tool.BaseTool{newStreamableTool(&schema.ToolInfo{Name: "run_cmd"},
+				func(ctx context.Context, in *cmdRequest) (*schema.StreamReader[string], error) {
+					toolCtx = ctx
+					return schema.StreamReaderFromArray([]string{"a", "b"}), nil
+				})},
+		})
+		assert.NoError(t, err)
+
+		sr, err := tn.Stream(ctx, &schema.Message{
+			Role:      schema.Assistant,
+			ToolCalls: []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "run_cmd", Arguments: "{}"}}},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, toolCtx.Err())
+
+		_, err = concatStreamReader(sr)
+		assert.NoError(t, err)
+		select {
+		case <-toolCtx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("ctx of tool call is not cancelled after stream closed")
+		}
+	})
+
+	t.Run("failed_call_aborts_the_others", func(t *testing.T) {
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+			Tools: []tool.BaseTool{
+				newTool(&schema.ToolInfo{Name: "slow_cmd"}, func(ctx context.Context, in *cmdRequest) (string, error) {
+					select {
+					case <-ctx.Done():
+						return "", ctx.Err()
+					case <-time.After(10 * time.Second):
+						return "ok", nil
+					}
+				}),
+				newTool(&schema.ToolInfo{Name: "bad_cmd"}, func(ctx context.Context, in *cmdRequest) (string, error) {
+					return "", errors.New("command not found")
+				}),
+			},
+		})
+		assert.NoError(t, err)
+
+		start := time.Now()
+		_, err = tn.Invoke(ctx, &schema.Message{
+			Role: schema.Assistant,
+			ToolCalls: []schema.ToolCall{
+				{ID: "1", Function: schema.FunctionCall{Name: "slow_cmd", Arguments: "{}"}},
+				{ID: "2", Function: schema.FunctionCall{Name: "bad_cmd", Arguments: "{}"}},
+			},
+		})
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+type dualModeTool struct{}
+
+func (d *dualModeTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "dual", Desc: "both invokable and streamable"}, nil
+}
+
+func (d *dualModeTool) InvokableRun(_ context.Context, _ string, _ ...tool.Option) (string, error) {
+	return "invoked", nil
+}
+
+func (d *dualModeTool) StreamableRun(_ context.Context, _ string, _ ...tool.Option) (*schema.StreamReader[string], error) {
+	return schema.StreamReaderFromArray([]string{"stream", "ed"}), nil
+}
+
+func TestToolsNodePreferStreamableTools(t *testing.T) {
+	ctx := context.Background()
+	input := schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "dual", Arguments: "{}"}}})
+
+	for _, tc := range []struct {
+		prefer bool
+		want   string
+	}{
+		{prefer: false, want: "invoked"},
+		{prefer: true, want: "streamed"},
+	} {
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{Tools: []tool.BaseTool{&dualModeTool{}}, PreferStreamableTools: tc.prefer})
+		assert.NoError(t, err)
+		out, err := tn.Invoke(ctx, input)
+		assert.NoError(t, err)
+		assert.Len(t, out, 1)
+		assert.Equal(t, tc.want, out[0].Content)
+	}
+}
+
+type rawResultTool struct {
+	name   string
+	result string
+}
+
+func (r rawResultTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: r.name}, nil
+}
+
+func (r rawResultTool) InvokableRun(_ context.Context, _ string, _ ...tool.Option) (string, error) {
+	return r.result, nil
+}
+
+func TestToolsNodeMaxResultBytes(t *testing.T) {
+	ctx := context.Background()
+
+	// 12 bytes of ascii followed by 3-byte characters, so that the cap falls in the middle of a character
+	content := "file content" + strings.Repeat("文件", 100)
+	input := schema.AssistantMessage("", []schema.ToolCall{
+		{ID: "call_cat", Function: schema.FunctionCall{Name: "cat_file", Arguments: "big.txt"}},
+		{ID: "call_find", Function: schema.FunctionCall{Name: "find_file", Arguments: "a.txt"}},
+	})
+	catTool := rawResultTool{name: "cat_file", result: content}
+	findTool := rawResultTool{name: "find_file", result: "found a.txt"}
+
+	tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+		Tools:          []tool.BaseTool{catTool, findTool},
+		MaxResultBytes: 20,
+	})
+	assert.NoError(t, err)
+
+	check := func(t *testing.T, out []*schema.Message) {
+		assert.Len(t, out, 2)
+		// 12 bytes + 2 characters of 3 bytes are kept, instead of splitting the 3rd character
+		assert.Equal(t, fmt.Sprintf("file content文件…[truncated %d bytes]", len(content)-18), out[0].Content)
+		assert.True(t, utf8.ValidString(out[0].Content))
+		// the result within the cap is kept as is
+		assert.Equal(t, "found a.txt", out[1].Content)
+	}
+
+	t.Run("invoke", func(t *testing.T) {
+		out, err := tn.Invoke(ctx, input)
+		assert.NoError(t, err)
+		check(t, out)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		sr, err := tn.Stream(ctx, input)
+		assert.NoError(t, err)
+		out, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		check(t, out)
+	})
+}
+
+func TestToolsNodeBeforeToolCall(t *testing.T) {
+	ctx := context.Background()
+
+	type findReq struct {
+		Dir string `json:"dir"`
+	}
+	var searched []string
+	findTool := newTool(&schema.ToolInfo{Name: "find_file"}, func(ctx context.Context, in *findReq) (string, error) {
+		searched = append(searched, in.Dir)
+		return "a.txt", nil
+	})
+
+	tn, err := NewToolNode(ctx, &ToolsNodeConfig{
+		Tools: []tool.BaseTool{findTool},
+		BeforeToolCall: func(ctx context.Context, toolName string, args json.RawMessage) error {
+			req := &findReq{}
+			if err := json.Unmarshal(args, req); err != nil {
+				return err
+			}
+			if toolName == "find_file" && strings.HasPrefix(req.Dir, "/etc") {
+				return errors.New("searching /etc is not allowed")
+			}
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	input := schema.AssistantMessage("", []schema.ToolCall{
+		{ID: "call_etc", Function: schema.FunctionCall{Name: "find_file", Arguments: `{"dir":"/etc/ssh"}`}},
+		{ID: "call_home", Function: schema.FunctionCall{Name: "find_file", Arguments: `{"dir":"/home"}`}},
+	})
+
+	// the model node following the ToolsNode sees the denial as the result of the denied tool call
+	var seen []*schema.Message
+	g := NewGraph[*schema.Message, string]()
+	assert.NoError(t, g.AddToolsNode("tools", tn))
+	assert.NoError(t, g.AddLambdaNode("model", InvokableLambda(func(ctx context.Context, in []*schema.Message) (string, error) {
+		seen = in
+		return "done", nil
+	})))
+	assert.NoError(t, g.AddEdge(START, "tools"))
+	assert.NoError(t, g.AddEdge("tools", "model"))
+	assert.NoError(t, g.AddEdge("model", END))
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	check := func(t *testing.T) {
+		assert.Equal(t, []string{"/home"}, searched)
+		assert.Len(t, seen, 2)
+		assert.Equal(t, "call of tool[name:find_file] is denied: searching /etc is not allowed", seen[0].Content)
+		assert.True(t, schema.IsToolError(seen[0]))
+		assert.Equal(t, `"a.txt"`, seen[1].Content)
+		assert.False(t, schema.IsToolError(seen[1]))
+	}
+
+	t.Run("invoke", func(t *testing.T) {
+		searched, seen = nil, nil
+		out, err := r.Invoke(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, "done", out)
+		check(t)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		searched, seen = nil, nil
+		sr, err := r.Stream(ctx, input)
+		assert.NoError(t, err)
+		_, err = concatStreamReader(sr)
+		assert.NoError(t, err)
+		check(t)
+	})
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+)
+
+// TraceEntry is the record of one execution of a node, with the input and output serialized in JSON.
+// For a node running in streaming mode, the input and output are the concatenation of the streams,
+// and the duration lasts until the output stream ends.
+type TraceEntry struct {
+	// NodePath is the path of the node from the outermost graph, e.g. ["agent", "chat_model"] for a node inside a subgraph.
+	NodePath []string      `json:"node_path"`
+	Input    string        `json:"input"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Tracer records the transcript of the nodes run with the context returned by WithTrace.
+// Unlike callbacks, which are invoked at each event, it keeps a consolidated and serializable transcript for post-mortem analysis.
+type Tracer struct {
+	maxValueLength int
+
+	mu      sync.Mutex
+	entries []*TraceEntry
+}
+
+// TraceOption configures the Tracer created by WithTrace.
+type TraceOption func(t *Tracer)
+
+// WithTraceMaxValueLength truncates the serialized input and output longer than n characters, which is not limited by default.
+func WithTraceMaxValueLength(n int) TraceOption {
+	return func(t *Tracer) {
+		t.maxValueLength = n
+	}
+}
+
+// WithTrace creates a context that makes the graphs run with it record the executions of their nodes, including the nodes of subgraphs,
+// into the returned Tracer, whose Entries can be read once the run finishes.
+// e.g.
+//
+//	ctx, tracer := compose.WithTrace(ctx, compose.WithTraceMaxValueLength(1024))
+//	out, err := runnable.Invoke(ctx, input)
+//	b, err := json.Marshal(tracer.Entries())
+func WithTrace(ctx context.Context, opts ...TraceOption) (context.Context, *Tracer) {
+	t := &Tracer{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return context.WithValue(ctx, tracerKey{}, t), t
+}
+
+// Entries returns the recorded executions ordered by their start time.
+// The executions that haven't finished yet, e.g. those whose output streams are not read to the end, are not included.
+func (t *Tracer) Entries() []TraceEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]TraceEntry, 0, len(t.entries))
+	for _, e := range t.entries {
+		entries = append(entries, *e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start.Before(entries[j].Start)
+	})
+	return entries
+}
+
+func (t *Tracer) record(path []string, start time.Time, input, output any, err error) {
+	e := &TraceEntry{
+		NodePath: path,
+		Input:    t.serialize(input),
+		Start:    start,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	} else {
+		e.Output = t.serialize(output)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, e)
+}
+
+func (t *Tracer) serialize(v any) string {
+	s, err := sonic.MarshalString(v)
+	if err != nil {
+		s = fmt.Sprintf("%+v", v)
+	}
+	if t.maxValueLength > 0 && utf8.RuneCountInString(s) > t.maxValueLength {
+		s = string([]rune(s)[:t.maxValueLength]) + fmt.Sprintf("...(truncated, %d bytes in total)", len(s))
+	}
+	return s
+}
+
+type tracerKey struct{}
+
+func applyTrace(key string, r *composableRunnable, helper *genericHelper) *composableRunnable {
+	if r.isPassthrough || helper == nil {
+		return r
+	}
+
+	nodePath := func(ctx context.Context) []string {
+		if path, ok := getNodePath(ctx); ok {
+			return path.path
+		}
+		return []string{key}
+	}
+
+	i, t := r.i, r.t
+	wrapper := *r
+	wrapper.i = func(ctx context.Context, input any, opts ...any) (any, error) {
+		tracer, _ := ctx.Value(tracerKey{}).(*Tracer)
+		if tracer == nil {
+			return i(ctx, input, opts...)
+		}
+
+		start := time.Now()
+		out, err := i(ctx, input, opts...)
+		tracer.record(nodePath(ctx), start, input, out, err)
+		return out, err
+	}
+	wrapper.t = func(ctx context.Context, input streamReader, opts ...any) (streamReader, error) {
+		tracer, _ := ctx.Value(tracerKey{}).(*Tracer)
+		if tracer == nil {
+			return t(ctx, input, opts...)
+		}
+
+		start := time.Now()
+		path := nodePath(ctx)
+		inputs := input.copy(2)
+		out, err := t(ctx, inputs[0], opts...)
+		if err != nil {
+			inputs[1].close()
+			tracer.record(path, start, nil, nil, err)
+			return nil, err
+		}
+
+		// the copies are read to the end in the background like the streams passed to callbacks.
+		outputs := out.copy(2)
+		go func() {
+			in, _ := helper.inputStreamConvertPair.concatStream(inputs[1])
+			o, err := helper.outputStreamConvertPair.concatStream(outputs[1])
+			tracer.record(path, start, in, o, err)
+		}()
+		return outputs[0], nil
+	}
+	return &wrapper
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestTrace(t *testing.T) {
+	ctx := context.Background()
+
+	build := func(t *testing.T, failErr error) Runnable[string, string] {
+		sub := NewGraph[string, string]()
+		assert.NoError(t, sub.AddLambdaNode("suffix", InvokableLambda(func(ctx context.Context, in string) (string, error) {
+			if failErr != nil {
+				return "", failErr
+			}
+			return in + "!", nil
+		})))
+		assert.NoError(t, sub.AddEdge(START, "suffix"))
+		assert.NoError(t, sub.AddEdge("suffix", END))
+
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("upper", StreamableLambda(func(ctx context.Context, in string) (*schema.StreamReader[string], error) {
+			return schema.StreamReaderFromArray([]string{strings.ToUpper(in[:1]), in[1:]}), nil
+		})))
+		assert.NoError(t, g.AddGraphNode("sub", sub))
+		assert.NoError(t, g.AddPassthroughNode("pass"))
+		assert.NoError(t, g.AddEdge(START, "upper"))
+		assert.NoError(t, g.AddEdge("upper", "sub"))
+		assert.NoError(t, g.AddEdge("sub", "pass"))
+		assert.NoError(t, g.AddEdge("pass", END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+		return r
+	}
+
+	paths := func(entries []TraceEntry) [][]string {
+		var ret [][]string
+		for _, e := range entries {
+			ret = append(ret, e.NodePath)
+		}
+		return ret
+	}
+
+	t.Run("invoke", func(t *testing.T) {
+		ctx, tracer := WithTrace(ctx)
+		out, err := build(t, nil).Invoke(ctx, "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello!", out)
+
+		entries := tracer.Entries()
+		assert.Equal(t, [][]string{{"upper"}, {"sub"}, {"sub", "suffix"}}, paths(entries))
+		assert.Equal(t, `"hello"`, entries[0].Input)
+		assert.Equal(t, `"Hello"`, entries[0].Output)
+		assert.Equal(t, `"Hello"`, entries[2].Input)
+		assert.Equal(t, `"Hello!"`, entries[2].Output)
+		assert.True(t, entries[1].Duration >= entries[2].Duration)
+
+		_, err = sonic.Marshal(entries)
+		assert.NoError(t, err)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		ctx, tracer := WithTrace(ctx)
+		sr, err := build(t, nil).Stream(ctx, "hello")
+		assert.NoError(t, err)
+		out, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello!", out)
+
+		// the entries of streams are recorded in the background once the streams end
+		assert.Eventually(t, func() bool {
+			return len(tracer.Entries()) == 3
+		}, time.Second, time.Millisecond)
+		entries := tracer.Entries()
+		assert.Equal(t, [][]string{{"upper"}, {"sub"}, {"sub", "suffix"}}, paths(entries))
+		assert.Equal(t, `"Hello"`, entries[0].Output)
+		assert.Equal(t, `"Hello!"`, entries[2].Output)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		ctx, tracer := WithTrace(ctx)
+		_, err := build(t, errors.New("suffix error")).Invoke(ctx, "hello")
+		assert.ErrorContains(t, err, "suffix error")
+
+		entries := tracer.Entries()
+		assert.Equal(t, [][]string{{"upper"}, {"sub"}, {"sub", "suffix"}}, paths(entries))
+		assert.Contains(t, entries[2].Error, "suffix error")
+		assert.Empty(t, entries[2].Output)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		ctx, tracer := WithTrace(ctx, WithTraceMaxValueLength(4))
+		_, err := build(t, nil).Invoke(ctx, "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, `"hel...(truncated, 7 bytes in total)`, tracer.Entries()[0].Input)
+	})
+}
@@ -19,6 +19,7 @@ package compose
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/cloudwego/eino/schema"
 )
@@ -263,3 +264,38 @@ func MessageParser[T any](p schema.MessageParser[T], opts ...LambdaOpt) *Lambda
 
 	return anyLambda(i, nil, nil, nil, opts...)
 }
+
+// DocumentsToMessage creates a lambda that renders the documents into a single message of role, usually used
+// between a retriever and a chat model to stuff the retrieved documents into the context.
+// Each document is rendered by template, in which {content} is replaced with the content of the document,
+// and {source} with its source name, see schema.Document.SourceName, then the rendered documents are joined by a blank line.
+// e.g.
+//
+//	toContext := compose.DocumentsToMessage("[{source}]\n{content}", schema.System)
+//
+//	chain := compose.NewChain[string, []*schema.Message]()
+//	chain.AppendRetriever(retriever)
+//	chain.AppendLambda(toContext)
+//	chain.AppendLambda(compose.ToList[*schema.Message]())
+func DocumentsToMessage(template string, role schema.RoleType, opts ...LambdaOpt) *Lambda {
+	i := func(ctx context.Context, docs []*schema.Document, opts_ ...unreachableOption) (*schema.Message, error) {
+		rendered := make([]string, 0, len(docs))
+		for _, doc := range docs {
+			if doc == nil {
+				continue
+			}
+			rendered = append(rendered, strings.NewReplacer(
+				"{content}", doc.Content,
+				"{source}", doc.SourceName(),
+			).Replace(template))
+		}
+		return &schema.Message{
+			Role:    role,
+			Content: strings.Join(rendered, "\n\n"),
+		}, nil
+	}
+
+	opts = append([]LambdaOpt{WithLambdaType("DocumentsToMessage")}, opts...)
+
+	return anyLambda(i, nil, nil, nil, opts...)
+}
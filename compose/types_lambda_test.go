@@ -18,6 +18,7 @@ package compose
 
 import (
 	"context"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -163,6 +164,80 @@ func TestLambda(t *testing.T) {
 	})
 }
 
+func TestLambdaCallParadigms(t *testing.T) {
+	ctx := context.Background()
+
+	// fanOut emits every message of the input as a separate chunk, the inverse of taking one message.
+	fanOut := StreamableLambda(func(ctx context.Context, input []*schema.Message) (*schema.StreamReader[*schema.Message], error) {
+		return schema.StreamReaderFromArray(input), nil
+	})
+
+	// joinAll collects the chunks and concatenates their contents into a single message.
+	joinAll := CollectableLambda(func(ctx context.Context, input *schema.StreamReader[*schema.Message]) (*schema.Message, error) {
+		defer input.Close()
+		var content string
+		for {
+			msg, err := input.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			content += msg.Content
+		}
+		return schema.AssistantMessage(content, nil), nil
+	})
+
+	g := NewGraph[[]*schema.Message, *schema.Message]()
+	assert.NoError(t, g.AddLambdaNode("fan_out", fanOut))
+	assert.NoError(t, g.AddLambdaNode("join_all", joinAll))
+	assert.NoError(t, g.AddEdge(START, "fan_out"))
+	assert.NoError(t, g.AddEdge("fan_out", "join_all"))
+	assert.NoError(t, g.AddEdge("join_all", END))
+
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	in := []*schema.Message{schema.UserMessage("a"), schema.UserMessage("b"), schema.UserMessage("c")}
+
+	t.Run("Invoke", func(t *testing.T) {
+		out, err := r.Invoke(ctx, in)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", out.Content)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		sr, err := r.Stream(ctx, in)
+		assert.NoError(t, err)
+		out, err := schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", out.Content)
+	})
+
+	t.Run("Collect", func(t *testing.T) {
+		out, err := r.Collect(ctx, schema.StreamReaderFromArray([][]*schema.Message{in}))
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", out.Content)
+	})
+
+	t.Run("Transform", func(t *testing.T) {
+		sr, err := r.Transform(ctx, schema.StreamReaderFromArray([][]*schema.Message{in}))
+		assert.NoError(t, err)
+		out, err := schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", out.Content)
+	})
+
+	t.Run("mismatched edge type", func(t *testing.T) {
+		g := NewGraph[[]*schema.Message, *schema.Message]()
+		assert.NoError(t, g.AddLambdaNode("fan_out", fanOut))
+		assert.NoError(t, g.AddLambdaNode("fan_out_again", fanOut))
+		assert.NoError(t, g.AddEdge(START, "fan_out"))
+		assert.Error(t, g.AddEdge("fan_out", "fan_out_again"))
+	})
+}
+
 type TestStructForParse struct {
 	ID int `json:"id"`
 }
@@ -210,3 +285,26 @@ func TestMessageParser(t *testing.T) {
 		assert.Equal(t, 1, parsed.ID)
 	})
 }
+
+func TestDocumentsToMessage(t *testing.T) {
+	ctx := context.Background()
+	docs := []*schema.Document{
+		(&schema.Document{Content: "Eino is a framework for LLM applications."}).WithSourceName("readme.md"),
+		(&schema.Document{Content: "Graphs are compiled before running."}).WithSourceName("compose.md"),
+	}
+
+	chain := NewChain[[]*schema.Document, *schema.Message]()
+	chain.AppendLambda(DocumentsToMessage("source: {source}\n{content}", schema.System))
+	r, err := chain.Compile(ctx)
+	assert.NoError(t, err)
+
+	msg, err := r.Invoke(ctx, docs)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.System, msg.Role)
+	assert.Equal(t, "source: readme.md\nEino is a framework for LLM applications.\n\n"+
+		"source: compose.md\nGraphs are compiled before running.", msg.Content)
+
+	msg, err = r.Invoke(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", msg.Content)
+}
@@ -252,23 +252,43 @@ func checkAssignable(input, arg reflect.Type) assignableType {
 	return assignableTypeMustNot
 }
 
+// typeMismatchHint suggests how to bridge two mismatched node types, which is always done by a lambda node converting one into the other.
+func typeMismatchHint(output, input reflect.Type) string {
+	switch {
+	case output.Kind() == reflect.Slice && checkAssignable(output.Elem(), input) != assignableTypeMustNot:
+		return fmt.Sprintf("consider adding a lambda node in between that picks one element, e.g. InvokableLambda(func(ctx context.Context, in %s) (%s, error)), "+
+			"or registering a reducer by WithAutoReduce when creating the graph", output.String(), input.String())
+	case input.Kind() == reflect.Slice && checkAssignable(output, input.Elem()) != assignableTypeMustNot:
+		return fmt.Sprintf("consider adding a lambda node in between that wraps the output into a slice, e.g. InvokableLambda(func(ctx context.Context, in %s) (%s, error))",
+			output.String(), input.String())
+	default:
+		return fmt.Sprintf("consider adding a lambda node in between to convert the type, e.g. InvokableLambda(func(ctx context.Context, in %s) (%s, error))",
+			output.String(), input.String())
+	}
+}
+
 func extractOption(nodes map[string]*chanCall, opts ...Option) (map[string][]any, error) {
 	optMap := map[string][]any{}
+	// common options are extracted before designated ones, so that options designated to a node
+	// always take precedence over common options, regardless of the order they are passed in.
 	for _, opt := range opts {
-		if len(opt.paths) == 0 {
-			// common, discard callback, filter option by type
-			if len(opt.options) == 0 {
-				continue
-			}
-			for name, c := range nodes {
-				if c.action.optionType == nil {
-					// subgraph
-					optMap[name] = append(optMap[name], opt)
-				} else if reflect.TypeOf(opt.options[0]) == c.action.optionType { // assume that types of options are the same
-					optMap[name] = append(optMap[name], opt.options...)
-				}
+		if len(opt.paths) != 0 {
+			continue
+		}
+		// common, discard callback, filter option by type
+		if len(opt.options) == 0 {
+			continue
+		}
+		for name, c := range nodes {
+			if c.action.optionType == nil {
+				// subgraph
+				optMap[name] = append(optMap[name], opt)
+			} else if reflect.TypeOf(opt.options[0]) == c.action.optionType { // assume that types of options are the same
+				optMap[name] = append(optMap[name], opt.options...)
 			}
 		}
+	}
+	for _, opt := range opts {
 		for _, path := range opt.paths {
 			if len(path.path) == 0 {
 				return nil, fmt.Errorf("call option has designated an empty path")
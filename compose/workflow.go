@@ -26,6 +26,7 @@ import (
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/reranker"
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/schema"
 )
@@ -107,6 +108,13 @@ func (wf *Workflow[I, O]) AddRetrieverNode(key string, retriever retriever.Retri
 	return wf.initNode(key)
 }
 
+// AddRerankerNode adds a reranker node and returns it.
+// The fields of its *reranker.Request input can be mapped from different predecessors, e.g. Query from START and Docs from a retriever.
+func (wf *Workflow[I, O]) AddRerankerNode(key string, reranker reranker.Reranker, opts ...GraphAddNodeOpt) *WorkflowNode {
+	_ = wf.g.AddRerankerNode(key, reranker, opts...)
+	return wf.initNode(key)
+}
+
 // AddEmbeddingNode adds an embedding node and returns it.
 func (wf *Workflow[I, O]) AddEmbeddingNode(key string, embedding embedding.Embedder, opts ...GraphAddNodeOpt) *WorkflowNode {
 	_ = wf.g.AddEmbeddingNode(key, embedding, opts...)
@@ -514,6 +522,10 @@ func (wf *Workflow[I, O]) getGenericHelper() *genericHelper {
 	return wf.g.getGenericHelper()
 }
 
+func (wf *Workflow[I, O]) requiredInputKeys() []string {
+	return wf.g.requiredInputKeys()
+}
+
 func (wf *Workflow[I, O]) inputType() reflect.Type {
 	return wf.g.inputType()
 }
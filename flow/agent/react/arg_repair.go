@@ -0,0 +1,114 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// newArgRepairMiddleware answers the tool calls whose arguments fail to unmarshal with a correction message including
+// the expected schema, instead of failing the agent, so that the model can call the tool again with fixed arguments.
+// It gives up with the error after maxAttempts consecutive rounds of the model that need repairing.
+func newArgRepairMiddleware(toolInfos []*schema.ToolInfo, maxAttempts int) compose.ToolMiddleware {
+	infos := make(map[string]*schema.ToolInfo, len(toolInfos))
+	for _, info := range toolInfos {
+		infos[info.Name] = info
+	}
+
+	// repair returns the correction message if the error is caused by invalid arguments and attempts remain.
+	repair := func(ctx context.Context, input *compose.ToolInput, err error) (string, bool) {
+		if !errors.Is(err, compose.ErrInvalidToolArgs) {
+			return "", false
+		}
+		allowed := false
+		_ = compose.ProcessState[*state](ctx, func(_ context.Context, st *state) error {
+			if st.ArgRepairAttempts < maxAttempts {
+				st.ArgRepaired = true
+				allowed = true
+			}
+			return nil
+		})
+		if !allowed {
+			return "", false
+		}
+		return argRepairPrompt(input, infos[input.Name], err), true
+	}
+
+	return compose.ToolMiddleware{
+		Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+				err := checkArguments(input.Arguments)
+				var output *compose.ToolOutput
+				if err == nil {
+					output, err = next(ctx, input)
+				}
+				if err != nil {
+					if prompt, ok := repair(ctx, input, err); ok {
+						return &compose.ToolOutput{Result: prompt}, nil
+					}
+					return nil, err
+				}
+				return output, nil
+			}
+		},
+		Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+				err := checkArguments(input.Arguments)
+				var output *compose.StreamToolOutput
+				if err == nil {
+					output, err = next(ctx, input)
+				}
+				if err != nil {
+					if prompt, ok := repair(ctx, input, err); ok {
+						return &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{prompt})}, nil
+					}
+					return nil, err
+				}
+				return output, nil
+			}
+		},
+	}
+}
+
+func checkArguments(arguments string) error {
+	if len(arguments) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(arguments), &v); err != nil {
+		return fmt.Errorf("%w: arguments are not valid JSON: %v", compose.ErrInvalidToolArgs, err)
+	}
+	return nil
+}
+
+func argRepairPrompt(input *compose.ToolInput, info *schema.ToolInfo, err error) string {
+	prompt := fmt.Sprintf("your arguments of tool %s were invalid: %v.", input.Name, err)
+	if info != nil && info.ParamsOneOf != nil {
+		if js, e := info.ParamsOneOf.ToJSONSchema(); e == nil && js != nil {
+			if b, e := json.Marshal(js); e == nil {
+				prompt += fmt.Sprintf(" the arguments must be a JSON object conforming to the schema: %s.", b)
+			}
+		}
+	}
+	return prompt + " please call the tool again with valid arguments."
+}
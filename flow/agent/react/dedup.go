@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// newDedupToolCallMiddleware answers the tool calls repeating a call of the same tool with the same arguments
+// in the latest window rounds of the model with a message telling so, instead of running the tool again,
+// so that a model looping on the same call is pushed to use the result it already has.
+func newDedupToolCallMiddleware(window int) compose.ToolMiddleware {
+	// dedup returns the message answering the tool call if it's a repeated one.
+	dedup := func(ctx context.Context, input *compose.ToolInput) (string, bool) {
+		repeated := false
+		_ = compose.ProcessState[*state](ctx, func(_ context.Context, st *state) error {
+			repeated = isRepeatedToolCall(st.Messages, input, window)
+			return nil
+		})
+		if !repeated {
+			return "", false
+		}
+		return fmt.Sprintf("you already called tool %s with the same arguments %s, see its result above. "+
+			"don't call it again with the same arguments, use the result you have, "+
+			"call the tool with different arguments, or give the final answer.", input.Name, input.Arguments), true
+	}
+
+	return compose.ToolMiddleware{
+		Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+				if msg, ok := dedup(ctx, input); ok {
+					return &compose.ToolOutput{Result: msg}, nil
+				}
+				return next(ctx, input)
+			}
+		},
+		Streamable: func(next compose.StreamableToolEndpoint) compose.StreamableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.StreamToolOutput, error) {
+				if msg, ok := dedup(ctx, input); ok {
+					return &compose.StreamToolOutput{Result: schema.StreamReaderFromArray([]string{msg})}, nil
+				}
+				return next(ctx, input)
+			}
+		},
+	}
+}
+
+// isRepeatedToolCall reports whether the tool call is repeated in the latest window rounds of the model before the current one,
+// i.e. the assistant messages with tool calls before the one including the tool call.
+func isRepeatedToolCall(messages []*schema.Message, input *compose.ToolInput, window int) bool {
+	current := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if hasToolCall(messages[i], input.CallID) {
+			current = i
+			break
+		}
+	}
+
+	args := normalizeArguments(input.Arguments)
+	rounds := 0
+	for i := current - 1; i >= 0 && rounds < window; i-- {
+		msg := messages[i]
+		if msg.Role != schema.Assistant || len(msg.ToolCalls) == 0 {
+			continue
+		}
+		rounds++
+		for _, tc := range msg.ToolCalls {
+			if tc.Function.Name == input.Name && normalizeArguments(tc.Function.Arguments) == args {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasToolCall(msg *schema.Message, callID string) bool {
+	for _, tc := range msg.ToolCalls {
+		if tc.ID == callID {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeArguments re-marshals the arguments, so that the ones differing only in spaces or the order of keys are equal.
+func normalizeArguments(arguments string) string {
+	var v any
+	if err := json.Unmarshal([]byte(arguments), &v); err != nil {
+		return arguments
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return arguments
+	}
+	return string(b)
+}
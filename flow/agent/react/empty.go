@@ -0,0 +1,123 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/internal/generic"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrEmptyResponse is returned when the model answers with an empty message and AgentConfig.EmptyResponsePolicy
+// is EmptyResponseError, or is EmptyResponseRetry and the retry is empty too.
+var ErrEmptyResponse = errors.New("model returned an empty message")
+
+// EmptyResponsePolicy is the way the agent handles an empty message returned by the model, see AgentConfig.EmptyResponsePolicy.
+type EmptyResponsePolicy uint8
+
+const (
+	// EmptyResponseAllow returns the empty message as the answer of the agent.
+	EmptyResponseAllow EmptyResponsePolicy = iota
+	// EmptyResponseError fails the agent with ErrEmptyResponse.
+	EmptyResponseError
+	// EmptyResponseRetry calls the model once more with the same input, and fails the agent with ErrEmptyResponse if it's empty again.
+	EmptyResponseRetry
+)
+
+// emptyResponseChatModel wraps the chat model of the agent when AgentConfig.EmptyResponsePolicy is not EmptyResponseAllow.
+// An empty message is told by schema.Message.IsEmpty, in streaming mode it's a stream whose chunks are all empty.
+type emptyResponseChatModel struct {
+	model.BaseChatModel
+	policy EmptyResponsePolicy
+}
+
+func (m *emptyResponseChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	for attempt := 0; ; attempt++ {
+		msg, err := m.BaseChatModel.Generate(ctx, input, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if !msg.IsEmpty() {
+			return msg, nil
+		}
+		if m.policy != EmptyResponseRetry || attempt > 0 {
+			return nil, ErrEmptyResponse
+		}
+	}
+}
+
+func (m *emptyResponseChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (
+	*schema.StreamReader[*schema.Message], error) {
+
+	for attempt := 0; ; attempt++ {
+		sr, err := m.BaseChatModel.Stream(ctx, input, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		// the chunks read until the first non-empty one are replayed to the caller by the other copy.
+		copies := sr.Copy(2)
+		empty, err := isEmptyStream(copies[0])
+		if err != nil {
+			copies[1].Close()
+			return nil, err
+		}
+		if !empty {
+			return copies[1], nil
+		}
+		copies[1].Close()
+
+		if m.policy != EmptyResponseRetry || attempt > 0 {
+			return nil, ErrEmptyResponse
+		}
+	}
+}
+
+// isEmptyStream reads sr until a non-empty chunk, and closes it.
+func isEmptyStream(sr *schema.StreamReader[*schema.Message]) (bool, error) {
+	defer sr.Close()
+
+	for {
+		chunk, err := sr.Recv()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !chunk.IsEmpty() {
+			return false, nil
+		}
+	}
+}
+
+func (m *emptyResponseChatModel) GetType() string {
+	if typ, ok := components.GetType(m.BaseChatModel); ok {
+		return typ
+	}
+	return generic.ParseTypeName(reflect.ValueOf(m.BaseChatModel))
+}
+
+func (m *emptyResponseChatModel) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(m.BaseChatModel)
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+	ub "github.com/cloudwego/eino/utils/callbacks"
+)
+
+// AgentEventType is the type of AgentEvent.
+type AgentEventType string
+
+const (
+	// AgentEventModelThought is emitted when the model returns an assistant message with tool calls.
+	AgentEventModelThought AgentEventType = "model_thought"
+	// AgentEventToolCallStarted is emitted when a tool starts to run.
+	AgentEventToolCallStarted AgentEventType = "tool_call_started"
+	// AgentEventToolResultChunk is emitted for each chunk of the output of a streamable tool while it runs, if AgentConfig.StreamingTools is set.
+	AgentEventToolResultChunk AgentEventType = "tool_result_chunk"
+	// AgentEventToolResult is emitted when a tool returns its result.
+	AgentEventToolResult AgentEventType = "tool_result"
+	// AgentEventFinalAnswer is emitted with the output of the agent, as the last event of the run.
+	AgentEventFinalAnswer AgentEventType = "final_answer"
+)
+
+// AgentEvent is an intermediate step of the agent run, see Agent.StreamEvents.
+type AgentEvent struct {
+	Type AgentEventType
+	// Message is the assistant message of AgentEventModelThought, the tool message of AgentEventToolResult,
+	// the tool message chunk of AgentEventToolResultChunk, and the output of the agent of AgentEventFinalAnswer.
+	Message *schema.Message
+	// ToolCall is the tool call of AgentEventToolCallStarted, AgentEventToolResultChunk and AgentEventToolResult.
+	ToolCall *schema.ToolCall
+}
+
+// StreamEvents runs the agent and returns a stream of the intermediate steps of the run, in the order they happen,
+// e.g. to show "calling get_weather(北京)..." to users progressively.
+// The stream ends with an AgentEventFinalAnswer event when the run completes, or with the error of the run if it fails.
+// Closing the stream before it ends cancels the run.
+// NOTE: the agent runs in Generate mode, so the events carry complete messages instead of message chunks,
+// except the AgentEventToolResultChunk events forwarding the output of streamable tools if AgentConfig.StreamingTools is set.
+// The chunks of a tool call are emitted in order, before the AgentEventToolResult event of it,
+// while the chunks of tool calls running in parallel may interleave, which are told apart by ToolCall.ID.
+func (r *Agent) StreamEvents(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (
+	*schema.StreamReader[*AgentEvent], error) {
+
+	sr, sw := schema.Pipe[*AgentEvent](0)
+	ctx, cancel := context.WithCancel(ctx)
+
+	send := func(e *AgentEvent) {
+		if closed := sw.Send(e, nil); closed {
+			cancel()
+		}
+	}
+
+	cmHandler := &ub.ModelCallbackHandler{
+		OnEnd: func(ctx context.Context, _ *callbacks.RunInfo, output *model.CallbackOutput) context.Context {
+			if output.Message != nil && len(output.Message.ToolCalls) > 0 {
+				send(&AgentEvent{Type: AgentEventModelThought, Message: output.Message})
+			}
+			return ctx
+		},
+	}
+
+	toolHandler := &ub.ToolCallbackHandler{
+		OnStart: func(ctx context.Context, info *callbacks.RunInfo, input *tool.CallbackInput) context.Context {
+			tc := &schema.ToolCall{
+				ID:       compose.GetToolCallID(ctx),
+				Type:     "function",
+				Function: schema.FunctionCall{Name: info.Name, Arguments: input.ArgumentsInJSON},
+			}
+			send(&AgentEvent{Type: AgentEventToolCallStarted, ToolCall: tc})
+			return context.WithValue(ctx, agentEventToolCallKey{}, tc)
+		},
+		OnEnd: func(ctx context.Context, info *callbacks.RunInfo, output *tool.CallbackOutput) context.Context {
+			send(toolResultEvent(ctx, info, output.Response))
+			return ctx
+		},
+		OnEndWithStreamOutput: func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[*tool.CallbackOutput]) context.Context {
+			defer output.Close()
+
+			var sb strings.Builder
+			for {
+				chunk, err := output.Recv()
+				if err != nil {
+					// the error of the stream, if any, is returned by the run
+					break
+				}
+				sb.WriteString(chunk.Response)
+				if r.streamingTools {
+					e := toolResultEvent(ctx, info, chunk.Response)
+					e.Type = AgentEventToolResultChunk
+					send(e)
+				}
+			}
+			send(toolResultEvent(ctx, info, sb.String()))
+			return ctx
+		},
+	}
+
+	cb := ub.NewHandlerHelper().ChatModel(cmHandler).Tool(toolHandler).Handler()
+	opts = append(opts, agent.WithComposeOptions(compose.WithCallbacks(cb)))
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				sw.Send(nil, safe.NewPanicErr(p, debug.Stack()))
+			}
+			sw.Close()
+			cancel()
+		}()
+
+		out, err := r.Generate(ctx, input, opts...)
+		if err != nil {
+			sw.Send(nil, err)
+			return
+		}
+		send(&AgentEvent{Type: AgentEventFinalAnswer, Message: out})
+	}()
+
+	return sr, nil
+}
+
+type agentEventToolCallKey struct{}
+
+func toolResultEvent(ctx context.Context, info *callbacks.RunInfo, result string) *AgentEvent {
+	tc, _ := ctx.Value(agentEventToolCallKey{}).(*schema.ToolCall)
+	if tc == nil {
+		tc = &schema.ToolCall{ID: compose.GetToolCallID(ctx), Function: schema.FunctionCall{Name: info.Name}}
+	}
+	return &AgentEvent{
+		Type:     AgentEventToolResult,
+		Message:  schema.ToolMessage(result, tc.ID, schema.WithToolName(info.Name)),
+		ToolCall: tc,
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// ToolsPlaceholder is the placeholder in AgentConfig.SystemPromptTemplate, which is replaced by the descriptions of the tools.
+const ToolsPlaceholder = "{tools}"
+
+// DefaultSystemPromptTemplate is a ReAct system prompt that can be used as AgentConfig.SystemPromptTemplate.
+const DefaultSystemPromptTemplate = `You are a helpful assistant that solves the user's task step by step.
+You can call the following tools when needed:
+
+{tools}
+
+Think about which tool fits the task best before calling it, and answer the user directly once you have enough information.`
+
+// FormatToolDescriptions formats the name, description and parameters schema of each tool.
+// Each tool is formatted as a line of "- {name}: {desc}", followed by an indented line of
+// "parameters: {json schema}" if the tool has parameters.
+func FormatToolDescriptions(tools []*schema.ToolInfo) (string, error) {
+	descs := make([]string, 0, len(tools))
+	for _, info := range tools {
+		if info == nil {
+			continue
+		}
+
+		desc := fmt.Sprintf("- %s: %s", info.Name, info.Desc)
+
+		sc, err := info.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return "", fmt.Errorf("convert parameters of tool[%s] to json schema failed: %w", info.Name, err)
+		}
+		if sc != nil {
+			params, err := json.Marshal(sc)
+			if err != nil {
+				return "", fmt.Errorf("marshal parameters of tool[%s] failed: %w", info.Name, err)
+			}
+			desc += "\n  parameters: " + string(params)
+		}
+
+		descs = append(descs, desc)
+	}
+
+	return strings.Join(descs, "\n"), nil
+}
+
+// renderSystemPrompt fills the tools placeholder of the template with the descriptions of the tools.
+func renderSystemPrompt(tmpl string, tools []*schema.ToolInfo) (*schema.Message, error) {
+	if !strings.Contains(tmpl, ToolsPlaceholder) {
+		return nil, fmt.Errorf("system prompt template must contain the %s placeholder", ToolsPlaceholder)
+	}
+
+	descs, err := FormatToolDescriptions(tools)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.SystemMessage(strings.ReplaceAll(tmpl, ToolsPlaceholder, descs)), nil
+}
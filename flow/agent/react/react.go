@@ -18,6 +18,8 @@ package react
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 
 	"github.com/cloudwego/eino/components/model"
@@ -51,6 +53,10 @@ func getToolResultSendersFromCtx(ctx context.Context) *toolResultSenders {
 type state struct {
 	Messages                 []*schema.Message
 	ReturnDirectlyToolCallID string
+	// ArgRepairAttempts is the number of consecutive rounds of the model whose tool call arguments are repaired.
+	ArgRepairAttempts int
+	// ArgRepaired indicates whether tool call arguments are repaired in the current round.
+	ArgRepaired bool
 }
 
 func init() {
@@ -121,6 +127,13 @@ type AgentConfig struct {
 	// NOTE: if both MessageModifier and MessageRewriter are set, MessageRewriter will be called before MessageModifier.
 	MessageRewriter MessageModifier
 
+	// SystemPromptTemplate is the template of the system prompt prepended to the messages before the model is called.
+	// The ToolsPlaceholder {tools} in it is replaced by the name, description and parameters schema of every tool in ToolsConfig.Tools,
+	// so that adding a tool doesn't require editing the prompt, see FormatToolDescriptions for the format.
+	// Optional. No system prompt is added if not set, use DefaultSystemPromptTemplate for a ready-made one.
+	// NOTE: the system prompt is not stored in the state, and is added before MessageModifier is called.
+	SystemPromptTemplate string
+
 	// MaxStep.
 	// default 12 of steps in pregel (node num + 10).
 	MaxStep int `json:"max_step"`
@@ -144,6 +157,45 @@ type AgentConfig struct {
 	// Note: If your ChatModel doesn't output tool calls first, you can try adding prompts to constrain the model from generating extra text during the tool call.
 	StreamToolCallChecker func(ctx context.Context, modelOutput *schema.StreamReader[*schema.Message]) (bool, error)
 
+	// StreamFinalOnly makes Agent.Stream stream only the terminal turn of the model, i.e. the final answer without tool calls.
+	// The intermediate turns deciding tool calls are buffered and passed on as a single message, as if the model were Invoked,
+	// so that callbacks and handlers observing the model node don't receive their tokens.
+	// Whether a turn calls tools is decided by StreamToolCallChecker, so the chunks read by it are held back before being streamed.
+	// A model triggering its own callbacks has them suppressed, and the model node reports the buffered turns instead,
+	// so the typed model.CallbackInput and model.CallbackOutput of such a model, e.g. its Config, are not reported.
+	// Optional. By default, every turn of the model is streamed.
+	StreamFinalOnly bool
+
+	// MaxArgRepairAttempts is the max number of consecutive rounds in which the tool calls with invalid arguments,
+	// i.e. arguments that are not valid JSON or fail to unmarshal (compose.ErrInvalidToolArgs), are answered with a correction message
+	// including the expected schema of the arguments, so that the model can call the tools again, instead of failing the agent.
+	// Optional. By default, the agent fails with compose.ErrInvalidToolArgs at once.
+	MaxArgRepairAttempts int
+
+	// DedupToolCalls answers a tool call repeating a call of the same tool with the same arguments in the latest DedupToolCallWindow rounds
+	// of the model with a message telling the model it already called the tool with the same arguments, instead of running the tool again,
+	// which breaks the loop of a model calling the same tool over and over. Arguments differing only in spaces or the order of keys are the same.
+	// Optional. By default, repeated tool calls are run as usual.
+	DedupToolCalls bool
+	// DedupToolCallWindow is the number of the latest rounds of the model calling tools, which are checked for repeated tool calls.
+	// Optional. Default 1, i.e. only the tool calls of the previous round are checked.
+	DedupToolCallWindow int
+
+	// StreamingTools forwards the output of streamable tools chunk by chunk while they run,
+	// as AgentEventToolResultChunk events of Agent.StreamEvents, so that the caller could show the progress of long-running tools.
+	// The tools implementing both InvokableTool and StreamableTool are run by StreamableRun, see compose.ToolsNodeConfig.PreferStreamableTools.
+	// The chunks of a tool call are followed by an AgentEventToolResult event carrying their concatenation,
+	// which is the tool message the model receives in the next turn, i.e. the model still reacts after the tool finishes.
+	// Optional. By default, only the AgentEventToolResult event is emitted for a tool call.
+	StreamingTools bool
+
+	// EmptyResponsePolicy determines how an empty message returned by the model is handled, i.e. a message without content
+	// or tool calls, see schema.Message.IsEmpty, which some models return occasionally and the user would see nothing from.
+	// EmptyResponseError fails the agent with ErrEmptyResponse, EmptyResponseRetry calls the model once more before failing.
+	// In streaming mode, the chunks are read until a non-empty one is received before the stream is returned.
+	// Optional. By default (EmptyResponseAllow), the empty message is returned as the answer.
+	EmptyResponsePolicy EmptyResponsePolicy
+
 	// GraphName is the graph name of the ReAct Agent.
 	// Optional. Default `ReActAgent`.
 	GraphName string
@@ -240,6 +292,7 @@ type Agent struct {
 	runnable         compose.Runnable[[]*schema.Message, *schema.Message]
 	graph            *compose.Graph[[]*schema.Message, *schema.Message]
 	graphAddNodeOpts []compose.GraphAddNodeOpt
+	streamingTools   bool
 }
 
 // NewAgent creates a ReAct agent that feeds tool response into next round of Chat Model generation.
@@ -254,6 +307,7 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 		toolInfos       []*schema.ToolInfo
 		toolCallChecker = config.StreamToolCallChecker
 		messageModifier = config.MessageModifier
+		systemPrompt    *schema.Message
 	)
 
 	graphName := GraphName
@@ -283,10 +337,35 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 		return nil, err
 	}
 
-	config.ToolsConfig.ToolCallMiddlewares = append(
-		[]compose.ToolMiddleware{newToolResultCollectorMiddleware()},
-		config.ToolsConfig.ToolCallMiddlewares...,
-	)
+	if config.EmptyResponsePolicy != EmptyResponseAllow {
+		chatModel = &emptyResponseChatModel{BaseChatModel: chatModel, policy: config.EmptyResponsePolicy}
+	}
+
+	if config.StreamFinalOnly {
+		chatModel = &finalOnlyChatModel{BaseChatModel: chatModel, toolCallChecker: toolCallChecker}
+	}
+
+	if config.SystemPromptTemplate != "" {
+		if systemPrompt, err = renderSystemPrompt(config.SystemPromptTemplate, toolInfos); err != nil {
+			return nil, err
+		}
+	}
+
+	middlewares := []compose.ToolMiddleware{newToolResultCollectorMiddleware()}
+	if config.DedupToolCalls {
+		window := config.DedupToolCallWindow
+		if window <= 0 {
+			window = 1
+		}
+		middlewares = append(middlewares, newDedupToolCallMiddleware(window))
+	}
+	if config.MaxArgRepairAttempts > 0 {
+		middlewares = append(middlewares, newArgRepairMiddleware(toolInfos, config.MaxArgRepairAttempts))
+	}
+	config.ToolsConfig.ToolCallMiddlewares = append(middlewares, config.ToolsConfig.ToolCallMiddlewares...)
+	if config.StreamingTools {
+		config.ToolsConfig.PreferStreamableTools = true
+	}
 
 	if toolsNode, err = compose.NewToolNode(ctx, &config.ToolsConfig); err != nil {
 		return nil, err
@@ -299,16 +378,30 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 	modelPreHandle := func(ctx context.Context, input []*schema.Message, state *state) ([]*schema.Message, error) {
 		state.Messages = append(state.Messages, input...)
 
+		// consecutive rounds repairing tool call arguments are counted, while a round without repairing resets the count
+		if state.ArgRepaired {
+			state.ArgRepairAttempts++
+		} else {
+			state.ArgRepairAttempts = 0
+		}
+		state.ArgRepaired = false
+
 		if config.MessageRewriter != nil {
 			state.Messages = config.MessageRewriter(ctx, state.Messages)
 		}
 
-		if messageModifier == nil {
+		if systemPrompt == nil && messageModifier == nil {
 			return state.Messages, nil
 		}
 
-		modifiedInput := make([]*schema.Message, len(state.Messages))
-		copy(modifiedInput, state.Messages)
+		modifiedInput := make([]*schema.Message, 0, len(state.Messages)+1)
+		if systemPrompt != nil {
+			modifiedInput = append(modifiedInput, systemPrompt)
+		}
+		modifiedInput = append(modifiedInput, state.Messages...)
+		if messageModifier == nil {
+			return modifiedInput, nil
+		}
 		return messageModifier(ctx, modifiedInput), nil
 	}
 
@@ -324,6 +417,11 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 		if input == nil {
 			return state.Messages[len(state.Messages)-1], nil // used for rerun interrupt resume
 		}
+		if config.MaxArgRepairAttempts <= 0 {
+			if err := checkToolCallArguments(input); err != nil {
+				return nil, err
+			}
+		}
 		state.Messages = append(state.Messages, input)
 		state.ReturnDirectlyToolCallID = getReturnDirectlyToolCallID(input, config.ToolReturnDirectly)
 		return input, nil
@@ -359,6 +457,7 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 		runnable:         runnable,
 		graph:            graph,
 		graphAddNodeOpts: []compose.GraphAddNodeOpt{compose.WithGraphCompileOptions(compileOpts...)},
+		streamingTools:   config.StreamingTools,
 	}, nil
 }
 
@@ -428,6 +527,20 @@ func genToolInfos(ctx context.Context, config compose.ToolsNodeConfig) ([]*schem
 	return toolInfos, nil
 }
 
+// checkToolCallArguments makes sure the arguments of each tool call form a complete JSON document.
+// In stream mode the arguments are accumulated from the fragments sharing the same ToolCall.Index,
+// so a truncated stream surfaces here rather than as an opaque unmarshal error inside the tool.
+func checkToolCallArguments(input *schema.Message) error {
+	for i, tc := range input.ToolCalls {
+		if len(tc.Function.Arguments) == 0 || json.Valid([]byte(tc.Function.Arguments)) {
+			continue
+		}
+		return fmt.Errorf("%w: arguments of tool call[index:%d id:%s name:%s] are not complete JSON, "+
+			"the streamed argument fragments may be truncated: %s", compose.ErrInvalidToolArgs, i, tc.ID, tc.Function.Name, tc.Function.Arguments)
+	}
+	return nil
+}
+
 func getReturnDirectlyToolCallID(input *schema.Message, toolReturnDirectly map[string]struct{}) string {
 	if len(toolReturnDirectly) == 0 {
 		return ""
@@ -456,3 +569,18 @@ func (r *Agent) Stream(ctx context.Context, input []*schema.Message, opts ...age
 func (r *Agent) ExportGraph() (compose.AnyGraph, []compose.GraphAddNodeOpt) {
 	return r.graph, r.graphAddNodeOpts
 }
+
+// AddAgentNode adds the agent as a node of g, which takes []*schema.Message as input and outputs *schema.Message.
+// It's a shortcut of adding the graph exported by ExportGraph with AddGraphNode, so unlike wrapping Generate and Stream in a lambda,
+// the call options of the outer graph propagate into the agent, e.g. callbacks and chat model options,
+// which can also be designated to the nodes inside the agent with the node path, e.g. compose.NewNodePath(key, "chat").
+// e.g.
+//
+//	g := compose.NewGraph[string, string]()
+//	_ = g.AddLambdaNode("classify", classifyLambda)
+//	_ = react.AddAgentNode(g, "agent", agent)
+//	_ = g.AddLambdaNode("post_process", postProcessLambda)
+func AddAgentNode[I, O any](g *compose.Graph[I, O], key string, a *Agent, opts ...compose.GraphAddNodeOpt) error {
+	ag, agOpts := a.ExportGraph()
+	return g.AddGraphNode(key, ag, append(agOpts, opts...)...)
+}
@@ -22,16 +22,22 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/bytedance/sonic"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/internal/generic"
 	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
 	"github.com/cloudwego/eino/schema"
 	template "github.com/cloudwego/eino/utils/callbacks"
@@ -190,6 +196,413 @@ func TestReactWithMessageRewriterAndModifier(t *testing.T) {
 	assert.Equal(t, "final response", finalMsg.Content)
 }
 
+func TestReactWithSystemPromptTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	greet := &fakeToolGreetForTest{tarCount: 1}
+	info, err := greet.Info(ctx)
+	assert.NoError(t, err)
+
+	descs, err := FormatToolDescriptions([]*schema.ToolInfo{info, {Name: "no_param", Desc: "tool without parameters"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "- greet: greet with name\n"+
+		`  parameters: {"properties":{"name":{"description":"user name who to greet","type":"string"}},"required":["name"],"type":"object"}`+"\n"+
+		"- no_param: tool without parameters", descs)
+
+	t.Run("tools are injected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				// Expected: [system prompt with tools, persona, user]
+				assert.Len(t, input, 3)
+				assert.Equal(t, schema.System, input[0].Role)
+				assert.Equal(t, "tools:\n"+strings.Split(descs, "\n- no_param")[0], input[0].Content)
+				assert.Equal(t, "persona", input[1].Content)
+				assert.Equal(t, "hello", input[2].Content)
+				return schema.AssistantMessage("final response", nil), nil
+			}).Times(2)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+
+		ra, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel:     cm,
+			ToolsConfig:          compose.ToolsNodeConfig{Tools: []tool.BaseTool{greet}},
+			SystemPromptTemplate: "tools:\n{tools}",
+			MessageModifier: func(ctx context.Context, messages []*schema.Message) []*schema.Message {
+				// the rendered system prompt is added before the modifier is called
+				assert.Len(t, messages, 2)
+				return append([]*schema.Message{messages[0], schema.SystemMessage("persona")}, messages[1:]...)
+			},
+		})
+		assert.NoError(t, err)
+
+		msg, err := ra.Generate(ctx, []*schema.Message{schema.UserMessage("hello")})
+		assert.NoError(t, err)
+		assert.Equal(t, "final response", msg.Content)
+
+		msg, err = ra.Generate(ctx, []*schema.Message{schema.UserMessage("hello")})
+		assert.NoError(t, err)
+		assert.Equal(t, "final response", msg.Content)
+	})
+
+	t.Run("default template", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				assert.Len(t, input, 2)
+				assert.Contains(t, input[0].Content, "- greet: greet with name")
+				assert.NotContains(t, input[0].Content, ToolsPlaceholder)
+				return schema.AssistantMessage("final response", nil), nil
+			}).Times(1)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+
+		ra, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel:     cm,
+			ToolsConfig:          compose.ToolsNodeConfig{Tools: []tool.BaseTool{greet}},
+			SystemPromptTemplate: DefaultSystemPromptTemplate,
+		})
+		assert.NoError(t, err)
+
+		_, err = ra.Generate(ctx, []*schema.Message{schema.UserMessage("hello")})
+		assert.NoError(t, err)
+	})
+
+	t.Run("template without placeholder", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+
+		_, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel:     cm,
+			ToolsConfig:          compose.ToolsNodeConfig{Tools: []tool.BaseTool{greet}},
+			SystemPromptTemplate: "you are a helpful assistant",
+		})
+		assert.ErrorContains(t, err, "must contain the {tools} placeholder")
+	})
+}
+
+func TestReactTranscript(t *testing.T) {
+	ctx := context.Background()
+
+	toolCallMsg := schema.AssistantMessage("", []schema.ToolCall{
+		{ID: "call_1", Function: schema.FunctionCall{Name: "greet", Arguments: `{"name": "tom"}`}},
+		{ID: "call_2", Function: schema.FunctionCall{Name: "greet", Arguments: `{"name": "jerry"}`}},
+	})
+
+	newAgent := func(t *testing.T, rewriter MessageModifier) *Agent {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+
+		round := 0
+		generate := func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+			// the history sent to the model always keeps tool messages right after their assistant message
+			assert.NoError(t, checkToolMessages(input))
+			round++
+			if round == 1 {
+				return toolCallMsg, nil
+			}
+			assert.Len(t, input, 4)
+			return schema.AssistantMessage("final response", nil), nil
+		}
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(generate).AnyTimes()
+		cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+				msg, err := generate(ctx, input, opts...)
+				if err != nil {
+					return nil, err
+				}
+				return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+			}).AnyTimes()
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+
+		ra, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel: cm,
+			ToolsConfig: compose.ToolsNodeConfig{
+				Tools:               []tool.BaseTool{&fakeToolGreetForTest{tarCount: 10}},
+				ExecuteSequentially: true,
+			},
+			MessageRewriter: rewriter,
+		})
+		assert.NoError(t, err)
+		return ra
+	}
+
+	assertTranscript := func(t *testing.T, msgs []*schema.Message) {
+		assert.Len(t, msgs, 5)
+		assert.Equal(t, "hello", msgs[0].Content)
+		assert.Equal(t, []string{"call_1", "call_2"}, []string{msgs[1].ToolCalls[0].ID, msgs[1].ToolCalls[1].ID})
+		for i, tc := range msgs[1].ToolCalls {
+			// tool messages reference the tool calls of their parent assistant message in order
+			assert.Equal(t, schema.Tool, msgs[2+i].Role)
+			assert.Equal(t, tc.ID, msgs[2+i].ToolCallID)
+		}
+		assert.Equal(t, `{"say": "hello tom"}`, msgs[2].Content)
+		assert.Equal(t, `{"say": "hello jerry"}`, msgs[3].Content)
+		assert.Equal(t, "final response", msgs[4].Content)
+	}
+
+	t.Run("generate", func(t *testing.T) {
+		opt, transcript := WithTranscript()
+		out, err := newAgent(t, nil).Generate(ctx, []*schema.Message{schema.UserMessage("hello")}, opt)
+		assert.NoError(t, err)
+		assert.Equal(t, "final response", out.Content)
+
+		msgs, err := transcript.Messages()
+		assert.NoError(t, err)
+		assertTranscript(t, msgs)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		opt, transcript := WithTranscript()
+		sr, err := newAgent(t, nil).Stream(ctx, []*schema.Message{schema.UserMessage("hello")}, opt)
+		assert.NoError(t, err)
+		out, err := schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "final response", out.Content)
+
+		msgs, err := transcript.Messages()
+		assert.NoError(t, err)
+		assertTranscript(t, msgs)
+	})
+
+	t.Run("user history is not checked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(schema.AssistantMessage("final response", nil), nil).Times(1)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+
+		ra, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel: cm,
+			ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{&fakeToolGreetForTest{tarCount: 10}}},
+		})
+		assert.NoError(t, err)
+
+		// e.g. a history truncated by the user, or tool calls of a provider without IDs
+		out, err := ra.Generate(ctx, []*schema.Message{
+			schema.ToolMessage("a", "call_1"),
+			schema.AssistantMessage("", []schema.ToolCall{{Function: schema.FunctionCall{Name: "greet"}}, {Function: schema.FunctionCall{Name: "greet"}}}),
+			schema.ToolMessage("b", ""), schema.ToolMessage("c", ""),
+			schema.UserMessage("hello"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "final response", out.Content)
+	})
+
+	t.Run("check tool messages", func(t *testing.T) {
+		assert.NoError(t, checkToolMessages([]*schema.Message{
+			schema.UserMessage("hello"), toolCallMsg,
+			schema.ToolMessage("b", "call_2"), schema.ToolMessage("a", "call_1"),
+		}))
+		assert.ErrorContains(t, checkToolMessages([]*schema.Message{
+			schema.UserMessage("hello"), schema.ToolMessage("a", "call_1"),
+		}), "tool message[index:1] references tool call[id:call_1]")
+		assert.ErrorContains(t, checkToolMessages([]*schema.Message{
+			toolCallMsg, schema.ToolMessage("a", "call_1"), schema.UserMessage("hello"), schema.ToolMessage("b", "call_2"),
+		}), "not answered before message[index:2], pending tool call ids: [call_2]")
+	})
+}
+
+func TestReactStreamEvents(t *testing.T) {
+	ctx := context.Background()
+
+	toolCallMsg := schema.AssistantMessage("let me greet them", []schema.ToolCall{
+		{ID: "call_1", Function: schema.FunctionCall{Name: "greet", Arguments: `{"name": "tom"}`}},
+		{ID: "call_2", Function: schema.FunctionCall{Name: "greet", Arguments: `{"name": "jerry"}`}},
+	})
+
+	newAgent := func(t *testing.T, finalErr error) *Agent {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+
+		round := 0
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				round++
+				if round == 1 {
+					return toolCallMsg, nil
+				}
+				if finalErr != nil {
+					return nil, finalErr
+				}
+				return schema.AssistantMessage("final response", nil), nil
+			}).AnyTimes()
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+
+		ra, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel: cm,
+			ToolsConfig: compose.ToolsNodeConfig{
+				Tools:               []tool.BaseTool{&fakeToolGreetForTest{tarCount: 10}},
+				ExecuteSequentially: true,
+			},
+		})
+		assert.NoError(t, err)
+		return ra
+	}
+
+	t.Run("success", func(t *testing.T) {
+		sr, err := newAgent(t, nil).StreamEvents(ctx, []*schema.Message{schema.UserMessage("hello")})
+		assert.NoError(t, err)
+
+		var events []*AgentEvent
+		for {
+			e, err := sr.Recv()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			events = append(events, e)
+		}
+
+		var types []AgentEventType
+		for _, e := range events {
+			types = append(types, e.Type)
+		}
+		assert.Equal(t, []AgentEventType{
+			AgentEventModelThought,
+			AgentEventToolCallStarted, AgentEventToolResult,
+			AgentEventToolCallStarted, AgentEventToolResult,
+			AgentEventFinalAnswer,
+		}, types)
+
+		assert.Equal(t, "let me greet them", events[0].Message.Content)
+		assert.Equal(t, "call_1", events[1].ToolCall.ID)
+		assert.Equal(t, "greet", events[1].ToolCall.Function.Name)
+		assert.Equal(t, `{"name": "tom"}`, events[1].ToolCall.Function.Arguments)
+		assert.Equal(t, "call_1", events[2].ToolCall.ID)
+		assert.Equal(t, schema.ToolMessage(`{"say": "hello tom"}`, "call_1", schema.WithToolName("greet")), events[2].Message)
+		assert.Equal(t, "call_2", events[3].ToolCall.ID)
+		assert.Equal(t, `{"say": "hello jerry"}`, events[4].Message.Content)
+		assert.Equal(t, "final response", events[5].Message.Content)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		modelErr := errors.New("model error")
+		sr, err := newAgent(t, modelErr).StreamEvents(ctx, []*schema.Message{schema.UserMessage("hello")})
+		assert.NoError(t, err)
+
+		var types []AgentEventType
+		for {
+			e, err := sr.Recv()
+			if err == io.EOF {
+				assert.Fail(t, "stream should end with the error of the run")
+				break
+			}
+			if err != nil {
+				assert.ErrorIs(t, err, modelErr)
+				break
+			}
+			types = append(types, e.Type)
+		}
+		assert.NotContains(t, types, AgentEventFinalAnswer)
+
+		_, err = sr.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+}
+
+// progressToolForTest reports the progress of its work in three chunks when streamed.
+type progressToolForTest struct{}
+
+func (t *progressToolForTest) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "download", Desc: "download a file"}, nil
+}
+
+func (t *progressToolForTest) InvokableRun(_ context.Context, _ string, _ ...tool.Option) (string, error) {
+	return "done", nil
+}
+
+func (t *progressToolForTest) StreamableRun(_ context.Context, _ string, _ ...tool.Option) (*schema.StreamReader[string], error) {
+	return schema.StreamReaderFromArray([]string{"33%;", "66%;", "done"}), nil
+}
+
+func TestReactStreamingTools(t *testing.T) {
+	ctx := context.Background()
+
+	run := func(t *testing.T, streamingTools bool) ([]*AgentEvent, []*schema.Message) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+
+		var lastInput []*schema.Message
+		round := 0
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				lastInput = input
+				round++
+				if round == 1 {
+					return schema.AssistantMessage("", []schema.ToolCall{
+						{ID: "call_1", Function: schema.FunctionCall{Name: "download", Arguments: `{}`}},
+					}), nil
+				}
+				return schema.AssistantMessage("downloaded", nil), nil
+			}).AnyTimes()
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+
+		ra, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel: cm,
+			ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{&progressToolForTest{}}},
+			StreamingTools:   streamingTools,
+		})
+		assert.NoError(t, err)
+
+		sr, err := ra.StreamEvents(ctx, []*schema.Message{schema.UserMessage("download it")})
+		assert.NoError(t, err)
+		var events []*AgentEvent
+		for {
+			e, err := sr.Recv()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			events = append(events, e)
+		}
+		return events, lastInput
+	}
+
+	t.Run("streaming", func(t *testing.T) {
+		events, lastInput := run(t, true)
+
+		var types []AgentEventType
+		var chunks []string
+		for _, e := range events {
+			types = append(types, e.Type)
+			if e.Type == AgentEventToolResultChunk {
+				assert.Equal(t, "call_1", e.ToolCall.ID)
+				assert.Equal(t, "call_1", e.Message.ToolCallID)
+				chunks = append(chunks, e.Message.Content)
+			}
+		}
+		assert.Equal(t, []AgentEventType{
+			AgentEventModelThought,
+			AgentEventToolCallStarted,
+			AgentEventToolResultChunk, AgentEventToolResultChunk, AgentEventToolResultChunk,
+			AgentEventToolResult,
+			AgentEventFinalAnswer,
+		}, types)
+		assert.Equal(t, []string{"33%;", "66%;", "done"}, chunks)
+
+		// the final tool message consolidates the chunks, which is what the model receives
+		assert.Equal(t, schema.ToolMessage("33%;66%;done", "call_1", schema.WithToolName("download")), events[5].Message)
+		assert.Equal(t, "33%;66%;done", lastInput[len(lastInput)-1].Content)
+	})
+
+	t.Run("not streaming", func(t *testing.T) {
+		events, lastInput := run(t, false)
+
+		var types []AgentEventType
+		for _, e := range events {
+			types = append(types, e.Type)
+		}
+		assert.Equal(t, []AgentEventType{
+			AgentEventModelThought, AgentEventToolCallStarted, AgentEventToolResult, AgentEventFinalAnswer,
+		}, types)
+		assert.Equal(t, "done", lastInput[len(lastInput)-1].Content)
+	})
+}
+
 func TestReactStream(t *testing.T) {
 	ctx := context.Background()
 
@@ -396,6 +809,463 @@ func TestReactStream(t *testing.T) {
 	t.Log("parallel tool call with return directly: ", msg.Content)
 }
 
+func TestReactStreamFragmentedToolCallArguments(t *testing.T) {
+	ctx := context.Background()
+
+	fakeTool := &fakeToolGreetForTest{tarCount: 20}
+	info, err := fakeTool.Info(ctx)
+	assert.NoError(t, err)
+
+	newModel := func(t *testing.T, fragments ...string) model.ChatModel {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockChatModel(ctrl)
+		cm.EXPECT().BindTools(gomock.Any()).Return(nil).AnyTimes()
+		times := 0
+		cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (
+				*schema.StreamReader[*schema.Message], error) {
+				times++
+				if times > 1 {
+					return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("bye", nil)}), nil
+				}
+
+				chunks := make([]*schema.Message, 0, len(fragments))
+				for i, fragment := range fragments {
+					tc := schema.ToolCall{Index: generic.PtrOf(0), Function: schema.FunctionCall{Arguments: fragment}}
+					if i == 0 {
+						tc.ID = "call_1"
+						tc.Function.Name = info.Name
+					}
+					chunks = append(chunks, schema.AssistantMessage("", []schema.ToolCall{tc}))
+				}
+				return schema.StreamReaderFromArray(chunks), nil
+			}).AnyTimes()
+		return cm
+	}
+
+	t.Run("accumulated arguments", func(t *testing.T) {
+		var received []string
+		a, err := NewAgent(ctx, &AgentConfig{
+			Model: newModel(t, `{"na`, `me": `, `"max"}`),
+			ToolsConfig: compose.ToolsNodeConfig{
+				Tools: []tool.BaseTool{fakeTool},
+				ToolCallMiddlewares: []compose.ToolMiddleware{{
+					Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+						return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+							received = append(received, input.Arguments)
+							return next(ctx, input)
+						}
+					},
+				}},
+			},
+		})
+		assert.NoError(t, err)
+
+		out, err := a.Stream(ctx, []*schema.Message{schema.UserMessage("greet max")})
+		assert.NoError(t, err)
+		msg, err := schema.ConcatMessageStream(out)
+		assert.NoError(t, err)
+		assert.Equal(t, "bye", msg.Content)
+		assert.Equal(t, []string{`{"name": "max"}`}, received)
+	})
+
+	t.Run("truncated arguments", func(t *testing.T) {
+		a, err := NewAgent(ctx, &AgentConfig{
+			Model: newModel(t, `{"na`, `me": "ma`),
+			ToolsConfig: compose.ToolsNodeConfig{
+				Tools: []tool.BaseTool{fakeTool},
+			},
+		})
+		assert.NoError(t, err)
+
+		out, err := a.Stream(ctx, []*schema.Message{schema.UserMessage("greet max")})
+		if err == nil {
+			_, err = schema.ConcatMessageStream(out)
+		}
+		assert.ErrorContains(t, err, "not complete JSON")
+		assert.ErrorContains(t, err, "name:greet")
+	})
+}
+
+// callbackEnabledModel triggers the callbacks of its Stream itself, as model providers do.
+type callbackEnabledModel struct {
+	model.ToolCallingChatModel
+}
+
+func (m *callbackEnabledModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	cm, err := m.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &callbackEnabledModel{ToolCallingChatModel: cm}, nil
+}
+
+func (m *callbackEnabledModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (
+	*schema.StreamReader[*schema.Message], error) {
+
+	ctx = callbacks.EnsureRunInfo(ctx, "Stub", components.ComponentOfChatModel)
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input})
+	sr, err := m.ToolCallingChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		callbacks.OnError(ctx, err)
+		return nil, err
+	}
+	_, out := callbacks.OnEndWithStreamOutput(ctx, schema.StreamReaderWithConvert(sr,
+		func(msg *schema.Message) (*model.CallbackOutput, error) {
+			return &model.CallbackOutput{Message: msg}, nil
+		}))
+	return schema.StreamReaderWithConvert(out, func(o *model.CallbackOutput) (*schema.Message, error) {
+		return o.Message, nil
+	}), nil
+}
+
+func (m *callbackEnabledModel) IsCallbacksEnabled() bool {
+	return true
+}
+
+func TestReactStreamFinalOnly(t *testing.T) {
+	ctx := context.Background()
+
+	fakeTool := &fakeToolGreetForTest{tarCount: 20}
+	info, err := fakeTool.Info(ctx)
+	assert.NoError(t, err)
+
+	newModel := func(t *testing.T) model.ToolCallingChatModel {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		times := 0
+		cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (
+				*schema.StreamReader[*schema.Message], error) {
+				times++
+				if times > 1 {
+					return schema.StreamReaderFromArray([]*schema.Message{
+						schema.AssistantMessage("hello ", nil),
+						schema.AssistantMessage("max", nil),
+					}), nil
+				}
+				return schema.StreamReaderFromArray([]*schema.Message{
+					schema.AssistantMessage("", []schema.ToolCall{{Index: generic.PtrOf(0), ID: "call_1",
+						Function: schema.FunctionCall{Name: info.Name, Arguments: `{"name": `}}}),
+					schema.AssistantMessage("", []schema.ToolCall{{Index: generic.PtrOf(0),
+						Function: schema.FunctionCall{Arguments: `"max"}`}}}),
+				}), nil
+			}).Times(2)
+		return cm
+	}
+
+	// run returns the chunks of every model turn observed by the callbacks, and the chunks streamed by the agent.
+	run := func(t *testing.T, streamFinalOnly bool, cm model.ToolCallingChatModel) (turns [][]*schema.Message, output []*schema.Message) {
+		a, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel: cm,
+			ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{fakeTool}},
+			StreamFinalOnly:  streamFinalOnly,
+		})
+		assert.NoError(t, err)
+
+		handler := BuildAgentCallback(&template.ModelCallbackHandler{
+			OnEndWithStreamOutput: func(ctx context.Context, runInfo *callbacks.RunInfo, output *schema.StreamReader[*model.CallbackOutput]) context.Context {
+				defer output.Close()
+				var chunks []*schema.Message
+				for {
+					chunk, err := output.Recv()
+					if err != nil {
+						break
+					}
+					chunks = append(chunks, chunk.Message)
+				}
+				turns = append(turns, chunks)
+				return ctx
+			},
+		}, nil)
+
+		sr, err := a.Stream(ctx, []*schema.Message{schema.UserMessage("greet max")}, agent.WithComposeOptions(compose.WithCallbacks(handler)))
+		assert.NoError(t, err)
+		defer sr.Close()
+		for {
+			chunk, err := sr.Recv()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			output = append(output, chunk)
+		}
+		return turns, output
+	}
+
+	t.Run("stream every turn", func(t *testing.T) {
+		turns, output := run(t, false, newModel(t))
+		assert.Len(t, turns, 2)
+		assert.Len(t, turns[0], 2)
+		assert.Len(t, turns[1], 2)
+		assert.Len(t, output, 2)
+	})
+
+	t.Run("stream final only", func(t *testing.T) {
+		turns, output := run(t, true, newModel(t))
+		assert.Len(t, turns, 2)
+		if assert.Len(t, turns[0], 1) {
+			assert.Equal(t, `{"name": "max"}`, turns[0][0].ToolCalls[0].Function.Arguments)
+		}
+		assert.Len(t, turns[1], 2)
+		if assert.Len(t, output, 2) {
+			assert.Equal(t, "hello ", output[0].Content)
+			assert.Equal(t, "max", output[1].Content)
+		}
+	})
+
+	t.Run("stream final only with callbacks enabled model", func(t *testing.T) {
+		turns, output := run(t, true, &callbackEnabledModel{ToolCallingChatModel: newModel(t)})
+		assert.Len(t, turns, 2)
+		if assert.Len(t, turns[0], 1) {
+			assert.Equal(t, `{"name": "max"}`, turns[0][0].ToolCalls[0].Function.Arguments)
+		}
+		assert.Len(t, turns[1], 2)
+		assert.Len(t, output, 2)
+	})
+}
+
+func TestReactArgRepair(t *testing.T) {
+	ctx := context.Background()
+
+	fakeTool := &fakeToolGreetForTest{tarCount: 20}
+	info, err := fakeTool.Info(ctx)
+	assert.NoError(t, err)
+
+	// newModel returns a model calling the tool with badRounds rounds of malformed arguments before the valid ones.
+	newModel := func(t *testing.T, badRounds int, inputs *[][]*schema.Message) model.ChatModel {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockChatModel(ctrl)
+		cm.EXPECT().BindTools(gomock.Any()).Return(nil).AnyTimes()
+		times := 0
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				*inputs = append(*inputs, input)
+				times++
+				args := `{"name": "max"}`
+				switch {
+				case times <= badRounds:
+					args = `{"name": "max"`
+				case times > badRounds+1:
+					return schema.AssistantMessage("bye", nil), nil
+				}
+				return schema.AssistantMessage("", []schema.ToolCall{{
+					ID:       fmt.Sprintf("call_%d", times),
+					Function: schema.FunctionCall{Name: info.Name, Arguments: args},
+				}}), nil
+			}).AnyTimes()
+		return cm
+	}
+
+	t.Run("repaired", func(t *testing.T) {
+		var inputs [][]*schema.Message
+		a, err := NewAgent(ctx, &AgentConfig{
+			Model:                newModel(t, 1, &inputs),
+			ToolsConfig:          compose.ToolsNodeConfig{Tools: []tool.BaseTool{fakeTool}},
+			MaxArgRepairAttempts: 1,
+		})
+		assert.NoError(t, err)
+
+		out, err := a.Generate(ctx, []*schema.Message{schema.UserMessage("greet max")})
+		assert.NoError(t, err)
+		assert.Equal(t, "bye", out.Content)
+		assert.Len(t, inputs, 3)
+
+		correction := inputs[1][len(inputs[1])-1]
+		assert.Equal(t, schema.Tool, correction.Role)
+		assert.Equal(t, "call_1", correction.ToolCallID)
+		assert.Contains(t, correction.Content, "not valid JSON")
+		assert.Contains(t, correction.Content, `"required":["name"]`)
+
+		assert.Contains(t, inputs[2][len(inputs[2])-1].Content, "hello max")
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		var inputs [][]*schema.Message
+		a, err := NewAgent(ctx, &AgentConfig{
+			Model:                newModel(t, 2, &inputs),
+			ToolsConfig:          compose.ToolsNodeConfig{Tools: []tool.BaseTool{fakeTool}},
+			MaxArgRepairAttempts: 1,
+		})
+		assert.NoError(t, err)
+
+		_, err = a.Generate(ctx, []*schema.Message{schema.UserMessage("greet max")})
+		assert.ErrorIs(t, err, compose.ErrInvalidToolArgs)
+		assert.Len(t, inputs, 2)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var inputs [][]*schema.Message
+		a, err := NewAgent(ctx, &AgentConfig{
+			Model:       newModel(t, 1, &inputs),
+			ToolsConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{fakeTool}},
+		})
+		assert.NoError(t, err)
+
+		_, err = a.Generate(ctx, []*schema.Message{schema.UserMessage("greet max")})
+		assert.ErrorIs(t, err, compose.ErrInvalidToolArgs)
+		assert.Len(t, inputs, 1)
+	})
+}
+
+func TestReactDedupToolCalls(t *testing.T) {
+	ctx := context.Background()
+
+	// newModel returns a model calling the greet tool with the arguments of each round in order, then saying bye,
+	// while it gives up calling once told the call is repeated.
+	newModel := func(t *testing.T, rounds []string, inputs *[][]*schema.Message) model.ChatModel {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockChatModel(ctrl)
+		cm.EXPECT().BindTools(gomock.Any()).Return(nil).AnyTimes()
+		times := 0
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				*inputs = append(*inputs, input)
+				if last := input[len(input)-1]; last.Role == schema.Tool && strings.Contains(last.Content, "already called") {
+					return schema.AssistantMessage("stop repeating", nil), nil
+				}
+				if times >= len(rounds) {
+					return schema.AssistantMessage("bye", nil), nil
+				}
+				times++
+				return schema.AssistantMessage("", []schema.ToolCall{{
+					ID:       fmt.Sprintf("call_%d", times),
+					Function: schema.FunctionCall{Name: "greet", Arguments: rounds[times-1]},
+				}}), nil
+			}).AnyTimes()
+		return cm
+	}
+
+	run := func(t *testing.T, config *AgentConfig, rounds []string) (*schema.Message, [][]*schema.Message, int) {
+		var inputs [][]*schema.Message
+		fakeTool := &fakeToolGreetForTest{tarCount: 20}
+		config.Model = newModel(t, rounds, &inputs)
+		config.ToolsConfig = compose.ToolsNodeConfig{Tools: []tool.BaseTool{fakeTool}}
+		a, err := NewAgent(ctx, config)
+		assert.NoError(t, err)
+
+		out, err := a.Generate(ctx, []*schema.Message{schema.UserMessage("greet max")})
+		assert.NoError(t, err)
+		return out, inputs, fakeTool.curCount
+	}
+
+	t.Run("repeated", func(t *testing.T) {
+		out, inputs, toolRuns := run(t, &AgentConfig{DedupToolCalls: true}, []string{`{"name": "max"}`, `{ "name":"max" }`})
+		assert.Equal(t, "stop repeating", out.Content)
+		assert.Equal(t, 1, toolRuns)
+		assert.Len(t, inputs, 3)
+
+		feedback := inputs[2][len(inputs[2])-1]
+		assert.Equal(t, "call_2", feedback.ToolCallID)
+		assert.Contains(t, feedback.Content, `you already called tool greet with the same arguments { "name":"max" }`)
+	})
+
+	t.Run("window", func(t *testing.T) {
+		rounds := []string{`{"name": "max"}`, `{"name": "tom"}`, `{"name": "max"}`}
+
+		out, _, toolRuns := run(t, &AgentConfig{DedupToolCalls: true}, rounds)
+		assert.Equal(t, "bye", out.Content)
+		assert.Equal(t, 3, toolRuns)
+
+		out, _, toolRuns = run(t, &AgentConfig{DedupToolCalls: true, DedupToolCallWindow: 2}, rounds)
+		assert.Equal(t, "stop repeating", out.Content)
+		assert.Equal(t, 2, toolRuns)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		out, _, toolRuns := run(t, &AgentConfig{}, []string{`{"name": "max"}`, `{"name": "max"}`})
+		assert.Equal(t, "bye", out.Content)
+		assert.Equal(t, 2, toolRuns)
+	})
+}
+
+func TestReactEmptyResponse(t *testing.T) {
+	ctx := context.Background()
+
+	// newModel returns a model answering with the given messages in turn, and the pointer to the number of its calls.
+	newModel := func(t *testing.T, answers ...*schema.Message) (model.ToolCallingChatModel, *int) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		times := 0
+		next := func() *schema.Message {
+			msg := answers[times%len(answers)]
+			times++
+			return msg
+		}
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				return next(), nil
+			}).AnyTimes()
+		cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+				msg := next()
+				// an empty answer is streamed as an empty chunk, as some models do
+				return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("", nil), msg}), nil
+			}).AnyTimes()
+		return cm, &times
+	}
+
+	empty := schema.AssistantMessage("", nil)
+	hello := schema.AssistantMessage("hello", nil)
+
+	assert.True(t, (*schema.Message)(nil).IsEmpty())
+	assert.True(t, empty.IsEmpty())
+	assert.False(t, hello.IsEmpty())
+	assert.False(t, schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1"}}).IsEmpty())
+
+	tests := []struct {
+		name    string
+		policy  EmptyResponsePolicy
+		answers []*schema.Message
+		want    *schema.Message
+		wantErr error
+		calls   int
+	}{
+		{name: "allow", policy: EmptyResponseAllow, answers: []*schema.Message{empty, hello}, want: empty, calls: 1},
+		{name: "error", policy: EmptyResponseError, answers: []*schema.Message{empty, hello}, wantErr: ErrEmptyResponse, calls: 1},
+		{name: "retry", policy: EmptyResponseRetry, answers: []*schema.Message{empty, hello}, want: hello, calls: 2},
+		{name: "retry empty again", policy: EmptyResponseRetry, answers: []*schema.Message{empty}, wantErr: ErrEmptyResponse, calls: 2},
+		{name: "not empty", policy: EmptyResponseError, answers: []*schema.Message{hello}, want: hello, calls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("generate", func(t *testing.T) {
+				cm, calls := newModel(t, tt.answers...)
+				a, err := NewAgent(ctx, &AgentConfig{ToolCallingModel: cm, EmptyResponsePolicy: tt.policy})
+				assert.NoError(t, err)
+
+				out, err := a.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					assert.NoError(t, err)
+					assert.Equal(t, tt.want.Content, out.Content)
+				}
+				assert.Equal(t, tt.calls, *calls)
+			})
+
+			t.Run("stream", func(t *testing.T) {
+				cm, calls := newModel(t, tt.answers...)
+				a, err := NewAgent(ctx, &AgentConfig{ToolCallingModel: cm, EmptyResponsePolicy: tt.policy})
+				assert.NoError(t, err)
+
+				sr, err := a.Stream(ctx, []*schema.Message{schema.UserMessage("hi")})
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					assert.NoError(t, err)
+					out, err := schema.ConcatMessageStream(sr)
+					assert.NoError(t, err)
+					assert.Equal(t, tt.want.Content, out.Content)
+				}
+				assert.Equal(t, tt.calls, *calls)
+			})
+		})
+	}
+}
+
 func TestReactWithModifier(t *testing.T) {
 	ctx := context.Background()
 
@@ -610,6 +1480,71 @@ func TestAgentInGraph(t *testing.T) {
 
 }
 
+func TestAddAgentNode(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	cm := mockModel.NewMockToolCallingChatModel(ctrl)
+
+	weatherTool, err := utils.InferTool("get_weather", "查询城市天气", func(ctx context.Context, in *struct {
+		City string `json:"city"`
+	}) (string, error) {
+		return in.City + "：晴，20度", nil
+	})
+	assert.NoError(t, err)
+
+	var temperatures []float32
+	cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+	cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+			temperatures = append(temperatures, *model.GetCommonOptions(&model.Options{}, opts...).Temperature)
+			if input[len(input)-1].Role == schema.Tool {
+				return schema.AssistantMessage("北京今天晴，20度", nil), nil
+			}
+			return schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{
+				Name: "get_weather", Arguments: `{"city":"北京"}`,
+			}}}), nil
+		}).Times(2)
+
+	a, err := NewAgent(ctx, &AgentConfig{
+		ToolCallingModel: cm,
+		ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{weatherTool}},
+	})
+	assert.NoError(t, err)
+
+	// classify -> agent -> post process
+	g := compose.NewGraph[string, string]()
+	assert.NoError(t, g.AddLambdaNode("classify", compose.InvokableLambda(func(ctx context.Context, in string) ([]*schema.Message, error) {
+		return []*schema.Message{schema.UserMessage(in)}, nil
+	})))
+	assert.NoError(t, AddAgentNode(g, "agent", a))
+	assert.NoError(t, g.AddLambdaNode("post_process", compose.InvokableLambda(func(ctx context.Context, in *schema.Message) (string, error) {
+		return "[weather] " + in.Content, nil
+	})))
+	assert.NoError(t, g.AddEdge(compose.START, "classify"))
+	assert.NoError(t, g.AddEdge("classify", "agent"))
+	assert.NoError(t, g.AddEdge("agent", "post_process"))
+	assert.NoError(t, g.AddEdge("post_process", compose.END))
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	var toolCalls int
+	handler := callbacks.NewHandlerBuilder().OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+		if info.Component == compose.ComponentOfToolsNode {
+			toolCalls++
+		}
+		return ctx
+	}).Build()
+
+	out, err := r.Invoke(ctx, "北京天气怎么样",
+		compose.WithCallbacks(handler),
+		compose.WithChatModelOption(model.WithTemperature(0.5)).DesignateNodeWithPath(compose.NewNodePath("agent", nodeKeyModel)))
+	assert.NoError(t, err)
+	assert.Equal(t, "[weather] 北京今天晴，20度", out)
+	// the options of the outer graph propagate into the agent
+	assert.Equal(t, 1, toolCalls)
+	assert.Equal(t, []float32{0.5, 0.5}, temperatures)
+}
+
 func TestWithTools(t *testing.T) {
 	ctx := context.Background()
 
@@ -820,3 +1755,46 @@ func randStr() string {
 }
 
 var callbackForTest = BuildAgentCallback(&template.ModelCallbackHandler{}, &template.ToolCallbackHandler{})
+
+// checkToolMessages makes sure each assistant message with ToolCalls is immediately followed by the tool messages
+// answering all of its tool calls by ToolCallID, which is required by model APIs such as OpenAI.
+func checkToolMessages(msgs []*schema.Message) error {
+	// tool call IDs of the last assistant message which are not answered yet
+	pending := make(map[string]bool)
+	for i, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+
+		if msg.Role == schema.Tool {
+			if !pending[msg.ToolCallID] {
+				return fmt.Errorf("tool message[index:%d] references tool call[id:%s], "+
+					"which is not called by the preceding assistant message or answered already", i, msg.ToolCallID)
+			}
+			delete(pending, msg.ToolCallID)
+			continue
+		}
+
+		if len(pending) > 0 {
+			return fmt.Errorf("tool calls of assistant message are not answered before message[index:%d], pending tool call ids: %v",
+				i, sortedKeys(pending))
+		}
+		for _, tc := range msg.ToolCalls {
+			pending[tc.ID] = true
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("tool calls of the last assistant message are not answered, pending tool call ids: %v", sortedKeys(pending))
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	icb "github.com/cloudwego/eino/internal/callbacks"
+	"github.com/cloudwego/eino/internal/generic"
+	"github.com/cloudwego/eino/schema"
+)
+
+// finalOnlyChatModel wraps the chat model of the agent when AgentConfig.StreamFinalOnly is set.
+// In streaming mode, the turns deciding tool calls are buffered and returned as a single chunk, as if they were Invoked,
+// while the terminal turn without tool calls is streamed chunk by chunk as the model produces it.
+// The callbacks of the wrapped model are suppressed, and the model node reports those of the wrapper instead,
+// so that a model triggering its own callbacks doesn't report the raw chunks of the turns deciding tool calls.
+type finalOnlyChatModel struct {
+	model.BaseChatModel
+	toolCallChecker func(ctx context.Context, modelOutput *schema.StreamReader[*schema.Message]) (bool, error)
+}
+
+func (m *finalOnlyChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.BaseChatModel.Generate(m.suppressCallbacks(ctx), input, opts...)
+}
+
+func (m *finalOnlyChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (
+	*schema.StreamReader[*schema.Message], error) {
+
+	sr, err := m.BaseChatModel.Stream(m.suppressCallbacks(ctx), input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the checker reads only as many chunks as it needs, which are replayed to the caller by the other copy.
+	copies := sr.Copy(2)
+	isToolCall, err := m.toolCallChecker(ctx, copies[0])
+	if err != nil {
+		copies[1].Close()
+		return nil, err
+	}
+	if !isToolCall {
+		return copies[1], nil
+	}
+
+	msg, err := schema.ConcatMessageStream(copies[1])
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *finalOnlyChatModel) GetType() string {
+	if typ, ok := components.GetType(m.BaseChatModel); ok {
+		return typ
+	}
+	return generic.ParseTypeName(reflect.ValueOf(m.BaseChatModel))
+}
+
+// suppressCallbacks keeps the wrapped model from triggering its own callbacks, which the model node reports for the wrapper.
+func (m *finalOnlyChatModel) suppressCallbacks(ctx context.Context) context.Context {
+	if !components.IsCallbacksEnabled(m.BaseChatModel) {
+		return ctx
+	}
+	return icb.SuppressHandlers(ctx)
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+	ub "github.com/cloudwego/eino/utils/callbacks"
+)
+
+// Transcript is the full message trace of an agent run, i.e. the input messages, followed by each assistant message
+// and the tool messages answering its tool calls, in the order they are appended to the history, ending with the final answer.
+type Transcript struct {
+	mu    sync.Mutex
+	wg    sync.WaitGroup
+	slots [][]*schema.Message
+	err   error
+}
+
+// WithTranscript returns an agent option and a Transcript recording the messages of the run.
+// The Transcript is reset when the run starts, and should be read after the run completes,
+// i.e. Generate returns, or the stream returned by Stream is read to the end.
+// eg:
+//
+//	opt, transcript := react.WithTranscript()
+//	out, err := agent.Generate(ctx, input, opt)
+//	msgs, err := transcript.Messages()
+func WithTranscript() (agent.AgentOption, *Transcript) {
+	t := &Transcript{}
+
+	graphHandler := callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, _ *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			msgs, _ := input.([]*schema.Message)
+			t.reset(msgs)
+			return ctx
+		}).
+		OnStartWithStreamInputFn(func(ctx context.Context, _ *callbacks.RunInfo, input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
+			t.reset(nil)
+			t.fill(0, func() ([]*schema.Message, error) {
+				defer input.Close()
+
+				// chunks of the input are parts of the message list
+				var msgs []*schema.Message
+				for {
+					chunk, err := input.Recv()
+					if errors.Is(err, io.EOF) {
+						return msgs, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					part, _ := chunk.([]*schema.Message)
+					msgs = append(msgs, part...)
+				}
+			})
+			return ctx
+		}).Build()
+
+	cmHandler := &ub.ModelCallbackHandler{
+		OnEnd: func(ctx context.Context, _ *callbacks.RunInfo, output *model.CallbackOutput) context.Context {
+			t.append(output.Message)
+			return ctx
+		},
+		OnEndWithStreamOutput: func(ctx context.Context, _ *callbacks.RunInfo, output *schema.StreamReader[*model.CallbackOutput]) context.Context {
+			slot := t.reserve()
+			t.fill(slot, func() ([]*schema.Message, error) {
+				msg, err := schema.ConcatMessageStream(schema.StreamReaderWithConvert(output,
+					func(o *model.CallbackOutput) (*schema.Message, error) {
+						return o.Message, nil
+					}))
+				if err != nil {
+					return nil, err
+				}
+				return []*schema.Message{msg}, nil
+			})
+			return ctx
+		},
+	}
+
+	tnHandler := &ub.ToolsNodeCallbackHandlers{
+		OnEnd: func(ctx context.Context, _ *callbacks.RunInfo, output []*schema.Message) context.Context {
+			t.append(output...)
+			return ctx
+		},
+		OnEndWithStreamOutput: func(ctx context.Context, _ *callbacks.RunInfo, output *schema.StreamReader[[]*schema.Message]) context.Context {
+			slot := t.reserve()
+			t.fill(slot, func() ([]*schema.Message, error) {
+				return concatMessageArrayStream(output)
+			})
+			return ctx
+		},
+	}
+
+	cb := ub.NewHandlerHelper().ChatModel(cmHandler).ToolsNode(tnHandler).Graph(graphHandler).Handler()
+
+	return agent.WithComposeOptions(compose.WithCallbacks(cb)), t
+}
+
+// Messages returns the messages recorded, waiting for the streamed messages to be concatenated.
+// The error is the first one met when concatenating the streamed messages, if any.
+func (t *Transcript) Messages() ([]*schema.Message, error) {
+	t.wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	var msgs []*schema.Message
+	for _, slot := range t.slots {
+		for _, msg := range slot {
+			if msg != nil {
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+	return msgs, nil
+}
+
+func (t *Transcript) reset(input []*schema.Message) {
+	t.wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.slots = [][]*schema.Message{append([]*schema.Message{}, input...)}
+	t.err = nil
+}
+
+func (t *Transcript) append(msgs ...*schema.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.slots = append(t.slots, msgs)
+}
+
+// reserve keeps the position of messages to be concatenated from stream, so that the order of messages is kept.
+func (t *Transcript) reserve() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.slots = append(t.slots, nil)
+	return len(t.slots) - 1
+}
+
+func (t *Transcript) fill(slot int, concat func() ([]*schema.Message, error)) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		msgs, err := concat()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if err != nil {
+			if t.err == nil {
+				t.err = err
+			}
+			return
+		}
+		t.slots[slot] = msgs
+	}()
+}
+
+func concatMessageArrayStream(sr *schema.StreamReader[[]*schema.Message]) ([]*schema.Message, error) {
+	defer sr.Close()
+
+	var chunks [][]*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	return schema.ConcatMessageArray(chunks)
+}
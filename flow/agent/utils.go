@@ -33,6 +33,9 @@ func ChatModelWithTools(cm model.ChatModel, toolCallingModel model.ToolCallingCh
 		if len(toolInfos) == 0 {
 			return toolCallingModel, nil
 		}
+		if err := validateToolSchemas(toolCallingModel, toolInfos); err != nil {
+			return nil, err
+		}
 		return toolCallingModel.WithTools(toolInfos)
 	}
 
@@ -40,6 +43,9 @@ func ChatModelWithTools(cm model.ChatModel, toolCallingModel model.ToolCallingCh
 		if len(toolInfos) == 0 {
 			return cm, nil
 		}
+		if err := validateToolSchemas(cm, toolInfos); err != nil {
+			return nil, err
+		}
 		err := cm.BindTools(toolInfos)
 		if err != nil {
 			return nil, err
@@ -50,3 +56,11 @@ func ChatModelWithTools(cm model.ChatModel, toolCallingModel model.ToolCallingCh
 
 	return nil, errors.New("no chat model provided")
 }
+
+// validateToolSchemas validates the tool schemas by the model if it implements model.ProviderSchemaValidator.
+func validateToolSchemas(m model.BaseChatModel, toolInfos []*schema.ToolInfo) error {
+	if v, ok := m.(model.ProviderSchemaValidator); ok {
+		return v.ValidateToolSchemas(toolInfos)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/components/tool"
+	toolutils "github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/schema"
+)
+
+// RetrieverToolConfig is the config for NewRetrieverTool.
+type RetrieverToolConfig struct {
+	// Retriever is the retriever to search documents with, required.
+	Retriever retriever.Retriever
+	// Name is the name of the tool, required. e.g. "search_docs".
+	Name string
+	// Desc is the description of the tool, which tells the model when to use it, required.
+	Desc string
+	// RetrieveOptions are passed to Retriever.Retrieve on each call, e.g. retriever.WithTopK(3).
+	RetrieveOptions []retriever.Option
+	// DocumentsFormatter formats the retrieved documents into the tool result.
+	// Default is FormatDocuments if not set.
+	DocumentsFormatter func(ctx context.Context, docs []*schema.Document) (string, error)
+}
+
+type retrieverToolRequest struct {
+	Query string `json:"query" jsonschema:"description=the query to search documents with"`
+}
+
+// NewRetrieverTool adapts a retriever into a tool, so that the model can search documents by calling it.
+// The tool can be used in compose.ToolsNodeConfig.Tools together with other tools.
+// e.g.
+//
+//	searchTool, err := utils.NewRetrieverTool(ctx, &utils.RetrieverToolConfig{
+//		Retriever: retriever,
+//		Name:      "search_docs",
+//		Desc:      "search the product documents",
+//	})
+func NewRetrieverTool(ctx context.Context, conf *RetrieverToolConfig) (tool.InvokableTool, error) {
+	if conf == nil || conf.Retriever == nil {
+		return nil, errors.New("retriever is required")
+	}
+	if len(conf.Name) == 0 || len(conf.Desc) == 0 {
+		return nil, errors.New("name and desc of retriever tool are required")
+	}
+
+	formatter := conf.DocumentsFormatter
+	if formatter == nil {
+		formatter = FormatDocuments
+	}
+
+	return toolutils.InferTool(conf.Name, conf.Desc, func(ctx context.Context, req *retrieverToolRequest) (string, error) {
+		docs, err := retrieveWithCallback(ctx, conf.Retriever, req.Query, conf.RetrieveOptions...)
+		if err != nil {
+			return "", err
+		}
+		return formatter(ctx, docs)
+	})
+}
+
+// FormatDocuments formats documents into a numbered list, with the score and source of each document, e.g.
+//
+//	[1] score: 0.8500, source: ./docs/a.md
+//	content of a
+//
+//	[2] score: 0.4200
+//	content of b
+func FormatDocuments(_ context.Context, docs []*schema.Document) (string, error) {
+	if len(docs) == 0 {
+		return "no relevant documents found", nil
+	}
+
+	sb := strings.Builder{}
+	for i, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("[%d] score: %.4f", i+1, doc.Score()))
+		if source, ok := doc.MetaData[parser.MetaKeySource].(string); ok && len(source) > 0 {
+			sb.WriteString(", source: " + source)
+		}
+		sb.WriteString("\n" + doc.Content)
+	}
+
+	return sb.String(), nil
+}
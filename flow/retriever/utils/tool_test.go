@@ -0,0 +1,127 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/components/tool"
+	toolutils "github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+type mockRetriever struct {
+	queries []string
+	topK    *int
+	docs    []*schema.Document
+	err     error
+}
+
+func (m *mockRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	m.queries = append(m.queries, query)
+	m.topK = retriever.GetCommonOptions(&retriever.Options{}, opts...).TopK
+	return m.docs, m.err
+}
+
+func TestRetrieverTool(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewRetrieverTool(ctx, &RetrieverToolConfig{Name: "search_docs", Desc: "search docs"})
+		assert.ErrorContains(t, err, "retriever is required")
+		_, err = NewRetrieverTool(ctx, &RetrieverToolConfig{Retriever: &mockRetriever{}})
+		assert.ErrorContains(t, err, "name and desc")
+	})
+
+	t.Run("format documents", func(t *testing.T) {
+		r := &mockRetriever{docs: []*schema.Document{
+			(&schema.Document{Content: "doc a", MetaData: map[string]any{parser.MetaKeySource: "a.md"}}).WithScore(0.85),
+			(&schema.Document{Content: "doc b"}).WithScore(0.42),
+		}}
+		st, err := NewRetrieverTool(ctx, &RetrieverToolConfig{
+			Retriever:       r,
+			Name:            "search_docs",
+			Desc:            "search docs",
+			RetrieveOptions: []retriever.Option{retriever.WithTopK(2)},
+		})
+		assert.NoError(t, err)
+
+		info, err := st.Info(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "search_docs", info.Name)
+		js, err := info.ParamsOneOf.ToJSONSchema()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"query"}, js.Required)
+
+		out, err := st.InvokableRun(ctx, `{"query":"eino"}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "[1] score: 0.8500, source: a.md\ndoc a\n\n[2] score: 0.4200\ndoc b", out)
+		assert.Equal(t, []string{"eino"}, r.queries)
+		assert.Equal(t, 2, *r.topK)
+
+		r.docs = nil
+		out, err = st.InvokableRun(ctx, `{"query":"nothing"}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "no relevant documents found", out)
+	})
+
+	t.Run("retrieve error", func(t *testing.T) {
+		st, err := NewRetrieverTool(ctx, &RetrieverToolConfig{
+			Retriever: &mockRetriever{err: errors.New("vector store unavailable")},
+			Name:      "search_docs",
+			Desc:      "search docs",
+		})
+		assert.NoError(t, err)
+
+		_, err = st.InvokableRun(ctx, `{"query":"eino"}`)
+		assert.ErrorContains(t, err, "vector store unavailable")
+	})
+
+	t.Run("tools node", func(t *testing.T) {
+		st, err := NewRetrieverTool(ctx, &RetrieverToolConfig{
+			Retriever: &mockRetriever{docs: []*schema.Document{{Content: "eino is a llm framework"}}},
+			Name:      "search_docs",
+			Desc:      "search docs",
+		})
+		assert.NoError(t, err)
+		weather, err := toolutils.InferTool("get_weather", "get weather", func(ctx context.Context, in *struct {
+			City string `json:"city"`
+		}) (string, error) {
+			return "sunny in " + in.City, nil
+		})
+		assert.NoError(t, err)
+
+		tn, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{Tools: []tool.BaseTool{weather, st}})
+		assert.NoError(t, err)
+
+		out, err := tn.Invoke(ctx, schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "1", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"beijing"}`}},
+			{ID: "2", Function: schema.FunctionCall{Name: "search_docs", Arguments: `{"query":"eino"}`}},
+		}))
+		assert.NoError(t, err)
+		assert.Len(t, out, 2)
+		assert.Equal(t, "sunny in beijing", out[0].Content)
+		assert.Equal(t, "[1] score: 0.0000\neino is a llm framework", out[1].Content)
+	})
+}
@@ -15,7 +15,7 @@
  */
 
 // Package utils provides helper utilities for retriever flows, including
-// concurrent retrieval with callback instrumentation.
+// concurrent retrieval with callback instrumentation and adapting a retriever into a tool.
 package utils
 
 import (
@@ -46,29 +46,33 @@ func ConcurrentRetrieveWithCallback(ctx context.Context, tasks []*RetrieveTask)
 	for i := range tasks {
 		wg.Add(1)
 		go func(ctx context.Context, t *RetrieveTask) {
-			ctx = ctxWithRetrieverRunInfo(ctx, t.Retriever)
+			defer wg.Done()
+			t.Result, t.Err = retrieveWithCallback(ctx, t.Retriever, t.Query, t.RetrieveOptions...)
+		}(ctx, tasks[i])
+	}
+	wg.Wait()
+}
 
-			defer func() {
-				if e := recover(); e != nil {
-					t.Err = fmt.Errorf("retrieve panic, query: %s, error: %v", t.Query, e)
-					ctx = callbacks.OnError(ctx, t.Err)
-				}
-				wg.Done()
-			}()
+// retrieveWithCallback retrieves documents with the callbacks of the retriever, recovering from panics.
+func retrieveWithCallback(ctx context.Context, r retriever.Retriever, query string, opts ...retriever.Option) (docs []*schema.Document, err error) {
+	ctx = ctxWithRetrieverRunInfo(ctx, r)
 
-			ctx = callbacks.OnStart(ctx, t.Query)
-			docs, err := t.Retriever.Retrieve(ctx, t.Query, t.RetrieveOptions...)
-			if err != nil {
-				callbacks.OnError(ctx, err)
-				t.Err = err
-				return
-			}
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("retrieve panic, query: %s, error: %v", query, e)
+			callbacks.OnError(ctx, err)
+		}
+	}()
 
-			callbacks.OnEnd(ctx, docs)
-			t.Result = docs
-		}(ctx, tasks[i])
+	ctx = callbacks.OnStart(ctx, query)
+	docs, err = r.Retrieve(ctx, query, opts...)
+	if err != nil {
+		callbacks.OnError(ctx, err)
+		return nil, err
 	}
-	wg.Wait()
+
+	callbacks.OnEnd(ctx, docs)
+	return docs, nil
 }
 
 func ctxWithRetrieverRunInfo(ctx context.Context, r retriever.Retriever) context.Context {
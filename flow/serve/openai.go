@@ -0,0 +1,477 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ChatAgent is an agent answering chat messages, e.g. *react.Agent.
+type ChatAgent interface {
+	Generate(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.Message, error)
+	Stream(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.StreamReader[*schema.Message], error)
+}
+
+// ChatCompletionsHandlerConfig is the config for ChatCompletionsHandler.
+type ChatCompletionsHandlerConfig struct {
+	// Runnable is the compiled graph to serve, which takes the messages of the request and returns the assistant message.
+	// Either Runnable or Agent is required.
+	Runnable compose.Runnable[[]*schema.Message, *schema.Message]
+	// Agent is the agent to serve, e.g. *react.Agent.
+	// Either Runnable or Agent is required.
+	Agent ChatAgent
+	// Model is the model name in the responses when the request doesn't specify one.
+	// Optional.
+	Model string
+	// CallOptions returns the call options of the graph for the request, e.g. callbacks for tracing.
+	// They're passed to Agent by agent.WithComposeOptions.
+	// Optional.
+	CallOptions func(r *http.Request) []compose.Option
+}
+
+// ChatCompletionsHandler is a http.Handler serving a compiled graph or an agent as an OpenAI-compatible
+// chat completions endpoint, so that existing OpenAI clients can talk to it, e.g.
+//
+//	h, _ := serve.NewChatCompletionsHandler(ctx, &serve.ChatCompletionsHandlerConfig{Agent: reactAgent, Model: "my-agent"})
+//	http.Handle("/v1/chat/completions", h)
+//
+// The messages of the request are converted to schema.Message, including the tool calls of assistant messages and tool messages.
+// The answer is returned as a chat completion, or as chat completion chunks by server-sent events ended by "[DONE]"
+// if the request sets stream. The tool calls of the answer are returned as the tool_calls of the choice.
+// The other request parameters, such as tools and temperature, are ignored, since they're determined by the graph or agent.
+type ChatCompletionsHandler struct {
+	generate    func(ctx context.Context, input []*schema.Message, opts ...compose.Option) (*schema.Message, error)
+	stream      func(ctx context.Context, input []*schema.Message, opts ...compose.Option) (*schema.StreamReader[*schema.Message], error)
+	model       string
+	callOptions func(r *http.Request) []compose.Option
+}
+
+// NewChatCompletionsHandler creates a new ChatCompletionsHandler.
+func NewChatCompletionsHandler(_ context.Context, conf *ChatCompletionsHandlerConfig) (*ChatCompletionsHandler, error) {
+	if conf == nil || (conf.Runnable == nil && conf.Agent == nil) {
+		return nil, errors.New("runnable or agent of chat completions handler is required")
+	}
+	if conf.Runnable != nil && conf.Agent != nil {
+		return nil, errors.New("only one of runnable and agent of chat completions handler can be set")
+	}
+
+	h := &ChatCompletionsHandler{
+		model:       conf.Model,
+		callOptions: conf.CallOptions,
+	}
+	if conf.Runnable != nil {
+		h.generate = conf.Runnable.Invoke
+		h.stream = conf.Runnable.Stream
+	} else {
+		a := conf.Agent
+		h.generate = func(ctx context.Context, input []*schema.Message, opts ...compose.Option) (*schema.Message, error) {
+			return a.Generate(ctx, input, agent.WithComposeOptions(opts...))
+		}
+		h.stream = func(ctx context.Context, input []*schema.Message, opts ...compose.Option) (*schema.StreamReader[*schema.Message], error) {
+			return a.Stream(ctx, input, agent.WithComposeOptions(opts...))
+		}
+	}
+
+	return h, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *ChatCompletionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", fmt.Sprintf("method %s is not allowed", r.Method))
+		return
+	}
+
+	req := &chatCompletionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("decode request failed: %v", err))
+		return
+	}
+	input, err := toSchemaMessages(req.Messages)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	var opts []compose.Option
+	if h.callOptions != nil {
+		opts = h.callOptions(r)
+	}
+
+	model := req.Model
+	if model == "" {
+		model = h.model
+	}
+	resp := &chatCompletionResponseBase{
+		ID:      "chatcmpl-" + uuid.NewString(),
+		Created: time.Now().Unix(),
+		Model:   model,
+	}
+
+	if req.Stream {
+		h.serveStream(w, r, input, opts, resp)
+		return
+	}
+
+	msg, err := h.generate(r.Context(), input, opts...)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	resp.Object = "chat.completion"
+	completion := &chatCompletion{
+		chatCompletionResponseBase: *resp,
+		Choices: []*chatCompletionChoice{{
+			Message: &chatCompletionResponseMessage{
+				Role:      string(schema.Assistant),
+				Content:   msg.Content,
+				ToolCalls: toOpenAIToolCalls(msg.ToolCalls),
+			},
+			FinishReason: finishReason(msg.ResponseMeta, len(msg.ToolCalls) > 0),
+		}},
+	}
+	if msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
+		u := msg.ResponseMeta.Usage
+		completion.Usage = &chatCompletionUsage{
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			TotalTokens:      u.TotalTokens,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(completion)
+}
+
+func (h *ChatCompletionsHandler) serveStream(w http.ResponseWriter, r *http.Request, input []*schema.Message,
+	opts []compose.Option, resp *chatCompletionResponseBase) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "streaming is not supported by the response writer")
+		return
+	}
+
+	// cancelled when the client disconnects, or when writing to the client fails
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	sr, err := h.stream(ctx, input, opts...)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	defer sr.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	resp.Object = "chat.completion.chunk"
+	var (
+		first        = true
+		hasToolCalls bool
+		meta         *schema.ResponseMeta
+	)
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = writeSSEData(w, flusher, &openAIErrorResponse{Error: &openAIError{Message: err.Error(), Type: "server_error"}})
+			return
+		}
+
+		delta := &chatCompletionResponseMessage{
+			Content:   chunk.Content,
+			ToolCalls: toOpenAIToolCalls(chunk.ToolCalls),
+		}
+		if first {
+			delta.Role = string(schema.Assistant)
+			first = false
+		}
+		hasToolCalls = hasToolCalls || len(chunk.ToolCalls) > 0
+		if chunk.ResponseMeta != nil && chunk.ResponseMeta.FinishReason != "" {
+			meta = chunk.ResponseMeta
+		}
+
+		if err = writeSSEData(w, flusher, &chatCompletionChunk{
+			chatCompletionResponseBase: *resp,
+			Choices:                    []*chatCompletionChunkChoice{{Delta: delta}},
+		}); err != nil {
+			// the client is gone, stop the graph
+			return
+		}
+	}
+
+	reason := finishReason(meta, hasToolCalls)
+	if err = writeSSEData(w, flusher, &chatCompletionChunk{
+		chatCompletionResponseBase: *resp,
+		Choices:                    []*chatCompletionChunkChoice{{Delta: &chatCompletionResponseMessage{}, FinishReason: &reason}},
+	}); err != nil {
+		return
+	}
+	if _, err = io.WriteString(w, "data: [DONE]\n\n"); err == nil {
+		flusher.Flush()
+	}
+}
+
+type chatCompletionRequest struct {
+	Model    string                   `json:"model"`
+	Messages []*chatCompletionMessage `json:"messages"`
+	Stream   bool                     `json:"stream"`
+}
+
+type chatCompletionMessage struct {
+	Role       string                    `json:"role"`
+	Content    json.RawMessage           `json:"content"`
+	Name       string                    `json:"name"`
+	ToolCalls  []*chatCompletionToolCall `json:"tool_calls"`
+	ToolCallID string                    `json:"tool_call_id"`
+}
+
+type chatCompletionContentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	ImageURL *struct {
+		URL    string `json:"url"`
+		Detail string `json:"detail"`
+	} `json:"image_url"`
+}
+
+type chatCompletionToolCall struct {
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatCompletionResponseBase struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+}
+
+type chatCompletion struct {
+	chatCompletionResponseBase
+	Choices []*chatCompletionChoice `json:"choices"`
+	Usage   *chatCompletionUsage    `json:"usage,omitempty"`
+}
+
+type chatCompletionChoice struct {
+	Index        int                            `json:"index"`
+	Message      *chatCompletionResponseMessage `json:"message"`
+	FinishReason string                         `json:"finish_reason"`
+}
+
+type chatCompletionChunk struct {
+	chatCompletionResponseBase
+	Choices []*chatCompletionChunkChoice `json:"choices"`
+}
+
+type chatCompletionChunkChoice struct {
+	Index        int                            `json:"index"`
+	Delta        *chatCompletionResponseMessage `json:"delta"`
+	FinishReason *string                        `json:"finish_reason"`
+}
+
+type chatCompletionResponseMessage struct {
+	Role      string                    `json:"role,omitempty"`
+	Content   string                    `json:"content"`
+	ToolCalls []*chatCompletionToolCall `json:"tool_calls,omitempty"`
+}
+
+type chatCompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type openAIErrorResponse struct {
+	Error *openAIError `json:"error"`
+}
+
+type openAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+func toSchemaMessages(msgs []*chatCompletionMessage) ([]*schema.Message, error) {
+	if len(msgs) == 0 {
+		return nil, errors.New("messages of the request are required")
+	}
+
+	ret := make([]*schema.Message, 0, len(msgs))
+	for i, m := range msgs {
+		content, parts, err := decodeContent(m.Content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content of messages[%d]: %w", i, err)
+		}
+
+		var msg *schema.Message
+		switch m.Role {
+		case "system", "developer":
+			msg = schema.SystemMessage(content)
+		case "user":
+			msg = schema.UserMessage(content)
+			msg.UserInputMultiContent = parts
+		case "assistant":
+			toolCalls := make([]schema.ToolCall, 0, len(m.ToolCalls))
+			for _, tc := range m.ToolCalls {
+				toolCalls = append(toolCalls, schema.ToolCall{
+					ID:       tc.ID,
+					Type:     tc.Type,
+					Function: schema.FunctionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+				})
+			}
+			msg = schema.AssistantMessage(content, toolCalls)
+		case "tool":
+			msg = schema.ToolMessage(content, m.ToolCallID)
+		default:
+			return nil, fmt.Errorf("unsupported role %q of messages[%d]", m.Role, i)
+		}
+		if len(parts) > 0 && m.Role != "user" {
+			return nil, fmt.Errorf("only user message supports image content, got role %q of messages[%d]", m.Role, i)
+		}
+		msg.Name = m.Name
+		ret = append(ret, msg)
+	}
+	return ret, nil
+}
+
+// decodeContent decodes the content of a message, which is either a string or an array of content parts.
+// the parts are returned only if there are non-text parts, otherwise the texts are joined into the content.
+func decodeContent(raw json.RawMessage) (string, []schema.MessageInputPart, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil, nil
+	}
+	var content string
+	if err := json.Unmarshal(raw, &content); err == nil {
+		return content, nil, nil
+	}
+
+	var cps []*chatCompletionContentPart
+	if err := json.Unmarshal(raw, &cps); err != nil {
+		return "", nil, errors.New("content should be a string or an array of content parts")
+	}
+
+	var (
+		texts    []string
+		parts    []schema.MessageInputPart
+		hasImage bool
+	)
+	for _, p := range cps {
+		switch p.Type {
+		case "text":
+			texts = append(texts, p.Text)
+			parts = append(parts, schema.MessageInputPart{Type: schema.ChatMessagePartTypeText, Text: p.Text})
+		case "image_url":
+			if p.ImageURL == nil {
+				return "", nil, errors.New("image_url of the image part is required")
+			}
+			url := p.ImageURL.URL
+			parts = append(parts, schema.MessageInputPart{
+				Type: schema.ChatMessagePartTypeImageURL,
+				Image: &schema.MessageInputImage{
+					MessagePartCommon: schema.MessagePartCommon{URL: &url},
+					Detail:            schema.ImageURLDetail(p.ImageURL.Detail),
+				},
+			})
+			hasImage = true
+		default:
+			return "", nil, fmt.Errorf("unsupported content part type %q", p.Type)
+		}
+	}
+	if hasImage {
+		return "", parts, nil
+	}
+	return strings.Join(texts, ""), nil, nil
+}
+
+func toOpenAIToolCalls(toolCalls []schema.ToolCall) []*chatCompletionToolCall {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+	ret := make([]*chatCompletionToolCall, len(toolCalls))
+	for i, tc := range toolCalls {
+		index := i
+		if tc.Index != nil {
+			index = *tc.Index
+		}
+		c := &chatCompletionToolCall{
+			Index: &index,
+			ID:    tc.ID,
+			Type:  tc.Type,
+		}
+		if c.ID != "" && c.Type == "" {
+			c.Type = "function"
+		}
+		c.Function.Name = tc.Function.Name
+		c.Function.Arguments = tc.Function.Arguments
+		ret[i] = c
+	}
+	return ret
+}
+
+func finishReason(meta *schema.ResponseMeta, hasToolCalls bool) string {
+	switch {
+	case meta != nil && meta.FinishReason != "":
+		return meta.FinishReason
+	case hasToolCalls:
+		return "tool_calls"
+	default:
+		return "stop"
+	}
+}
+
+// writeSSEData writes v as the data of an unnamed event and flushes it, as OpenAI streams chat completion chunks.
+func writeSSEData(w io.Writer, flusher http.Flusher, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, typ, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&openAIErrorResponse{Error: &openAIError{Message: msg, Type: typ}})
+}
@@ -0,0 +1,233 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+)
+
+var _ ChatAgent = (*react.Agent)(nil)
+
+type fakeChatAgent struct {
+	input  []*schema.Message
+	answer []*schema.Message
+}
+
+func (a *fakeChatAgent) Generate(_ context.Context, input []*schema.Message, _ ...agent.AgentOption) (*schema.Message, error) {
+	a.input = input
+	return schema.ConcatMessages(a.answer)
+}
+
+func (a *fakeChatAgent) Stream(_ context.Context, input []*schema.Message, _ ...agent.AgentOption) (*schema.StreamReader[*schema.Message], error) {
+	a.input = input
+	return schema.StreamReaderFromArray(a.answer), nil
+}
+
+func newOpenAIClient(t *testing.T, h http.Handler) openai.Client {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return openai.NewClient(option.WithBaseURL(srv.URL+"/v1"), option.WithAPIKey("test"), option.WithMaxRetries(0))
+}
+
+func TestChatCompletionsHandler(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewChatCompletionsHandler(ctx, &ChatCompletionsHandlerConfig{})
+	assert.ErrorContains(t, err, "runnable or agent of chat completions handler is required")
+
+	t.Run("runnable", func(t *testing.T) {
+		var input []*schema.Message
+		g := compose.NewGraph[[]*schema.Message, *schema.Message]()
+		assert.NoError(t, g.AddLambdaNode("echo", compose.InvokableLambda(func(ctx context.Context, in []*schema.Message) (*schema.Message, error) {
+			input = in
+			return &schema.Message{
+				Role:         schema.Assistant,
+				Content:      "it's sunny in " + in[len(in)-1].Content,
+				ResponseMeta: &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7}},
+			}, nil
+		})))
+		assert.NoError(t, g.AddEdge(compose.START, "echo"))
+		assert.NoError(t, g.AddEdge("echo", compose.END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		h, err := NewChatCompletionsHandler(ctx, &ChatCompletionsHandlerConfig{Runnable: r})
+		assert.NoError(t, err)
+		client := newOpenAIClient(t, h)
+
+		assistant := openai.AssistantMessage("")
+		assistant.OfAssistant.ToolCalls = []openai.ChatCompletionMessageToolCallParam{{
+			ID:       "call_1",
+			Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: "get_city", Arguments: `{}`},
+		}}
+		resp, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Model: "my-agent",
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage("you are a weather assistant"),
+				openai.UserMessage("how is the weather?"),
+				assistant,
+				openai.ToolMessage("Beijing", "call_1"),
+			},
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []*schema.Message{
+			schema.SystemMessage("you are a weather assistant"),
+			schema.UserMessage("how is the weather?"),
+			schema.AssistantMessage("", []schema.ToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: schema.FunctionCall{Name: "get_city", Arguments: `{}`},
+			}}),
+			schema.ToolMessage("Beijing", "call_1"),
+		}, input)
+
+		assert.Equal(t, "my-agent", resp.Model)
+		assert.True(t, strings.HasPrefix(resp.ID, "chatcmpl-"))
+		assert.Len(t, resp.Choices, 1)
+		assert.Equal(t, "it's sunny in Beijing", resp.Choices[0].Message.Content)
+		assert.Equal(t, "stop", resp.Choices[0].FinishReason)
+		assert.Equal(t, int64(7), resp.Usage.TotalTokens)
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		a := &fakeChatAgent{answer: []*schema.Message{schema.AssistantMessage("", []schema.ToolCall{{
+			ID:       "call_weather",
+			Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"Beijing"}`},
+		}})}}
+		h, err := NewChatCompletionsHandler(ctx, &ChatCompletionsHandlerConfig{Agent: a, Model: "default-agent"})
+		assert.NoError(t, err)
+		client := newOpenAIClient(t, h)
+
+		resp, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("how is the weather in Beijing?")},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "default-agent", resp.Model)
+		assert.Equal(t, "tool_calls", resp.Choices[0].FinishReason)
+		assert.Len(t, resp.Choices[0].Message.ToolCalls, 1)
+		tc := resp.Choices[0].Message.ToolCalls[0]
+		assert.Equal(t, "call_weather", tc.ID)
+		assert.Equal(t, "function", string(tc.Type))
+		assert.Equal(t, "get_weather", tc.Function.Name)
+		assert.Equal(t, `{"city":"Beijing"}`, tc.Function.Arguments)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		a := &fakeChatAgent{answer: []*schema.Message{
+			schema.AssistantMessage("it's ", nil),
+			schema.AssistantMessage("sunny", nil),
+		}}
+		h, err := NewChatCompletionsHandler(ctx, &ChatCompletionsHandlerConfig{Agent: a})
+		assert.NoError(t, err)
+		client := newOpenAIClient(t, h)
+
+		stream := client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+			Model:    "my-agent",
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("how is the weather?")},
+		})
+		acc := openai.ChatCompletionAccumulator{}
+		var deltas []string
+		for stream.Next() {
+			chunk := stream.Current()
+			acc.AddChunk(chunk)
+			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+				deltas = append(deltas, chunk.Choices[0].Delta.Content)
+			}
+		}
+		assert.NoError(t, stream.Err())
+		assert.NoError(t, stream.Close())
+
+		assert.Equal(t, []*schema.Message{schema.UserMessage("how is the weather?")}, a.input)
+		assert.Equal(t, []string{"it's ", "sunny"}, deltas)
+		assert.Equal(t, "it's sunny", acc.Choices[0].Message.Content)
+		assert.Equal(t, "stop", acc.Choices[0].FinishReason)
+		assert.Equal(t, "my-agent", acc.Model)
+	})
+
+	t.Run("stream tool calls", func(t *testing.T) {
+		a := &fakeChatAgent{answer: []*schema.Message{
+			schema.AssistantMessage("", []schema.ToolCall{{
+				ID:       "call_weather",
+				Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":`},
+			}}),
+			schema.AssistantMessage("", []schema.ToolCall{{
+				Function: schema.FunctionCall{Arguments: `"Beijing"}`},
+			}}),
+		}}
+		h, err := NewChatCompletionsHandler(ctx, &ChatCompletionsHandlerConfig{Agent: a})
+		assert.NoError(t, err)
+		client := newOpenAIClient(t, h)
+
+		stream := client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("how is the weather in Beijing?")},
+		})
+		acc := openai.ChatCompletionAccumulator{}
+		for stream.Next() {
+			acc.AddChunk(stream.Current())
+		}
+		assert.NoError(t, stream.Err())
+
+		assert.Equal(t, "tool_calls", acc.Choices[0].FinishReason)
+		assert.Len(t, acc.Choices[0].Message.ToolCalls, 1)
+		assert.Equal(t, "call_weather", acc.Choices[0].Message.ToolCalls[0].ID)
+		assert.Equal(t, "get_weather", acc.Choices[0].Message.ToolCalls[0].Function.Name)
+		assert.Equal(t, `{"city":"Beijing"}`, acc.Choices[0].Message.ToolCalls[0].Function.Arguments)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		g := compose.NewGraph[[]*schema.Message, *schema.Message]()
+		assert.NoError(t, g.AddLambdaNode("fail", compose.InvokableLambda(func(ctx context.Context, in []*schema.Message) (*schema.Message, error) {
+			return nil, errors.New("model is down")
+		})))
+		assert.NoError(t, g.AddEdge(compose.START, "fail"))
+		assert.NoError(t, g.AddEdge("fail", compose.END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+		h, err := NewChatCompletionsHandler(ctx, &ChatCompletionsHandlerConfig{Runnable: r})
+		assert.NoError(t, err)
+		client := newOpenAIClient(t, h)
+
+		var apiErr *openai.Error
+
+		_, err = client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{})
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Contains(t, apiErr.Message, "messages of the request are required")
+
+		_, err = client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+		})
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.Contains(t, apiErr.Message, "model is down")
+	})
+}
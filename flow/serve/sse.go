@@ -0,0 +1,185 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package serve provides helpers to expose compiled graphs and agents over HTTP.
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+const (
+	// SSEEventMessage is the event name of each chunk streamed by the graph.
+	SSEEventMessage = "message"
+	// SSEEventDone is the event name sent after the last chunk when the graph completes.
+	SSEEventDone = "done"
+	// SSEEventError is the event name sent when the graph fails, with the error message as data.
+	SSEEventError = "error"
+)
+
+// SSEHandlerConfig is the config for SSEHandler.
+type SSEHandlerConfig[I, O any] struct {
+	// Runnable is the compiled graph to serve, its Stream is called for each request.
+	Runnable compose.Runnable[I, O]
+	// DecodeInput decodes the graph input from the request.
+	// Optional. Default decodes the request body as JSON.
+	DecodeInput func(r *http.Request) (I, error)
+	// EncodeChunk encodes each chunk streamed by the graph into the data of an event.
+	// Optional. Default encodes the chunk as JSON.
+	EncodeChunk func(chunk O) (string, error)
+	// CallOptions returns the call options of the graph for the request, e.g. callbacks for tracing.
+	// Optional.
+	CallOptions func(r *http.Request) []compose.Option
+}
+
+// SSEHandler is a http.Handler that streams the output of a compiled graph as server-sent events.
+// Each chunk is written as a "message" event and flushed immediately, followed by a terminal "done" event
+// when the graph completes, or an "error" event when it fails.
+// The graph runs with the context of the request, so it's cancelled when the client disconnects.
+// e.g.
+//
+//	r, _ := graph.Compile(ctx)
+//	h, _ := serve.NewSSEHandler(ctx, &serve.SSEHandlerConfig[[]*schema.Message, *schema.Message]{Runnable: r})
+//	http.Handle("/chat", h)
+type SSEHandler[I, O any] struct {
+	runnable    compose.Runnable[I, O]
+	decodeInput func(r *http.Request) (I, error)
+	encodeChunk func(chunk O) (string, error)
+	callOptions func(r *http.Request) []compose.Option
+}
+
+// NewSSEHandler creates a new SSEHandler.
+func NewSSEHandler[I, O any](_ context.Context, conf *SSEHandlerConfig[I, O]) (*SSEHandler[I, O], error) {
+	if conf == nil || conf.Runnable == nil {
+		return nil, errors.New("runnable of sse handler is required")
+	}
+
+	h := &SSEHandler[I, O]{
+		runnable:    conf.Runnable,
+		decodeInput: conf.DecodeInput,
+		encodeChunk: conf.EncodeChunk,
+		callOptions: conf.CallOptions,
+	}
+	if h.decodeInput == nil {
+		h.decodeInput = decodeJSONInput[I]
+	}
+	if h.encodeChunk == nil {
+		h.encodeChunk = encodeJSONChunk[O]
+	}
+
+	return h, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *SSEHandler[I, O]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported by the response writer", http.StatusInternalServerError)
+		return
+	}
+
+	input, err := h.decodeInput(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decode input failed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// cancelled when the client disconnects, or when writing to the client fails
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var opts []compose.Option
+	if h.callOptions != nil {
+		opts = h.callOptions(r)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	sr, err := h.runnable.Stream(ctx, input, opts...)
+	if err != nil {
+		_ = writeSSEEvent(w, flusher, SSEEventError, err.Error())
+		return
+	}
+	defer sr.Close()
+
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			_ = writeSSEEvent(w, flusher, SSEEventDone, "")
+			return
+		}
+		if err != nil {
+			_ = writeSSEEvent(w, flusher, SSEEventError, err.Error())
+			return
+		}
+
+		data, err := h.encodeChunk(chunk)
+		if err != nil {
+			_ = writeSSEEvent(w, flusher, SSEEventError, fmt.Sprintf("encode chunk failed: %v", err))
+			return
+		}
+		if err = writeSSEEvent(w, flusher, SSEEventMessage, data); err != nil {
+			// the client is gone, stop the graph
+			return
+		}
+	}
+}
+
+// writeSSEEvent writes an event and flushes it, multi-line data is split into multiple data fields.
+func writeSSEEvent(w io.Writer, flusher http.Flusher, event, data string) error {
+	var sb strings.Builder
+	sb.WriteString("event: ")
+	sb.WriteString(event)
+	sb.WriteString("\n")
+	for _, line := range strings.Split(data, "\n") {
+		sb.WriteString("data: ")
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+func decodeJSONInput[I any](r *http.Request) (I, error) {
+	var input I
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func encodeJSONChunk[O any](chunk O) (string, error) {
+	b, err := json.Marshal(chunk)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serve
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+func newTokenRunnable(t *testing.T, stream func(ctx context.Context, input string) (*schema.StreamReader[string], error)) compose.Runnable[string, string] {
+	g := compose.NewGraph[string, string]()
+	assert.NoError(t, g.AddLambdaNode("tokens", compose.StreamableLambda(stream)))
+	assert.NoError(t, g.AddEdge(compose.START, "tokens"))
+	assert.NoError(t, g.AddEdge("tokens", compose.END))
+	r, err := g.Compile(context.Background())
+	assert.NoError(t, err)
+	return r
+}
+
+func post(t *testing.T, url, body string) string {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestSSEHandler(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewSSEHandler[string, string](ctx, &SSEHandlerConfig[string, string]{})
+	assert.ErrorContains(t, err, "runnable of sse handler is required")
+
+	t.Run("stream chunks", func(t *testing.T) {
+		r := newTokenRunnable(t, func(ctx context.Context, input string) (*schema.StreamReader[string], error) {
+			return schema.StreamReaderFromArray([]string{"hello", " " + input}), nil
+		})
+		h, err := NewSSEHandler(ctx, &SSEHandlerConfig[string, string]{Runnable: r})
+		assert.NoError(t, err)
+
+		srv := httptest.NewServer(h)
+		defer srv.Close()
+
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`"eino"`))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		b, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "event: message\ndata: \"hello\"\n\n"+
+			"event: message\ndata: \" eino\"\n\n"+
+			"event: done\ndata: \n\n", string(b))
+	})
+
+	t.Run("multi-line data", func(t *testing.T) {
+		r := newTokenRunnable(t, func(ctx context.Context, input string) (*schema.StreamReader[string], error) {
+			return schema.StreamReaderFromArray([]string{"line1\nline2"}), nil
+		})
+		h, err := NewSSEHandler(ctx, &SSEHandlerConfig[string, string]{
+			Runnable: r,
+			DecodeInput: func(r *http.Request) (string, error) {
+				return r.URL.Query().Get("q"), nil
+			},
+			EncodeChunk: func(chunk string) (string, error) {
+				return chunk, nil
+			},
+		})
+		assert.NoError(t, err)
+
+		srv := httptest.NewServer(h)
+		defer srv.Close()
+
+		assert.Equal(t, "event: message\ndata: line1\ndata: line2\n\nevent: done\ndata: \n\n", post(t, srv.URL+"?q=x", ""))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		r := newTokenRunnable(t, func(ctx context.Context, input string) (*schema.StreamReader[string], error) {
+			sr, sw := schema.Pipe[string](2)
+			sw.Send("partial", nil)
+			sw.Send("", errors.New("model unavailable"))
+			sw.Close()
+			return sr, nil
+		})
+		h, err := NewSSEHandler(ctx, &SSEHandlerConfig[string, string]{Runnable: r})
+		assert.NoError(t, err)
+
+		srv := httptest.NewServer(h)
+		defer srv.Close()
+
+		body := post(t, srv.URL, `"eino"`)
+		assert.True(t, strings.HasPrefix(body, "event: message\ndata: \"partial\"\n\nevent: error\ndata: "), body)
+		assert.Contains(t, body, "model unavailable")
+		assert.NotContains(t, body, "event: done")
+	})
+
+	t.Run("bad input", func(t *testing.T) {
+		r := newTokenRunnable(t, func(ctx context.Context, input string) (*schema.StreamReader[string], error) {
+			return schema.StreamReaderFromArray([]string{input}), nil
+		})
+		h, err := NewSSEHandler(ctx, &SSEHandlerConfig[string, string]{Runnable: r})
+		assert.NoError(t, err)
+
+		srv := httptest.NewServer(h)
+		defer srv.Close()
+
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{`))
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("client disconnect cancels the graph", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		r := newTokenRunnable(t, func(ctx context.Context, input string) (*schema.StreamReader[string], error) {
+			sr, sw := schema.Pipe[string](0)
+			go func() {
+				defer sw.Close()
+				for {
+					select {
+					case <-ctx.Done():
+						close(cancelled)
+						return
+					case <-time.After(10 * time.Millisecond):
+						if sw.Send("token", nil) {
+							close(cancelled)
+							return
+						}
+					}
+				}
+			}()
+			return sr, nil
+		})
+		h, err := NewSSEHandler(ctx, &SSEHandlerConfig[string, string]{Runnable: r})
+		assert.NoError(t, err)
+
+		srv := httptest.NewServer(h)
+		defer srv.Close()
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, srv.URL, strings.NewReader(`"eino"`))
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		assert.NoError(t, err)
+		assert.Equal(t, "event: message\n", line)
+
+		cancel()
+		_ = resp.Body.Close()
+
+		select {
+		case <-cancelled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("graph is not cancelled after the client disconnected")
+		}
+	})
+}
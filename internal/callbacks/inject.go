@@ -69,6 +69,11 @@ func AppendHandlers(ctx context.Context, info *RunInfo, handlers ...Handler) con
 	return InitCallbacks(ctx, info, nh...)
 }
 
+// SuppressHandlers returns a ctx in which no handler is triggered, not even a global one.
+func SuppressHandlers(ctx context.Context) context.Context {
+	return ctxWithManager(ctx, &manager{})
+}
+
 type Handle[T any] func(context.Context, T, *RunInfo, []Handler) (context.Context, T)
 
 func On[T any](ctx context.Context, inOut T, handle Handle[T], timing CallbackTiming, start bool) (context.Context, T) {
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package modelutil provides helpers shared by the chat model adapters that talk to provider APIs over net/http.
+package modelutil
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ValidateTools checks that tools are non-nil, named, valid and have unique names.
+func ValidateTools(tools []*schema.ToolInfo) error {
+	names := make(map[string]bool, len(tools))
+	for i, t := range tools {
+		if t == nil {
+			return fmt.Errorf("tool[%d] is nil", i)
+		}
+		if t.Name == "" {
+			return fmt.Errorf("name of tool[%d] is empty", i)
+		}
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("tool[%d] is invalid: %w", i, err)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("duplicate tool name: %s", t.Name)
+		}
+		names[t.Name] = true
+	}
+	return nil
+}
+
+// FilterAllowedTools keeps the tools named in allowed, in that order. All tools are returned when allowed is empty.
+func FilterAllowedTools(tools []*schema.ToolInfo, allowed []string) ([]*schema.ToolInfo, error) {
+	if len(allowed) == 0 {
+		return tools, nil
+	}
+	byName := make(map[string]*schema.ToolInfo, len(tools))
+	for _, t := range tools {
+		byName[t.Name] = t
+	}
+	ret := make([]*schema.ToolInfo, 0, len(allowed))
+	for _, name := range allowed {
+		t, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("allowed tool %q is not bound to the model", name)
+		}
+		ret = append(ret, t)
+	}
+	return ret, nil
+}
+
+// Float32To64 converts f by its shortest decimal representation, so that 0.7 stays 0.7 rather than 0.699999988079071.
+func Float32To64(f float32) float64 {
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'f', -1, 32), 64)
+	return v
+}
+
+// TextContent returns the text of msg. For multimodal messages, the text parts are joined as a fallback.
+func TextContent(msg *schema.Message) string {
+	if len(msg.UserInputMultiContent) == 0 {
+		return msg.Content
+	}
+	texts := make([]string, 0, len(msg.UserInputMultiContent)+1)
+	if msg.Content != "" {
+		texts = append(texts, msg.Content)
+	}
+	for _, part := range msg.UserInputMultiContent {
+		if part.Type == schema.ChatMessagePartTypeText && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// SetHeaders sets the headers of model.WithHeaders on req, in a stable order.
+func SetHeaders(req *http.Request, headers map[string]string) {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		req.Header.Set(k, headers[k])
+	}
+}
+
+// ClassifyHTTPStatusError wraps err with the model error matching statusCode,
+// e.g. 529 returned by an overloaded Anthropic API is treated as model.ErrModelUnavailable.
+func ClassifyHTTPStatusError(statusCode int, err error) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", model.ErrRateLimited, err)
+	case statusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: %w", model.ErrModelUnavailable, err)
+	case statusCode >= http.StatusBadRequest:
+		return fmt.Errorf("%w: %w", model.ErrBadRequest, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modelutil
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestFilterAllowedTools(t *testing.T) {
+	tools := []*schema.ToolInfo{{Name: "a"}, {Name: "b"}}
+
+	ret, err := FilterAllowedTools(tools, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, tools, ret)
+
+	ret, err = FilterAllowedTools(tools, []string{"b"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*schema.ToolInfo{{Name: "b"}}, ret)
+
+	_, err = FilterAllowedTools(tools, []string{"c"})
+	assert.ErrorContains(t, err, `allowed tool "c" is not bound to the model`)
+}
+
+func TestClassifyHTTPStatusError(t *testing.T) {
+	cause := errors.New("cause")
+	for status, expected := range map[int]error{
+		http.StatusTooManyRequests:     model.ErrRateLimited,
+		529:                            model.ErrModelUnavailable,
+		http.StatusInternalServerError: model.ErrModelUnavailable,
+		http.StatusNotFound:            model.ErrBadRequest,
+	} {
+		err := ClassifyHTTPStatusError(status, cause)
+		assert.ErrorIs(t, err, expected, "status %d", status)
+		assert.ErrorIs(t, err, cause, "status %d", status)
+	}
+	assert.Equal(t, cause, ClassifyHTTPStatusError(http.StatusFound, cause))
+}
+
+func TestFloat32To64(t *testing.T) {
+	assert.Equal(t, 0.7, Float32To64(0.7))
+}
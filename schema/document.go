@@ -23,6 +23,8 @@ const (
 	docMetaDataKeyDSL          = "_dsl"
 	docMetaDataKeyDenseVector  = "_dense_vector"
 	docMetaDataKeySparseVector = "_sparse_vector"
+	// docMetaDataKeySource is the same key as parser.MetaKeySource, under which parsers store the URI of the source
+	docMetaDataKeySource = "_source"
 )
 
 // Document is a piece of text with metadata.
@@ -35,6 +37,49 @@ type Document struct {
 	MetaData map[string]any `json:"meta_data"`
 }
 
+// NewDocument creates a document with the id and the content.
+// eg:
+//
+//	doc := schema.NewDocument("doc_1", "eino is a framework for LLM applications").WithSourceName("README.md")
+func NewDocument(id, content string) *Document {
+	return &Document{ID: id, Content: content, MetaData: make(map[string]any)}
+}
+
+// GetMetaData returns the metadata of the document under the key, if it exists and is of type T.
+// eg:
+//
+//	page, ok := schema.GetMetaData[int](doc, "page")
+func GetMetaData[T any](d *Document, key string) (T, bool) {
+	var t T
+	if d == nil || d.MetaData == nil {
+		return t, false
+	}
+	t, ok := d.MetaData[key].(T)
+	return t, ok
+}
+
+// WithMetaData sets the metadata of the document under the key.
+// can use GetMetaData() to get the typed metadata.
+func (d *Document) WithMetaData(key string, value any) *Document {
+	if d.MetaData == nil {
+		d.MetaData = make(map[string]any)
+	}
+
+	d.MetaData[key] = value
+
+	return d
+}
+
+// ToMessage converts the document into a message of the role, to inject the document into the context of the model,
+// the source name is put before the content if any.
+func (d *Document) ToMessage(role RoleType) *Message {
+	content := d.Content
+	if source := d.SourceName(); source != "" {
+		content = "source: " + source + "\n" + content
+	}
+	return &Message{Role: role, Content: content}
+}
+
 // String returns the content of the document.
 func (d *Document) String() string {
 	return d.Content
@@ -201,3 +246,30 @@ func (d *Document) SparseVector() map[int]float64 {
 
 	return nil
 }
+
+// WithSourceName sets the source name of the document, e.g. the URI or the file name it's loaded from.
+// can use doc.SourceName() to get the source name.
+func (d *Document) WithSourceName(name string) *Document {
+	if d.MetaData == nil {
+		d.MetaData = make(map[string]any)
+	}
+
+	d.MetaData[docMetaDataKeySource] = name
+
+	return d
+}
+
+// SourceName returns the source name of the document, which is set by the parsers as the URI of the source by default.
+// can use doc.WithSourceName() to set the source name.
+func (d *Document) SourceName() string {
+	if d.MetaData == nil {
+		return ""
+	}
+
+	name, ok := d.MetaData[docMetaDataKeySource].(string)
+	if ok {
+		return name
+	}
+
+	return ""
+}
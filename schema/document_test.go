@@ -51,3 +51,27 @@ func TestDocument(t *testing.T) {
 		convey.So(d.DenseVector(), convey.ShouldEqual, vector)
 	})
 }
+
+func TestDocumentHelpers(t *testing.T) {
+	convey.Convey("test document helpers", t, func() {
+		d := NewDocument("asd", "qwe").
+			WithSourceName("docs/readme.md").
+			WithMetaData("page", 3)
+
+		convey.So(d.SourceName(), convey.ShouldEqual, "docs/readme.md")
+
+		page, ok := GetMetaData[int](d, "page")
+		convey.So(ok, convey.ShouldBeTrue)
+		convey.So(page, convey.ShouldEqual, 3)
+		_, ok = GetMetaData[string](d, "page")
+		convey.So(ok, convey.ShouldBeFalse)
+		_, ok = GetMetaData[int](nil, "page")
+		convey.So(ok, convey.ShouldBeFalse)
+
+		msg := d.ToMessage(System)
+		convey.So(msg.Role, convey.ShouldEqual, System)
+		convey.So(msg.Content, convey.ShouldEqual, "source: docs/readme.md\nqwe")
+
+		convey.So((&Document{Content: "qwe"}).ToMessage(User).Content, convey.ShouldEqual, "qwe")
+	})
+}
@@ -465,6 +465,8 @@ type Message struct {
 	// AssistantGenMultiContent is for receiving multimodal output from the model.
 	AssistantGenMultiContent []MessageOutputPart `json:"assistant_output_multi_content,omitempty"`
 
+	// Name is the name of the participant who produces the message, e.g. the user or the agent,
+	// which differentiates participants of the same role in multi-user or multi-agent conversations.
 	Name string `json:"name,omitempty"`
 
 	// only for AssistantMessage
@@ -791,6 +793,14 @@ func formatUserInputMultiContent(userInputMultiContent []MessageInputPart, vs ma
 	return copiedUIMC, nil
 }
 
+// IsEmpty reports whether the message carries nothing to act on, i.e. it's nil,
+// or it has no content, multi content or tool calls, such as the empty response some models return.
+// ReasoningContent, ResponseMeta and Extra are not counted, since they're not part of the answer.
+func (m *Message) IsEmpty() bool {
+	return m == nil || (len(m.Content) == 0 && len(m.MultiContent) == 0 && len(m.UserInputMultiContent) == 0 &&
+		len(m.AssistantGenMultiContent) == 0 && len(m.ToolCalls) == 0)
+}
+
 // String returns the string representation of the message.
 // e.g.
 //
@@ -864,10 +874,54 @@ func UserMessage(content string) *Message {
 
 }
 
+// UserMessageParts represents a multimodal message with Role "user".
+// The parts are carried in UserInputMultiContent, e.g.
+//
+//	msg := schema.UserMessageParts(
+//		schema.TextInputPart("what is in this image?"),
+//		schema.ImageURLInputPart("https://example.com/cat.jpg", schema.ImageURLDetailAuto),
+//	)
+func UserMessageParts(parts ...MessageInputPart) *Message {
+	return &Message{
+		Role:                  User,
+		UserInputMultiContent: parts,
+	}
+}
+
+// TextInputPart returns a text part to be used in UserInputMultiContent.
+func TextInputPart(text string) MessageInputPart {
+	return MessageInputPart{
+		Type: ChatMessagePartTypeText,
+		Text: text,
+	}
+}
+
+// ImageURLInputPart returns an image part referencing url to be used in UserInputMultiContent.
+func ImageURLInputPart(url string, detail ImageURLDetail) MessageInputPart {
+	return MessageInputPart{
+		Type: ChatMessagePartTypeImageURL,
+		Image: &MessageInputImage{
+			MessagePartCommon: MessagePartCommon{
+				URL: &url,
+			},
+			Detail: detail,
+		},
+	}
+}
+
 type toolMessageOptions struct {
 	toolName string
+	meta     map[string]any
+	isError  bool
 }
 
+const (
+	// toolResultMetaExtraKey is the key of Message.Extra holding the metadata of the tool result.
+	toolResultMetaExtraKey = "_eino_tool_result_meta"
+	// toolErrorExtraKey is the key of Message.Extra flagging the tool message as the result of a failed tool call.
+	toolErrorExtraKey = "_eino_tool_error"
+)
+
 // ToolMessageOption defines a option for ToolMessage
 type ToolMessageOption func(*toolMessageOptions)
 
@@ -878,18 +932,84 @@ func WithToolName(name string) ToolMessageOption {
 	}
 }
 
-// ToolMessage represents a message with Role "tool".
+// WithToolResultMeta attaches the structured metadata of the tool result to the tool message, e.g. the status code or the elapsed time.
+// It's kept in Message.Extra, which is not sent to the model, see GetToolResultMeta.
+func WithToolResultMeta(meta map[string]any) ToolMessageOption {
+	return func(o *toolMessageOptions) {
+		o.meta = meta
+	}
+}
+
+// WithToolError flags the tool message as the result of a failed tool call, whose content describes the failure, see IsToolError.
+func WithToolError() ToolMessageOption {
+	return func(o *toolMessageOptions) {
+		o.isError = true
+	}
+}
+
+// ToolMessage represents a message with Role "tool", which answers the tool call with the id toolCallID,
+// i.e. ToolCall.ID of the assistant message calling the tool.
+// e.g.
+//
+//	msg := schema.ToolMessage(result, toolCall.ID,
+//		schema.WithToolName(toolCall.Function.Name),
+//		schema.WithToolResultMeta(map[string]any{"status": 200}))
 func ToolMessage(content string, toolCallID string, opts ...ToolMessageOption) *Message {
 	o := &toolMessageOptions{}
 	for _, opt := range opts {
 		opt(o)
 	}
-	return &Message{
+	msg := &Message{
 		Role:       Tool,
 		Content:    content,
 		ToolCallID: toolCallID,
 		ToolName:   o.toolName,
 	}
+	if len(o.meta) > 0 || o.isError {
+		msg.Extra = make(map[string]any)
+	}
+	if len(o.meta) > 0 {
+		msg.Extra[toolResultMetaExtraKey] = o.meta
+	}
+	if o.isError {
+		msg.Extra[toolErrorExtraKey] = true
+	}
+	return msg
+}
+
+// GetToolResultMeta returns the metadata of the tool result attached by WithToolResultMeta, or nil if there is none.
+func GetToolResultMeta(msg *Message) map[string]any {
+	if msg == nil {
+		return nil
+	}
+	meta, _ := msg.Extra[toolResultMetaExtraKey].(map[string]any)
+	return meta
+}
+
+// IsToolError reports whether the tool message is flagged as the result of a failed tool call by WithToolError.
+func IsToolError(msg *Message) bool {
+	if msg == nil {
+		return false
+	}
+	isError, _ := msg.Extra[toolErrorExtraKey].(bool)
+	return isError
+}
+
+// StripReasoning returns the messages with ReasoningContent removed, e.g. before appending the output of a reasoning model
+// like DeepSeek-R1 to the history, since feeding the reasoning back may confuse the subsequent turns.
+// The messages with reasoning content are shallow copied, the input messages are not modified.
+func StripReasoning(msgs []*Message) []*Message {
+	ret := make([]*Message, len(msgs))
+	for i, msg := range msgs {
+		if msg == nil || msg.ReasoningContent == "" {
+			ret[i] = msg
+			continue
+		}
+		cpy := *msg
+		cpy.ReasoningContent = ""
+		ret[i] = &cpy
+	}
+	return ret
 }
 
 func concatToolCalls(chunks []ToolCall) ([]ToolCall, error) {
@@ -1107,6 +1227,66 @@ func concatExtra(extraList []map[string]any) (map[string]any, error) {
 //
 // concatedMsg, err := ConcatMessages(msgs) // concatedMsg.Content will be full content of all messages
 func ConcatMessages(msgs []*Message) (*Message, error) {
+	return ConcatMessagesWithOptions(msgs)
+}
+
+// ContentConcatStrategy decides how a content delta of streamed message chunks is appended to the content concatenated so far,
+// returning the part of delta to append. It fixes up providers which repeat a leading space or send overlapping fragments.
+type ContentConcatStrategy func(acc, delta string) string
+
+// TrimDuplicateSpace drops the leading spaces of delta if the content concatenated so far already ends with a space,
+// e.g. "Hello " + " world" is concatenated to "Hello world".
+func TrimDuplicateSpace() ContentConcatStrategy {
+	return func(acc, delta string) string {
+		if strings.HasSuffix(acc, " ") {
+			return strings.TrimLeft(delta, " ")
+		}
+		return delta
+	}
+}
+
+// TrimOverlap drops the longest prefix of delta which repeats the end of the content concatenated so far,
+// e.g. "Hello wor" + "world" is concatenated to "Hello world".
+// Overlaps shorter than minOverlap bytes are kept, since short repetitions like "ll" are usually intended, minOverlap is at least 1.
+func TrimOverlap(minOverlap int) ContentConcatStrategy {
+	if minOverlap < 1 {
+		minOverlap = 1
+	}
+	return func(acc, delta string) string {
+		for k := min(len(acc), len(delta)); k >= minOverlap; k-- {
+			if acc[len(acc)-k:] == delta[:k] {
+				return delta[k:]
+			}
+		}
+		return delta
+	}
+}
+
+type concatOptions struct {
+	contentStrategies []ContentConcatStrategy
+}
+
+// ConcatOption is the option of ConcatMessagesWithOptions and ConcatMessageStream.
+type ConcatOption func(*concatOptions)
+
+// WithContentConcatStrategy sets the strategies to concatenate Content and ReasoningContent, which are applied to each delta in order.
+// Without any strategy, deltas are concatenated as they are.
+func WithContentConcatStrategy(strategies ...ContentConcatStrategy) ConcatOption {
+	return func(o *concatOptions) {
+		o.contentStrategies = append(o.contentStrategies, strategies...)
+	}
+}
+
+// ConcatMessagesWithOptions is ConcatMessages with options, e.g. to fix up the content deltas of some providers.
+// e.g.
+//
+//	msg, err := schema.ConcatMessagesWithOptions(msgs, schema.WithContentConcatStrategy(schema.TrimDuplicateSpace()))
+func ConcatMessagesWithOptions(msgs []*Message, opts ...ConcatOption) (*Message, error) {
+	o := &concatOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	var (
 		contents                      []string
 		contentLen                    int
@@ -1228,28 +1408,10 @@ func ConcatMessages(msgs []*Message) (*Message, error) {
 	}
 
 	if len(contents) > 0 {
-		var sb strings.Builder
-		sb.Grow(contentLen)
-		for _, content := range contents {
-			_, err := sb.WriteString(content)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		ret.Content = sb.String()
+		ret.Content = concatContents(contents, contentLen, o.contentStrategies)
 	}
 	if len(reasoningContents) > 0 {
-		var sb strings.Builder
-		sb.Grow(reasoningContentLen)
-		for _, rc := range reasoningContents {
-			_, err := sb.WriteString(rc)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		ret.ReasoningContent = sb.String()
+		ret.ReasoningContent = concatContents(reasoningContents, reasoningContentLen, o.contentStrategies)
 	}
 
 	if len(toolCalls) > 0 {
@@ -1287,9 +1449,21 @@ func ConcatMessages(msgs []*Message) (*Message, error) {
 	return &ret, nil
 }
 
+func concatContents(contents []string, totalLen int, strategies []ContentConcatStrategy) string {
+	var sb strings.Builder
+	sb.Grow(totalLen)
+	for _, content := range contents {
+		for _, strategy := range strategies {
+			content = strategy(sb.String(), content)
+		}
+		sb.WriteString(content)
+	}
+	return sb.String()
+}
+
 // ConcatMessageStream drains a stream of messages and returns a single
 // concatenated message representing the merged content.
-func ConcatMessageStream(s *StreamReader[*Message]) (*Message, error) {
+func ConcatMessageStream(s *StreamReader[*Message], opts ...ConcatOption) (*Message, error) {
 	defer s.Close()
 
 	var msgs []*Message
@@ -1306,7 +1480,7 @@ func ConcatMessageStream(s *StreamReader[*Message]) (*Message, error) {
 		msgs = append(msgs, msg)
 	}
 
-	return ConcatMessages(msgs)
+	return ConcatMessagesWithOptions(msgs, opts...)
 }
 
 // custom jinja env
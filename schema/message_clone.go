@@ -0,0 +1,158 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"github.com/cloudwego/eino/internal/gmap"
+)
+
+// Clone returns a deep copy of the message, so that the copy can be mutated without affecting the original one,
+// e.g. when the same messages are fed into multiple parallel branches.
+// Slices, pointers and maps of the message are copied recursively,
+// while the values of the Extra maps are copied by assignment, as their types are unknown.
+func (m *Message) Clone() *Message {
+	if m == nil {
+		return nil
+	}
+
+	ret := *m
+	ret.MultiContent = cloneSlice(m.MultiContent, ChatMessagePart.clone)
+	ret.UserInputMultiContent = cloneSlice(m.UserInputMultiContent, MessageInputPart.clone)
+	ret.AssistantGenMultiContent = cloneSlice(m.AssistantGenMultiContent, MessageOutputPart.clone)
+	ret.ToolCalls = cloneSlice(m.ToolCalls, ToolCall.clone)
+	ret.ResponseMeta = m.ResponseMeta.clone()
+	ret.Extra = gmap.Clone(m.Extra)
+
+	return &ret
+}
+
+// CloneMessages returns a deep copy of the messages, see Message.Clone.
+func CloneMessages(msgs []*Message) []*Message {
+	return cloneSlice(msgs, (*Message).Clone)
+}
+
+func cloneSlice[T any](s []T, cloneFn func(T) T) []T {
+	if s == nil {
+		return nil
+	}
+	ret := make([]T, len(s))
+	for i := range s {
+		ret[i] = cloneFn(s[i])
+	}
+	return ret
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func cloneValue[T any](v T) T {
+	return v
+}
+
+func (tc ToolCall) clone() ToolCall {
+	tc.Index = clonePtr(tc.Index)
+	tc.Extra = gmap.Clone(tc.Extra)
+	return tc
+}
+
+func (rm *ResponseMeta) clone() *ResponseMeta {
+	if rm == nil {
+		return nil
+	}
+	ret := *rm
+	ret.Usage = clonePtr(rm.Usage)
+	if rm.LogProbs != nil {
+		ret.LogProbs = &LogProbs{Content: cloneSlice(rm.LogProbs.Content, LogProb.clone)}
+	}
+	return &ret
+}
+
+func (lp LogProb) clone() LogProb {
+	lp.Bytes = cloneSlice(lp.Bytes, cloneValue[int64])
+	lp.TopLogProbs = cloneSlice(lp.TopLogProbs, func(tlp TopLogProb) TopLogProb {
+		tlp.Bytes = cloneSlice(tlp.Bytes, cloneValue[int64])
+		return tlp
+	})
+	return lp
+}
+
+func (c MessagePartCommon) clone() MessagePartCommon {
+	c.URL = clonePtr(c.URL)
+	c.Base64Data = clonePtr(c.Base64Data)
+	c.Extra = gmap.Clone(c.Extra)
+	return c
+}
+
+func (p MessageInputPart) clone() MessageInputPart {
+	if p.Image != nil {
+		p.Image = &MessageInputImage{MessagePartCommon: p.Image.MessagePartCommon.clone(), Detail: p.Image.Detail}
+	}
+	if p.Audio != nil {
+		p.Audio = &MessageInputAudio{MessagePartCommon: p.Audio.MessagePartCommon.clone()}
+	}
+	if p.Video != nil {
+		p.Video = &MessageInputVideo{MessagePartCommon: p.Video.MessagePartCommon.clone()}
+	}
+	if p.File != nil {
+		p.File = &MessageInputFile{MessagePartCommon: p.File.MessagePartCommon.clone(), Name: p.File.Name}
+	}
+	p.Extra = gmap.Clone(p.Extra)
+	return p
+}
+
+func (p MessageOutputPart) clone() MessageOutputPart {
+	if p.Image != nil {
+		p.Image = &MessageOutputImage{MessagePartCommon: p.Image.MessagePartCommon.clone()}
+	}
+	if p.Audio != nil {
+		p.Audio = &MessageOutputAudio{MessagePartCommon: p.Audio.MessagePartCommon.clone()}
+	}
+	if p.Video != nil {
+		p.Video = &MessageOutputVideo{MessagePartCommon: p.Video.MessagePartCommon.clone()}
+	}
+	p.Extra = gmap.Clone(p.Extra)
+	return p
+}
+
+func (p ChatMessagePart) clone() ChatMessagePart {
+	if p.ImageURL != nil {
+		u := *p.ImageURL
+		u.Extra = gmap.Clone(u.Extra)
+		p.ImageURL = &u
+	}
+	if p.AudioURL != nil {
+		u := *p.AudioURL
+		u.Extra = gmap.Clone(u.Extra)
+		p.AudioURL = &u
+	}
+	if p.VideoURL != nil {
+		u := *p.VideoURL
+		u.Extra = gmap.Clone(u.Extra)
+		p.VideoURL = &u
+	}
+	if p.FileURL != nil {
+		u := *p.FileURL
+		u.Extra = gmap.Clone(u.Extra)
+		p.FileURL = &u
+	}
+	return p
+}
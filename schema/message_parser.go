@@ -139,3 +139,16 @@ func (p *MessageJSONParser[T]) parse(data string) (parsed T, err error) {
 
 	return parsed, nil
 }
+
+// ParseJSON unmarshals the content of the message into T, e.g. the output of a model generating with a ResponseFormat.
+// It is a function instead of a method of Message, since go methods can not have type parameters.
+// eg:
+//
+//	report, err := schema.ParseJSON[WeatherReport](msg)
+func ParseJSON[T any](m *Message) (T, error) {
+	if m == nil {
+		var zero T
+		return zero, fmt.Errorf("message is nil")
+	}
+	return NewMessageJSONParser[T](nil).Parse(context.Background(), m)
+}
@@ -177,3 +177,21 @@ func TestMessageJSONParser(t *testing.T) {
 	})
 
 }
+
+func TestParseJSON(t *testing.T) {
+	parsed, err := ParseJSON[TestStructForParse](AssistantMessage(`{"id": 1, "name": "test", "xx": {"yy": 2}}`, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, parsed.ID)
+	assert.Equal(t, "test", parsed.Name)
+	assert.Equal(t, 2, parsed.XX.YY)
+
+	ids, err := ParseJSON[[]int](AssistantMessage(`[1, 2]`, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, ids)
+
+	_, err = ParseJSON[TestStructForParse](AssistantMessage(`{"id": 1,`, nil))
+	assert.NotNil(t, err)
+
+	_, err = ParseJSON[TestStructForParse](nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"errors"
+	"io"
+)
+
+// SplitMessageStream splits a stream of message chunks from a model into a stream of the content and a stream of the tool calls,
+// so that the content can be rendered as it arrives while the tool calls are being generated.
+//   - content: the chunks with the tool calls removed, skipping the chunks carrying nothing but tool call fragments.
+//     A chunk with both content and tool call fragments is kept in content with the fragments moved to toolCalls.
+//   - toolCalls: the tool calls assembled from the fragments by ToolCall.Index, in the order of index,
+//     which are sent after the source stream ends, as fragments of a tool call may arrive at any point of the stream.
+//
+// An error of the source stream is returned by both streams. Both streams must be closed or read to the end, and the source
+// stream is closed once both are. e.g.
+//
+//	content, toolCalls := schema.SplitMessageStream(sr)
+//	go func() {
+//		defer content.Close()
+//		for {
+//			chunk, err := content.Recv()
+//			if err != nil {
+//				return
+//			}
+//			render(chunk.Content)
+//		}
+//	}()
+//	defer toolCalls.Close()
+//	for {
+//		call, err := toolCalls.Recv()
+//		...
+//	}
+func SplitMessageStream(sr *StreamReader[*Message]) (content *StreamReader[*Message], toolCalls *StreamReader[ToolCall]) {
+	srs := sr.Copy(2)
+
+	content = StreamReaderWithConvert(srs[0], func(msg *Message) (*Message, error) {
+		if msg == nil || len(msg.ToolCalls) == 0 {
+			return msg, nil
+		}
+
+		cpy := *msg
+		cpy.ToolCalls = nil
+		if isEmptyMessageChunk(&cpy) {
+			return nil, ErrNoValue
+		}
+		return &cpy, nil
+	})
+
+	toolCalls, sw := Pipe[ToolCall](0)
+	go func() {
+		defer func() {
+			srs[1].Close()
+			sw.Close()
+		}()
+
+		var fragments []ToolCall
+		for {
+			msg, err := srs[1].Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				sw.Send(ToolCall{}, err)
+				return
+			}
+			if msg != nil {
+				fragments = append(fragments, msg.ToolCalls...)
+			}
+		}
+		if len(fragments) == 0 {
+			return
+		}
+
+		calls, err := concatToolCalls(fragments)
+		if err != nil {
+			sw.Send(ToolCall{}, err)
+			return
+		}
+		for _, call := range calls {
+			if closed := sw.Send(call, nil); closed {
+				return
+			}
+		}
+	}()
+
+	return content, toolCalls
+}
+
+// isEmptyMessageChunk reports whether the chunk carries nothing but the role, e.g. the remains of a tool call fragment.
+func isEmptyMessageChunk(msg *Message) bool {
+	return msg.Content == "" && msg.ReasoningContent == "" && len(msg.MultiContent) == 0 &&
+		len(msg.AssistantGenMultiContent) == 0 && msg.ResponseMeta == nil && len(msg.Extra) == 0
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMessageStream(t *testing.T) {
+	idx := func(i int) *int { return &i }
+	readAll := func(content *StreamReader[*Message], toolCalls *StreamReader[ToolCall]) ([]string, []ToolCall, error, error) {
+		var texts []string
+		var contentErr error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer content.Close()
+			for {
+				msg, err := content.Recv()
+				if err != nil {
+					if !errors.Is(err, io.EOF) {
+						contentErr = err
+					}
+					return
+				}
+				texts = append(texts, msg.Content)
+			}
+		}()
+
+		var calls []ToolCall
+		var callErr error
+		defer toolCalls.Close()
+		for {
+			call, err := toolCalls.Recv()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					callErr = err
+				}
+				break
+			}
+			calls = append(calls, call)
+		}
+		<-done
+		return texts, calls, contentErr, callErr
+	}
+
+	t.Run("content and fragmented tool calls", func(t *testing.T) {
+		sr := StreamReaderFromArray([]*Message{
+			AssistantMessage("let me ", nil),
+			AssistantMessage("check", []ToolCall{{Index: idx(0), ID: "call_1", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":`}}}),
+			AssistantMessage("", []ToolCall{{Index: idx(0), Function: FunctionCall{Arguments: `"beijing"}`}}}),
+			AssistantMessage("", []ToolCall{{Index: idx(1), ID: "call_2", Function: FunctionCall{Name: "get_time", Arguments: `{}`}}}),
+			AssistantMessage(".", nil),
+		})
+		texts, calls, contentErr, callErr := readAll(SplitMessageStream(sr))
+		assert.NoError(t, contentErr)
+		assert.NoError(t, callErr)
+		assert.Equal(t, []string{"let me ", "check", "."}, texts)
+		assert.Equal(t, []ToolCall{
+			{Index: idx(0), ID: "call_1", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"beijing"}`}},
+			{Index: idx(1), ID: "call_2", Function: FunctionCall{Name: "get_time", Arguments: `{}`}},
+		}, calls)
+	})
+
+	t.Run("content only", func(t *testing.T) {
+		texts, calls, contentErr, callErr := readAll(SplitMessageStream(StreamReaderFromArray([]*Message{
+			AssistantMessage("hello", nil),
+		})))
+		assert.NoError(t, contentErr)
+		assert.NoError(t, callErr)
+		assert.Equal(t, []string{"hello"}, texts)
+		assert.Empty(t, calls)
+	})
+
+	t.Run("source error", func(t *testing.T) {
+		sr, sw := Pipe[*Message](2)
+		sw.Send(AssistantMessage("hi", nil), nil)
+		sw.Send(nil, errors.New("broken"))
+		sw.Close()
+		texts, _, contentErr, callErr := readAll(SplitMessageStream(sr))
+		assert.Equal(t, []string{"hi"}, texts)
+		assert.EqualError(t, contentErr, "broken")
+		assert.EqualError(t, callErr, "broken")
+	})
+
+	t.Run("tool calls closed early", func(t *testing.T) {
+		content, toolCalls := SplitMessageStream(StreamReaderFromArray([]*Message{
+			AssistantMessage("", []ToolCall{{Index: idx(0), ID: "call_1", Function: FunctionCall{Name: "a"}}}),
+			AssistantMessage("", []ToolCall{{Index: idx(1), ID: "call_2", Function: FunctionCall{Name: "b"}}}),
+		}))
+		toolCalls.Close()
+		_, err := content.Recv()
+		assert.ErrorIs(t, err, io.EOF)
+		content.Close()
+	})
+}
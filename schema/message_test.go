@@ -1008,3 +1008,185 @@ func TestFormatUserInputMultiContent(t *testing.T) {
 		}
 	})
 }
+
+func TestUserMessageParts(t *testing.T) {
+	msg := UserMessageParts(
+		TextInputPart("what is in this image?"),
+		ImageURLInputPart("https://example.com/cat.jpg", ImageURLDetailHigh),
+	)
+	assert.Equal(t, User, msg.Role)
+	assert.Empty(t, msg.Content)
+	if assert.Len(t, msg.UserInputMultiContent, 2) {
+		assert.Equal(t, ChatMessagePartTypeText, msg.UserInputMultiContent[0].Type)
+		assert.Equal(t, "what is in this image?", msg.UserInputMultiContent[0].Text)
+		assert.Equal(t, ChatMessagePartTypeImageURL, msg.UserInputMultiContent[1].Type)
+		assert.Equal(t, "https://example.com/cat.jpg", *msg.UserInputMultiContent[1].Image.URL)
+		assert.Equal(t, ImageURLDetailHigh, msg.UserInputMultiContent[1].Image.Detail)
+	}
+
+	msgs, err := UserMessageParts(TextInputPart("hello {name}")).Format(context.Background(), map[string]any{"name": "eino"}, FString)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello eino", msgs[0].UserInputMultiContent[0].Text)
+}
+
+func TestMessageClone(t *testing.T) {
+	url := "https://example.com/cat.jpg"
+	orig := &Message{
+		Role:    Assistant,
+		Content: "content",
+		UserInputMultiContent: []MessageInputPart{
+			{Type: ChatMessagePartTypeImageURL, Image: &MessageInputImage{
+				MessagePartCommon: MessagePartCommon{URL: &url, Extra: map[string]any{"k": "v"}},
+				Detail:            ImageURLDetailHigh,
+			}},
+		},
+		MultiContent: []ChatMessagePart{
+			{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: url}},
+		},
+		ToolCalls: []ToolCall{
+			{Index: generic.PtrOf(0), ID: "call_1", Function: FunctionCall{Name: "search", Arguments: "{}"}, Extra: map[string]any{"k": "v"}},
+		},
+		ResponseMeta: &ResponseMeta{
+			FinishReason: "tool_calls",
+			Usage:        &TokenUsage{TotalTokens: 10},
+			LogProbs:     &LogProbs{Content: []LogProb{{Token: "a", Bytes: []int64{97}, TopLogProbs: []TopLogProb{{Token: "a"}}}}},
+		},
+		Extra: map[string]any{"k": "v"},
+	}
+
+	cpy := orig.Clone()
+	assert.Equal(t, orig, cpy)
+
+	cpy.ToolCalls[0].Function.Arguments = `{"q":"eino"}`
+	*cpy.ToolCalls[0].Index = 1
+	cpy.ToolCalls[0].Extra["k"] = "changed"
+	cpy.ToolCalls = append(cpy.ToolCalls, ToolCall{ID: "call_2"})
+	*cpy.UserInputMultiContent[0].Image.URL = "changed"
+	cpy.UserInputMultiContent[0].Image.Extra["k"] = "changed"
+	cpy.MultiContent[0].ImageURL.URL = "changed"
+	cpy.ResponseMeta.Usage.TotalTokens = 20
+	cpy.ResponseMeta.LogProbs.Content[0].Bytes[0] = 98
+	cpy.ResponseMeta.LogProbs.Content[0].TopLogProbs[0].Token = "b"
+	cpy.Extra["k"] = "changed"
+
+	assert.Equal(t, "{}", orig.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, 0, *orig.ToolCalls[0].Index)
+	assert.Equal(t, "v", orig.ToolCalls[0].Extra["k"])
+	assert.Len(t, orig.ToolCalls, 1)
+	assert.Equal(t, "https://example.com/cat.jpg", *orig.UserInputMultiContent[0].Image.URL)
+	assert.Equal(t, "v", orig.UserInputMultiContent[0].Image.Extra["k"])
+	assert.Equal(t, "https://example.com/cat.jpg", orig.MultiContent[0].ImageURL.URL)
+	assert.Equal(t, 10, orig.ResponseMeta.Usage.TotalTokens)
+	assert.Equal(t, int64(97), orig.ResponseMeta.LogProbs.Content[0].Bytes[0])
+	assert.Equal(t, "a", orig.ResponseMeta.LogProbs.Content[0].TopLogProbs[0].Token)
+	assert.Equal(t, "v", orig.Extra["k"])
+
+	var nilMsg *Message
+	assert.Nil(t, nilMsg.Clone())
+
+	msgs := []*Message{UserMessage("hi"), nil, orig}
+	cpys := CloneMessages(msgs)
+	assert.Equal(t, msgs, cpys)
+	assert.NotSame(t, msgs[0], cpys[0])
+	assert.Nil(t, cpys[1])
+	assert.Nil(t, CloneMessages(nil))
+}
+
+func TestExtractVariables(t *testing.T) {
+	t.Run("fstring", func(t *testing.T) {
+		vars := ExtractVariables(FString,
+			SystemMessage("context: {context}, {{escaped}}, {user.name}, {score:.2f}"),
+			MessagesPlaceholder("history", false),
+			MessagesPlaceholder("optional_history", true),
+			&Message{Role: User, UserInputMultiContent: []MessageInputPart{{Type: ChatMessagePartTypeText, Text: "{question}"}}})
+		assert.Equal(t, []string{"context", "history", "question", "score", "user"}, vars)
+	})
+
+	t.Run("go template", func(t *testing.T) {
+		vars := ExtractVariables(GoTemplate,
+			UserMessage("{{.question}} {{if .verbose}}{{.detail}}{{end}} {{range .items}}{{.name}}{{end}} {{printf \"%s\" .user.name}}"))
+		assert.Equal(t, []string{"detail", "items", "question", "user", "verbose"}, vars)
+	})
+
+	t.Run("jinja2", func(t *testing.T) {
+		vars := ExtractVariables(Jinja2,
+			UserMessage("{{ question }} {% if not verbose %}{{ detail|upper }}{% endif %} {% for item in items %}{{ item.name }}{{ loop.index }}{% endfor %}"))
+		assert.Equal(t, []string{"detail", "items", "question", "verbose"}, vars)
+	})
+}
+
+func TestStripReasoning(t *testing.T) {
+	withReasoning := &Message{Role: Assistant, Content: "answer", ReasoningContent: "let me think"}
+	user := UserMessage("question")
+
+	stripped := StripReasoning([]*Message{user, withReasoning, nil})
+	assert.Equal(t, []*Message{user, {Role: Assistant, Content: "answer"}, nil}, stripped)
+	assert.Same(t, user, stripped[0])
+	// the input is not modified
+	assert.Equal(t, "let me think", withReasoning.ReasoningContent)
+}
+
+func TestConcatMessagesWithOptions(t *testing.T) {
+	chunks := func(contents ...string) []*Message {
+		msgs := make([]*Message, 0, len(contents))
+		for _, c := range contents {
+			msgs = append(msgs, &Message{Role: Assistant, Content: c, ReasoningContent: c})
+		}
+		return msgs
+	}
+
+	t.Run("raw by default", func(t *testing.T) {
+		msg, err := ConcatMessagesWithOptions(chunks("Hello ", " world", "world!"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello  worldworld!", msg.Content)
+	})
+
+	t.Run("leading space duplication", func(t *testing.T) {
+		msg, err := ConcatMessagesWithOptions(chunks("The", " weather ", " is", " sunny ", "  today."),
+			WithContentConcatStrategy(TrimDuplicateSpace()))
+		assert.NoError(t, err)
+		assert.Equal(t, "The weather is sunny today.", msg.Content)
+		assert.Equal(t, "The weather is sunny today.", msg.ReasoningContent)
+	})
+
+	t.Run("overlapping fragments", func(t *testing.T) {
+		msg, err := ConcatMessagesWithOptions(chunks("Hello wor", "world, it's", "it's sunny", "ll"),
+			WithContentConcatStrategy(TrimOverlap(2)))
+		assert.NoError(t, err)
+		// "ll" is shorter than minOverlap and kept
+		assert.Equal(t, "Hello world, it's sunnyll", msg.Content)
+	})
+
+	t.Run("strategies in order", func(t *testing.T) {
+		sr := StreamReaderFromArray(chunks("Hello ", " Hello world", "world!"))
+		msg, err := ConcatMessageStream(sr, WithContentConcatStrategy(TrimDuplicateSpace(), TrimOverlap(3)))
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello world!", msg.Content)
+	})
+}
+
+func TestToolMessage(t *testing.T) {
+	msg := ToolMessage("sunny", "call_1", WithToolName("get_weather"))
+	assert.Equal(t, &Message{Role: Tool, Content: "sunny", ToolCallID: "call_1", ToolName: "get_weather"}, msg)
+	assert.Nil(t, GetToolResultMeta(msg))
+	assert.False(t, IsToolError(msg))
+
+	msg = ToolMessage("failed to get weather: timeout", "call_2",
+		WithToolName("get_weather"),
+		WithToolResultMeta(map[string]any{"status": 504, "elapsed_ms": 3000}),
+		WithToolError())
+	assert.Equal(t, Tool, msg.Role)
+	assert.Equal(t, "call_2", msg.ToolCallID)
+	assert.Equal(t, map[string]any{"status": 504, "elapsed_ms": 3000}, GetToolResultMeta(msg))
+	assert.True(t, IsToolError(msg))
+
+	// metadata is kept when the chunks are concatenated
+	concated, err := ConcatMessages([]*Message{msg, ToolMessage(" after 3s", "call_2")})
+	assert.NoError(t, err)
+	assert.Equal(t, "failed to get weather: timeout after 3s", concated.Content)
+	assert.Equal(t, 504, GetToolResultMeta(concated)["status"])
+	assert.True(t, IsToolError(concated))
+
+	assert.Nil(t, GetToolResultMeta(nil))
+	assert.False(t, IsToolError(nil))
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import "github.com/eino-contrib/jsonschema"
+
+// ResponseFormatType is the format the model must output in.
+type ResponseFormatType string
+
+const (
+	// ResponseFormatTypeText indicates that the model outputs free text, which is the default.
+	ResponseFormatTypeText ResponseFormatType = "text"
+
+	// ResponseFormatTypeJSONObject indicates that the model must output a valid JSON object, of no specific schema.
+	// Corresponds to "json_object" in OpenAI Chat Completion.
+	ResponseFormatTypeJSONObject ResponseFormatType = "json_object"
+
+	// ResponseFormatTypeJSONSchema indicates that the model must output a JSON value conforming to ResponseFormat.JSONSchema.
+	// Corresponds to "json_schema" in OpenAI Chat Completion.
+	ResponseFormatTypeJSONSchema ResponseFormatType = "json_schema"
+)
+
+// ResponseFormat specifies the format of the content generated by the model, i.e. structured output.
+// Models not supporting the format may ignore it or return an error, which depends on the ChatModel implementation.
+// Use ParseJSON to unmarshal the content of the generated message.
+type ResponseFormat struct {
+	Type ResponseFormatType
+	// JSONSchema is required when Type is ResponseFormatTypeJSONSchema.
+	JSONSchema *ResponseFormatJSONSchema
+}
+
+// ResponseFormatJSONSchema describes the JSON value the model must output.
+type ResponseFormatJSONSchema struct {
+	// Name of the schema, e.g. "weather_report".
+	Name string
+	// Desc tells the model what the output is for.
+	Desc string
+	// Schema is the JSON schema of the output, can be generated from a go struct by jsonschema.Reflect.
+	Schema *jsonschema.Schema
+	// Strict requires the model to follow the schema exactly, if supported by the model.
+	Strict bool
+}
@@ -51,6 +51,9 @@ var ErrNoValue = errors.New("no value")
 // This error should not occur during normal use of StreamReader.Recv. If it does, please check your application code.
 var ErrRecvAfterClosed = errors.New("recv after stream closed")
 
+// ErrStreamTooLong is returned by StreamReaderCollect when the stream has more chunks than the cap.
+var ErrStreamTooLong = errors.New("stream exceeds max items")
+
 // SourceEOF represents an EOF error from a specific source stream.
 // It is only returned by the method Recv() of StreamReader created
 // with MergeNamedStreamReaders when one of its source streams reaches EOF.
@@ -137,6 +140,27 @@ func (sw *StreamWriter[T]) Close() {
 	sw.stm.closeSend()
 }
 
+// CloseWithError notify the receiver that the stream sender has finished because of err.
+// The stream receiver will get err from the next StreamReader.Recv(), followed by io.EOF.
+// If err is nil, CloseWithError behaves the same as Close.
+// eg.
+//
+//	for ... {
+//		if err != nil {
+//			sw.CloseWithError(err)
+//			return
+//		}
+//		sw.Send(chunk, nil)
+//	}
+//	sw.Close()
+func (sw *StreamWriter[T]) CloseWithError(err error) {
+	if err != nil {
+		var zero T
+		sw.stm.send(zero, err)
+	}
+	sw.stm.closeSend()
+}
+
 // StreamReader the receiver of a stream.
 // created by Pipe function.
 // eg.
@@ -629,6 +653,51 @@ func StreamReaderWithConvert[T, D any](sr *StreamReader[T], convert func(T) (D,
 	return newStreamReaderWithConvert(sr, c, opts...)
 }
 
+// StreamReaderFilter returns a stream reader forwarding only the chunks of sr satisfying pred,
+// while errors and EOF of sr are propagated as is. Closing the returned reader closes sr.
+//
+// eg.
+//
+//	nonEmpty := StreamReaderFilter(msgReader, func(m *Message) bool {
+//		return m.Content != "" || len(m.ToolCalls) > 0
+//	})
+//	defer nonEmpty.Close()
+func StreamReaderFilter[T any](sr *StreamReader[T], pred func(T) bool) *StreamReader[T] {
+	return StreamReaderWithConvert(sr, func(t T) (T, error) {
+		if !pred(t) {
+			return t, ErrNoValue
+		}
+		return t, nil
+	})
+}
+
+// StreamReaderCollect reads all the chunks of sr into a slice until EOF, and closes sr on return.
+// maxItems caps the number of chunks to guard against unbounded streams, no cap if it's not positive.
+// If sr has more than maxItems chunks, the first maxItems chunks are returned along with an error wrapping ErrStreamTooLong.
+// If sr returns an error, the chunks read so far are returned along with the error.
+//
+// eg.
+//
+//	msgs, err := StreamReaderCollect(msgReader, 1000)
+func StreamReaderCollect[T any](sr *StreamReader[T], maxItems int) ([]T, error) {
+	defer sr.Close()
+
+	var items []T
+	for {
+		item, err := sr.Recv()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return items, err
+		}
+		if maxItems > 0 && len(items) == maxItems {
+			return items, fmt.Errorf("%w: %d", ErrStreamTooLong, maxItems)
+		}
+		items = append(items, item)
+	}
+}
+
 func (srw *streamReaderWithConvert[T]) recv() (T, error) {
 	for {
 		out, err := srw.sr.recvAny()
@@ -62,6 +62,43 @@ func TestStream(t *testing.T) {
 	wg.Wait()
 }
 
+func TestStreamWriterCloseWithError(t *testing.T) {
+	t.Run("with error", func(t *testing.T) {
+		sr, sw := Pipe[int](1)
+		errBoom := errors.New("boom")
+		go func() {
+			sw.Send(1, nil)
+			sw.CloseWithError(errBoom)
+		}()
+		defer sr.Close()
+
+		v, err := sr.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+
+		_, err = sr.Recv()
+		assert.ErrorIs(t, err, errBoom)
+
+		_, err = sr.Recv()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		sr, sw := Pipe[int](1)
+		sw.CloseWithError(nil)
+		defer sr.Close()
+
+		_, err := sr.Recv()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("receiver closed", func(t *testing.T) {
+		sr, sw := Pipe[int](0)
+		sr.Close()
+		sw.CloseWithError(errors.New("boom"))
+	})
+}
+
 func TestStreamCopy(t *testing.T) {
 	s := newStream[string](10)
 	srs := s.asReader().Copy(2)
@@ -504,6 +541,64 @@ func TestStreamReaderWithConvert(t *testing.T) {
 	assert.Equal(t, cntA, 2)
 }
 
+func TestStreamReaderFilter(t *testing.T) {
+	recvAll := func(sr *StreamReader[*Message]) ([]string, error) {
+		defer sr.Close()
+		var contents []string
+		for {
+			msg, err := sr.Recv()
+			if err == io.EOF {
+				return contents, nil
+			}
+			if err != nil {
+				return contents, err
+			}
+			contents = append(contents, msg.Content)
+		}
+	}
+	nonEmpty := func(m *Message) bool {
+		return m.Content != ""
+	}
+
+	t.Run("all filtered", func(t *testing.T) {
+		sr := StreamReaderFromArray([]*Message{AssistantMessage("", nil), AssistantMessage("", nil)})
+		contents, err := recvAll(StreamReaderFilter(sr, nonEmpty))
+		assert.NoError(t, err)
+		assert.Empty(t, contents)
+	})
+
+	t.Run("none filtered", func(t *testing.T) {
+		sr := StreamReaderFromArray([]*Message{AssistantMessage("a", nil), AssistantMessage("b", nil)})
+		contents, err := recvAll(StreamReaderFilter(sr, nonEmpty))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, contents)
+	})
+
+	t.Run("partly filtered with error", func(t *testing.T) {
+		sr, sw := Pipe[*Message](3)
+		go func() {
+			defer sw.Close()
+			sw.Send(AssistantMessage("", nil), nil)
+			sw.Send(AssistantMessage("a", nil), nil)
+			sw.Send(nil, fmt.Errorf("mock err"))
+		}()
+
+		contents, err := recvAll(StreamReaderFilter(sr, nonEmpty))
+		assert.EqualError(t, err, "mock err")
+		assert.Equal(t, []string{"a"}, contents)
+	})
+
+	t.Run("close closes source", func(t *testing.T) {
+		sr, sw := Pipe[*Message](0)
+		filtered := StreamReaderFilter(sr, nonEmpty)
+		filtered.Close()
+
+		closed := sw.Send(AssistantMessage("a", nil), nil)
+		assert.True(t, closed)
+		sw.Close()
+	})
+}
+
 func TestArrayStreamCombined(t *testing.T) {
 	asr := &StreamReader[int]{
 		typ: readerTypeArray,
@@ -855,3 +950,44 @@ func TestMergeNamedStreamReaders(t *testing.T) {
 		}
 	})
 }
+
+func TestStreamReaderCollect(t *testing.T) {
+	t.Run("completed", func(t *testing.T) {
+		items, err := StreamReaderCollect(StreamReaderFromArray([]int{1, 2, 3}), 3)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3}, items)
+
+		items, err = StreamReaderCollect(StreamReaderFromArray([]int{1, 2, 3}), 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3}, items)
+
+		items, err = StreamReaderCollect(StreamReaderFromArray([]int{}), 1)
+		assert.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		sr, sw := Pipe[int](3)
+		sw.Send(1, nil)
+		sw.Send(0, errors.New("broken"))
+		sw.Close()
+		items, err := StreamReaderCollect(sr, 10)
+		assert.EqualError(t, err, "broken")
+		assert.Equal(t, []int{1}, items)
+	})
+
+	t.Run("cap exceeded", func(t *testing.T) {
+		sr, sw := Pipe[int](0)
+		go func() {
+			defer sw.Close()
+			for i := 0; ; i++ {
+				if closed := sw.Send(i, nil); closed {
+					return
+				}
+			}
+		}()
+		items, err := StreamReaderCollect(sr, 2)
+		assert.ErrorIs(t, err, ErrStreamTooLong)
+		assert.Equal(t, []int{0, 1}, items)
+	})
+}
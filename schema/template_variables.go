@@ -0,0 +1,201 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ExtractVariables returns the sorted names of the variables referenced by the templates, i.e. the keys expected in the
+// variables passed to Format, which are the placeholders in the contents of messages and the keys of non-optional MessagesPlaceholder.
+// It's a best-effort static analysis, variables referenced dynamically, e.g. by index expressions or macros, are not found.
+// e.g.
+//
+//	vars := schema.ExtractVariables(schema.FString,
+//		schema.SystemMessage("answer the question based on the context: {context}"),
+//		schema.MessagesPlaceholder("history", true),
+//		schema.UserMessage("{question}"))
+//	// vars is ["context", "question"]
+func ExtractVariables(formatType FormatType, templates ...MessagesTemplate) []string {
+	vars := make(map[string]bool)
+	for _, tpl := range templates {
+		switch t := tpl.(type) {
+		case *messagesPlaceholder:
+			if !t.optional {
+				vars[t.key] = true
+			}
+		case *Message:
+			if t == nil {
+				continue
+			}
+			contents := []string{t.Content}
+			for _, part := range t.MultiContent {
+				contents = append(contents, part.Text)
+			}
+			for _, part := range t.UserInputMultiContent {
+				contents = append(contents, part.Text)
+			}
+			for _, content := range contents {
+				extractContentVariables(content, formatType, vars)
+			}
+		}
+	}
+
+	ret := make([]string, 0, len(vars))
+	for v := range vars {
+		ret = append(ret, v)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func extractContentVariables(content string, formatType FormatType, vars map[string]bool) {
+	if content == "" {
+		return
+	}
+	switch formatType {
+	case FString:
+		extractFStringVariables(content, vars)
+	case GoTemplate:
+		extractGoTemplateVariables(content, vars)
+	case Jinja2:
+		extractJinja2Variables(content, vars)
+	}
+}
+
+// extractFStringVariables finds the fields of the replacement fields like {name}, {name.attr} or {name:format}, skipping the escaped {{ and }}.
+func extractFStringVariables(content string, vars map[string]bool) {
+	for i := 0; i < len(content); i++ {
+		if content[i] != '{' {
+			continue
+		}
+		if i+1 < len(content) && content[i+1] == '{' {
+			i++
+			continue
+		}
+		end := strings.IndexByte(content[i:], '}')
+		if end < 0 {
+			return
+		}
+		field := content[i+1 : i+end]
+		if j := strings.IndexAny(field, ".[:!"); j >= 0 {
+			field = field[:j]
+		}
+		if field = strings.TrimSpace(field); field != "" {
+			vars[field] = true
+		}
+		i += end
+	}
+}
+
+// extractGoTemplateVariables finds the top level fields of dot, e.g. {{.name}} or {{if .ok}}, while the fields inside range and with,
+// where dot is changed, are not considered.
+func extractGoTemplateVariables(content string, vars map[string]bool) {
+	tmpl, err := template.New("template").Parse(content)
+	if err != nil {
+		return
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && t.Tree.Root != nil {
+			walkGoTemplateNode(t.Tree.Root, vars)
+		}
+	}
+}
+
+func walkGoTemplateNode(node parse.Node, vars map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			walkGoTemplateNode(c, vars)
+		}
+	case *parse.ActionNode:
+		walkGoTemplateNode(n.Pipe, vars)
+	case *parse.IfNode:
+		walkGoTemplateNode(n.Pipe, vars)
+		walkGoTemplateNode(n.List, vars)
+		walkGoTemplateNode(n.ElseList, vars)
+	case *parse.RangeNode:
+		walkGoTemplateNode(n.Pipe, vars)
+		walkGoTemplateNode(n.ElseList, vars)
+	case *parse.WithNode:
+		walkGoTemplateNode(n.Pipe, vars)
+		walkGoTemplateNode(n.ElseList, vars)
+	case *parse.TemplateNode:
+		walkGoTemplateNode(n.Pipe, vars)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkGoTemplateNode(cmd, vars)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkGoTemplateNode(arg, vars)
+		}
+	case *parse.FieldNode:
+		if len(n.Ident) > 0 {
+			vars[n.Ident[0]] = true
+		}
+	case *parse.ChainNode:
+		walkGoTemplateNode(n.Node, vars)
+	}
+}
+
+var (
+	jinja2ExprRegexp = regexp.MustCompile(`\{\{-?\s*([A-Za-z_]\w*)`)
+	jinja2StmtRegexp = regexp.MustCompile(`\{%-?\s*(?:if|elif)\s+(?:not\s+)?([A-Za-z_]\w*)`)
+	jinja2ForRegexp  = regexp.MustCompile(`\{%-?\s*for\s+([A-Za-z_]\w*)(?:\s*,\s*([A-Za-z_]\w*))?\s+in\s+([A-Za-z_]\w*)`)
+	jinja2SetRegexp  = regexp.MustCompile(`\{%-?\s*set\s+([A-Za-z_]\w*)`)
+)
+
+// extractJinja2Variables finds the leading names of expressions and statements, e.g. {{ name }}, {% if ok %} and {% for x in items %},
+// excluding the names defined by for and set, and the loop variable.
+func extractJinja2Variables(content string, vars map[string]bool) {
+	locals := map[string]bool{"loop": true, "true": true, "false": true, "none": true, "True": true, "False": true, "None": true}
+	for _, m := range jinja2ForRegexp.FindAllStringSubmatch(content, -1) {
+		locals[m[1]] = true
+		if m[2] != "" {
+			locals[m[2]] = true
+		}
+	}
+	for _, m := range jinja2SetRegexp.FindAllStringSubmatch(content, -1) {
+		locals[m[1]] = true
+	}
+
+	add := func(name string) {
+		if !locals[name] {
+			vars[name] = true
+		}
+	}
+	for _, m := range jinja2ExprRegexp.FindAllStringSubmatch(content, -1) {
+		add(m[1])
+	}
+	for _, m := range jinja2StmtRegexp.FindAllStringSubmatch(content, -1) {
+		add(m[1])
+	}
+	for _, m := range jinja2ForRegexp.FindAllStringSubmatch(content, -1) {
+		add(m[3])
+	}
+}
@@ -17,7 +17,10 @@
 package schema
 
 import (
+	"fmt"
+	"regexp"
 	"sort"
+	"unicode/utf8"
 
 	"github.com/eino-contrib/jsonschema"
 	orderedmap "github.com/wk8/go-ordered-map/v2"
@@ -73,6 +76,43 @@ type ToolInfo struct {
 	*ParamsOneOf
 }
 
+// MaxToolDescLength is the max length in characters of ToolInfo.Desc accepted by Validate.
+const MaxToolDescLength = 1024
+
+// toolNamePattern is the pattern of tool names accepted by most model providers, e.g. OpenAI.
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// NewToolInfo creates a ToolInfo and validates it, see ToolInfo.Validate.
+// params can be nil if the tool does not need any input parameter.
+func NewToolInfo(name, desc string, params *ParamsOneOf) (*ToolInfo, error) {
+	info := &ToolInfo{
+		Name:        name,
+		Desc:        desc,
+		ParamsOneOf: params,
+	}
+	if err := info.Validate(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Validate checks the tool against the constraints of model providers, so that a misconfigured tool is caught early
+// instead of failing at model call time with an opaque error:
+//   - the name must match ^[a-zA-Z0-9_-]{1,64}$
+//   - the description must be no longer than MaxToolDescLength characters
+func (t *ToolInfo) Validate() error {
+	if t == nil {
+		return fmt.Errorf("tool info is nil")
+	}
+	if !toolNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid tool name %q: must be 1 to 64 characters of letters, digits, underscores or hyphens", t.Name)
+	}
+	if n := utf8.RuneCountInString(t.Desc); n > MaxToolDescLength {
+		return fmt.Errorf("description of tool[%s] is too long: %d characters, at most %d", t.Name, n, MaxToolDescLength)
+	}
+	return nil
+}
+
 // ParameterInfo is the information of a parameter.
 // It is used to describe the parameters of a tool.
 type ParameterInfo struct {
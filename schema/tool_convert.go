@@ -0,0 +1,257 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/bytedance/sonic"
+	"github.com/eino-contrib/jsonschema"
+)
+
+// ToOpenAITool converts the tool into the function tool definition of OpenAI Chat Completion, i.e.
+//
+//	{"type": "function", "function": {"name": ..., "description": ..., "parameters": {...}}}
+//
+// which is also accepted by OpenAI compatible APIs, e.g. Ollama, vLLM and DeepSeek.
+func ToOpenAITool(t *ToolInfo) (map[string]any, error) {
+	params, err := toolParameters(t)
+	if err != nil {
+		return nil, err
+	}
+
+	function := map[string]any{
+		"name":       t.Name,
+		"parameters": params,
+	}
+	if t.Desc != "" {
+		function["description"] = t.Desc
+	}
+
+	return map[string]any{
+		"type":     "function",
+		"function": function,
+	}, nil
+}
+
+// ToOpenAIStrictTool is like ToOpenAITool, but enables the strict mode of OpenAI function calling, i.e.
+//
+//	{"type": "function", "function": {"name": ..., "description": ..., "parameters": {...}, "strict": true}}
+//
+// in which the arguments generated by the model are guaranteed to match the parameters,
+// which are converted by ToStrictJSONSchema to satisfy the requirements of strict mode.
+func ToOpenAIStrictTool(t *ToolInfo) (map[string]any, error) {
+	params, err := strictToolParameters(t)
+	if err != nil {
+		return nil, err
+	}
+
+	function := map[string]any{
+		"name":       t.Name,
+		"parameters": params,
+		"strict":     true,
+	}
+	if t.Desc != "" {
+		function["description"] = t.Desc
+	}
+
+	return map[string]any{
+		"type":     "function",
+		"function": function,
+	}, nil
+}
+
+// ToAnthropicTool converts the tool into the tool definition of Anthropic Messages API, i.e.
+//
+//	{"name": ..., "description": ..., "input_schema": {...}}
+func ToAnthropicTool(t *ToolInfo) (map[string]any, error) {
+	params, err := toolParameters(t)
+	if err != nil {
+		return nil, err
+	}
+
+	tool := map[string]any{
+		"name":         t.Name,
+		"input_schema": params,
+	}
+	if t.Desc != "" {
+		tool["description"] = t.Desc
+	}
+
+	return tool, nil
+}
+
+// ToGeminiFunctionDeclaration converts the tool into the function declaration of Gemini API, i.e.
+//
+//	{"name": ..., "description": ..., "parametersJsonSchema": {...}}
+//
+// The JSON schema is passed by parametersJsonSchema instead of parameters, which accepts only a subset of OpenAPI schema.
+// A Gemini tool carries declarations of several functions: {"functionDeclarations": [...]}.
+func ToGeminiFunctionDeclaration(t *ToolInfo) (map[string]any, error) {
+	params, err := toolParameters(t)
+	if err != nil {
+		return nil, err
+	}
+
+	decl := map[string]any{
+		"name":                 t.Name,
+		"parametersJsonSchema": params,
+	}
+	if t.Desc != "" {
+		decl["description"] = t.Desc
+	}
+
+	return decl, nil
+}
+
+// toolParameters returns the JSON schema of the tool parameters as a JSON object.
+// A tool without parameters gets an object schema without properties, because providers reject a missing or null schema.
+func toolParameters(t *ToolInfo) (map[string]any, error) {
+	if t == nil {
+		return nil, fmt.Errorf("tool info is nil")
+	}
+
+	js, err := t.ParamsOneOf.ToJSONSchema()
+	if err != nil {
+		return nil, fmt.Errorf("convert params of tool[%s] to json schema failed: %w", t.Name, err)
+	}
+	if js == nil {
+		return map[string]any{"type": string(Object), "properties": map[string]any{}}, nil
+	}
+
+	return jsonSchemaToMap(t.Name, js)
+}
+
+func strictToolParameters(t *ToolInfo) (map[string]any, error) {
+	if t == nil {
+		return nil, fmt.Errorf("tool info is nil")
+	}
+
+	js, err := t.ParamsOneOf.ToJSONSchema()
+	if err != nil {
+		return nil, fmt.Errorf("convert params of tool[%s] to json schema failed: %w", t.Name, err)
+	}
+	if js == nil {
+		return map[string]any{"type": string(Object), "properties": map[string]any{}, "additionalProperties": false}, nil
+	}
+
+	if js, err = ToStrictJSONSchema(js); err != nil {
+		return nil, fmt.Errorf("convert params of tool[%s] to strict json schema failed: %w", t.Name, err)
+	}
+
+	return jsonSchemaToMap(t.Name, js)
+}
+
+func jsonSchemaToMap(toolName string, js *jsonschema.Schema) (map[string]any, error) {
+	b, err := sonic.Marshal(js)
+	if err != nil {
+		return nil, fmt.Errorf("marshal json schema of tool[%s] failed: %w", toolName, err)
+	}
+	params := map[string]any{}
+	if err = sonic.Unmarshal(b, &params); err != nil {
+		return nil, fmt.Errorf("unmarshal json schema of tool[%s] failed: %w", toolName, err)
+	}
+
+	return params, nil
+}
+
+// ToStrictJSONSchema returns a copy of the JSON schema satisfying the requirements of the strict mode of OpenAI function calling
+// and structured outputs, leaving the original schema untouched. For each object in the schema:
+//   - additionalProperties is set to false, unless it's already a schema of the values of a map, which strict mode doesn't support.
+//   - all properties are required, and the ones not required originally become nullable, e.g. "type": ["string", "null"],
+//     so that the model passes null for the optional properties it omits.
+func ToStrictJSONSchema(js *jsonschema.Schema) (*jsonschema.Schema, error) {
+	if js == nil {
+		return nil, nil
+	}
+
+	b, err := sonic.Marshal(js)
+	if err != nil {
+		return nil, fmt.Errorf("marshal json schema failed: %w", err)
+	}
+	cpy := &jsonschema.Schema{}
+	if err = sonic.Unmarshal(b, cpy); err != nil {
+		return nil, fmt.Errorf("unmarshal json schema failed: %w", err)
+	}
+
+	toStrict(cpy)
+	return cpy, nil
+}
+
+func toStrict(js *jsonschema.Schema) {
+	if js == nil {
+		return
+	}
+
+	if js.Type == string(Object) || js.Properties != nil {
+		if js.AdditionalProperties == nil || reflect.DeepEqual(js.AdditionalProperties, jsonschema.TrueSchema) {
+			js.AdditionalProperties = jsonschema.FalseSchema
+		}
+
+		if js.Properties != nil {
+			required := make(map[string]bool, len(js.Required))
+			for _, r := range js.Required {
+				required[r] = true
+			}
+			js.Required = make([]string, 0, js.Properties.Len())
+			for pair := js.Properties.Oldest(); pair != nil; pair = pair.Next() {
+				if !required[pair.Key] {
+					toNullable(pair.Value)
+				}
+				js.Required = append(js.Required, pair.Key)
+				toStrict(pair.Value)
+			}
+		}
+	}
+
+	toStrict(js.Items)
+	for _, s := range js.AnyOf {
+		toStrict(s)
+	}
+	for _, s := range js.OneOf {
+		toStrict(s)
+	}
+	for _, s := range js.AllOf {
+		toStrict(s)
+	}
+	for _, s := range js.Definitions {
+		toStrict(s)
+	}
+}
+
+func toNullable(js *jsonschema.Schema) {
+	if js == nil {
+		return
+	}
+
+	switch {
+	case js.Type == "null":
+	case js.Type != "":
+		js.TypeEnhanced = []string{js.Type, "null"}
+		js.Type = ""
+	case len(js.TypeEnhanced) > 0:
+		for _, t := range js.TypeEnhanced {
+			if t == "null" {
+				return
+			}
+		}
+		js.TypeEnhanced = append(js.TypeEnhanced, "null")
+	case len(js.AnyOf) > 0:
+		js.AnyOf = append(js.AnyOf, &jsonschema.Schema{Type: "null"})
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/smartystreets/goconvey/convey"
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+func TestToolConverters(t *testing.T) {
+	convey.Convey("convert tool info to provider tool definitions", t, func() {
+		params := NewParamsOneOfByParams(map[string]*ParameterInfo{
+			"city": {Type: String, Desc: "name of the city", Required: true},
+			"days": {Type: Integer, Desc: "days to forecast"},
+		})
+		weather := &ToolInfo{Name: "get_weather", Desc: "get weather of a city", ParamsOneOf: params}
+		expectedParams := `{"type":"object","properties":{"city":{"type":"string","description":"name of the city"},` +
+			`"days":{"type":"integer","description":"days to forecast"}},"required":["city"]}`
+
+		// toJSON re-encodes the definition for comparison, keys of maps are sorted by sonic.ConfigStd.
+		toJSON := func(v any) string {
+			b, err := sonic.ConfigStd.Marshal(v)
+			convey.So(err, convey.ShouldBeNil)
+			return string(b)
+		}
+		expected := func(js string) string {
+			var v any
+			convey.So(sonic.UnmarshalString(js, &v), convey.ShouldBeNil)
+			return toJSON(v)
+		}
+
+		convey.Convey("openai", func() {
+			def, err := ToOpenAITool(weather)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(toJSON(def), convey.ShouldEqual, expected(`{"type":"function","function":{"name":"get_weather",`+
+				`"description":"get weather of a city","parameters":`+expectedParams+`}}`))
+		})
+
+		convey.Convey("openai strict", func() {
+			def, err := ToOpenAIStrictTool(weather)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(toJSON(def), convey.ShouldEqual, expected(`{"type":"function","function":{"name":"get_weather",`+
+				`"description":"get weather of a city","strict":true,"parameters":{"type":"object","properties":{`+
+				`"city":{"type":"string","description":"name of the city"},"days":{"type":["integer","null"],"description":"days to forecast"}},`+
+				`"required":["city","days"],"additionalProperties":false}}}`))
+
+			// the params of the tool info are not modified
+			js, err := weather.ToJSONSchema()
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(js.Required, convey.ShouldResemble, []string{"city"})
+		})
+
+		convey.Convey("anthropic", func() {
+			def, err := ToAnthropicTool(weather)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(toJSON(def), convey.ShouldEqual, expected(`{"name":"get_weather","description":"get weather of a city",`+
+				`"input_schema":`+expectedParams+`}`))
+		})
+
+		convey.Convey("gemini", func() {
+			def, err := ToGeminiFunctionDeclaration(weather)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(toJSON(def), convey.ShouldEqual, expected(`{"name":"get_weather","description":"get weather of a city",`+
+				`"parametersJsonSchema":`+expectedParams+`}`))
+		})
+
+		convey.Convey("json schema params", func() {
+			props := orderedmap.New[string, *jsonschema.Schema]()
+			props.Set("path", &jsonschema.Schema{Type: "string"})
+			info := &ToolInfo{Name: "cat_file", ParamsOneOf: NewParamsOneOfByJSONSchema(&jsonschema.Schema{
+				Type:       "object",
+				Properties: props,
+				Required:   []string{"path"},
+			})}
+			def, err := ToAnthropicTool(info)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(toJSON(def), convey.ShouldEqual, expected(`{"name":"cat_file","input_schema":`+
+				`{"type":"object","properties":{"path":{"type":"string"}},"required":["path"]}}`))
+		})
+
+		convey.Convey("no params", func() {
+			def, err := ToOpenAITool(&ToolInfo{Name: "now"})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(toJSON(def), convey.ShouldEqual, expected(`{"type":"function","function":{"name":"now",`+
+				`"parameters":{"type":"object","properties":{}}}}`))
+		})
+
+		convey.Convey("nil tool", func() {
+			_, err := ToOpenAITool(nil)
+			convey.So(err, convey.ShouldNotBeNil)
+		})
+	})
+}
@@ -18,6 +18,7 @@ package schema
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/eino-contrib/jsonschema"
@@ -133,3 +134,36 @@ func TestParamsOneOfToJSONSchema(t *testing.T) {
 
 	})
 }
+
+func TestToolInfoValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		info, err := NewToolInfo("get_weather-v2", "query the weather of a city", NewParamsOneOfByParams(map[string]*ParameterInfo{
+			"city": {Type: String, Required: true},
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, "get_weather-v2", info.Name)
+		assert.NotNil(t, info.ParamsOneOf)
+
+		_, err = NewToolInfo(strings.Repeat("a", 64), strings.Repeat("描", MaxToolDescLength), nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := NewToolInfo("get weather", "name with spaces", nil)
+		assert.ErrorContains(t, err, `invalid tool name "get weather": must be 1 to 64 characters of letters, digits, underscores or hyphens`)
+
+		for _, name := range []string{"", "查询天气", "get.weather", strings.Repeat("a", 65)} {
+			assert.Error(t, (&ToolInfo{Name: name}).Validate(), name)
+		}
+	})
+
+	t.Run("description too long", func(t *testing.T) {
+		err := (&ToolInfo{Name: "get_weather", Desc: strings.Repeat("a", MaxToolDescLength+1)}).Validate()
+		assert.ErrorContains(t, err, "description of tool[get_weather] is too long: 1025 characters, at most 1024")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var info *ToolInfo
+		assert.ErrorContains(t, info.Validate(), "tool info is nil")
+	})
+}
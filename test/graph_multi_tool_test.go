@@ -114,6 +114,8 @@ func TestGraphMultiTool(t *testing.T) {
 			Desc: "查询天气的tool,输入要查询的城市名,返回该城市的温度和天气",
 		},
 		GetWeather,
+		// 将返回值的结构（含字段描述）附加到工具描述中，便于模型理解返回结果
+		utils.WithResponseSchema(),
 	)
 
 	// 查找文件工具
@@ -137,6 +139,8 @@ func TestGraphMultiTool(t *testing.T) {
 	// 4. 创建 tools node
 	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{
 		Tools: []tool.BaseTool{weatherTool, findFileTool, catFileTool},
+		// 某个工具调用失败时（如 cat_file 读取失败），以工具消息返回错误，其余工具的结果照常返回
+		ContinueOnToolError: true,
 	})
 	assert.NoError(t, err)
 
@@ -153,15 +157,15 @@ func TestGraphMultiTool(t *testing.T) {
 	catFileToolInfo, _ := catFileTool.Info(ctx)
 
 	toolInfos = append(toolInfos, weatherToolInfo, findFileToolInfo, catFileToolInfo)
-	chatModel := NewOpenAIModel(&client, toolInfos)
+	chatModel := NewOpenAIModel(&client, toolInfos, WithModelName("deepseek-chat"))
 
-	// 7. 创建 takeOne lambda
-	takeOne := compose.InvokableLambda(func(ctx context.Context, input []*schema.Message) (*schema.Message, error) {
+	// 7. 创建 takeOne 归约函数，自动将 ToolsNode 的 []*schema.Message 输出转换为 END 所需的 *schema.Message
+	takeOne := func(input []*schema.Message) (*schema.Message, error) {
 		if len(input) > 0 {
 			return input[0], nil
 		}
 		return nil, fmt.Errorf("no messages to take")
-	})
+	}
 
 	// 8. 创建 branch
 	branch := compose.NewGraphBranch(func(ctx context.Context, msg *schema.Message) (string, error) {
@@ -175,7 +179,7 @@ func TestGraphMultiTool(t *testing.T) {
 	})
 
 	// 9. 创建 graph
-	graph := compose.NewGraph[map[string]any, *schema.Message]()
+	graph := compose.NewGraph[map[string]any, *schema.Message](compose.WithAutoReduce(takeOne))
 
 	// 10. 添加模板节点
 	chatTemplate := prompt.FromMessages(schema.FString,
@@ -193,9 +197,6 @@ func TestGraphMultiTool(t *testing.T) {
 	err = graph.AddToolsNode("node_tools", toolsNode)
 	assert.NoError(t, err)
 
-	err = graph.AddLambdaNode("node_converter", takeOne)
-	assert.NoError(t, err)
-
 	// 11. 添加边
 	err = graph.AddEdge(compose.START, "node_template")
 	assert.NoError(t, err)
@@ -206,10 +207,7 @@ func TestGraphMultiTool(t *testing.T) {
 	err = graph.AddBranch("node_model", branch)
 	assert.NoError(t, err)
 
-	err = graph.AddEdge("node_tools", "node_converter")
-	assert.NoError(t, err)
-
-	err = graph.AddEdge("node_converter", compose.END)
+	err = graph.AddEdge("node_tools", compose.END)
 	assert.NoError(t, err)
 
 	// 12. 编译graph
@@ -50,15 +50,15 @@ func TestGraph(t *testing.T) {
 
 	// 4. 创建 chatModel
 	toolInfo, _ := weatherTool.Info(ctx)
-	chatModel := NewOpenAIModel(&client, []*schema.ToolInfo{toolInfo})
+	chatModel := NewOpenAIModel(&client, []*schema.ToolInfo{toolInfo}, WithModelName("deepseek-chat"))
 
-	// 3. 创建 takeOne lambda
-	takeOne := compose.InvokableLambda(func(ctx context.Context, input []*schema.Message) (*schema.Message, error) {
+	// 3. 创建 takeOne 归约函数，自动将 ToolsNode 的 []*schema.Message 输出转换为 END 所需的 *schema.Message
+	takeOne := func(input []*schema.Message) (*schema.Message, error) {
 		if len(input) > 0 {
 			return input[0], nil
 		}
 		return nil, fmt.Errorf("no messages to take")
-	})
+	}
 
 	// 4. 创建 branch
 	branch := compose.NewGraphBranch(func(ctx context.Context, msg *schema.Message) (string, error) {
@@ -72,7 +72,7 @@ func TestGraph(t *testing.T) {
 	})
 
 	// 5. 创建 graph
-	graph := compose.NewGraph[map[string]any, *schema.Message]()
+	graph := compose.NewGraph[map[string]any, *schema.Message](compose.WithAutoReduce(takeOne))
 
 	// 6. 添加模板节点
 	chatTemplate := prompt.FromMessages(schema.FString,
@@ -90,9 +90,6 @@ func TestGraph(t *testing.T) {
 	err = graph.AddToolsNode("node_tools", toolsNode)
 	assert.NoError(t, err)
 
-	err = graph.AddLambdaNode("node_converter", takeOne)
-	assert.NoError(t, err)
-
 	// 7. 添加边
 	err = graph.AddEdge(compose.START, "node_template")
 	assert.NoError(t, err)
@@ -103,10 +100,7 @@ func TestGraph(t *testing.T) {
 	err = graph.AddBranch("node_model", branch)
 	assert.NoError(t, err)
 
-	err = graph.AddEdge("node_tools", "node_converter")
-	assert.NoError(t, err)
-
-	err = graph.AddEdge("node_converter", compose.END)
+	err = graph.AddEdge("node_tools", compose.END)
 	assert.NoError(t, err)
 
 	// 8. 编译graph
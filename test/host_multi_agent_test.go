@@ -0,0 +1,110 @@
+package test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model/mock"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/multiagent/host"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSpecialist 将 react agent 包装为 host multi-agent 的 specialist，同时支持 Generate 与 Stream
+func newSpecialist(t *testing.T, name, intendedUse string, cm *mock.ScriptedChatModel, tools ...tool.BaseTool) *host.Specialist {
+	a, err := react.NewAgent(context.Background(), &react.AgentConfig{
+		ToolCallingModel: cm,
+		ToolsConfig:      compose.ToolsNodeConfig{Tools: tools},
+	})
+	assert.NoError(t, err)
+
+	return &host.Specialist{
+		AgentMeta: host.AgentMeta{
+			Name:        name,
+			IntendedUse: intendedUse,
+		},
+		Invokable:  a.Generate,
+		Streamable: a.Stream,
+	}
+}
+
+func TestHostMultiAgentWithReactSpecialists(t *testing.T) {
+	ctx := context.Background()
+
+	// newMultiAgent 创建 host 与天气、文件系统两个 specialist，host 选择天气 specialist
+	newMultiAgent := func(t *testing.T) (*host.MultiAgent, *mock.ScriptedChatModel, *mock.ScriptedChatModel, *mock.ScriptedChatModel) {
+		hostModel := mock.NewScriptedChatModel(toolCallMessage("call_host", "weather_agent", `{"reason":"asking about weather"}`))
+		weatherModel := mock.NewScriptedChatModel(
+			toolCallMessage("call_1", "get_weather", `{"city":"北京"}`),
+			schema.AssistantMessage("北京今天晴，25度", nil),
+		)
+		fileModel := mock.NewScriptedChatModel()
+
+		catFileTool := utils.NewTool[CatFileReq, CatFileResp](
+			&schema.ToolInfo{
+				Name: "cat_file",
+				Desc: "读取文件内容的tool,输入文件路径,返回文件内容",
+			},
+			CatFile,
+		)
+
+		ma, err := host.NewMultiAgent(ctx, &host.MultiAgentConfig{
+			Host: host.Host{ToolCallingModel: hostModel},
+			Specialists: []*host.Specialist{
+				newSpecialist(t, "weather_agent", "查询城市天气", weatherModel, newFakeWeatherTool()),
+				newSpecialist(t, "file_agent", "读取本地文件", fileModel, catFileTool),
+			},
+		})
+		assert.NoError(t, err)
+		return ma, hostModel, weatherModel, fileModel
+	}
+
+	t.Run("generate", func(t *testing.T) {
+		ma, hostModel, weatherModel, fileModel := newMultiAgent(t)
+
+		out, err := ma.Generate(ctx, []*schema.Message{schema.UserMessage("how's weather of beijing")})
+		assert.NoError(t, err)
+		assert.Equal(t, "北京今天晴，25度", out.Content)
+
+		// host 以 tool 的形式看到各 specialist 的名称与用途
+		hostCalls := hostModel.Calls()
+		assert.Len(t, hostCalls, 1)
+		assert.Len(t, hostCalls[0].Tools, 2)
+		assert.Equal(t, "weather_agent", hostCalls[0].Tools[0].Name)
+		assert.Equal(t, "查询城市天气", hostCalls[0].Tools[0].Desc)
+		assert.Equal(t, "file_agent", hostCalls[0].Tools[1].Name)
+
+		weatherCalls := weatherModel.Calls()
+		assert.Len(t, weatherCalls, 2)
+		assert.Equal(t, "how's weather of beijing", weatherCalls[0].Input[len(weatherCalls[0].Input)-1].Content)
+		assert.Empty(t, fileModel.Calls())
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		ma, _, weatherModel, fileModel := newMultiAgent(t)
+
+		sr, err := ma.Stream(ctx, []*schema.Message{schema.UserMessage("how's weather of beijing")})
+		assert.NoError(t, err)
+		defer sr.Close()
+
+		var chunks []*schema.Message
+		for {
+			chunk, err := sr.Recv()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			chunks = append(chunks, chunk)
+		}
+		out, err := schema.ConcatMessages(chunks)
+		assert.NoError(t, err)
+		assert.Equal(t, "北京今天晴，25度", out.Content)
+		assert.Len(t, weatherModel.Calls(), 2)
+		assert.Empty(t, fileModel.Calls())
+	})
+}
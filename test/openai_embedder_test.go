@@ -0,0 +1,216 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/compose"
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/assert"
+)
+
+// defaultEmbeddingBatchSize 为单次请求的最大文本数，OpenAI embeddings 接口对 input 数量有限制
+const defaultEmbeddingBatchSize = 256
+
+// OpenAIEmbedder 包装 openai-go 客户端，实现 Embedder 接口
+type OpenAIEmbedder struct {
+	client *openai.Client
+	model  string
+	// batchSize 为单次请求的最大文本数，超过时分批请求
+	batchSize int
+}
+
+// NewOpenAIEmbedder 创建一个新的 OpenAIEmbedder 实例，batchSize <= 0 时使用默认值
+func NewOpenAIEmbedder(client *openai.Client, model string, batchSize int) *OpenAIEmbedder {
+	if batchSize <= 0 {
+		batchSize = defaultEmbeddingBatchSize
+	}
+	return &OpenAIEmbedder{
+		client:    client,
+		model:     model,
+		batchSize: batchSize,
+	}
+}
+
+// EmbedBatchError 表示某一批次的 embedding 请求失败
+type EmbedBatchError struct {
+	// Batch 为失败批次的序号，从 0 开始
+	Batch int
+	// Start, End 为失败批次在输入文本中的范围 [Start, End)
+	Start, End int
+	Err        error
+}
+
+func (e *EmbedBatchError) Error() string {
+	return fmt.Sprintf("embed batch %d (texts[%d:%d]) failed: %v", e.Batch, e.Start, e.End, e.Err)
+}
+
+func (e *EmbedBatchError) Unwrap() error {
+	return e.Err
+}
+
+// EmbedStrings 实现 Embedder 接口，按 batchSize 分批请求。
+// 某一批次失败时返回 *EmbedBatchError，同时返回之前已成功批次的向量
+func (e *OpenAIEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	options := embedding.GetCommonOptions(&embedding.Options{Model: &e.model}, opts...)
+
+	vectors := make([][]float64, 0, len(texts))
+	for batch, start := 0, 0; start < len(texts); batch, start = batch+1, start+e.batchSize {
+		end := min(start+e.batchSize, len(texts))
+
+		batchVectors, err := e.embedBatch(ctx, texts[start:end], *options.Model)
+		if err != nil {
+			return vectors, &EmbedBatchError{Batch: batch, Start: start, End: end, Err: err}
+		}
+		vectors = append(vectors, batchVectors...)
+	}
+
+	return vectors, nil
+}
+
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	resp, err := e.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		Model: openai.EmbeddingModel(model),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, but got %d", len(texts), len(resp.Data))
+	}
+
+	// 返回结果不保证有序，按 Index 放回对应位置
+	vectors := make([][]float64, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || int(d.Index) >= len(texts) || vectors[d.Index] != nil {
+			return nil, fmt.Errorf("invalid embedding index: %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+
+	return vectors, nil
+}
+
+// stubEmbeddingServer 模拟 OpenAI embeddings 接口，向量为 [文本长度, 批内序号]，输入包含 "fail" 时返回错误
+type stubEmbeddingServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	inputs [][]string
+	models []string
+}
+
+func newStubEmbeddingServer(t *testing.T) *stubEmbeddingServer {
+	s := &stubEmbeddingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input []string `json:"input"`
+			Model string   `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.inputs = append(s.inputs, body.Input)
+		s.models = append(s.models, body.Model)
+		s.mu.Unlock()
+
+		data := make([]any, 0, len(body.Input))
+		// 倒序返回，验证按 Index 还原顺序
+		for i := len(body.Input) - 1; i >= 0; i-- {
+			if body.Input[i] == "fail" {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"message":"invalid input","type":"invalid_request_error"}}`))
+				return
+			}
+			data = append(data, map[string]any{
+				"object": "embedding", "index": i,
+				"embedding": []float64{float64(len(body.Input[i])), float64(i)},
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"object": "list", "model": body.Model, "data": data,
+			"usage": map[string]any{"prompt_tokens": 0, "total_tokens": 0},
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubEmbeddingServer) client() *openai.Client {
+	client := openai.NewClient(
+		option.WithAPIKey("stub"),
+		option.WithBaseURL(s.URL),
+		option.WithMaxRetries(0),
+	)
+	return &client
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("batching", func(t *testing.T) {
+		srv := newStubEmbeddingServer(t)
+		e := NewOpenAIEmbedder(srv.client(), "text-embedding-3-small", 2)
+
+		vectors, err := e.EmbedStrings(ctx, []string{"a", "bb", "ccc", "dddd", "eeeee"})
+		assert.NoError(t, err)
+		assert.Equal(t, [][]float64{{1, 0}, {2, 1}, {3, 0}, {4, 1}, {5, 0}}, vectors)
+		assert.Equal(t, [][]string{{"a", "bb"}, {"ccc", "dddd"}, {"eeeee"}}, srv.inputs)
+		assert.Equal(t, []string{"text-embedding-3-small", "text-embedding-3-small", "text-embedding-3-small"}, srv.models)
+	})
+
+	t.Run("model option", func(t *testing.T) {
+		srv := newStubEmbeddingServer(t)
+		e := NewOpenAIEmbedder(srv.client(), "text-embedding-3-small", 0)
+
+		_, err := e.EmbedStrings(ctx, []string{"a"}, embedding.WithModel("text-embedding-3-large"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"text-embedding-3-large"}, srv.models)
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		srv := newStubEmbeddingServer(t)
+		e := NewOpenAIEmbedder(srv.client(), "text-embedding-3-small", 2)
+
+		vectors, err := e.EmbedStrings(ctx, []string{"a", "bb", "ccc", "fail", "eeeee"})
+		var batchErr *EmbedBatchError
+		assert.True(t, errors.As(err, &batchErr))
+		assert.Equal(t, 1, batchErr.Batch)
+		assert.Equal(t, 2, batchErr.Start)
+		assert.Equal(t, 4, batchErr.End)
+		assert.ErrorContains(t, err, "embed batch 1 (texts[2:4]) failed")
+		assert.Equal(t, [][]float64{{1, 0}, {2, 1}}, vectors)
+		assert.Len(t, srv.inputs, 2)
+	})
+
+	t.Run("embedding node in graph", func(t *testing.T) {
+		srv := newStubEmbeddingServer(t)
+		e := NewOpenAIEmbedder(srv.client(), "text-embedding-3-small", 0)
+
+		g := compose.NewGraph[[]string, [][]float64]()
+		assert.NoError(t, g.AddEmbeddingNode("embedder", e))
+		assert.NoError(t, g.AddEdge(compose.START, "embedder"))
+		assert.NoError(t, g.AddEdge("embedder", compose.END))
+
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		vectors, err := r.Invoke(ctx, []string{"hello", "hi"})
+		assert.NoError(t, err)
+		assert.Equal(t, [][]float64{{5, 0}, {2, 1}}, vectors)
+	})
+}
@@ -0,0 +1,1990 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/packages/respjson"
+	"github.com/openai/openai-go/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+// OpenAIModel 包装 openai-go 客户端，实现 ToolCallingChatModel 接口
+type OpenAIModel struct {
+	client *openai.Client
+	tools  []*schema.ToolInfo
+	// modelName 为默认的模型名称，可通过 model.WithModel 在单次调用中覆盖
+	modelName string
+	// responseFormatRetries 为返回内容不符合 ResponseFormat 时的重试次数
+	responseFormatRetries int
+	// defaultOpts 为默认的调用选项，如 temperature，可在单次调用中覆盖
+	defaultOpts []model.Option
+	// textOnly 为 true 时，多模态内容会退化为拼接后的纯文本，用于不支持多模态的模型
+	textOnly bool
+	// requestOpts 会附加到每次请求上，如自定义的 HTTP 客户端、base URL 与 API key，优先于 client 自身的配置
+	requestOpts []option.RequestOption
+	// schemaConstraints 为接口支持的 JSON schema 子集，绑定工具时据此校验参数 schema
+	schemaConstraints *model.SchemaConstraints
+	// batchConcurrency 为 BatchGenerate 同时发出的最大请求数，不大于 0 时使用 model.ConcurrentGenerate 的默认值
+	batchConcurrency int
+}
+
+// OpenAIModelOption 为创建 OpenAIModel 时的可选配置
+type OpenAIModelOption func(m *OpenAIModel)
+
+// WithModelName 设置默认的模型名称，如 "gpt-4o"、"deepseek-chat"
+func WithModelName(name string) OpenAIModelOption {
+	return func(m *OpenAIModel) {
+		m.modelName = name
+	}
+}
+
+// WithDefaultOptions 设置默认的调用选项，如 model.WithTemperature，单次调用传入的同名选项优先
+func WithDefaultOptions(opts ...model.Option) OpenAIModelOption {
+	return func(m *OpenAIModel) {
+		m.defaultOpts = append(m.defaultOpts, opts...)
+	}
+}
+
+// WithResponseFormatRetries 设置 Generate 返回内容不符合 JSON ResponseFormat 时的重试次数，默认不重试
+func WithResponseFormatRetries(n int) OpenAIModelOption {
+	return func(m *OpenAIModel) {
+		m.responseFormatRetries = n
+	}
+}
+
+// WithSchemaConstraints 设置接口支持的 JSON schema 子集，用于 OpenAI 兼容但支持范围不同的服务，
+// 默认仅要求参数 schema 的顶层为 object
+func WithSchemaConstraints(c *model.SchemaConstraints) OpenAIModelOption {
+	return func(m *OpenAIModel) {
+		m.schemaConstraints = c
+	}
+}
+
+// WithBatchConcurrency 设置 BatchGenerate 同时发出的最大请求数，避免批量调用触发限流
+func WithBatchConcurrency(n int) OpenAIModelOption {
+	return func(m *OpenAIModel) {
+		m.batchConcurrency = n
+	}
+}
+
+// WithTextOnly 将多模态内容退化为拼接后的纯文本发送，用于不支持多模态的模型
+func WithTextOnly() OpenAIModelOption {
+	return func(m *OpenAIModel) {
+		m.textOnly = true
+	}
+}
+
+// WithHTTPClient 设置发送请求使用的 HTTP 客户端，可用于配置代理、整体的请求超时（http.Client.Timeout）与重试等
+func WithHTTPClient(c *http.Client) OpenAIModelOption {
+	return func(m *OpenAIModel) {
+		m.requestOpts = append(m.requestOpts, option.WithHTTPClient(c))
+	}
+}
+
+// WithBaseURL 设置接口的 base URL，如 "https://api.deepseek.com"
+func WithBaseURL(url string) OpenAIModelOption {
+	return func(m *OpenAIModel) {
+		m.requestOpts = append(m.requestOpts, option.WithBaseURL(url))
+	}
+}
+
+// WithAPIKey 设置接口的 API key
+func WithAPIKey(key string) OpenAIModelOption {
+	return func(m *OpenAIModel) {
+		m.requestOpts = append(m.requestOpts, option.WithAPIKey(key))
+	}
+}
+
+// NewOpenAIModel 创建一个新的 OpenAIModel 实例，
+// 未通过 WithModelName 设置模型名称时，每次调用都需要通过 model.WithModel 指定，否则返回错误。
+// client 为 nil 时会创建默认的客户端，此时通过 WithBaseURL、WithAPIKey、WithHTTPClient 配置，见 NewOpenAIModelWithOptions
+func NewOpenAIModel(client *openai.Client, tools []*schema.ToolInfo, opts ...OpenAIModelOption) *OpenAIModel {
+	if client == nil {
+		c := openai.NewClient()
+		client = &c
+	}
+	m := &OpenAIModel{
+		client:            client,
+		tools:             tools,
+		schemaConstraints: &model.SchemaConstraints{RequireObjectRoot: true},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewOpenAIModelWithOptions 仅通过选项创建 OpenAIModel，不依赖外部构建的 openai.Client，工具可通过 WithTools 绑定
+// eg:
+//
+//	m := NewOpenAIModelWithOptions(
+//		WithBaseURL("https://api.deepseek.com"),
+//		WithAPIKey(apiKey),
+//		WithModelName("deepseek-chat"),
+//		WithHTTPClient(&http.Client{Timeout: 30 * time.Second}),
+//	)
+func NewOpenAIModelWithOptions(opts ...OpenAIModelOption) *OpenAIModel {
+	return NewOpenAIModel(nil, nil, opts...)
+}
+
+// getOptions 合并实例的默认配置与单次调用的选项
+func (m *OpenAIModel) getOptions(opts ...model.Option) *model.Options {
+	base := &model.Options{Tools: m.tools}
+	if m.modelName != "" {
+		base.Model = &m.modelName
+	}
+	return model.GetCommonOptions(base, append(slices.Clone(m.defaultOpts), opts...)...)
+}
+
+// Generate 实现 BaseChatModel 接口的 Generate 方法。
+// 指定了 JSON 格式的 ResponseFormat 时会校验返回内容，不符合时按 WithResponseFormatRetries 重试，仍不符合则返回错误
+func (m *OpenAIModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	options := m.getOptions(opts...)
+	params, err := m.buildParams(input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	reqOpts := m.requestOptions(options)
+	for attempt := 0; ; attempt++ {
+		result, err := m.generate(ctx, params, reqOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = checkToolChoice(options, result); err != nil {
+			return nil, err
+		}
+
+		err = checkResponseFormat(options.ResponseFormat, result)
+		if err == nil {
+			return result, nil
+		}
+		if attempt >= m.responseFormatRetries {
+			return nil, err
+		}
+	}
+}
+
+// requestOptions 在实例的请求选项之后追加 model.WithHeaders 指定的请求头，与 client 上配置的请求头合并，同名时覆盖
+func (m *OpenAIModel) requestOptions(options *model.Options) []option.RequestOption {
+	if len(options.Headers) == 0 {
+		return m.requestOpts
+	}
+	keys := make([]string, 0, len(options.Headers))
+	for k := range options.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	reqOpts := slices.Clone(m.requestOpts)
+	for _, k := range keys {
+		reqOpts = append(reqOpts, option.WithHeader(k, options.Headers[k]))
+	}
+	return reqOpts
+}
+
+// generate 调用一次 OpenAI API 并转换返回结果
+func (m *OpenAIModel) generate(ctx context.Context, params openai.ChatCompletionNewParams, reqOpts []option.RequestOption) (*schema.Message, error) {
+	// 调用 OpenAI API
+	resp, err := m.client.Chat.Completions.New(ctx, params, reqOpts...)
+	if err != nil {
+		return nil, classifyOpenAIError(err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices returned from OpenAI")
+	}
+
+	choice := resp.Choices[0]
+	result := &schema.Message{
+		Role:             schema.Assistant,
+		Content:          choice.Message.Content,
+		ReasoningContent: reasoningContent(choice.Message.JSON.ExtraFields),
+	}
+
+	// 处理工具调用
+	if len(choice.Message.ToolCalls) > 0 {
+		result.ToolCalls = make([]schema.ToolCall, 0, len(choice.Message.ToolCalls))
+		for _, toolCall := range choice.Message.ToolCalls {
+			if toolCall.Type == "function" {
+				result.ToolCalls = append(result.ToolCalls, schema.ToolCall{
+					ID:   toolCall.ID,
+					Type: "function",
+					Function: schema.FunctionCall{
+						Name:      toolCall.Function.Name,
+						Arguments: toolCall.Function.Arguments,
+					},
+				})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// buildParams 根据输入消息与调用选项构造请求参数
+func (m *OpenAIModel) buildParams(input []*schema.Message, options *model.Options) (openai.ChatCompletionNewParams, error) {
+	if options.Model == nil || *options.Model == "" {
+		return openai.ChatCompletionNewParams{}, fmt.Errorf("model name is not set, set it by WithModelName or model.WithModel")
+	}
+	if err := validateSamplingOptions(options); err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+
+	// 将 schema.Message 转换为 openai 的消息格式
+	messages, err := m.toOpenAIMessages(input)
+	if err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+
+	if err = validateTools(options.Tools); err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+
+	toolInfos, err := filterAllowedTools(options.Tools, options.AllowedToolNames)
+	if err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+
+	// 准备工具参数
+	var tools []openai.ChatCompletionToolParam
+	if len(toolInfos) > 0 {
+		tools = make([]openai.ChatCompletionToolParam, 0, len(toolInfos))
+		for _, toolInfo := range toolInfos {
+			// 将 schema.ToolInfo 转换为 openai 的工具格式，参数为完整的 JSON Schema；strict 模式下参数转换为 strict 兼容的 JSON Schema
+			strict := options.StrictTools != nil && *options.StrictTools
+			var def map[string]any
+			if strict {
+				def, err = schema.ToOpenAIStrictTool(toolInfo)
+			} else {
+				def, err = schema.ToOpenAITool(toolInfo)
+			}
+			if err != nil {
+				return openai.ChatCompletionNewParams{}, err
+			}
+			params := shared.FunctionParameters(def["function"].(map[string]any)["parameters"].(map[string]any))
+
+			// 创建 param.Opt 值
+			descOpt := openai.Opt(toolInfo.Desc)
+
+			function := shared.FunctionDefinitionParam{
+				Name:        toolInfo.Name,
+				Description: descOpt,
+				Parameters:  params,
+			}
+			if strict {
+				function.Strict = openai.Bool(true)
+			}
+			tools = append(tools, openai.ChatCompletionToolParam{
+				Type:     "function",
+				Function: function,
+			})
+		}
+	}
+
+	toolChoice, err := toOpenAIToolChoice(options)
+	if err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Model:      *options.Model,
+		Messages:   messages,
+		Tools:      tools,
+		ToolChoice: toolChoice,
+	}
+	if options.Temperature != nil {
+		params.Temperature = openai.Float(float32To64(*options.Temperature))
+	}
+	if options.TopP != nil {
+		params.TopP = openai.Float(float32To64(*options.TopP))
+	}
+	if options.MaxTokens != nil {
+		params.MaxTokens = openai.Int(int64(*options.MaxTokens))
+	}
+	if options.Seed != nil {
+		params.Seed = openai.Int(int64(*options.Seed))
+	}
+	if options.PresencePenalty != nil {
+		params.PresencePenalty = openai.Float(float32To64(*options.PresencePenalty))
+	}
+	if options.FrequencyPenalty != nil {
+		params.FrequencyPenalty = openai.Float(float32To64(*options.FrequencyPenalty))
+	}
+	if len(options.Stop) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: options.Stop}
+	}
+	// parallel_tool_calls 仅在设置了工具时有意义，未设置工具时发送该参数会被 OpenAI 拒绝
+	if options.ParallelToolCalls != nil && len(tools) > 0 {
+		params.ParallelToolCalls = openai.Bool(*options.ParallelToolCalls)
+	}
+	if params.ResponseFormat, err = toOpenAIResponseFormat(options.ResponseFormat); err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+
+	return params, nil
+}
+
+// toOpenAIResponseFormat 将 schema.ResponseFormat 映射为 openai 的 response_format 参数。
+// 不支持 json_schema 的模型（如 deepseek-chat 仅支持 json_object）会由接口返回错误
+func toOpenAIResponseFormat(format *schema.ResponseFormat) (openai.ChatCompletionNewParamsResponseFormatUnion, error) {
+	if format == nil {
+		return openai.ChatCompletionNewParamsResponseFormatUnion{}, nil
+	}
+	switch format.Type {
+	case schema.ResponseFormatTypeText:
+		return openai.ChatCompletionNewParamsResponseFormatUnion{OfText: &shared.ResponseFormatTextParam{}}, nil
+	case schema.ResponseFormatTypeJSONObject:
+		return openai.ChatCompletionNewParamsResponseFormatUnion{OfJSONObject: &shared.ResponseFormatJSONObjectParam{}}, nil
+	case schema.ResponseFormatTypeJSONSchema:
+		js := format.JSONSchema
+		if js == nil || js.Name == "" || js.Schema == nil {
+			return openai.ChatCompletionNewParamsResponseFormatUnion{}, fmt.Errorf("name and schema are required for json_schema response format")
+		}
+		param := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   js.Name,
+			Schema: js.Schema,
+			Strict: openai.Bool(js.Strict),
+		}
+		if js.Desc != "" {
+			param.Description = openai.String(js.Desc)
+		}
+		return openai.ChatCompletionNewParamsResponseFormatUnion{OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{JSONSchema: param}}, nil
+	default:
+		return openai.ChatCompletionNewParamsResponseFormatUnion{}, fmt.Errorf("unknown response format type: %s", format.Type)
+	}
+}
+
+// checkResponseFormat 校验返回内容是否为合法 JSON，指定了 schema 时同时校验是否符合 schema
+func checkResponseFormat(format *schema.ResponseFormat, result *schema.Message) error {
+	if format == nil || format.Type == schema.ResponseFormatTypeText || len(result.ToolCalls) > 0 {
+		return nil
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(result.Content), &v); err != nil {
+		return fmt.Errorf("model returned malformed JSON: %w", err)
+	}
+
+	if format.Type == schema.ResponseFormatTypeJSONObject {
+		if _, ok := v.(map[string]any); !ok {
+			return fmt.Errorf("model returned JSON which is not an object")
+		}
+		return nil
+	}
+
+	root := format.JSONSchema.Schema
+	if err := validateJSONValue(v, root, root.Definitions, "$"); err != nil {
+		return fmt.Errorf("model returned JSON not conforming to schema %s: %w", format.JSONSchema.Name, err)
+	}
+	return nil
+}
+
+// validateJSONValue 按 schema 的 $ref（仅 #/$defs/ 下的定义）、type、enum、required、properties、items、anyOf、oneOf 做基本校验，
+// 不覆盖 JSON Schema 的全部关键字
+func validateJSONValue(v any, s *jsonschema.Schema, defs jsonschema.Definitions, path string) error {
+	if s == nil {
+		return nil
+	}
+
+	if s.Ref != "" {
+		def, ok := defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			return fmt.Errorf("unresolvable $ref %s at %s", s.Ref, path)
+		}
+		return validateJSONValue(v, def, defs, path)
+	}
+
+	if len(s.AnyOf) > 0 || len(s.OneOf) > 0 {
+		for _, sub := range append(slices.Clone(s.AnyOf), s.OneOf...) {
+			if validateJSONValue(v, sub, defs, path) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s matches none of the candidate schemas", path)
+	}
+
+	types := s.TypeEnhanced
+	if s.Type != "" {
+		types = []string{s.Type}
+	}
+	if len(types) > 0 && !slices.ContainsFunc(types, func(typ string) bool { return isJSONType(v, typ) }) {
+		return fmt.Errorf("%s should be %s, got %T", path, strings.Join(types, " or "), v)
+	}
+
+	if len(s.Enum) > 0 {
+		got, _ := json.Marshal(v)
+		if !slices.ContainsFunc(s.Enum, func(e any) bool {
+			expected, _ := json.Marshal(e)
+			return string(expected) == string(got)
+		}) {
+			return fmt.Errorf("%s should be one of %v, got %s", path, s.Enum, got)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		for _, key := range s.Required {
+			if _, ok := val[key]; !ok {
+				return fmt.Errorf("%s.%s is required", path, key)
+			}
+		}
+		if s.Properties != nil {
+			for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+				if fv, ok := val[pair.Key]; ok {
+					if err := validateJSONValue(fv, pair.Value, defs, path+"."+pair.Key); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	case []any:
+		for i, item := range val {
+			if err := validateJSONValue(item, s.Items, defs, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func isJSONType(v any, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	default:
+		return true
+	}
+}
+
+// validateSamplingOptions 校验采样参数取值范围，与 OpenAI 接口保持一致
+func validateSamplingOptions(options *model.Options) error {
+	if t := options.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return fmt.Errorf("temperature must be in [0, 2], got %v", *t)
+	}
+	if p := options.TopP; p != nil && (*p < 0 || *p > 1) {
+		return fmt.Errorf("top_p must be in [0, 1], got %v", *p)
+	}
+	if n := options.MaxTokens; n != nil && *n <= 0 {
+		return fmt.Errorf("max_tokens must be positive, got %d", *n)
+	}
+	if p := options.PresencePenalty; p != nil && (*p < -2 || *p > 2) {
+		return fmt.Errorf("presence_penalty must be in [-2, 2], got %v", *p)
+	}
+	if p := options.FrequencyPenalty; p != nil && (*p < -2 || *p > 2) {
+		return fmt.Errorf("frequency_penalty must be in [-2, 2], got %v", *p)
+	}
+	if len(options.Stop) > 4 {
+		return fmt.Errorf("at most 4 stop sequences are supported, got %d", len(options.Stop))
+	}
+	return nil
+}
+
+// float32To64 按 float32 的最短十进制表示转换，避免 0.7 变为 0.699999988079071
+func float32To64(f float32) float64 {
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'f', -1, 32), 64)
+	return v
+}
+
+// filterAllowedTools 按 AllowedToolNames 过滤工具，未指定时返回全部工具
+func filterAllowedTools(tools []*schema.ToolInfo, allowed []string) ([]*schema.ToolInfo, error) {
+	if len(allowed) == 0 {
+		return tools, nil
+	}
+	byName := make(map[string]*schema.ToolInfo, len(tools))
+	for _, t := range tools {
+		byName[t.Name] = t
+	}
+	ret := make([]*schema.ToolInfo, 0, len(allowed))
+	for _, name := range allowed {
+		t, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("allowed tool %q is not bound to the model", name)
+		}
+		ret = append(ret, t)
+	}
+	return ret, nil
+}
+
+// toOpenAIToolChoice 将 schema.ToolChoice 映射为 openai 的 tool_choice 参数
+func toOpenAIToolChoice(options *model.Options) (openai.ChatCompletionToolChoiceOptionUnionParam, error) {
+	if options.ToolChoice == nil {
+		return openai.ChatCompletionToolChoiceOptionUnionParam{}, nil
+	}
+	switch *options.ToolChoice {
+	case schema.ToolChoiceForbidden:
+		return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("none")}, nil
+	case schema.ToolChoiceAllowed:
+		return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("auto")}, nil
+	case schema.ToolChoiceForced:
+		if len(options.AllowedToolNames) == 1 {
+			return openai.ChatCompletionToolChoiceOptionParamOfChatCompletionNamedToolChoice(
+				openai.ChatCompletionNamedToolChoiceFunctionParam{Name: options.AllowedToolNames[0]}), nil
+		}
+		return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("required")}, nil
+	default:
+		return openai.ChatCompletionToolChoiceOptionUnionParam{}, fmt.Errorf("unknown tool choice: %s", *options.ToolChoice)
+	}
+}
+
+// checkToolChoice 在强制调用工具时校验模型确实返回了符合要求的工具调用
+func checkToolChoice(options *model.Options, result *schema.Message) error {
+	if options.ToolChoice == nil || *options.ToolChoice != schema.ToolChoiceForced {
+		return nil
+	}
+	if len(result.ToolCalls) == 0 {
+		return fmt.Errorf("tool call is forced but model returned no tool call")
+	}
+	if len(options.AllowedToolNames) == 0 {
+		return nil
+	}
+	for _, tc := range result.ToolCalls {
+		if !slices.Contains(options.AllowedToolNames, tc.Function.Name) {
+			return fmt.Errorf("tool call is forced to %v but model called %s", options.AllowedToolNames, tc.Function.Name)
+		}
+	}
+	return nil
+}
+
+func (m *OpenAIModel) toOpenAIMessages(input []*schema.Message) ([]openai.ChatCompletionMessageParamUnion, error) {
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(input))
+	for _, msg := range input {
+		switch msg.Role {
+		case schema.User:
+			if len(msg.UserInputMultiContent) == 0 || m.textOnly {
+				messages = append(messages, withName(openai.UserMessage(textContent(msg)), msg.Name))
+				continue
+			}
+			parts, err := toOpenAIContentParts(msg.UserInputMultiContent)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, withName(openai.UserMessage(parts), msg.Name))
+		case schema.Assistant:
+			assistant := openai.AssistantMessage(textContent(msg))
+			assistant.OfAssistant.ToolCalls = toOpenAIToolCalls(msg.ToolCalls)
+			messages = append(messages, withName(assistant, msg.Name))
+		case schema.System:
+			messages = append(messages, withName(openai.SystemMessage(textContent(msg)), msg.Name))
+		case schema.Tool:
+			// 工具消息必须带有所回复的工具调用 ID，否则接口会返回 400
+			if msg.ToolCallID == "" {
+				return nil, fmt.Errorf("tool message of tool[%s] has no tool call id", msg.ToolName)
+			}
+			messages = append(messages, openai.ToolMessage(textContent(msg), msg.ToolCallID))
+		}
+	}
+	return messages, nil
+}
+
+// toOpenAIToolCalls 将助手消息中的工具调用转换为 openai 的参数，后续的工具消息通过 ID 与之对应
+func toOpenAIToolCalls(toolCalls []schema.ToolCall) []openai.ChatCompletionMessageToolCallParam {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+	ret := make([]openai.ChatCompletionMessageToolCallParam, 0, len(toolCalls))
+	for _, tc := range toolCalls {
+		ret = append(ret, openai.ChatCompletionMessageToolCallParam{
+			ID: tc.ID,
+			Function: openai.ChatCompletionMessageToolCallFunctionParam{
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			},
+		})
+	}
+	return ret
+}
+
+// withName 设置消息的参与者名称，用于在多用户/多 agent 对话中区分同一角色的不同参与者，名称为空时省略
+func withName(msg openai.ChatCompletionMessageParamUnion, name string) openai.ChatCompletionMessageParamUnion {
+	if name == "" {
+		return msg
+	}
+	switch {
+	case msg.OfUser != nil:
+		msg.OfUser.Name = openai.String(name)
+	case msg.OfAssistant != nil:
+		msg.OfAssistant.Name = openai.String(name)
+	case msg.OfSystem != nil:
+		msg.OfSystem.Name = openai.String(name)
+	}
+	return msg
+}
+
+// toOpenAIContentParts 将 UserInputMultiContent 转换为 openai 的 content parts
+func toOpenAIContentParts(parts []schema.MessageInputPart) ([]openai.ChatCompletionContentPartUnionParam, error) {
+	ret := make([]openai.ChatCompletionContentPartUnionParam, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case schema.ChatMessagePartTypeText:
+			ret = append(ret, openai.TextContentPart(part.Text))
+		case schema.ChatMessagePartTypeImageURL:
+			if part.Image == nil {
+				return nil, fmt.Errorf("image part without image")
+			}
+			url, err := partURL(part.Image.MessagePartCommon)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+				URL:    url,
+				Detail: string(part.Image.Detail),
+			}))
+		default:
+			return nil, fmt.Errorf("unsupported message part type: %s", part.Type)
+		}
+	}
+	return ret, nil
+}
+
+// partURL 返回 part 的 URL，Base64Data 会被转换为 data URL
+func partURL(common schema.MessagePartCommon) (string, error) {
+	if common.URL != nil && *common.URL != "" {
+		return *common.URL, nil
+	}
+	if common.Base64Data != nil && *common.Base64Data != "" {
+		if common.MIMEType == "" {
+			return "", fmt.Errorf("mime type is required for base64 data")
+		}
+		return fmt.Sprintf("data:%s;base64,%s", common.MIMEType, *common.Base64Data), nil
+	}
+	return "", fmt.Errorf("neither url nor base64 data is provided")
+}
+
+// textContent 返回消息的文本内容，存在多模态内容时拼接其中的文本部分作为降级
+func textContent(msg *schema.Message) string {
+	if len(msg.UserInputMultiContent) == 0 {
+		return msg.Content
+	}
+	texts := make([]string, 0, len(msg.UserInputMultiContent)+1)
+	if msg.Content != "" {
+		texts = append(texts, msg.Content)
+	}
+	for _, part := range msg.UserInputMultiContent {
+		if part.Type == schema.ChatMessagePartTypeText && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// ValidateToolSchemas 实现 model.ProviderSchemaValidator 接口，按 WithSchemaConstraints 设置的约束校验工具的参数 schema
+func (m *OpenAIModel) ValidateToolSchemas(tools []*schema.ToolInfo) error {
+	if m.schemaConstraints == nil {
+		return nil
+	}
+	return m.schemaConstraints.Validate(tools)
+}
+
+// BoundTools 实现 model.ToolsAdvertiser 接口，返回绑定到模型的工具，供 compose.WithToolDecider 在编译时检查
+func (m *OpenAIModel) BoundTools() []*schema.ToolInfo {
+	return m.tools
+}
+
+// BatchGenerate 实现 model.BatchChatModel 接口，并发调用 Generate，并发数由 WithBatchConcurrency 限制，输出顺序与输入一致。
+// 部分输入失败时返回 *model.BatchError，成功的输出仍会返回
+func (m *OpenAIModel) BatchGenerate(ctx context.Context, inputs [][]*schema.Message, opts ...model.Option) ([]*schema.Message, error) {
+	return model.ConcurrentGenerate(ctx, m, inputs, m.batchConcurrency, opts...)
+}
+
+// Stream 实现 BaseChatModel 接口的 Stream 方法，ResponseFormat 会传给接口，但不校验流式返回的内容
+func (m *OpenAIModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	options := m.getOptions(opts...)
+	params, err := m.buildParams(input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := m.client.Chat.Completions.NewStreaming(ctx, params, m.requestOptions(options)...)
+	if err = stream.Err(); err != nil {
+		return nil, classifyOpenAIError(err)
+	}
+
+	sr, sw := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer func() {
+			_ = stream.Close()
+		}()
+
+		for stream.Next() {
+			chunk := stream.Current()
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+			if closed := sw.Send(chunkToMessage(chunk.Choices[0]), nil); closed {
+				return
+			}
+		}
+		// 流中途出错时，下一次 Recv 会收到该错误
+		sw.CloseWithError(classifyOpenAIError(stream.Err()))
+	}()
+
+	return sr, nil
+}
+
+// classifyOpenAIError 按 HTTP 状态码将接口错误包装为 model 包中的错误类型，便于调用方通过 errors.Is 区分并决定是否重试
+func classifyOpenAIError(err error) error {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch {
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", model.ErrRateLimited, err)
+	case apiErr.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: %w", model.ErrModelUnavailable, err)
+	case apiErr.StatusCode >= http.StatusBadRequest:
+		return fmt.Errorf("%w: %w", model.ErrBadRequest, err)
+	default:
+		return err
+	}
+}
+
+// reasoningContent 读取 DeepSeek-R1 等推理模型在 content 之外返回的 reasoning_content 字段，openai-go 将其放在 ExtraFields 中
+func reasoningContent(extraFields map[string]respjson.Field) string {
+	field, ok := extraFields["reasoning_content"]
+	if !ok {
+		return ""
+	}
+	var reasoning string
+	if err := json.Unmarshal([]byte(field.Raw()), &reasoning); err != nil {
+		return ""
+	}
+	return reasoning
+}
+
+// chunkToMessage 将流式响应的一个 chunk 转换为 schema.Message，工具调用通过 Index 在拼接时合并
+func chunkToMessage(choice openai.ChatCompletionChunkChoice) *schema.Message {
+	msg := &schema.Message{
+		Role:             schema.Assistant,
+		Content:          choice.Delta.Content,
+		ReasoningContent: reasoningContent(choice.Delta.JSON.ExtraFields),
+	}
+	for _, toolCall := range choice.Delta.ToolCalls {
+		index := int(toolCall.Index)
+		msg.ToolCalls = append(msg.ToolCalls, schema.ToolCall{
+			Index: &index,
+			ID:    toolCall.ID,
+			Type:  toolCall.Type,
+			Function: schema.FunctionCall{
+				Name:      toolCall.Function.Name,
+				Arguments: toolCall.Function.Arguments,
+			},
+		})
+	}
+	if choice.FinishReason != "" {
+		msg.ResponseMeta = &schema.ResponseMeta{FinishReason: choice.FinishReason}
+	}
+	return msg
+}
+
+// WithTools 实现 ToolCallingChatModel 接口的 WithTools 方法，以 tools 替换已绑定的工具
+func (m *OpenAIModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	if err := validateTools(tools); err != nil {
+		return nil, err
+	}
+	if err := m.ValidateToolSchemas(tools); err != nil {
+		return nil, err
+	}
+	// 创建新的实例，避免修改原实例
+	newModel := &OpenAIModel{
+		client:                m.client,
+		tools:                 make([]*schema.ToolInfo, len(tools)),
+		modelName:             m.modelName,
+		responseFormatRetries: m.responseFormatRetries,
+		defaultOpts:           m.defaultOpts,
+		textOnly:              m.textOnly,
+		requestOpts:           m.requestOpts,
+		batchConcurrency:      m.batchConcurrency,
+		schemaConstraints:     m.schemaConstraints,
+	}
+	copy(newModel.tools, tools)
+	return newModel, nil
+}
+
+// WithExtraTools 在已绑定的工具之上追加 tools 并返回新实例，与已有工具重名时返回错误，避免工具被静默覆盖
+func (m *OpenAIModel) WithExtraTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	merged := make([]*schema.ToolInfo, 0, len(m.tools)+len(tools))
+	merged = append(merged, m.tools...)
+	merged = append(merged, tools...)
+	return m.WithTools(merged)
+}
+
+// validateTools 校验工具非空、名称非空、符合 OpenAI 的名称与描述约束且不重复
+func validateTools(tools []*schema.ToolInfo) error {
+	names := make(map[string]bool, len(tools))
+	for i, t := range tools {
+		if t == nil {
+			return fmt.Errorf("tool[%d] is nil", i)
+		}
+		if t.Name == "" {
+			return fmt.Errorf("name of tool[%d] is empty", i)
+		}
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("tool[%d] is invalid: %w", i, err)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("duplicate tool name: %s", t.Name)
+		}
+		names[t.Name] = true
+	}
+	return nil
+}
+
+// stubOpenAIServer 模拟 OpenAI chat completions 接口，记录收到的请求，便于在没有 API key 时测试 OpenAIModel
+type stubOpenAIServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []map[string]any
+	headers  []http.Header
+	// response 为返回的 completion JSON
+	response string
+	// queue 中的 completion JSON 按顺序优先返回，用完后返回 response
+	queue []string
+	// chunks 为流式请求返回的 chunk JSON 列表
+	chunks []string
+}
+
+const stubCompletion = `{"id":"stub","object":"chat.completion","created":0,"model":"stub","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"ok"}}]}`
+
+func newStubOpenAIServer(t *testing.T) *stubOpenAIServer {
+	s := &stubOpenAIServer{response: stubCompletion}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, body)
+		s.headers = append(s.headers, r.Header.Clone())
+		resp, chunks := s.response, s.chunks
+		if stream, _ := body["stream"].(bool); !stream && len(s.queue) > 0 {
+			resp, s.queue = s.queue[0], s.queue[1:]
+		}
+		s.mu.Unlock()
+		if stream, _ := body["stream"].(bool); stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, chunk := range chunks {
+				_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+			}
+			_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubOpenAIServer) client() *openai.Client {
+	client := openai.NewClient(
+		option.WithAPIKey("stub"),
+		option.WithBaseURL(s.URL),
+		option.WithMaxRetries(0),
+	)
+	return &client
+}
+
+func (s *stubOpenAIServer) setResponse(resp string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.response = resp
+}
+
+func (s *stubOpenAIServer) queueResponses(resps ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, resps...)
+}
+
+func (s *stubOpenAIServer) setChunks(chunks ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chunks = chunks
+}
+
+// stubChunk 构造一个流式 chunk JSON
+func stubChunk(t *testing.T, delta map[string]any, finishReason string) string {
+	b, err := json.Marshal(map[string]any{
+		"id": "stub", "object": "chat.completion.chunk", "created": 0, "model": "stub",
+		"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finishReason}},
+	})
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func (s *stubOpenAIServer) lastHeader() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.headers) == 0 {
+		return nil
+	}
+	return s.headers[len(s.headers)-1]
+}
+
+func (s *stubOpenAIServer) lastRequest() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+func TestOpenAIModelMultiContent(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+
+	input := []*schema.Message{
+		schema.SystemMessage("you are a helpful assistant."),
+		schema.UserMessageParts(
+			schema.TextInputPart("what is in this image?"),
+			schema.ImageURLInputPart("https://example.com/cat.jpg", schema.ImageURLDetailLow),
+		),
+	}
+
+	t.Run("multimodal", func(t *testing.T) {
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"))
+		out, err := m.Generate(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", out.Content)
+
+		messages := srv.lastRequest()["messages"].([]any)
+		assert.Len(t, messages, 2)
+		content := messages[1].(map[string]any)["content"].([]any)
+		assert.Equal(t, map[string]any{"type": "text", "text": "what is in this image?"}, content[0])
+		assert.Equal(t, map[string]any{
+			"type":      "image_url",
+			"image_url": map[string]any{"url": "https://example.com/cat.jpg", "detail": "low"},
+		}, content[1])
+	})
+
+	t.Run("text only fallback", func(t *testing.T) {
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"), WithTextOnly())
+		_, err := m.Generate(ctx, input)
+		assert.NoError(t, err)
+
+		messages := srv.lastRequest()["messages"].([]any)
+		assert.Equal(t, "what is in this image?", messages[1].(map[string]any)["content"])
+	})
+
+	t.Run("base64 image", func(t *testing.T) {
+		data := "aGVsbG8="
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"))
+		_, err := m.Generate(ctx, []*schema.Message{schema.UserMessageParts(schema.MessageInputPart{
+			Type: schema.ChatMessagePartTypeImageURL,
+			Image: &schema.MessageInputImage{MessagePartCommon: schema.MessagePartCommon{
+				Base64Data: &data,
+				MIMEType:   "image/png",
+			}},
+		})})
+		assert.NoError(t, err)
+		messages := srv.lastRequest()["messages"].([]any)
+		content := messages[0].(map[string]any)["content"].([]any)
+		assert.Equal(t, "data:image/png;base64,aGVsbG8=", content[0].(map[string]any)["image_url"].(map[string]any)["url"])
+	})
+
+	t.Run("unsupported part", func(t *testing.T) {
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"))
+		_, err := m.Generate(ctx, []*schema.Message{schema.UserMessageParts(schema.MessageInputPart{
+			Type: schema.ChatMessagePartTypeVideoURL,
+		})})
+		assert.ErrorContains(t, err, "unsupported message part type")
+	})
+}
+
+// stubToolCallCompletion 构造一个包含工具调用的 completion JSON
+func stubToolCallCompletion(t *testing.T, name, arguments string) string {
+	resp := map[string]any{
+		"id": "stub", "object": "chat.completion", "created": 0, "model": "stub",
+		"choices": []any{map[string]any{
+			"index": 0, "finish_reason": "tool_calls",
+			"message": map[string]any{
+				"role":    "assistant",
+				"content": "",
+				"tool_calls": []any{map[string]any{
+					"id": "call_1", "type": "function",
+					"function": map[string]any{"name": name, "arguments": arguments},
+				}},
+			},
+		}},
+	}
+	b, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestOpenAIModelToolChoice(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	tools := []*schema.ToolInfo{
+		{Name: "get_weather", Desc: "查询天气"},
+		{Name: "find_file", Desc: "搜索文件"},
+	}
+	m := NewOpenAIModel(srv.client(), tools, WithModelName("stub-model"))
+	input := []*schema.Message{schema.UserMessage("how's weather of beijing")}
+
+	t.Run("forced to a specific tool", func(t *testing.T) {
+		srv.setResponse(stubToolCallCompletion(t, "get_weather", `{"city":"北京"}`))
+		out, err := m.Generate(ctx, input, model.WithToolChoice(schema.ToolChoiceForced, "get_weather"))
+		assert.NoError(t, err)
+		assert.Equal(t, "get_weather", out.ToolCalls[0].Function.Name)
+
+		req := srv.lastRequest()
+		assert.Equal(t, map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}, req["tool_choice"])
+		assert.Len(t, req["tools"], 1)
+	})
+
+	t.Run("auto none required", func(t *testing.T) {
+		srv.setResponse(stubToolCallCompletion(t, "find_file", `{}`))
+		for choice, expected := range map[schema.ToolChoice]string{
+			schema.ToolChoiceAllowed:   "auto",
+			schema.ToolChoiceForbidden: "none",
+			schema.ToolChoiceForced:    "required",
+		} {
+			_, err := m.Generate(ctx, input, model.WithToolChoice(choice))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, srv.lastRequest()["tool_choice"])
+			assert.Len(t, srv.lastRequest()["tools"], 2)
+		}
+	})
+
+	t.Run("forced tool not called", func(t *testing.T) {
+		srv.setResponse(stubCompletion)
+		_, err := m.Generate(ctx, input, model.WithToolChoice(schema.ToolChoiceForced))
+		assert.ErrorContains(t, err, "returned no tool call")
+
+		srv.setResponse(stubToolCallCompletion(t, "find_file", `{}`))
+		_, err = m.Generate(ctx, input, model.WithToolChoice(schema.ToolChoiceForced, "get_weather"))
+		assert.ErrorContains(t, err, "model called find_file")
+	})
+
+	t.Run("unbound allowed tool", func(t *testing.T) {
+		_, err := m.Generate(ctx, input, model.WithToolChoice(schema.ToolChoiceForced, "cat_file"))
+		assert.ErrorContains(t, err, "not bound to the model")
+	})
+
+	t.Run("through graph call option", func(t *testing.T) {
+		srv.setResponse(stubToolCallCompletion(t, "get_weather", `{"city":"北京"}`))
+		graph := compose.NewGraph[[]*schema.Message, *schema.Message]()
+		assert.NoError(t, graph.AddChatModelNode("node_model", m))
+		assert.NoError(t, graph.AddEdge(compose.START, "node_model"))
+		assert.NoError(t, graph.AddEdge("node_model", compose.END))
+		r, err := graph.Compile(ctx)
+		assert.NoError(t, err)
+
+		out, err := r.Invoke(ctx, input, compose.WithChatModelOption(
+			model.WithToolChoice(schema.ToolChoiceForced, "get_weather")).DesignateNode("node_model"))
+		assert.NoError(t, err)
+		assert.Equal(t, "get_weather", out.ToolCalls[0].Function.Name)
+		assert.Equal(t, "function", srv.lastRequest()["tool_choice"].(map[string]any)["type"])
+	})
+}
+
+// TestOpenAIModelToolParameters 验证请求中的工具参数为完整的 JSON Schema，而非简化的空对象
+func TestOpenAIModelToolParameters(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	srv.setResponse(stubCompletion)
+	tools := []*schema.ToolInfo{
+		{Name: "get_weather", Desc: "查询天气", ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"city": {Type: schema.String, Desc: "城市名", Required: true},
+		})},
+		{Name: "now", Desc: "当前时间"},
+	}
+	m := NewOpenAIModel(srv.client(), tools, WithModelName("stub-model"))
+
+	_, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("how's weather of beijing")})
+	assert.NoError(t, err)
+
+	reqTools := srv.lastRequest()["tools"].([]any)
+	assert.Len(t, reqTools, 2)
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"city": map[string]any{"type": "string", "description": "城市名"},
+		},
+		"required": []any{"city"},
+	}, reqTools[0].(map[string]any)["function"].(map[string]any)["parameters"])
+	assert.Equal(t, map[string]any{"type": "object", "properties": map[string]any{}},
+		reqTools[1].(map[string]any)["function"].(map[string]any)["parameters"])
+
+	t.Run("strict", func(t *testing.T) {
+		_, err := m.Generate(ctx, []*schema.Message{schema.UserMessage("how's weather of beijing")},
+			model.WithTools([]*schema.ToolInfo{{Name: "get_weather", Desc: "查询天气", ParamsOneOf: schema.NewParamsOneOfByParams(
+				map[string]*schema.ParameterInfo{
+					"city": {Type: schema.String, Desc: "城市名", Required: true},
+					"days": {Type: schema.Integer, Desc: "预报天数"},
+				})}}),
+			model.WithStrictTools(true))
+		assert.NoError(t, err)
+
+		function := srv.lastRequest()["tools"].([]any)[0].(map[string]any)["function"].(map[string]any)
+		assert.Equal(t, true, function["strict"])
+		assert.Equal(t, map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city": map[string]any{"type": "string", "description": "城市名"},
+				"days": map[string]any{"type": []any{"integer", "null"}, "description": "预报天数"},
+			},
+			"required":             []any{"city", "days"},
+			"additionalProperties": false,
+		}, function["parameters"])
+	})
+}
+
+func TestOpenAIModelStream(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	m := NewOpenAIModel(srv.client(), []*schema.ToolInfo{{Name: "get_weather", Desc: "查询天气"}}, WithModelName("stub-model"))
+	input := []*schema.Message{schema.UserMessage("how's weather of beijing")}
+
+	t.Run("content", func(t *testing.T) {
+		srv.setChunks(
+			stubChunk(t, map[string]any{"role": "assistant", "content": "北京"}, ""),
+			stubChunk(t, map[string]any{"content": "晴"}, ""),
+			stubChunk(t, map[string]any{}, "stop"),
+		)
+		sr, err := m.Stream(ctx, input)
+		assert.NoError(t, err)
+		msg, err := schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "北京晴", msg.Content)
+		assert.Equal(t, "stop", msg.ResponseMeta.FinishReason)
+		assert.Equal(t, true, srv.lastRequest()["stream"])
+	})
+
+	t.Run("fragmented tool call", func(t *testing.T) {
+		srv.setChunks(
+			stubChunk(t, map[string]any{"role": "assistant", "tool_calls": []any{map[string]any{
+				"index": 0, "id": "call_1", "type": "function",
+				"function": map[string]any{"name": "get_weather", "arguments": `{"ci`},
+			}}}, ""),
+			stubChunk(t, map[string]any{"tool_calls": []any{map[string]any{
+				"index": 0, "function": map[string]any{"arguments": `ty":"北京"}`},
+			}}}, "tool_calls"),
+		)
+		sr, err := m.Stream(ctx, input)
+		assert.NoError(t, err)
+		msg, err := schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Len(t, msg.ToolCalls, 1)
+		assert.Equal(t, "call_1", msg.ToolCalls[0].ID)
+		assert.Equal(t, "get_weather", msg.ToolCalls[0].Function.Name)
+		assert.Equal(t, `{"city":"北京"}`, msg.ToolCalls[0].Function.Arguments)
+	})
+}
+
+func TestOpenAIModelWithTools(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"))
+	weather := &schema.ToolInfo{Name: "get_weather", Desc: "查询天气"}
+	catFile := &schema.ToolInfo{Name: "cat_file", Desc: "读取文件"}
+
+	t.Run("reject invalid tools", func(t *testing.T) {
+		_, err := m.WithTools([]*schema.ToolInfo{weather, {Name: "get_weather", Desc: "另一个天气工具"}})
+		assert.ErrorContains(t, err, "duplicate tool name: get_weather")
+
+		_, err = m.WithTools([]*schema.ToolInfo{weather, {Desc: "没有名字"}})
+		assert.ErrorContains(t, err, "name of tool[1] is empty")
+
+		_, err = m.WithTools([]*schema.ToolInfo{nil})
+		assert.ErrorContains(t, err, "tool[0] is nil")
+
+		_, err = m.WithTools([]*schema.ToolInfo{{Name: "get weather", Desc: "名称包含空格"}})
+		assert.ErrorContains(t, err, `tool[0] is invalid: invalid tool name "get weather"`)
+
+		_, err = m.Generate(ctx, []*schema.Message{schema.UserMessage("hi")}, model.WithTools([]*schema.ToolInfo{weather, weather}))
+		assert.ErrorContains(t, err, "duplicate tool name: get_weather")
+	})
+
+	t.Run("extra tools", func(t *testing.T) {
+		withWeather, err := m.WithTools([]*schema.ToolInfo{weather})
+		assert.NoError(t, err)
+
+		merged, err := withWeather.(*OpenAIModel).WithExtraTools([]*schema.ToolInfo{catFile})
+		assert.NoError(t, err)
+		_, err = merged.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+
+		tools := srv.lastRequest()["tools"].([]any)
+		assert.Len(t, tools, 2)
+		assert.Equal(t, "get_weather", tools[0].(map[string]any)["function"].(map[string]any)["name"])
+		assert.Equal(t, "cat_file", tools[1].(map[string]any)["function"].(map[string]any)["name"])
+
+		_, err = merged.(*OpenAIModel).WithExtraTools([]*schema.ToolInfo{{Name: "cat_file", Desc: "覆盖读取文件"}})
+		assert.ErrorContains(t, err, "duplicate tool name: cat_file")
+		// 原实例不受影响
+		assert.Len(t, withWeather.(*OpenAIModel).tools, 1)
+	})
+}
+
+func TestOpenAIModelName(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	srv.setChunks(stubChunk(t, map[string]any{"role": "assistant", "content": "ok"}, "stop"))
+	input := []*schema.Message{schema.UserMessage("hi")}
+
+	t.Run("default model name", func(t *testing.T) {
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("gpt-4o"))
+		_, err := m.Generate(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, "gpt-4o", srv.lastRequest()["model"])
+
+		sr, err := m.Stream(ctx, input)
+		assert.NoError(t, err)
+		_, err = schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "gpt-4o", srv.lastRequest()["model"])
+	})
+
+	t.Run("per call override", func(t *testing.T) {
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("gpt-4o"))
+		_, err := m.Generate(ctx, input, model.WithModel("gpt-4o-mini"))
+		assert.NoError(t, err)
+		assert.Equal(t, "gpt-4o-mini", srv.lastRequest()["model"])
+
+		withTools, err := m.WithTools([]*schema.ToolInfo{{Name: "get_weather", Desc: "查询天气"}})
+		assert.NoError(t, err)
+		sr, err := withTools.Stream(ctx, input, model.WithModel("deepseek-chat"))
+		assert.NoError(t, err)
+		_, err = schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "deepseek-chat", srv.lastRequest()["model"])
+	})
+
+	t.Run("model name not set", func(t *testing.T) {
+		m := NewOpenAIModel(srv.client(), nil)
+		_, err := m.Generate(ctx, input)
+		assert.ErrorContains(t, err, "model name is not set")
+		_, err = m.Stream(ctx, input)
+		assert.ErrorContains(t, err, "model name is not set")
+
+		_, err = m.Generate(ctx, input, model.WithModel("gpt-4o"))
+		assert.NoError(t, err)
+	})
+}
+
+// recordingTransport 记录经过的请求，用于确认使用了自定义的 HTTP 客户端
+type recordingTransport struct {
+	mu    sync.Mutex
+	auths []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.auths = append(rt.auths, req.Header.Get("Authorization"))
+	rt.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestOpenAIModelHTTPClient(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	srv.setChunks(stubChunk(t, map[string]any{"role": "assistant", "content": "ok"}, "stop"))
+	input := []*schema.Message{schema.UserMessage("hi")}
+
+	t.Run("self-contained", func(t *testing.T) {
+		rt := &recordingTransport{}
+		m := NewOpenAIModelWithOptions(
+			WithBaseURL(srv.URL),
+			WithAPIKey("key-from-option"),
+			WithModelName("stub-model"),
+			WithHTTPClient(&http.Client{Transport: rt}),
+		)
+
+		out, err := m.Generate(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", out.Content)
+
+		withTools, err := m.WithTools([]*schema.ToolInfo{{Name: "get_weather", Desc: "查询天气"}})
+		assert.NoError(t, err)
+		sr, err := withTools.Stream(ctx, input)
+		assert.NoError(t, err)
+		out, err = schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", out.Content)
+
+		assert.Equal(t, []string{"Bearer key-from-option", "Bearer key-from-option"}, rt.auths)
+		assert.Equal(t, "stub-model", srv.lastRequest()["model"])
+	})
+
+	t.Run("client-passing form", func(t *testing.T) {
+		rt := &recordingTransport{}
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"), WithHTTPClient(&http.Client{Transport: rt}))
+
+		_, err := m.Generate(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Bearer stub"}, rt.auths)
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer slow.Close()
+		defer close(release)
+
+		m := NewOpenAIModelWithOptions(
+			WithBaseURL(slow.URL),
+			WithAPIKey("stub"),
+			WithModelName("stub-model"),
+			WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}),
+		)
+
+		start := time.Now()
+		_, err := m.Generate(ctx, input)
+		assert.Error(t, err)
+		// 默认会重试 2 次，每次都在超时后返回
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestOpenAIModelHeaders(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	srv.setChunks(stubChunk(t, map[string]any{"role": "assistant", "content": "ok"}, "stop"))
+	input := []*schema.Message{schema.UserMessage("hi")}
+
+	client := openai.NewClient(
+		option.WithAPIKey("stub"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+		option.WithHeader("X-Tenant-ID", "tenant-from-client"),
+		option.WithHeader("X-App", "eino"),
+	)
+	m := NewOpenAIModel(&client, nil, WithModelName("stub-model"),
+		WithDefaultOptions(model.WithHeaders(map[string]string{"X-Trace-ID": "default-trace"})))
+
+	// 未指定时使用 client 与默认选项中的请求头
+	_, err := m.Generate(ctx, input)
+	assert.NoError(t, err)
+	h := srv.lastHeader()
+	assert.Equal(t, "tenant-from-client", h.Get("X-Tenant-ID"))
+	assert.Equal(t, "default-trace", h.Get("X-Trace-ID"))
+
+	// 单次调用的请求头与已有的合并，同名时覆盖
+	_, err = m.Generate(ctx, input, model.WithHeaders(map[string]string{"X-Tenant-ID": "tenant-a", "X-Trace-ID": "trace-1"}))
+	assert.NoError(t, err)
+	h = srv.lastHeader()
+	assert.Equal(t, "tenant-a", h.Get("X-Tenant-ID"))
+	assert.Equal(t, "trace-1", h.Get("X-Trace-ID"))
+	assert.Equal(t, "eino", h.Get("X-App"))
+
+	sr, err := m.Stream(ctx, input, model.WithHeaders(map[string]string{"X-Trace-ID": "trace-2"}))
+	assert.NoError(t, err)
+	_, err = schema.ConcatMessageStream(sr)
+	assert.NoError(t, err)
+	h = srv.lastHeader()
+	assert.Equal(t, "tenant-from-client", h.Get("X-Tenant-ID"))
+	assert.Equal(t, "trace-2", h.Get("X-Trace-ID"))
+}
+
+func TestOpenAIModelErrorClassification(t *testing.T) {
+	ctx := context.Background()
+	input := []*schema.Message{schema.UserMessage("hi")}
+
+	cases := []struct {
+		status   int
+		expected error
+	}{
+		{status: http.StatusTooManyRequests, expected: model.ErrRateLimited},
+		{status: http.StatusBadRequest, expected: model.ErrBadRequest},
+		{status: http.StatusServiceUnavailable, expected: model.ErrModelUnavailable},
+	}
+	for _, c := range cases {
+		t.Run(strconv.Itoa(c.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(c.status)
+				_, _ = fmt.Fprintf(w, `{"error":{"message":"status %d","type":"stub"}}`, c.status)
+			}))
+			defer srv.Close()
+
+			client := openai.NewClient(option.WithAPIKey("stub"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+			m := NewOpenAIModel(&client, nil, WithModelName("stub-model"))
+
+			_, err := m.Generate(ctx, input)
+			assert.ErrorIs(t, err, c.expected)
+			var apiErr *openai.Error
+			assert.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, c.status, apiErr.StatusCode)
+
+			_, err = m.Stream(ctx, input)
+			assert.ErrorIs(t, err, c.expected)
+		})
+	}
+
+	t.Run("tool not found", func(t *testing.T) {
+		srv := newStubOpenAIServer(t)
+		srv.setResponse(stubToolCallCompletion(t, "get_stock", `{"code":"600000"}`))
+		agent, err := react.NewAgent(ctx, &react.AgentConfig{
+			ToolCallingModel: NewOpenAIModel(srv.client(), nil, WithModelName("stub-model")),
+			ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{newFakeWeatherTool()}},
+		})
+		assert.NoError(t, err)
+
+		_, err = agent.Generate(ctx, input)
+		assert.ErrorIs(t, err, compose.ErrToolNotFound)
+	})
+}
+
+func TestOpenAIModelSamplingOptions(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	srv.setChunks(stubChunk(t, map[string]any{"role": "assistant", "content": "ok"}, "stop"))
+	input := []*schema.Message{schema.UserMessage("hi")}
+
+	m := NewOpenAIModel(srv.client(), nil, WithModelName("gpt-4o"), WithDefaultOptions(
+		model.WithTemperature(0.7),
+		model.WithMaxTokens(512),
+	))
+
+	t.Run("defaults", func(t *testing.T) {
+		_, err := m.Generate(ctx, input)
+		assert.NoError(t, err)
+		req := srv.lastRequest()
+		assert.Equal(t, 0.7, req["temperature"])
+		assert.Equal(t, float64(512), req["max_tokens"])
+		assert.NotContains(t, req, "top_p")
+		assert.NotContains(t, req, "stop")
+		assert.NotContains(t, req, "seed")
+		assert.NotContains(t, req, "presence_penalty")
+		assert.NotContains(t, req, "frequency_penalty")
+	})
+
+	t.Run("seed and penalties", func(t *testing.T) {
+		_, err := m.Generate(ctx, input, model.WithSeed(42), model.WithPresencePenalty(0.5), model.WithFrequencyPenalty(-0.3))
+		assert.NoError(t, err)
+		req := srv.lastRequest()
+		assert.Equal(t, float64(42), req["seed"])
+		assert.Equal(t, 0.5, req["presence_penalty"])
+		assert.Equal(t, -0.3, req["frequency_penalty"])
+
+		sr, err := m.Stream(ctx, input, model.WithSeed(7))
+		assert.NoError(t, err)
+		_, err = schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		req = srv.lastRequest()
+		assert.Equal(t, float64(7), req["seed"])
+		assert.NotContains(t, req, "presence_penalty")
+	})
+
+	t.Run("per call override", func(t *testing.T) {
+		sr, err := m.Stream(ctx, input, model.WithTemperature(0.2), model.WithTopP(0.9), model.WithStop([]string{"\n\n"}))
+		assert.NoError(t, err)
+		_, err = schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		req := srv.lastRequest()
+		assert.Equal(t, 0.2, req["temperature"])
+		assert.Equal(t, 0.9, req["top_p"])
+		assert.Equal(t, float64(512), req["max_tokens"])
+		assert.Equal(t, []any{"\n\n"}, req["stop"])
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := m.Generate(ctx, input, model.WithTemperature(2.5))
+		assert.ErrorContains(t, err, "temperature must be in [0, 2]")
+		_, err = m.Stream(ctx, input, model.WithTopP(1.5))
+		assert.ErrorContains(t, err, "top_p must be in [0, 1]")
+		_, err = m.Generate(ctx, input, model.WithMaxTokens(0))
+		assert.ErrorContains(t, err, "max_tokens must be positive")
+		_, err = m.Generate(ctx, input, model.WithStop([]string{"a", "b", "c", "d", "e"}))
+		assert.ErrorContains(t, err, "at most 4 stop sequences")
+		_, err = m.Generate(ctx, input, model.WithPresencePenalty(2.5))
+		assert.ErrorContains(t, err, "presence_penalty must be in [-2, 2]")
+		_, err = m.Stream(ctx, input, model.WithFrequencyPenalty(-3))
+		assert.ErrorContains(t, err, "frequency_penalty must be in [-2, 2]")
+
+		invalidDefault := NewOpenAIModel(srv.client(), nil, WithModelName("gpt-4o"), WithDefaultOptions(model.WithTemperature(-1)))
+		_, err = invalidDefault.Generate(ctx, input)
+		assert.ErrorContains(t, err, "temperature must be in [0, 2]")
+	})
+}
+
+// stubContentCompletion 构造一个 content 为指定内容的 completion JSON
+func stubContentCompletion(t *testing.T, content string) string {
+	b, err := json.Marshal(map[string]any{
+		"id": "stub", "object": "chat.completion", "created": 0, "model": "stub",
+		"choices": []any{map[string]any{
+			"index": 0, "finish_reason": "stop",
+			"message": map[string]any{"role": "assistant", "content": content},
+		}},
+	})
+	assert.NoError(t, err)
+	return string(b)
+}
+
+type weatherReport struct {
+	City    string `json:"city"`
+	Weather string `json:"weather" jsonschema:"enum=sunny,enum=rainy"`
+	Temp    int    `json:"temp"`
+}
+
+func TestOpenAIModelResponseFormat(t *testing.T) {
+	ctx := context.Background()
+	input := []*schema.Message{schema.UserMessage("how's weather of beijing")}
+	reportFormat := &schema.ResponseFormat{
+		Type: schema.ResponseFormatTypeJSONSchema,
+		JSONSchema: &schema.ResponseFormatJSONSchema{
+			Name:   "weather_report",
+			Desc:   "天气报告",
+			Schema: jsonschema.Reflect(&weatherReport{}),
+			Strict: true,
+		},
+	}
+
+	t.Run("json schema", func(t *testing.T) {
+		srv := newStubOpenAIServer(t)
+		srv.setResponse(stubContentCompletion(t, `{"city":"北京","weather":"sunny","temp":25}`))
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("gpt-4o"))
+
+		out, err := m.Generate(ctx, input, model.WithResponseFormat(reportFormat))
+		assert.NoError(t, err)
+		report, err := schema.ParseJSON[weatherReport](out)
+		assert.NoError(t, err)
+		assert.Equal(t, weatherReport{City: "北京", Weather: "sunny", Temp: 25}, report)
+
+		format := srv.lastRequest()["response_format"].(map[string]any)
+		assert.Equal(t, "json_schema", format["type"])
+		js := format["json_schema"].(map[string]any)
+		assert.Equal(t, "weather_report", js["name"])
+		assert.Equal(t, "天气报告", js["description"])
+		assert.Equal(t, true, js["strict"])
+		assert.Equal(t, "#/$defs/weatherReport", js["schema"].(map[string]any)["$ref"])
+	})
+
+	t.Run("json object", func(t *testing.T) {
+		srv := newStubOpenAIServer(t)
+		srv.setResponse(stubContentCompletion(t, `["not", "an", "object"]`))
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("deepseek-chat"))
+
+		_, err := m.Generate(ctx, input, model.WithResponseFormat(&schema.ResponseFormat{Type: schema.ResponseFormatTypeJSONObject}))
+		assert.ErrorContains(t, err, "not an object")
+		assert.Equal(t, map[string]any{"type": "json_object"}, srv.lastRequest()["response_format"])
+	})
+
+	t.Run("retry on malformed JSON", func(t *testing.T) {
+		srv := newStubOpenAIServer(t)
+		srv.queueResponses(
+			stubContentCompletion(t, `{"city":"北京","weather":"sunny"`),
+			stubContentCompletion(t, `{"city":"北京","weather":"cloudy","temp":25}`),
+			stubContentCompletion(t, `{"city":"北京","weather":"sunny","temp":25.5}`),
+			stubContentCompletion(t, `{"city":"北京","weather":"sunny","temp":25}`),
+		)
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("gpt-4o"), WithResponseFormatRetries(3))
+
+		out, err := m.Generate(ctx, input, model.WithResponseFormat(reportFormat))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"city":"北京","weather":"sunny","temp":25}`, out.Content)
+		assert.Len(t, srv.requests, 4)
+	})
+
+	t.Run("error after retries", func(t *testing.T) {
+		srv := newStubOpenAIServer(t)
+		srv.setResponse(stubContentCompletion(t, `{"city":"北京"}`))
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("gpt-4o"), WithResponseFormatRetries(1))
+
+		_, err := m.Generate(ctx, input, model.WithResponseFormat(reportFormat))
+		assert.ErrorContains(t, err, "not conforming to schema weather_report: $.weather is required")
+		assert.Len(t, srv.requests, 2)
+
+		srv.setResponse(stubContentCompletion(t, `not json`))
+		_, err = m.Generate(ctx, input, model.WithResponseFormat(reportFormat))
+		assert.ErrorContains(t, err, "malformed JSON")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		srv := newStubOpenAIServer(t)
+		m := NewOpenAIModel(srv.client(), nil, WithModelName("gpt-4o"))
+
+		_, err := m.Generate(ctx, input, model.WithResponseFormat(&schema.ResponseFormat{Type: schema.ResponseFormatTypeJSONSchema}))
+		assert.ErrorContains(t, err, "name and schema are required")
+		_, err = m.Stream(ctx, input, model.WithResponseFormat(&schema.ResponseFormat{Type: "xml"}))
+		assert.ErrorContains(t, err, "unknown response format type")
+	})
+}
+
+// stubMultiToolCallCompletion 构造一个在同一条消息中包含多个工具调用的 completion JSON，calls 依次为 id、name、arguments
+func stubMultiToolCallCompletion(t *testing.T, calls ...[3]string) string {
+	toolCalls := make([]any, 0, len(calls))
+	for _, c := range calls {
+		toolCalls = append(toolCalls, map[string]any{
+			"id": c[0], "type": "function",
+			"function": map[string]any{"name": c[1], "arguments": c[2]},
+		})
+	}
+	b, err := json.Marshal(map[string]any{
+		"id": "stub", "object": "chat.completion", "created": 0, "model": "stub",
+		"choices": []any{map[string]any{
+			"index": 0, "finish_reason": "tool_calls",
+			"message": map[string]any{"role": "assistant", "content": "", "tool_calls": toolCalls},
+		}},
+	})
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestOpenAIModelParallelToolCalls(t *testing.T) {
+	ctx := context.Background()
+	input := []*schema.Message{schema.UserMessage("北京和上海的天气怎么样")}
+
+	t.Run("map to request", func(t *testing.T) {
+		srv := newStubOpenAIServer(t)
+		weather := &schema.ToolInfo{Name: "get_weather", Desc: "查询天气"}
+
+		m := NewOpenAIModel(srv.client(), []*schema.ToolInfo{weather}, WithModelName("stub-model"))
+		_, err := m.Generate(ctx, input)
+		assert.NoError(t, err)
+		assert.NotContains(t, srv.lastRequest(), "parallel_tool_calls")
+
+		_, err = m.Generate(ctx, input, model.WithParallelToolCalls(false))
+		assert.NoError(t, err)
+		assert.Equal(t, false, srv.lastRequest()["parallel_tool_calls"])
+
+		// 未设置工具时不发送 parallel_tool_calls
+		noTools := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"))
+		_, err = noTools.Generate(ctx, input, model.WithParallelToolCalls(false))
+		assert.NoError(t, err)
+		assert.NotContains(t, srv.lastRequest(), "parallel_tool_calls")
+	})
+
+	// 即使关闭了 parallel_tool_calls，模型仍可能在一条消息中返回多个工具调用，react agent 需要全部执行
+	for _, parallel := range []bool{true, false} {
+		t.Run(fmt.Sprintf("react agent with two tool calls, parallel=%v", parallel), func(t *testing.T) {
+			srv := newStubOpenAIServer(t)
+			srv.queueResponses(
+				stubMultiToolCallCompletion(t,
+					[3]string{"call_bj", "get_weather", `{"city":"北京"}`},
+					[3]string{"call_sh", "get_weather", `{"city":"上海"}`},
+				),
+				stubContentCompletion(t, "北京和上海今天都是晴天"),
+			)
+
+			weatherTool := newFakeWeatherTool()
+			info, err := weatherTool.Info(ctx)
+			assert.NoError(t, err)
+
+			a, err := react.NewAgent(ctx, &react.AgentConfig{
+				ToolCallingModel: NewOpenAIModel(srv.client(), []*schema.ToolInfo{info}, WithModelName("stub-model")),
+				ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{weatherTool}},
+			})
+			assert.NoError(t, err)
+
+			out, err := a.Generate(ctx, input, react.WithChatModelOptions(model.WithParallelToolCalls(parallel)))
+			assert.NoError(t, err)
+			assert.Equal(t, "北京和上海今天都是晴天", out.Content)
+
+			srv.mu.Lock()
+			requests := srv.requests
+			srv.mu.Unlock()
+			assert.Len(t, requests, 2)
+			for _, req := range requests {
+				assert.Equal(t, parallel, req["parallel_tool_calls"])
+			}
+
+			// 第二次请求包含两个工具调用的结果，tool_call_id 与调用一一对应
+			var toolMsgs []map[string]any
+			for _, msg := range requests[1]["messages"].([]any) {
+				if msg.(map[string]any)["role"] == "tool" {
+					toolMsgs = append(toolMsgs, msg.(map[string]any))
+				}
+			}
+			assert.Len(t, toolMsgs, 2)
+			assert.Equal(t, "call_bj", toolMsgs[0]["tool_call_id"])
+			assert.Equal(t, "call_sh", toolMsgs[1]["tool_call_id"])
+		})
+	}
+}
+
+func TestOpenAIModelBatchGenerate(t *testing.T) {
+	ctx := context.Background()
+
+	var inFlight, maxInFlight int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		var body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		content := body.Messages[len(body.Messages)-1].Content
+		w.Header().Set("Content-Type", "application/json")
+		if content == "fail" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = fmt.Fprint(w, `{"error":{"message":"slow down","type":"stub"}}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, stubContentCompletion(t, "echo "+content))
+	}))
+	defer srv.Close()
+
+	client := openai.NewClient(option.WithAPIKey("stub"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+	m := NewOpenAIModel(&client, nil, WithModelName("stub-model"), WithBatchConcurrency(2))
+	var _ model.BatchChatModel = m
+
+	withTools, err := m.WithTools(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, withTools.(*OpenAIModel).batchConcurrency)
+
+	inputs := func(contents ...string) [][]*schema.Message {
+		ret := make([][]*schema.Message, len(contents))
+		for i, c := range contents {
+			ret[i] = []*schema.Message{schema.UserMessage(c)}
+		}
+		return ret
+	}
+
+	t.Run("order preserved", func(t *testing.T) {
+		outs, err := m.BatchGenerate(ctx, inputs("1", "2", "3", "4", "5"))
+		assert.NoError(t, err)
+		var contents []string
+		for _, out := range outs {
+			contents = append(contents, out.Content)
+		}
+		assert.Equal(t, []string{"echo 1", "echo 2", "echo 3", "echo 4", "echo 5"}, contents)
+		assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		outs, err := m.BatchGenerate(ctx, inputs("1", "fail", "3"))
+		assert.ErrorIs(t, err, model.ErrRateLimited)
+		var batchErr *model.BatchError
+		assert.ErrorAs(t, err, &batchErr)
+		assert.Nil(t, batchErr.Errors[0])
+		assert.Error(t, batchErr.Errors[1])
+		assert.Nil(t, batchErr.Errors[2])
+		assert.Equal(t, "echo 1", outs[0].Content)
+		assert.Nil(t, outs[1])
+		assert.Equal(t, "echo 3", outs[2].Content)
+	})
+
+	t.Run("batch node in graph", func(t *testing.T) {
+		g := compose.NewGraph[[][]*schema.Message, []*schema.Message]()
+		assert.NoError(t, g.AddBatchChatModelNode("batch", m))
+		assert.NoError(t, g.AddEdge(compose.START, "batch"))
+		assert.NoError(t, g.AddEdge("batch", compose.END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+
+		outs, err := r.Invoke(ctx, inputs("a", "b"))
+		assert.NoError(t, err)
+		assert.Len(t, outs, 2)
+		assert.Equal(t, "echo a", outs[0].Content)
+		assert.Equal(t, "echo b", outs[1].Content)
+
+		_, err = r.Invoke(ctx, inputs("a", "fail"))
+		var batchErr *model.BatchError
+		assert.ErrorAs(t, err, &batchErr)
+	})
+}
+
+func TestOpenAIModelReasoningContent(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	m := NewOpenAIModel(srv.client(), nil, WithModelName("deepseek-reasoner"))
+	input := []*schema.Message{schema.UserMessage("9.11 和 9.9 哪个大")}
+
+	srv.setResponse(`{"id":"stub","object":"chat.completion","created":0,"model":"stub","choices":[{"index":0,"finish_reason":"stop",` +
+		`"message":{"role":"assistant","content":"9.9 更大","reasoning_content":"比较小数部分，0.9 大于 0.11"}}]}`)
+	out, err := m.Generate(ctx, input)
+	assert.NoError(t, err)
+	assert.Equal(t, "9.9 更大", out.Content)
+	assert.Equal(t, "比较小数部分，0.9 大于 0.11", out.ReasoningContent)
+
+	srv.setChunks(
+		stubChunk(t, map[string]any{"role": "assistant", "reasoning_content": "比较小数部分，"}, ""),
+		stubChunk(t, map[string]any{"reasoning_content": "0.9 大于 0.11"}, ""),
+		stubChunk(t, map[string]any{"content": "9.9 更大"}, "stop"),
+	)
+	sr, err := m.Stream(ctx, input)
+	assert.NoError(t, err)
+	streamed, err := schema.ConcatMessageStream(sr)
+	assert.NoError(t, err)
+	assert.Equal(t, "9.9 更大", streamed.Content)
+	assert.Equal(t, "比较小数部分，0.9 大于 0.11", streamed.ReasoningContent)
+
+	// 推理内容不回传给后续轮次
+	history := schema.StripReasoning(append(input, out, schema.UserMessage("那 9.11 和 9.2 呢")))
+	_, err = m.Generate(ctx, history)
+	assert.NoError(t, err)
+	assert.Empty(t, history[1].ReasoningContent)
+	assert.NotContains(t, fmt.Sprint(srv.lastRequest()["messages"]), "reasoning_content")
+}
+
+type reservationReq struct {
+	Date string `json:"date" jsonschema:"format=date"`
+}
+
+func TestOpenAIModelSchemaConstraints(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+
+	reserve, err := utils.InferTool("reserve", "预订餐厅", func(ctx context.Context, req *reservationReq) (string, error) {
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+	info, err := reserve.Info(ctx)
+	assert.NoError(t, err)
+
+	// 默认约束下 format 可以使用
+	m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"))
+	_, err = m.WithTools([]*schema.ToolInfo{info})
+	assert.NoError(t, err)
+
+	// 不支持 format 的服务在绑定工具时即报错，而非被模型静默忽略
+	strictModel := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"),
+		WithSchemaConstraints(&model.SchemaConstraints{UnsupportedKeywords: []string{"format"}, RequireObjectRoot: true}))
+	_, err = strictModel.WithTools([]*schema.ToolInfo{info})
+	assert.ErrorIs(t, err, model.ErrUnsupportedSchema)
+	assert.ErrorContains(t, err, "#/properties/date: keyword format is not supported")
+
+	_, err = react.NewAgent(ctx, &react.AgentConfig{
+		ToolCallingModel: strictModel,
+		ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{reserve}},
+	})
+	assert.ErrorIs(t, err, model.ErrUnsupportedSchema)
+}
+
+func TestOpenAIModelBoundTools(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+
+	m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"))
+	newGraph := func(cm model.BaseChatModel) *compose.Graph[[]*schema.Message, *schema.Message] {
+		g := compose.NewGraph[[]*schema.Message, *schema.Message]()
+		assert.NoError(t, g.AddChatModelNode("model", cm, compose.WithToolDecider()))
+		assert.NoError(t, g.AddEdge(compose.START, "model"))
+		assert.NoError(t, g.AddEdge("model", compose.END))
+		return g
+	}
+
+	// 声明为决定工具调用的节点，但模型未绑定工具，编译时即报错，而非运行时静默地从不调用工具
+	_, err := newGraph(m).Compile(ctx)
+	assert.ErrorContains(t, err, "node[model] is declared as the tool decider, but its chat model has no tools bound")
+
+	withTools, err := m.WithTools([]*schema.ToolInfo{{Name: "now", Desc: "获取当前时间"}})
+	assert.NoError(t, err)
+	_, err = newGraph(withTools).Compile(ctx)
+	assert.NoError(t, err)
+}
+
+func TestOpenAIModelMessageName(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"))
+
+	// 多 agent 对话中用 Name 标记消息由哪个专家产生
+	researcher := schema.AssistantMessage("北京明天晴", nil)
+	researcher.Name = "researcher"
+	user := schema.UserMessage("明天适合出游吗？")
+	user.Name = "alice"
+
+	_, err := m.Generate(ctx, []*schema.Message{
+		schema.SystemMessage("you are a helpful assistant."),
+		user,
+		researcher,
+		schema.UserMessage("谢谢"),
+	})
+	assert.NoError(t, err)
+
+	messages := srv.lastRequest()["messages"].([]any)
+	assert.Len(t, messages, 4)
+	// 名称为空时省略
+	assert.NotContains(t, messages[0].(map[string]any), "name")
+	assert.Equal(t, "alice", messages[1].(map[string]any)["name"])
+	assert.Equal(t, "researcher", messages[2].(map[string]any)["name"])
+	assert.NotContains(t, messages[3].(map[string]any), "name")
+}
+
+func TestOpenAIModelToolMessage(t *testing.T) {
+	ctx := context.Background()
+	srv := newStubOpenAIServer(t)
+	m := NewOpenAIModel(srv.client(), nil, WithModelName("stub-model"))
+
+	toolCall := schema.ToolCall{ID: "call_1", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"北京"}`}}
+	input := []*schema.Message{
+		schema.UserMessage("北京天气怎么样？"),
+		schema.AssistantMessage("", []schema.ToolCall{toolCall}),
+		// 元数据和错误标记保存在 Extra 中，不会发送给接口
+		schema.ToolMessage("请求超时", toolCall.ID, schema.WithToolName("get_weather"),
+			schema.WithToolResultMeta(map[string]any{"status": 504}), schema.WithToolError()),
+	}
+	out, err := m.Generate(ctx, input)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", out.Content)
+
+	messages := srv.lastRequest()["messages"].([]any)
+	assert.Len(t, messages, 3)
+	assert.Equal(t, []any{map[string]any{
+		"id":       "call_1",
+		"type":     "function",
+		"function": map[string]any{"name": "get_weather", "arguments": `{"city":"北京"}`},
+	}}, messages[1].(map[string]any)["tool_calls"])
+	assert.Equal(t, map[string]any{"role": "tool", "content": "请求超时", "tool_call_id": "call_1"}, messages[2])
+
+	// 缺少工具调用 ID 的工具消息在发送前即报错
+	_, err = m.Generate(ctx, []*schema.Message{input[0], input[1], {Role: schema.Tool, Content: "请求超时", ToolName: "get_weather"}})
+	assert.ErrorContains(t, err, "tool message of tool[get_weather] has no tool call id")
+}
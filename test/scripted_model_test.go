@@ -0,0 +1,123 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model/mock"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+// toolCallMessage 构造一条调用单个工具的 assistant 消息
+func toolCallMessage(id, name, arguments string) *schema.Message {
+	return schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       id,
+		Type:     "function",
+		Function: schema.FunctionCall{Name: name, Arguments: arguments},
+	}})
+}
+
+// newFakeWeatherTool 返回一个不访问网络的 get_weather 工具
+func newFakeWeatherTool() tool.InvokableTool {
+	return utils.NewTool[WeatherReq, WeatherResp](
+		&schema.ToolInfo{
+			Name: "get_weather",
+			Desc: "这是个查询天气的tool,输入要查询的城市名,返回该城市的温度和天气",
+		},
+		func(ctx context.Context, req WeatherReq) (WeatherResp, error) {
+			return WeatherResp{Weather: "Sunny", Temp: 25}, nil
+		},
+	)
+}
+
+func TestScriptedChatModel(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("graph branch to tools node", func(t *testing.T) {
+		cm := mock.NewScriptedChatModel(toolCallMessage("call_1", "get_weather", `{"city":"北京"}`))
+
+		toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{
+			Tools: []tool.BaseTool{newFakeWeatherTool()},
+		})
+		assert.NoError(t, err)
+
+		takeOne := func(input []*schema.Message) (*schema.Message, error) {
+			return input[0], nil
+		}
+		branch := compose.NewGraphBranch(func(ctx context.Context, msg *schema.Message) (string, error) {
+			if len(msg.ToolCalls) > 0 {
+				return "node_tools", nil
+			}
+			return compose.END, nil
+		}, map[string]bool{"node_tools": true, compose.END: true})
+
+		graph := compose.NewGraph[map[string]any, *schema.Message](compose.WithAutoReduce(takeOne))
+		assert.NoError(t, graph.AddChatTemplateNode("node_template", prompt.FromMessages(schema.FString,
+			schema.SystemMessage("you are a helpful assistant.\nhere is the context: {context}"),
+			schema.MessagesPlaceholder("chat_history", true),
+			schema.UserMessage("question: {question}"),
+		)))
+		assert.NoError(t, graph.AddChatModelNode("node_model", cm))
+		assert.NoError(t, graph.AddToolsNode("node_tools", toolsNode))
+		assert.NoError(t, graph.AddEdge(compose.START, "node_template"))
+		assert.NoError(t, graph.AddEdge("node_template", "node_model"))
+		assert.NoError(t, graph.AddBranch("node_model", branch))
+		assert.NoError(t, graph.AddEdge("node_tools", compose.END))
+
+		r, err := graph.Compile(ctx)
+		assert.NoError(t, err)
+
+		out, err := r.Invoke(ctx, map[string]any{
+			"context":  "weather information",
+			"question": "how's weather of beijing",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, schema.Tool, out.Role)
+		assert.Equal(t, "call_1", out.ToolCallID)
+		assert.JSONEq(t, `{"weather":"Sunny","temp":25}`, out.Content)
+
+		calls := cm.Calls()
+		assert.Len(t, calls, 1)
+		assert.Equal(t, []*schema.Message{
+			schema.SystemMessage("you are a helpful assistant.\nhere is the context: weather information"),
+			schema.UserMessage("question: how's weather of beijing"),
+		}, calls[0].Input)
+	})
+
+	t.Run("multi-turn react agent", func(t *testing.T) {
+		cm := mock.NewScriptedChatModel(
+			toolCallMessage("call_1", "get_weather", `{"city":"北京"}`),
+			schema.AssistantMessage("北京今天晴，25度", nil),
+		)
+		agent, err := react.NewAgent(ctx, &react.AgentConfig{
+			ToolCallingModel: cm,
+			ToolsConfig: compose.ToolsNodeConfig{
+				Tools: []tool.BaseTool{newFakeWeatherTool()},
+			},
+		})
+		assert.NoError(t, err)
+
+		out, err := agent.Generate(ctx, []*schema.Message{schema.UserMessage("how's weather of beijing")})
+		assert.NoError(t, err)
+		assert.Equal(t, "北京今天晴，25度", out.Content)
+
+		calls := cm.Calls()
+		assert.Len(t, calls, 2)
+		assert.Equal(t, "get_weather", calls[0].Tools[0].Name)
+		assert.Len(t, calls[1].Input, 3)
+		assert.Equal(t, "call_1", calls[1].Input[1].ToolCalls[0].ID)
+		assert.Equal(t, "call_1", calls[1].Input[2].ToolCallID)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		cm := mock.NewScriptedChatModel()
+		_, err := cm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.ErrorContains(t, err, "scripted model exhausted")
+	})
+}
@@ -11,7 +11,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
 	"github.com/cloudwego/eino/compose"
@@ -19,7 +18,6 @@ import (
 	"github.com/cloudwego/eino/schema"
 	openai "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
-	"github.com/openai/openai-go/shared"
 )
 
 type WeatherReq struct {
@@ -27,8 +25,8 @@ type WeatherReq struct {
 }
 
 type WeatherResp struct {
-	Weather string `json:"weather"`
-	Temp    int    `json:"temp"`
+	Weather string `json:"weather" description:"天气状况，如 晴、多云"`
+	Temp    int    `json:"temp" description:"当前温度，单位摄氏度"`
 }
 
 func GetWeather(ctx context.Context, req WeatherReq) (WeatherResp, error) {
@@ -140,139 +138,6 @@ func loadEnv() {
 	}
 }
 
-// OpenAIModel 包装 openai-go 客户端，实现 ToolCallingChatModel 接口
-type OpenAIModel struct {
-	client *openai.Client
-	tools  []*schema.ToolInfo
-}
-
-// NewOpenAIModel 创建一个新的 OpenAIModel 实例
-func NewOpenAIModel(client *openai.Client, tools []*schema.ToolInfo) *OpenAIModel {
-	return &OpenAIModel{
-		client: client,
-		tools:  tools,
-	}
-}
-
-// Generate 实现 BaseChatModel 接口的 Generate 方法
-func (m *OpenAIModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
-	// 将 schema.Message 转换为 openai 的消息格式
-	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(input))
-	for _, msg := range input {
-		switch msg.Role {
-		case schema.User:
-			messages = append(messages, openai.UserMessage(msg.Content))
-		case schema.Assistant:
-			messages = append(messages, openai.AssistantMessage(msg.Content))
-		case schema.System:
-			messages = append(messages, openai.SystemMessage(msg.Content))
-		case schema.Tool:
-			// 工具消息需要特殊处理
-			messages = append(messages, openai.ToolMessage(msg.Content, msg.ToolCallID))
-		}
-	}
-
-	// 准备工具参数
-	var tools []openai.ChatCompletionToolParam
-	if len(m.tools) > 0 {
-		tools = make([]openai.ChatCompletionToolParam, 0, len(m.tools))
-		for _, toolInfo := range m.tools {
-			// 将 schema.ToolInfo 转换为 openai 的工具格式
-			var params shared.FunctionParameters
-			if toolInfo.ParamsOneOf != nil {
-				jsonSchema, err := toolInfo.ParamsOneOf.ToJSONSchema()
-				if err != nil {
-					return nil, err
-				}
-				if jsonSchema != nil {
-					// 将 jsonschema.Schema 转换为 map[string]interface{}
-					// 这里简化处理，实际使用时需要更完整的转换
-					params = shared.FunctionParameters{
-						"Type": "object",
-					}
-				}
-			}
-
-			// 创建 param.Opt 值
-			descOpt := openai.Opt(toolInfo.Desc)
-
-			tools = append(tools, openai.ChatCompletionToolParam{
-				Type: "function",
-				Function: shared.FunctionDefinitionParam{
-					Name:        toolInfo.Name,
-					Description: descOpt,
-					Parameters:  params,
-				},
-			})
-		}
-	}
-
-	// 调用 OpenAI API
-	resp, err := m.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Model:    "deepseek-chat",
-		Messages: messages,
-		Tools:    tools,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no choices returned from OpenAI")
-	}
-
-	choice := resp.Choices[0]
-	result := &schema.Message{
-		Role:    schema.Assistant,
-		Content: choice.Message.Content,
-	}
-
-	// 处理工具调用
-	if len(choice.Message.ToolCalls) > 0 {
-		result.ToolCalls = make([]schema.ToolCall, 0, len(choice.Message.ToolCalls))
-		for _, toolCall := range choice.Message.ToolCalls {
-			if toolCall.Type == "function" {
-				result.ToolCalls = append(result.ToolCalls, schema.ToolCall{
-					ID:   toolCall.ID,
-					Type: "function",
-					Function: schema.FunctionCall{
-						Name:      toolCall.Function.Name,
-						Arguments: toolCall.Function.Arguments,
-					},
-				})
-			}
-		}
-	}
-
-	return result, nil
-}
-
-// Stream 实现 BaseChatModel 接口的 Stream 方法
-func (m *OpenAIModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
-	// 简化实现：对于测试，我们可以先不实现流式接口
-	// 在实际使用中，这里应该调用 OpenAI 的流式 API
-	msg, err := m.Generate(ctx, input, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	// 创建一个简单的流式读取器
-	stream := schema.StreamReaderFromArray([]*schema.Message{msg})
-
-	return stream, nil
-}
-
-// WithTools 实现 ToolCallingChatModel 接口的 WithTools 方法
-func (m *OpenAIModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
-	// 创建新的实例，避免修改原实例
-	newModel := &OpenAIModel{
-		client: m.client,
-		tools:  make([]*schema.ToolInfo, len(tools)),
-	}
-	copy(newModel.tools, tools)
-	return newModel, nil
-}
-
 func TestWeather(t *testing.T) {
 	ctx := context.Background()
 
@@ -295,7 +160,7 @@ func TestWeather(t *testing.T) {
 		option.WithBaseURL("https://api.deepseek.com"),
 	)
 	toolInfo, _ := weatherTool.Info(ctx)
-	model := NewOpenAIModel(&client, []*schema.ToolInfo{toolInfo})
+	model := NewOpenAIModel(&client, []*schema.ToolInfo{toolInfo}, WithModelName("deepseek-chat"))
 
 	// 注意：在实际测试中，您需要提供一个真实的模型或mock模型
 	// 这里为了演示，我们创建一个简单的agent配置
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model/mock"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+// answerInput 是汇总 lambda 的输入，字段分别来自 START 与模型输出
+type answerInput struct {
+	Question string
+	Answer   string
+}
+
+func TestWorkflowFieldMapping(t *testing.T) {
+	ctx := context.Background()
+
+	// newWorkflow 创建 template -> model -> lambda 的 workflow，只把模型输出的 Content 传给 lambda
+	newWorkflow := func(contentField string) (*compose.Workflow[map[string]any, string], *mock.ScriptedChatModel) {
+		cm := mock.NewScriptedChatModel(schema.AssistantMessage("北京今天晴，25度", nil))
+
+		wf := compose.NewWorkflow[map[string]any, string]()
+		wf.AddChatTemplateNode("node_template", prompt.FromMessages(schema.FString,
+			schema.SystemMessage("you are a helpful assistant."),
+			schema.UserMessage("question: {question}"),
+		)).AddInput(compose.START)
+		wf.AddChatModelNode("node_model", cm).AddInput("node_template")
+		wf.AddLambdaNode("node_answer", compose.InvokableLambda(func(ctx context.Context, in *answerInput) (string, error) {
+			return in.Question + " -> " + in.Answer, nil
+		})).
+			AddInput(compose.START, compose.MapFields("question", "Question")).
+			AddInput("node_model", compose.MapFields(contentField, "Answer"))
+		wf.End().AddInput("node_answer")
+
+		return wf, cm
+	}
+
+	t.Run("map message content to struct field", func(t *testing.T) {
+		wf, cm := newWorkflow("Content")
+
+		r, err := wf.Compile(ctx)
+		assert.NoError(t, err)
+
+		out, err := r.Invoke(ctx, map[string]any{"question": "北京天气怎么样"})
+		assert.NoError(t, err)
+		assert.Equal(t, "北京天气怎么样 -> 北京今天晴，25度", out)
+		assert.Len(t, cm.Calls(), 1)
+	})
+
+	t.Run("route only content into a string lambda", func(t *testing.T) {
+		// 依次应答 Invoke 与 Stream 两次调用
+		cm := mock.NewScriptedChatModel(
+			schema.AssistantMessage("北京今天晴，25度", nil),
+			schema.AssistantMessage("北京今天晴，25度", nil),
+		)
+
+		wf := compose.NewWorkflow[[]*schema.Message, int]()
+		wf.AddChatModelNode("node_model", cm).AddInput(compose.START)
+		wf.AddLambdaNode("node_len", compose.InvokableLambda(func(ctx context.Context, content string) (int, error) {
+			return len([]rune(content)), nil
+		})).AddInput("node_model", compose.FromField("Content"))
+		wf.End().AddInput("node_len")
+
+		r, err := wf.Compile(ctx)
+		assert.NoError(t, err)
+
+		out, err := r.Invoke(ctx, []*schema.Message{schema.UserMessage("北京天气怎么样")})
+		assert.NoError(t, err)
+		assert.Equal(t, 9, out)
+
+		sr, err := r.Stream(ctx, []*schema.Message{schema.UserMessage("北京天气怎么样")})
+		assert.NoError(t, err)
+		out, err = sr.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, 9, out)
+		sr.Close()
+	})
+
+	t.Run("unknown field fails at compile", func(t *testing.T) {
+		wf, _ := newWorkflow("Contents")
+
+		_, err := wf.Compile(ctx)
+		assert.ErrorContains(t, err, "Contents")
+	})
+}
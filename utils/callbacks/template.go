@@ -27,6 +27,7 @@ import (
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/reranker"
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
@@ -59,6 +60,7 @@ type HandlerHelper struct {
 	embeddingHandler   *EmbeddingCallbackHandler
 	indexerHandler     *IndexerCallbackHandler
 	retrieverHandler   *RetrieverCallbackHandler
+	rerankerHandler    *RerankerCallbackHandler
 	loaderHandler      *LoaderCallbackHandler
 	transformerHandler *TransformerCallbackHandler
 	toolHandler        *ToolCallbackHandler
@@ -101,6 +103,12 @@ func (c *HandlerHelper) Retriever(handler *RetrieverCallbackHandler) *HandlerHel
 	return c
 }
 
+// Reranker sets the reranker handler for the handler helper, which will be called when the reranker component is executed.
+func (c *HandlerHelper) Reranker(handler *RerankerCallbackHandler) *HandlerHelper {
+	c.rerankerHandler = handler
+	return c
+}
+
 // Loader sets the loader handler for the handler helper, which will be called when the loader component is executed.
 func (c *HandlerHelper) Loader(handler *LoaderCallbackHandler) *HandlerHelper {
 	c.loaderHandler = handler
@@ -161,6 +169,8 @@ func (c *handlerTemplate) OnStart(ctx context.Context, info *callbacks.RunInfo,
 		return c.indexerHandler.OnStart(ctx, info, indexer.ConvCallbackInput(input))
 	case components.ComponentOfRetriever:
 		return c.retrieverHandler.OnStart(ctx, info, retriever.ConvCallbackInput(input))
+	case components.ComponentOfReranker:
+		return c.rerankerHandler.OnStart(ctx, info, reranker.ConvCallbackInput(input))
 	case components.ComponentOfLoader:
 		return c.loaderHandler.OnStart(ctx, info, document.ConvLoaderCallbackInput(input))
 	case components.ComponentOfTransformer:
@@ -192,6 +202,8 @@ func (c *handlerTemplate) OnEnd(ctx context.Context, info *callbacks.RunInfo, ou
 		return c.indexerHandler.OnEnd(ctx, info, indexer.ConvCallbackOutput(output))
 	case components.ComponentOfRetriever:
 		return c.retrieverHandler.OnEnd(ctx, info, retriever.ConvCallbackOutput(output))
+	case components.ComponentOfReranker:
+		return c.rerankerHandler.OnEnd(ctx, info, reranker.ConvCallbackOutput(output))
 	case components.ComponentOfLoader:
 		return c.loaderHandler.OnEnd(ctx, info, document.ConvLoaderCallbackOutput(output))
 	case components.ComponentOfTransformer:
@@ -223,6 +235,8 @@ func (c *handlerTemplate) OnError(ctx context.Context, info *callbacks.RunInfo,
 		return c.indexerHandler.OnError(ctx, info, err)
 	case components.ComponentOfRetriever:
 		return c.retrieverHandler.OnError(ctx, info, err)
+	case components.ComponentOfReranker:
+		return c.rerankerHandler.OnError(ctx, info, err)
 	case components.ComponentOfLoader:
 		return c.loaderHandler.OnError(ctx, info, err)
 	case components.ComponentOfTransformer:
@@ -313,6 +327,10 @@ func (c *handlerTemplate) Needed(ctx context.Context, info *callbacks.RunInfo, t
 		if c.retrieverHandler != nil && c.retrieverHandler.Needed(ctx, info, timing) {
 			return true
 		}
+	case components.ComponentOfReranker:
+		if c.rerankerHandler != nil && c.rerankerHandler.Needed(ctx, info, timing) {
+			return true
+		}
 	case components.ComponentOfTool:
 		if c.toolHandler != nil && c.toolHandler.Needed(ctx, info, timing) {
 			return true
@@ -498,6 +516,30 @@ func (ch *RetrieverCallbackHandler) Needed(ctx context.Context, runInfo *callbac
 	}
 }
 
+// RerankerCallbackHandler is the handler for the reranker callback.
+type RerankerCallbackHandler struct {
+	// OnStart is the callback function for the start of the reranker.
+	OnStart func(ctx context.Context, runInfo *callbacks.RunInfo, input *reranker.CallbackInput) context.Context
+	// OnEnd is the callback function for the end of the reranker.
+	OnEnd func(ctx context.Context, runInfo *callbacks.RunInfo, output *reranker.CallbackOutput) context.Context
+	// OnError is the callback function for the error of the reranker.
+	OnError func(ctx context.Context, runInfo *callbacks.RunInfo, err error) context.Context
+}
+
+// Needed checks if the callback handler is needed for the given timing.
+func (ch *RerankerCallbackHandler) Needed(ctx context.Context, runInfo *callbacks.RunInfo, timing callbacks.CallbackTiming) bool {
+	switch timing {
+	case callbacks.TimingOnStart:
+		return ch.OnStart != nil
+	case callbacks.TimingOnEnd:
+		return ch.OnEnd != nil
+	case callbacks.TimingOnError:
+		return ch.OnError != nil
+	default:
+		return false
+	}
+}
+
 // ToolCallbackHandler is the handler for the tool callback.
 type ToolCallbackHandler struct {
 	OnStart               func(ctx context.Context, info *callbacks.RunInfo, input *tool.CallbackInput) context.Context